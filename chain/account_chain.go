@@ -31,6 +31,17 @@ func (c *chain) InsertAccountBlocks(vmAccountBlocks []*vm_context.VmAccountBlock
 	trieSaveCallback := make([]func(), 0)
 	var account *ledger.Account
 
+	// Trie nodes must not be swept between being saved and being committed
+	saveLocked := c.trieGc != nil
+	if saveLocked {
+		c.trieGc.lockSave()
+		defer func() {
+			if saveLocked {
+				c.trieGc.unlockSave()
+			}
+		}()
+	}
+
 	// Write vmContext
 	var addBlockHashList []types.Hash
 	for _, vmAccountBlock := range vmAccountBlocks {
@@ -94,6 +105,11 @@ func (c *chain) InsertAccountBlocks(vmAccountBlocks []*vm_context.VmAccountBlock
 			return saveBlockErr
 		}
 
+		// Save log index
+		if logList := unsavedCache.LogList(); len(logList) > 0 {
+			c.chainDb.Ac.WriteVmLogIndex(batch, account.AccountId, accountBlock, logList)
+		}
+
 		// Save block meta
 		refSnapshotHeight, getSnapshotHeightErr := c.chainDb.Sc.GetSnapshotBlockHeight(&accountBlock.SnapshotHash)
 		if getSnapshotHeightErr != nil {
@@ -101,6 +117,11 @@ func (c *chain) InsertAccountBlocks(vmAccountBlocks []*vm_context.VmAccountBlock
 			return getSnapshotHeightErr
 		}
 
+		// Save tx history index, the send block is indexed when it is confirmed
+		if c.cfg.TxHistoryIndex && accountBlock.IsReceiveBlock() {
+			c.chainDb.Ac.WriteTxHistoryIndex(batch, accountBlock, 0)
+		}
+
 		// If block is receive block, change status of the send block
 		if accountBlock.IsReceiveBlock() {
 			sendBlockMeta, getBlockMetaErr := c.chainDb.Ac.GetBlockMeta(&accountBlock.FromBlockHash)
@@ -159,6 +180,17 @@ func (c *chain) InsertAccountBlocks(vmAccountBlocks []*vm_context.VmAccountBlock
 		return err
 	}
 
+	if saveLocked {
+		roots := make([]*types.Hash, 0, len(vmAccountBlocks))
+		for _, vmAccountBlock := range vmAccountBlocks {
+			roots = append(roots, vmAccountBlock.VmContext.UnsavedCache().Trie().Hash())
+		}
+		c.trieGc.addRoots(roots, false)
+
+		c.trieGc.unlockSave()
+		saveLocked = false
+	}
+
 	// Set stateTriePool
 	c.stateTriePool.Set(&lastVmAccountBlock.AccountBlock.AccountAddress, lastVmAccountBlock.VmContext.UnsavedCache().Trie())
 
@@ -558,6 +590,11 @@ func (c *chain) GetUnConfirmAccountBlocks(addr *types.Address) []*ledger.Account
 }
 
 func (c *chain) DeleteAccountBlocks(addr *types.Address, toHeight uint64) (map[types.Address][]*ledger.AccountBlock, error) {
+	return c.deleteAccountBlocks(addr, toHeight, true)
+}
+
+// deleteAccountBlocks deletes the confirmed blocks too if needNoSnapshot is false, the snapshot blocks confirm them must be deleted later
+func (c *chain) deleteAccountBlocks(addr *types.Address, toHeight uint64, needNoSnapshot bool) (map[types.Address][]*ledger.AccountBlock, error) {
 	account, accountErr := c.chainDb.Account.GetAccountByAddress(addr)
 	if accountErr != nil {
 		c.log.Error("GetAccountByAddress failed, error is "+accountErr.Error(), "method", "DeleteAccountBlocks", "addr", addr, "toHeight", toHeight)
@@ -570,7 +607,7 @@ func (c *chain) DeleteAccountBlocks(addr *types.Address, toHeight uint64) (map[t
 
 	planToDelete := map[uint64]uint64{account.AccountId: toHeight}
 
-	deleteMap, reopenList, getErr := c.chainDb.Ac.GetDeleteMapAndReopenList(planToDelete, c.chainDb.Account.GetAccountByAddress, true, true)
+	deleteMap, reopenList, getErr := c.chainDb.Ac.GetDeleteMapAndReopenList(planToDelete, c.chainDb.Account.GetAccountByAddress, true, needNoSnapshot)
 	if getErr != nil {
 		c.log.Error("GetDeleteMapAndReopenList failed, error is "+getErr.Error(), "method", "DeleteAccountBlocks", "addr", addr, "toHeight", toHeight)
 		return nil, getErr
@@ -612,10 +649,20 @@ func (c *chain) DeleteAccountBlocks(addr *types.Address, toHeight uint64) (map[t
 	}
 	c.chainDb.Be.DeleteAccountBlocks(batch, deleteHashList)
 
-	needAddBlocks, needRemoveAddr, _, err := c.getNeedSnapshotMapByDeleteSubLedger(subLedger)
-	if err != nil {
-		c.log.Error("getNeedSnapshotMapByDeleteSubLedger failed, error is "+err.Error(), "method", "DeleteAccountBlocks", "addr", addr, "toHeight", toHeight)
-		return nil, err
+	var needAddBlocks map[types.Address]*ledger.AccountBlock
+	var needRemoveAddr []types.Address
+	if needNoSnapshot {
+		var err error
+		needAddBlocks, needRemoveAddr, _, err = c.getNeedSnapshotMapByDeleteSubLedger(subLedger)
+		if err != nil {
+			c.log.Error("getNeedSnapshotMapByDeleteSubLedger failed, error is "+err.Error(), "method", "DeleteAccountBlocks", "addr", addr, "toHeight", toHeight)
+			return nil, err
+		}
+	} else {
+		// the broken chain may not be continuous, needSnapshotCache of the accounts is rebuilt by the next start
+		for deleteAddr := range subLedger {
+			needRemoveAddr = append(needRemoveAddr, deleteAddr)
+		}
 	}
 
 	// Set needSnapshotCache, first remove
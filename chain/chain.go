@@ -1,14 +1,15 @@
 package chain
 
 import (
+	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/vitelabs/go-vite/chain/sender"
 	"github.com/vitelabs/go-vite/chain_db"
 	"github.com/vitelabs/go-vite/compress"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/monitor"
 	"github.com/vitelabs/go-vite/trie"
-	"github.com/vitelabs/go-vite/vm_context"
 	"path/filepath"
 	"sync"
 )
@@ -37,9 +38,17 @@ type chain struct {
 	cfg         *config.Chain
 	globalCfg   *config.Config
 	kafkaSender *sender.KafkaSender
+
+	trieGc *trieGc
 }
 
 func NewChain(cfg *config.Config) Chain {
+	if cfg.Chain != nil && cfg.Chain.Genesis != nil {
+		if err := InitGenesis(cfg.Chain.Genesis); err != nil {
+			log15.New("module", "chain").Crit("InitGenesis failed, error is "+err.Error(), "method", "NewChain")
+		}
+	}
+
 	chain := &chain{
 		log:                  log15.New("module", "chain"),
 		genesisSnapshotBlock: &GenesisSnapshotBlock,
@@ -78,14 +87,20 @@ func (c *chain) Init() {
 	compressor := compress.NewCompressor(c, c.dataDir)
 	c.compressor = compressor
 
-	// kafka sender
-	if len(c.cfg.KafkaProducers) > 0 {
+	// kafka sender, also drives other sinks
+	if len(c.cfg.KafkaProducers) > 0 || len(c.cfg.Sinks) > 0 {
 		var newKafkaErr error
 		c.kafkaSender, newKafkaErr = sender.NewKafkaSender(c, filepath.Join(c.dataDir, "ledger_mq"))
 		if newKafkaErr != nil {
 			c.log.Crit("NewKafkaSender failed, error is " + newKafkaErr.Error())
 		}
 	}
+
+	// trie gc
+	if c.cfg.PruneHeights > 0 {
+		c.trieGc = newTrieGc(c, c.cfg.PruneHeights)
+	}
+
 	// Finish initialize
 	c.log.Info("Chain module initialized")
 }
@@ -93,6 +108,15 @@ func (c *chain) Init() {
 func (c *chain) KafkaSender() *sender.KafkaSender {
 	return c.kafkaSender
 }
+
+// TrieGcStatus returns the progress of state pruning, nil if pruning is disabled
+func (c *chain) TrieGcStatus() *TrieGcStatus {
+	if c.trieGc == nil {
+		return nil
+	}
+	return c.trieGc.Status()
+}
+
 func (c *chain) checkAndInitData() {
 	sb := c.genesisSnapshotBlock
 	sb2 := SecondSnapshotBlock
@@ -139,35 +163,12 @@ func (c *chain) initData() {
 
 	}
 
-	// Insert mintage block
-	err = c.InsertAccountBlocks([]*vm_context.VmAccountBlock{{
-		AccountBlock: &GenesisMintageBlock,
-		VmContext:    GenesisMintageBlockVC,
-	}, {
-		AccountBlock: &GenesisMintageSendBlock,
-		VmContext:    GenesisMintageSendBlockVC,
-	}})
-	if err != nil {
-		c.log.Crit("InsertGenesisMintageBlock failed, error is "+err.Error(), "method", "initData")
-	}
-
-	// Insert consensus group block
-	err = c.InsertAccountBlocks([]*vm_context.VmAccountBlock{{
-		AccountBlock: &GenesisConsensusGroupBlock,
-		VmContext:    GenesisConsensusGroupBlockVC,
-	}})
-
-	if err != nil {
-		c.log.Crit("InsertGenesisConsensusGroupBlock failed, error is "+err.Error(), "method", "initData")
-	}
-
-	// Insert register block
-	err = c.InsertAccountBlocks([]*vm_context.VmAccountBlock{{
-		AccountBlock: &GenesisRegisterBlock,
-		VmContext:    GenesisRegisterBlockVC,
-	}})
-	if err != nil {
-		c.log.Crit("InsertGenesisRegisterBlock failed, error is "+err.Error(), "method", "initData")
+	// Insert mintage, consensus group, register and vote blocks
+	for _, blocks := range GenesisAccountBlocks() {
+		err = c.InsertAccountBlocks(blocks)
+		if err != nil {
+			c.log.Crit("InsertGenesisAccountBlocks failed, error is "+err.Error(), "method", "initData")
+		}
 	}
 
 	// Insert second snapshot block
@@ -176,6 +177,16 @@ func (c *chain) initData() {
 		c.log.Crit("WriteSnapshotBlock failed, error is "+err.Error(), "method", "initData")
 	}
 
+	// The genesis blocks are indexed
+	batch := new(leveldb.Batch)
+	c.chainDb.Ac.WriteVmLogIndexStart(batch, 1)
+	if c.cfg.TxHistoryIndex {
+		c.chainDb.Ac.WriteTxHistoryStart(batch, 1)
+	}
+	if err = c.chainDb.Commit(batch); err != nil {
+		c.log.Crit("Write index start failed, error is "+err.Error(), "method", "initData")
+	}
+
 	// rebuild cache
 	c.needSnapshotCache.Rebuild()
 }
@@ -188,10 +199,9 @@ func (c *chain) ChainDb() *chain_db.ChainDb {
 	return c.chainDb
 }
 
-func (c *chain) Start() {
-	// Start compress in the background
-	c.log.Info("Start chain module")
-
+// Load reads the chain db and builds the caches, Start calls it before starting the background workers.
+// The commands maintaining the chain db call it instead of Start, so the chain is only written by them.
+func (c *chain) Load() {
 	// needSnapshotCache
 	unconfirmedSubLedger, getSubLedgerErr := c.getUnConfirmedSubLedger()
 	if getSubLedgerErr != nil {
@@ -212,9 +222,31 @@ func (c *chain) Start() {
 		c.log.Crit("GetLatestBlock failed, error is "+getLatestBlockErr.Error(), "method", "Start")
 	}
 
+	// tx history index
+	if err := c.initTxHistoryIndex(); err != nil {
+		c.log.Crit("initTxHistoryIndex failed, error is "+err.Error(), "method", "Start")
+	}
+
+	// vm log index
+	if err := c.initVmLogIndex(); err != nil {
+		c.log.Crit("initVmLogIndex failed, error is "+err.Error(), "method", "Start")
+	}
+}
+
+func (c *chain) Start() {
+	// Start compress in the background
+	c.log.Info("Start chain module")
+
+	c.Load()
+
 	// start compressor
 	c.compressor.Start()
 
+	// start trie gc
+	if c.trieGc != nil {
+		c.trieGc.Start()
+	}
+
 	// start kafka sender
 	if c.kafkaSender != nil {
 		for _, producer := range c.cfg.KafkaProducers {
@@ -223,8 +255,20 @@ func (c *chain) Start() {
 				c.log.Crit("Start kafka sender failed, error is " + startErr.Error())
 			}
 		}
+
+		for _, sink := range c.cfg.Sinks {
+			startErr := c.kafkaSender.StartSink(sink)
+			if startErr != nil {
+				c.log.Crit("Start sink failed, error is " + startErr.Error())
+			}
+		}
 	}
 
+	// metrics
+	monitor.NewGaugeFunc("gvite_chain_snapshot_height", "Height of the latest snapshot block", func() float64 {
+		return float64(c.GetLatestSnapshotBlock().Height)
+	})
+
 	c.log.Info("Chain module started")
 }
 
@@ -232,9 +276,16 @@ func (c *chain) Stop() {
 	// Stop compress
 	c.log.Info("Stop chain module")
 
+	monitor.Unregister("gvite_chain_snapshot_height")
+
 	// stop compressor
 	c.compressor.Stop()
 
+	// stop trie gc
+	if c.trieGc != nil {
+		c.trieGc.Stop()
+	}
+
 	// stop kafka sender
 	if c.kafkaSender != nil {
 		c.kafkaSender.StopAll()
@@ -7,6 +7,7 @@ import (
 
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/quota"
 	"github.com/vitelabs/go-vite/vm_context"
 )
@@ -16,6 +17,20 @@ func (c *chain) GetContractGidByAccountBlock(block *ledger.AccountBlock) (*types
 		return nil, nil
 	}
 
+	// the contract account doesn't exist until its first receive block is inserted,
+	// so read the gid from the create send block
+	if block.Height == 1 && block.IsReceiveBlock() {
+		fromBlock, err := c.GetAccountBlockByHash(&block.FromBlockHash)
+		if err != nil {
+			c.log.Error("GetAccountBlockByHash failed, error is "+err.Error(), "method", "GetContractGidByAccountBlock")
+			return nil, err
+		}
+		if fromBlock != nil && fromBlock.BlockType == ledger.BlockTypeSendCreate {
+			gid := contracts.GetGidFromCreateContractData(fromBlock.Data)
+			return &gid, nil
+		}
+	}
+
 	return c.GetContractGid(&block.AccountAddress)
 }
 
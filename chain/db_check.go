@@ -0,0 +1,949 @@
+package chain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// DbProblem is an inconsistency found in the chain db by CheckDb
+type DbProblem struct {
+	Module string
+	Msg    string
+
+	// RollbackHeight is the lowest snapshot block to be deleted to repair the problem,
+	// 0 means the confirmed blocks are not broken
+	RollbackHeight uint64
+
+	// the account blocks from height are deleted to repair the problem
+	addr   *types.Address
+	height uint64
+
+	// rewrite the index derived from blocks
+	fix func(batch *leveldb.Batch) error
+}
+
+func (p *DbProblem) String() string {
+	return p.Module + ": " + p.Msg
+}
+
+type checkedBlock struct {
+	addr      types.Address
+	accountId uint64
+	height    uint64
+
+	isSend        bool
+	toAddress     types.Address
+	fromBlockHash types.Hash
+	logHash       *types.Hash
+
+	snapshotHash types.Hash
+}
+
+// the indexes derived from blocks are checked in windows of the hash space. Every prefix is iterated once,
+// the blocks and the keys not led by a block hash are sorted into the window of their hash, see loadWindows
+const dbCheckWindows = 16
+
+// the indexes keyed by an address or an account id before the block hash, the hash ends their keys
+var dbCheckSortedPrefixes = []byte{
+	database.DBKP_ONROADMETA,
+	database.DBKP_LOG_INDEX_ACCOUNT,
+	database.DBKP_LOG_INDEX_TOPIC,
+	database.DBKP_TX_HISTORY,
+	database.DBKP_TX_HISTORY_RECEIVE,
+}
+
+// checkWindow is the part of the blocks and the indexes whose hash is in a window of the hash space
+type checkWindow struct {
+	blocks    map[types.Hash]*checkedBlock
+	receives  map[types.Hash][]uint64 // receive block heights by send block hash
+	logHashes map[types.Hash]struct{}
+
+	// keys of dbCheckSortedPrefixes by prefix
+	keys map[byte][][]byte
+}
+
+func newCheckWindow() *checkWindow {
+	return &checkWindow{
+		blocks:    make(map[types.Hash]*checkedBlock),
+		receives:  make(map[types.Hash][]uint64),
+		logHashes: make(map[types.Hash]struct{}),
+		keys:      make(map[byte][][]byte),
+	}
+}
+
+func windowOf(hash types.Hash) int {
+	return int(hash[0]) * dbCheckWindows / 256
+}
+
+// dbChecker walks the chain db, blocks are the primary data, and the others are derived from them.
+type dbChecker struct {
+	c  *chain
+	db *leveldb.DB
+
+	problems []*DbProblem
+
+	addrs      map[uint64]types.Address
+	accountIds map[types.Address]uint64
+
+	// 0 if the tx history index is disabled
+	txHistoryStart uint64
+	// 0 if the db is created before the vm log index
+	vmLogStart uint64
+
+	// the window being checked, a window is released after it is checked
+	windows [dbCheckWindows]*checkWindow
+	window  int
+	*checkWindow
+
+	snapshots       map[uint64]types.Hash
+	snapshotHeights map[types.Hash]uint64
+}
+
+func newDbChecker(c *chain) *dbChecker {
+	return &dbChecker{
+		c:  c,
+		db: c.chainDb.Db(),
+
+		addrs:      make(map[uint64]types.Address),
+		accountIds: make(map[types.Address]uint64),
+
+		snapshots:       make(map[uint64]types.Hash),
+		snapshotHeights: make(map[types.Hash]uint64),
+	}
+}
+
+func (dc *dbChecker) report(module string, fix func(batch *leveldb.Batch) error, format string, args ...interface{}) {
+	dc.problems = append(dc.problems, &DbProblem{
+		Module: module,
+		Msg:    fmt.Sprintf(format, args...),
+		fix:    fix,
+	})
+}
+
+func (dc *dbChecker) rollback(module string, snapshotHeight uint64, format string, args ...interface{}) {
+	dc.problems = append(dc.problems, &DbProblem{
+		Module:         module,
+		Msg:            fmt.Sprintf(format, args...),
+		RollbackHeight: snapshotHeight,
+	})
+}
+
+func (dc *dbChecker) deleteKey(key []byte) func(batch *leveldb.Batch) error {
+	key = append([]byte(nil), key...)
+	return func(batch *leveldb.Batch) error {
+		batch.Delete(key)
+		return nil
+	}
+}
+
+func (dc *dbChecker) iterate(prefix byte, fn func(key, value []byte) error) error {
+	key, _ := database.EncodeKey(prefix)
+
+	iter := dc.db.NewIterator(util.BytesPrefix(key), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// iterateWindow iterates the keys of prefix followed by a block hash in the window
+func (dc *dbChecker) iterateWindow(prefix byte, fn func(key, value []byte) error) error {
+	slice := &util.Range{
+		Start: []byte{prefix, byte(dc.window * 256 / dbCheckWindows)},
+		Limit: []byte{prefix, byte((dc.window + 1) * 256 / dbCheckWindows)},
+	}
+	if dc.window == dbCheckWindows-1 {
+		slice.Limit = []byte{prefix + 1}
+	}
+
+	iter := dc.db.NewIterator(slice, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			return err
+		}
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// iterateSorted iterates the keys of prefix in the window, prefix is one of dbCheckSortedPrefixes
+func (dc *dbChecker) iterateSorted(prefix byte, fn func(key []byte) error) error {
+	for _, key := range dc.keys[prefix] {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dc *dbChecker) check() error {
+	start, err := dc.c.chainDb.Ac.GetTxHistoryStart()
+	if err != nil {
+		return err
+	}
+	dc.txHistoryStart = start
+
+	if dc.vmLogStart, err = dc.c.chainDb.Ac.GetVmLogIndexStart(); err != nil {
+		return err
+	}
+
+	steps := []func() error{
+		dc.checkAccounts,
+		dc.checkSnapshotChain,
+		dc.checkAccountChains,
+		dc.checkSnapshotContents,
+		dc.checkBrokenAccounts,
+	}
+	windowSteps := []func() error{
+		dc.checkBlockMetas,
+		dc.checkBeSnapshots,
+		dc.checkLogLists,
+		dc.checkOnRoad,
+		dc.checkVmLogIndex,
+		dc.checkTxHistoryIndex,
+	}
+
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	if err := dc.loadWindows(); err != nil {
+		return err
+	}
+	for dc.window = 0; dc.window < dbCheckWindows; dc.window++ {
+		dc.checkWindow = dc.windows[dc.window]
+		for _, step := range windowSteps {
+			if err := step(); err != nil {
+				return err
+			}
+		}
+		dc.windows[dc.window] = nil
+	}
+	dc.checkWindow = nil
+	return nil
+}
+
+// checkAccounts checks the account id index is the same as the account records
+func (dc *dbChecker) checkAccounts() error {
+	accounts := make(map[types.Address]*ledger.Account)
+	if err := dc.iterate(database.DBKP_ACCOUNT, func(key, value []byte) error {
+		account := &ledger.Account{}
+		if err := account.Deserialize(value); err != nil {
+			return errors.Wrap(err, "deserialize account")
+		}
+		account.AccountAddress, _ = types.BytesToAddress(key[1:])
+
+		accounts[account.AccountAddress] = account
+		dc.addrs[account.AccountId] = account.AccountAddress
+		dc.accountIds[account.AccountAddress] = account.AccountId
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := dc.iterate(database.DBKP_ACCOUNTID_INDEX, func(key, value []byte) error {
+		accountId := binary.BigEndian.Uint64(key[1:])
+		addr, err := types.BytesToAddress(value)
+		if err != nil {
+			return errors.Wrap(err, "deserialize account index")
+		}
+
+		account := accounts[addr]
+		if account == nil {
+			dc.addrs[accountId] = addr
+			dc.report("accounts", func(batch *leveldb.Batch) error {
+				return dc.rebuildAccount(batch, accountId, &addr)
+			}, "account %s of id %d is missing", addr, accountId)
+		} else if account.AccountId != accountId {
+			dc.report("accounts", dc.deleteKey(key), "account id index %d points to %s of id %d", accountId, addr, account.AccountId)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for addr, account := range accounts {
+		indexed, err := dc.c.chainDb.Account.GetAddressById(account.AccountId)
+		if err != nil && err != leveldb.ErrNotFound {
+			return err
+		}
+		if indexed == nil || *indexed != addr {
+			account := account
+			dc.report("accounts", func(batch *leveldb.Batch) error {
+				dc.c.chainDb.Account.WriteAccountIndex(batch, account.AccountId, &account.AccountAddress)
+				return nil
+			}, "account id index of %s is missing", addr)
+		}
+	}
+
+	return nil
+}
+
+// rebuildAccount writes the account record with the public key of its latest block, like createAccount does
+func (dc *dbChecker) rebuildAccount(batch *leveldb.Batch, accountId uint64, addr *types.Address) error {
+	account := &ledger.Account{
+		AccountAddress: *addr,
+		AccountId:      accountId,
+	}
+
+	block, err := dc.c.chainDb.Ac.GetLatestBlock(accountId)
+	if err != nil {
+		return err
+	}
+	if block != nil {
+		account.PublicKey = block.PublicKey
+	}
+
+	return dc.c.chainDb.Account.WriteAccount(batch, account)
+}
+
+// checkAccountChains checks the account blocks are linked one by one, their log lists exist and they are indexed
+func (dc *dbChecker) checkAccountChains() error {
+	var prev *ledger.AccountBlock
+	var prevAccountId uint64
+
+	return dc.iterate(database.DBKP_ACCOUNTBLOCK, func(key, value []byte) error {
+		accountId := binary.BigEndian.Uint64(key[1:9])
+		height := binary.BigEndian.Uint64(key[9:17])
+		hash, _ := types.BytesToHash(key[17:])
+
+		first := accountId != prevAccountId
+		if first {
+			prev = nil
+			prevAccountId = accountId
+		}
+
+		addr, ok := dc.addrs[accountId]
+		if !ok {
+			dc.report("account chains", dc.deleteKey(key), "account block %s/%d of unknown account id %d", hash, height, accountId)
+			return nil
+		}
+
+		if first && height != 1 {
+			dc.broken(accountId, 1, "account blocks 1-%d of %s are missing", height-1, addr)
+		}
+
+		block := &ledger.AccountBlock{}
+		if err := block.DbDeserialize(value); err != nil {
+			dc.broken(accountId, height, "account block %s/%d of %s can`t be deserialized: %v", hash, height, addr, err)
+			prev = nil
+			return nil
+		}
+		block.Hash = hash
+
+		if prev != nil {
+			switch {
+			case height == prev.Height:
+				dc.broken(accountId, height, "account blocks %s and %s of %s are at the same height %d", prev.Hash, hash, addr, height)
+			case height != prev.Height+1:
+				dc.broken(accountId, prev.Height+1, "account blocks %d-%d of %s are missing", prev.Height+1, height-1, addr)
+			case block.PrevHash != prev.Hash:
+				dc.broken(accountId, height, "account block %s/%d of %s isn't linked to %s", hash, height, addr, prev.Hash)
+			}
+		}
+
+		if block.LogHash != nil {
+			logList, err := dc.c.chainDb.Ac.GetVmLogList(block.LogHash)
+			if err != nil {
+				return err
+			}
+			if logList == nil {
+				dc.broken(accountId, height, "log list %s of account block %s/%d is missing", block.LogHash, hash, height)
+			} else if err := dc.checkVmLogIndexOf(accountId, block, logList); err != nil {
+				return err
+			}
+		}
+
+		block.AccountAddress = addr
+		if err := dc.checkTxHistoryIndexOf(block); err != nil {
+			return err
+		}
+
+		prev = block
+		return nil
+	})
+}
+
+// checkVmLogIndexOf checks the block with logs is indexed by its account and by every topic of its logs
+// if it is confirmed after the index is created
+func (dc *dbChecker) checkVmLogIndexOf(accountId uint64, block *ledger.AccountBlock, logList ledger.VmLogList) error {
+	if dc.vmLogStart == 0 {
+		return nil
+	}
+	confirmHeight, err := dc.c.chainDb.Ac.GetConfirmHeight(&block.Hash)
+	if err != nil {
+		return err
+	}
+	if confirmHeight > 0 && confirmHeight < dc.vmLogStart {
+		return nil
+	}
+
+	ok, err := dc.c.chainDb.Ac.HasVmLogIndex(accountId, block, logList)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		dc.report("vm log index", func(batch *leveldb.Batch) error {
+			dc.c.chainDb.Ac.WriteVmLogIndex(batch, accountId, block, logList)
+			return nil
+		}, "vm log index of account block %s/%d of %s is missing", block.Hash, block.Height, dc.addrs[accountId])
+	}
+	return nil
+}
+
+// checkTxHistoryIndexOf checks the block is in the tx history index if it is confirmed after the index is enabled,
+// the send block is indexed when it is confirmed and the receive block when it is inserted
+func (dc *dbChecker) checkTxHistoryIndexOf(block *ledger.AccountBlock) error {
+	if dc.txHistoryStart == 0 {
+		return nil
+	}
+	confirmHeight, err := dc.c.chainDb.Ac.GetConfirmHeight(&block.Hash)
+	if err != nil {
+		return err
+	}
+	if (confirmHeight > 0 && confirmHeight < dc.txHistoryStart) || (confirmHeight == 0 && block.IsSendBlock()) {
+		return nil
+	}
+
+	if ok, err := dc.c.chainDb.Ac.HasTxHistoryIndex(block, confirmHeight); err != nil {
+		return err
+	} else if !ok {
+		dc.report("tx history index", func(batch *leveldb.Batch) error {
+			dc.c.chainDb.Ac.WriteTxHistoryIndex(batch, block, confirmHeight)
+			return nil
+		}, "tx history index of account block %s/%d of %s is missing", block.Hash, block.Height, block.AccountAddress)
+	}
+	return nil
+}
+
+// loadWindows reads the account blocks and the keys of dbCheckSortedPrefixes once, and sorts them into
+// the windows of their hash. A receive block is sorted by its send block hash too.
+func (dc *dbChecker) loadWindows() error {
+	for i := range dc.windows {
+		dc.windows[i] = newCheckWindow()
+	}
+
+	if err := dc.iterate(database.DBKP_ACCOUNTBLOCK, func(key, value []byte) error {
+		accountId := binary.BigEndian.Uint64(key[1:9])
+		height := binary.BigEndian.Uint64(key[9:17])
+		hash, _ := types.BytesToHash(key[17:])
+
+		// reported by checkAccountChains
+		addr, ok := dc.addrs[accountId]
+		if !ok {
+			return nil
+		}
+		block := &ledger.AccountBlock{}
+		if err := block.DbDeserialize(value); err != nil {
+			return nil
+		}
+
+		if block.LogHash != nil {
+			dc.windows[windowOf(*block.LogHash)].logHashes[*block.LogHash] = struct{}{}
+		}
+		if block.IsReceiveBlock() {
+			w := dc.windows[windowOf(block.FromBlockHash)]
+			w.receives[block.FromBlockHash] = append(w.receives[block.FromBlockHash], height)
+		}
+		dc.windows[windowOf(hash)].blocks[hash] = &checkedBlock{
+			addr:          addr,
+			accountId:     accountId,
+			height:        height,
+			isSend:        block.IsSendBlock(),
+			toAddress:     block.ToAddress,
+			fromBlockHash: block.FromBlockHash,
+			logHash:       block.LogHash,
+			snapshotHash:  block.SnapshotHash,
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, prefix := range dbCheckSortedPrefixes {
+		prefix := prefix
+		if err := dc.iterate(prefix, func(key, value []byte) error {
+			if len(key) < 1+types.HashSize {
+				return nil
+			}
+			hash, _ := types.BytesToHash(key[len(key)-types.HashSize:])
+			w := dc.windows[windowOf(hash)]
+			w.keys[prefix] = append(w.keys[prefix], append([]byte(nil), key...))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dc *dbChecker) broken(accountId uint64, height uint64, format string, args ...interface{}) {
+	addr := dc.addrs[accountId]
+	dc.problems = append(dc.problems, &DbProblem{
+		Module: "account chains",
+		Msg:    fmt.Sprintf(format, args...),
+		addr:   &addr,
+		height: height,
+	})
+}
+
+// checkSnapshotChain checks the snapshot blocks are linked one by one, and their hash index and content exist
+func (dc *dbChecker) checkSnapshotChain() error {
+	var prev *ledger.SnapshotBlock
+
+	if err := dc.iterate(database.DBKP_SNAPSHOTBLOCK, func(key, value []byte) error {
+		height := binary.BigEndian.Uint64(key[1:9])
+		hash, _ := types.BytesToHash(key[9:])
+
+		block := &ledger.SnapshotBlock{}
+		if err := block.Deserialize(value); err != nil {
+			dc.rollback("snapshot chain", height, "snapshot block %s/%d can`t be deserialized: %v", hash, height, err)
+			prev = nil
+			return nil
+		}
+		block.Hash = hash
+
+		if prev != nil {
+			switch {
+			case height == prev.Height:
+				dc.rollback("snapshot chain", height, "snapshot blocks %s and %s are at the same height %d", prev.Hash, hash, height)
+			case height == prev.Height+1 && block.PrevHash != prev.Hash:
+				dc.rollback("snapshot chain", height, "snapshot block %s/%d isn't linked to %s", hash, height, prev.Hash)
+			}
+		}
+
+		if indexed, err := dc.c.chainDb.Sc.GetSnapshotBlockHeight(&hash); err != nil {
+			return err
+		} else if indexed != height {
+			dc.report("snapshot chain", func(batch *leveldb.Batch) error {
+				dc.c.chainDb.Sc.WriteSnapshotHash(batch, &hash, height)
+				return nil
+			}, "hash index of snapshot block %s/%d points to %d", hash, height, indexed)
+		}
+
+		contentKey, _ := database.EncodeKey(database.DBKP_SNAPSHOTCONTENT, height)
+		if ok, err := dc.db.Has(contentKey, nil); err != nil {
+			return err
+		} else if !ok {
+			dc.rollback("snapshot content", height, "content of snapshot block %s/%d is missing", hash, height)
+		}
+
+		dc.snapshots[height] = hash
+		dc.snapshotHeights[hash] = height
+		prev = block
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// hash index of the deleted snapshot blocks
+	return dc.iterate(database.DBKP_SNAPSHOTBLOCKHASH, func(key, value []byte) error {
+		hash, _ := types.BytesToHash(key[1:])
+		if _, ok := dc.snapshotHeights[hash]; !ok {
+			dc.report("snapshot chain", dc.deleteKey(key), "hash index of snapshot block %s is dangling", hash)
+		}
+		return nil
+	})
+}
+
+// iterateContents calls fn with the snapshot contents in order of the snapshot height
+func (dc *dbChecker) iterateContents(fn func(height uint64, content ledger.SnapshotContent) error) error {
+	heights := make([]uint64, 0, len(dc.snapshots))
+	for height := range dc.snapshots {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool {
+		return heights[i] < heights[j]
+	})
+
+	for _, height := range heights {
+		content, err := dc.c.chainDb.Sc.GetSnapshotContent(height)
+		if err != nil {
+			return err
+		}
+		if err := fn(height, content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSnapshotContents checks the account blocks in snapshot contents exist, and their BE_SNAPSHOT is the snapshot height
+func (dc *dbChecker) checkSnapshotContents() error {
+	return dc.iterateContents(func(height uint64, content ledger.SnapshotContent) error {
+		for addr, hashHeight := range content {
+			var hash *types.Hash
+			if accountId, ok := dc.accountIds[addr]; ok {
+				var err error
+				if hash, err = dc.c.chainDb.Ac.GetHashByHeight(accountId, hashHeight.Height); err != nil {
+					return err
+				}
+			}
+			if hash == nil || *hash != hashHeight.Hash {
+				dc.rollback("snapshot content", height, "account block %s/%d of %s in snapshot block %d is missing",
+					hashHeight.Hash, hashHeight.Height, addr, height)
+				continue
+			}
+
+			beSnapshot, err := dc.c.chainDb.Ac.GetBeSnapshot(hash)
+			if err != nil {
+				return err
+			}
+			if beSnapshot != height {
+				height := height
+				dc.report("block metas", func(batch *leveldb.Batch) error {
+					return dc.c.chainDb.Ac.WriteBeSnapshot(batch, hash, height)
+				}, "snapshot height of account block %s is %d, but it is confirmed by snapshot block %d", hash, beSnapshot, height)
+			}
+		}
+		return nil
+	})
+}
+
+// checkBrokenAccounts finds the snapshot block confirms the broken account blocks, they must be rolled back together
+func (dc *dbChecker) checkBrokenAccounts() error {
+	broken := make(map[types.Address][]*DbProblem)
+	for _, p := range dc.problems {
+		if p.addr != nil {
+			broken[*p.addr] = append(broken[*p.addr], p)
+		}
+	}
+	if len(broken) == 0 {
+		return nil
+	}
+
+	return dc.iterateContents(func(height uint64, content ledger.SnapshotContent) error {
+		for addr, hashHeight := range content {
+			for _, p := range broken[addr] {
+				if hashHeight.Height >= p.height && p.RollbackHeight == 0 {
+					p.RollbackHeight = height
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// expectedMeta derives the meta of the account block, the snapshot height is kept in BE_SNAPSHOT
+func (dc *dbChecker) expectedMeta(hash *types.Hash) *ledger.AccountBlockMeta {
+	block := dc.blocks[*hash]
+
+	receiveHeights := dc.receives[*hash]
+	sort.Slice(receiveHeights, func(i, j int) bool {
+		return receiveHeights[i] < receiveHeights[j]
+	})
+
+	return &ledger.AccountBlockMeta{
+		AccountId:           block.accountId,
+		Height:              block.height,
+		ReceiveBlockHeights: receiveHeights,
+		RefSnapshotHeight:   dc.snapshotHeights[block.snapshotHash],
+	}
+}
+
+func sameMeta(a, b *ledger.AccountBlockMeta) bool {
+	if a.AccountId != b.AccountId || a.Height != b.Height || a.RefSnapshotHeight != b.RefSnapshotHeight ||
+		len(a.ReceiveBlockHeights) != len(b.ReceiveBlockHeights) {
+		return false
+	}
+
+	heights := append([]uint64(nil), a.ReceiveBlockHeights...)
+	sort.Slice(heights, func(i, j int) bool {
+		return heights[i] < heights[j]
+	})
+	for i, height := range heights {
+		if height != b.ReceiveBlockHeights[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkBlockMetas checks every account block has a meta derived from the blocks
+func (dc *dbChecker) checkBlockMetas() error {
+	checked := make(map[types.Hash]struct{}, len(dc.blocks))
+
+	if err := dc.iterateWindow(database.DBKP_ACCOUNTBLOCKMETA, func(key, value []byte) error {
+		hash, _ := types.BytesToHash(key[1:])
+		checked[hash] = struct{}{}
+
+		if _, ok := dc.blocks[hash]; !ok {
+			dc.report("block metas", func(batch *leveldb.Batch) error {
+				dc.c.chainDb.Ac.DeleteBlockMeta(batch, &hash)
+				return nil
+			}, "meta of account block %s is dangling", hash)
+			return nil
+		}
+
+		meta := &ledger.AccountBlockMeta{}
+		expected := dc.expectedMeta(&hash)
+		if err := meta.Deserialize(value); err != nil || !sameMeta(meta, expected) {
+			dc.report("block metas", func(batch *leveldb.Batch) error {
+				return dc.c.chainDb.Ac.WriteBlockMeta(batch, &hash, expected)
+			}, "meta of account block %s/%d is inconsistent with blocks", hash, expected.Height)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for hash, block := range dc.blocks {
+		if _, ok := checked[hash]; ok {
+			continue
+		}
+
+		hash := hash
+		expected := dc.expectedMeta(&hash)
+		dc.report("block metas", func(batch *leveldb.Batch) error {
+			return dc.c.chainDb.Ac.WriteBlockMeta(batch, &hash, expected)
+		}, "meta of account block %s/%d of %s is missing", hash, block.height, block.addr)
+	}
+
+	return nil
+}
+
+// checkBeSnapshots checks BE_SNAPSHOT points to existing blocks.
+func (dc *dbChecker) checkBeSnapshots() error {
+	return dc.iterateWindow(database.DBKP_BE_SNAPSHOT, func(key, value []byte) error {
+		hash, _ := types.BytesToHash(key[1:])
+		height := binary.BigEndian.Uint64(value)
+
+		if _, ok := dc.blocks[hash]; !ok {
+			dc.report("block metas", dc.deleteKey(key), "snapshot height of account block %s is dangling", hash)
+		} else if _, ok := dc.snapshots[height]; !ok {
+			dc.report("block metas", dc.deleteKey(key), "account block %s is confirmed by snapshot block %d which doesn't exist", hash, height)
+		}
+		return nil
+	})
+}
+
+func (dc *dbChecker) checkLogLists() error {
+	return dc.iterateWindow(database.DBKP_LOG_LIST, func(key, value []byte) error {
+		hash, _ := types.BytesToHash(key[1:])
+		if _, ok := dc.logHashes[hash]; !ok {
+			dc.report("log lists", dc.deleteKey(key), "log list %s is dangling", hash)
+		}
+		return nil
+	})
+}
+
+// checkOnRoad checks every onroad meta points to a send block not received. A send block may have no
+// onroad meta though it isn't received, the onroad deletes it directly if the contract can`t receive it.
+func (dc *dbChecker) checkOnRoad() error {
+	if err := dc.iterateSorted(database.DBKP_ONROADMETA, func(key []byte) error {
+		addr, _ := types.BytesToAddress(key[1 : 1+types.AddressSize])
+		hash, _ := types.BytesToHash(key[1+types.AddressSize:])
+
+		block := dc.blocks[hash]
+		switch {
+		case block == nil || !block.isSend || block.toAddress != addr:
+			dc.report("onroad", dc.deleteKey(key), "onroad block %s of %s doesn't exist", hash, addr)
+		case len(dc.receives[hash]) > 0:
+			dc.report("onroad", dc.deleteKey(key), "onroad block %s of %s has been received", hash, addr)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return dc.iterateWindow(database.DBKP_ONROADRECEIVEERR, func(key, value []byte) error {
+		hash, _ := types.BytesToHash(key[1 : 1+types.HashSize])
+		if _, ok := dc.blocks[hash]; !ok {
+			dc.report("onroad", dc.deleteKey(key), "receive error count of onroad block %s is dangling", hash)
+		}
+		return nil
+	})
+}
+
+// checkVmLogIndex checks the vm log index points to the account blocks with logs, the topics must be in their logs
+func (dc *dbChecker) checkVmLogIndex() error {
+	if err := dc.iterateSorted(database.DBKP_LOG_INDEX_ACCOUNT, func(key []byte) error {
+		accountId := binary.BigEndian.Uint64(key[1:9])
+		height := binary.BigEndian.Uint64(key[9:17])
+		hash, _ := types.BytesToHash(key[17:])
+
+		block := dc.blocks[hash]
+		if block == nil || block.accountId != accountId || block.height != height || block.logHash == nil {
+			dc.report("vm log index", dc.deleteKey(key), "vm log index of account block %s/%d is dangling", hash, height)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return dc.iterateSorted(database.DBKP_LOG_INDEX_TOPIC, func(key []byte) error {
+		topic, _ := types.BytesToHash(key[1 : 1+types.HashSize])
+		offset := 1 + types.HashSize
+		accountId := binary.BigEndian.Uint64(key[offset : offset+8])
+		height := binary.BigEndian.Uint64(key[offset+8 : offset+16])
+		hash, _ := types.BytesToHash(key[offset+16:])
+
+		block := dc.blocks[hash]
+		if block != nil && block.accountId == accountId && block.height == height && block.logHash != nil {
+			logList, err := dc.c.chainDb.Ac.GetVmLogList(block.logHash)
+			if err != nil {
+				return err
+			}
+			for _, vmLog := range logList {
+				for _, logTopic := range vmLog.Topics {
+					if logTopic == topic {
+						return nil
+					}
+				}
+			}
+		}
+		dc.report("vm log index", dc.deleteKey(key), "vm log index of account block %s/%d by topic %s is dangling", hash, height, topic)
+		return nil
+	})
+}
+
+// checkTxHistoryIndex checks the tx history index points to the send blocks to the address confirmed at the height of the key,
+// and the receive blocks of the address
+func (dc *dbChecker) checkTxHistoryIndex() error {
+	if dc.txHistoryStart == 0 {
+		return nil
+	}
+
+	offset := 1 + types.AddressSize
+	if err := dc.iterateSorted(database.DBKP_TX_HISTORY, func(key []byte) error {
+		toAddr, _ := types.BytesToAddress(key[1:offset])
+		height := binary.BigEndian.Uint64(key[offset : offset+8])
+		hash, _ := types.BytesToHash(key[offset+8:])
+
+		block := dc.blocks[hash]
+		if block != nil && block.isSend && block.toAddress == toAddr {
+			confirmHeight, err := dc.c.chainDb.Ac.GetConfirmHeight(&hash)
+			if err != nil {
+				return err
+			}
+			if confirmHeight == height {
+				return nil
+			}
+		}
+		dc.report("tx history index", dc.deleteKey(key), "tx history index of send block %s to %s is dangling", hash, toAddr)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return dc.iterateSorted(database.DBKP_TX_HISTORY_RECEIVE, func(key []byte) error {
+		addr, _ := types.BytesToAddress(key[1:offset])
+		sendHash, _ := types.BytesToHash(key[offset : offset+types.HashSize])
+		hash, _ := types.BytesToHash(key[offset+types.HashSize:])
+
+		block := dc.blocks[hash]
+		if block == nil || block.isSend || block.addr != addr || block.fromBlockHash != sendHash {
+			dc.report("tx history index", dc.deleteKey(key), "tx history index of receive block %s of %s is dangling", hash, addr)
+		}
+		return nil
+	})
+}
+
+// CheckDb walks the chain db and returns the inconsistencies found, the chain must not be written meanwhile
+func (c *chain) CheckDb() ([]*DbProblem, error) {
+	dc := newDbChecker(c)
+	if err := dc.check(); err != nil {
+		c.log.Error("check failed, error is "+err.Error(), "method", "CheckDb")
+		return nil, err
+	}
+	return dc.problems, nil
+}
+
+// fixDb rewrites the indexes derived from blocks
+func (c *chain) fixDb(problems []*DbProblem) error {
+	batch := new(leveldb.Batch)
+	for _, p := range problems {
+		if p.fix == nil {
+			continue
+		}
+		if err := p.fix(batch); err != nil {
+			c.log.Error("fix failed, error is "+err.Error(), "method", "fixDb")
+			return err
+		}
+	}
+
+	if err := c.chainDb.Commit(batch); err != nil {
+		c.log.Error("Commit failed, error is "+err.Error(), "method", "fixDb")
+		return err
+	}
+	return nil
+}
+
+// refixDb rewrites the indexes left by the deleted blocks, eg. the snapshot height of
+// account blocks confirmed by a deleted snapshot block whose content is missing, and the vm log index
+// and the tx history index of the broken blocks which can`t be derived when they are deleted
+func (c *chain) refixDb() error {
+	problems, err := c.CheckDb()
+	if err != nil {
+		return err
+	}
+	return c.fixDb(problems)
+}
+
+// RepairDb rebuilds the indexes derived from blocks, deletes the broken account blocks, then rolls back
+// to the last snapshot block which confirms no broken blocks.
+func (c *chain) RepairDb(problems []*DbProblem) error {
+	if err := c.fixDb(problems); err != nil {
+		return err
+	}
+
+	var rollbackHeight uint64
+	broken := make(map[types.Address]uint64)
+	for _, p := range problems {
+		if p.RollbackHeight > 0 && (rollbackHeight == 0 || p.RollbackHeight < rollbackHeight) {
+			rollbackHeight = p.RollbackHeight
+		}
+
+		if p.addr != nil {
+			if height, ok := broken[*p.addr]; !ok || p.height < height {
+				broken[*p.addr] = p.height
+			}
+		}
+	}
+
+	if rollbackHeight == 1 {
+		return errors.New("genesis snapshot block is broken, the chain db must be removed")
+	}
+
+	// the snapshot blocks confirm them are deleted by the rollback
+	for addr, height := range broken {
+		if _, err := c.deleteAccountBlocks(&addr, height, false); err != nil {
+			c.log.Error("deleteAccountBlocks failed, error is "+err.Error(), "method", "RepairDb")
+			return err
+		}
+	}
+
+	if rollbackHeight > 0 {
+		if _, _, err := c.deleteSnapshotBlocksToHeight(rollbackHeight, true); err != nil {
+			c.log.Error("deleteSnapshotBlocksToHeight failed, error is "+err.Error(), "method", "RepairDb")
+			return err
+		}
+	}
+
+	if len(broken) > 0 || rollbackHeight > 0 {
+		return c.refixDb()
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package chain
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func checkDbProblems(t *testing.T, chainInstance Chain) []*DbProblem {
+	problems, err := chainInstance.CheckDb()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return problems
+}
+
+func TestCheckDb(t *testing.T) {
+	chainInstance := getChainInstance()
+	if problems := checkDbProblems(t, chainInstance); len(problems) > 0 {
+		t.Fatalf("chain db should be consistent: %v", problems)
+	}
+
+	var content ledger.SnapshotContent
+	for height := chainInstance.GetLatestSnapshotBlock().Height; height > 0 && len(content) == 0; height-- {
+		block, err := chainInstance.GetSnapshotBlockByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = block.SnapshotContent
+	}
+
+	chainDb := chainInstance.ChainDb()
+	batch := new(leveldb.Batch)
+
+	// confirmed account block loses its snapshot height
+	for _, hashHeight := range content {
+		chainDb.Ac.DeleteBeSnapshot(batch, &hashHeight.Hash)
+		break
+	}
+
+	// onroad block doesn't exist
+	addr, _, _ := types.CreateAddress()
+	onroadKey, _ := database.EncodeKey(database.DBKP_ONROADMETA, addr.Bytes(), types.DataHash([]byte("dangling")).Bytes())
+	batch.Put(onroadKey, []byte{0})
+
+	// log list isn't referred by any account block
+	if err := chainDb.Ac.WriteVmLogList(batch, ledger.VmLogList{{Data: []byte("dangling")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chainDb.Commit(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := checkDbProblems(t, chainInstance)
+	if len(problems) != 3 {
+		t.Fatalf("3 problems should be found: %v", problems)
+	}
+	for _, p := range problems {
+		if p.RollbackHeight != 0 {
+			t.Fatalf("%s should be repaired without rollback", p)
+		}
+	}
+
+	if err := chainInstance.RepairDb(problems); err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkDbProblems(t, chainInstance); len(problems) > 0 {
+		t.Fatalf("problems should be repaired: %v", problems)
+	}
+}
+
+func TestRepairDbRollback(t *testing.T) {
+	chainInstance := getChainInstance()
+
+	snapshotBlock, err := newSnapshotBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chainInstance.InsertSnapshotBlock(snapshotBlock); err != nil {
+		t.Fatal(err)
+	}
+
+	// crashed before the snapshot content is written
+	contentKey, _ := database.EncodeKey(database.DBKP_SNAPSHOTCONTENT, snapshotBlock.Height)
+	if err := chainInstance.ChainDb().Db().Delete(contentKey, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := checkDbProblems(t, chainInstance)
+	if len(problems) == 0 || problems[0].RollbackHeight != snapshotBlock.Height {
+		t.Fatalf("snapshot block %d should be rolled back: %v", snapshotBlock.Height, problems)
+	}
+
+	if err := chainInstance.RepairDb(problems); err != nil {
+		t.Fatal(err)
+	}
+	if latest := chainInstance.GetLatestSnapshotBlock(); latest.Height != snapshotBlock.Height-1 {
+		t.Fatalf("latest snapshot block should be %d, got %d", snapshotBlock.Height-1, latest.Height)
+	}
+	if problems := checkDbProblems(t, chainInstance); len(problems) > 0 {
+		t.Fatalf("problems should be repaired: %v", problems)
+	}
+}
+
+func TestRepairDbIndexes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "db_check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newTxHistoryChain(t, dir, true)
+	defer c.Destroy()
+	defer c.Stop()
+
+	addr := ledger.GenesisAccountAddress
+	other, _ := types.HexToAddress("vite_39f1ede9ab4979b8a77167bfade02a3b4df0c413ad048cb999")
+	sent := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: addr, ToAddress: other})
+	insertTestSnapshotBlock(t, c)
+	if problems := checkDbProblems(t, c); len(problems) > 0 {
+		t.Fatalf("chain db should be consistent: %v", problems)
+	}
+
+	batch := new(leveldb.Batch)
+	// the send block loses its tx history index
+	confirmHeight, err := c.chainDb.Ac.GetConfirmHeight(&sent.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.chainDb.Ac.DeleteTxHistoryIndex(batch, sent, confirmHeight)
+
+	// the indexes point to a deleted block
+	deleted := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: addr, ToAddress: other, Height: 100,
+		Hash: types.DataHash([]byte("deleted"))}
+	c.chainDb.Ac.WriteTxHistoryIndex(batch, deleted, confirmHeight)
+	c.chainDb.Ac.WriteVmLogIndex(batch, 1, deleted, ledger.VmLogList{{Topics: []types.Hash{types.DataHash([]byte("topic"))}}})
+
+	if err := c.chainDb.Commit(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	problems := checkDbProblems(t, c)
+	if len(problems) != 4 {
+		t.Fatalf("4 problems should be found: %v", problems)
+	}
+	if err := c.RepairDb(problems); err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkDbProblems(t, c); len(problems) > 0 {
+		t.Fatalf("problems should be repaired: %v", problems)
+	}
+
+	filter := &TransferFilter{Addr: other, FromSnapshotHeight: 1, ToSnapshotHeight: c.GetLatestSnapshotBlock().Height}
+	if transfers, err := c.GetTransfers(filter); err != nil || !reflect.DeepEqual(transferHashes(transfers), []types.Hash{sent.Hash}) {
+		t.Fatalf("the repaired index should return the sent transfer, got %v, error %v", transfers, err)
+	}
+}
@@ -1,14 +1,19 @@
 package chain
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
 	"strconv"
 	"time"
 
+	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/trie"
 	"github.com/vitelabs/go-vite/vm_context"
@@ -21,39 +26,346 @@ var SecondSnapshotBlock ledger.SnapshotBlock
 var GenesisMintageBlock ledger.AccountBlock
 var GenesisMintageBlockVC vmctxt_interface.VmDatabase
 
+// GenesisMintageSendBlock is the first block of GenesisMintageSendBlocks
 var GenesisMintageSendBlock ledger.AccountBlock
 var GenesisMintageSendBlockVC vmctxt_interface.VmDatabase
 
+// GenesisMintageSendBlocks send the initial balances from the mintage contract
+var GenesisMintageSendBlocks []*vm_context.VmAccountBlock
+
 var GenesisConsensusGroupBlock ledger.AccountBlock
 var GenesisConsensusGroupBlockVC vmctxt_interface.VmDatabase
 
 var GenesisRegisterBlock ledger.AccountBlock
 var GenesisRegisterBlockVC vmctxt_interface.VmDatabase
 
+// GenesisVoteBlock is nil if there is no initial vote
+var GenesisVoteBlock *ledger.AccountBlock
+var GenesisVoteBlockVC vmctxt_interface.VmDatabase
+
 func init() {
-	GenesisSnapshotBlock = genesisSnapshotBlock()
+	if err := InitGenesis(nil); err != nil {
+		panic("InitGenesis failed, error is " + err.Error())
+	}
+}
+
+var genesisTrieNodePool = trie.NewTrieNodePool()
+var genesisTimestamp time.Time
+
+var totalSupply = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e9))
+
+var defaultGenesisProducers = []string{
+	"vite_0acbb1335822c8df4488f3eea6e9000eabb0f19d8802f57c87",
+	"vite_14edbc9214bd1e5f6082438f707d10bf43463a6d599a4f2d08",
+	"vite_1630f8c0cf5eda3ce64bd49a0523b826f67b19a33bc2a5dcfb",
+	"vite_1b1dfa00323aea69465366d839703547fec5359d6c795c8cef",
+	"vite_27a258dd1ed0ce0de3f4abd019adacd1b4b163b879389d3eca",
+	"vite_31a02e4f4b536e2d6d9bde23910cdffe72d3369ef6fe9b9239",
+	"vite_383fedcbd5e3f52196a4e8a1392ed3ddc4d4360e4da9b8494e",
+	"vite_41ba695ff63caafd5460dcf914387e95ca3a900f708ac91f06",
+	"vite_545c8e4c74e7bb6911165e34cbfb83bc513bde3623b342d988",
+	"vite_5a1b5ece654138d035bdd9873c1892fb5817548aac2072992e",
+	"vite_70cfd586185e552635d11f398232344f97fc524fa15952006d",
+	"vite_76df2a0560694933d764497e1b9b11f9ffa1524b170f55dda0",
+	"vite_7b76ca2433c7ddb5a5fa315ca861e861d432b8b05232526767",
+	"vite_7caaee1d51abad4047a58f629f3e8e591247250dad8525998a",
+	"vite_826a1ab4c85062b239879544dc6b67e3b5ce32d0a1eba21461",
+	"vite_89007189ad81c6ee5cdcdc2600a0f0b6846e0a1aa9a58e5410",
+	"vite_9abcb7324b8d9029e4f9effe76f7336bfd28ed33cb5b877c8d",
+	"vite_af60cf485b6cc2280a12faac6beccfef149597ea518696dcf3",
+	"vite_c1090802f735dfc279a6c24aacff0e3e4c727934e547c24e5e",
+	"vite_c10ae7a14649800b85a7eaaa8bd98c99388712412b41908cc0",
+	"vite_d45ac37f6fcdb1c362a33abae4a7d324a028aa49aeea7e01cb",
+	"vite_d8974670af8e1f3c4378d01d457be640c58644bc0fa87e3c30",
+	"vite_e289d98f33c3ef5f1b41048c2cb8b389142f033d1df9383818",
+	"vite_f53dcf7d40b582cd4b806d2579c6dd7b0b131b96c2b2ab5218",
+	"vite_fac06662d84a7bea269265e78ea2d9151921ba2fae97595608",
+}
+
+// DefaultGenesisConfig returns the spec of the mainnet genesis blocks
+func DefaultGenesisConfig() *config.Genesis {
+	registerPledgeAmount := new(big.Int).Mul(big.NewInt(5e5), big.NewInt(1e18))
+
+	cfg := &config.Genesis{
+		Timestamp: 1541650394,
+		Mintage: &config.GenesisMintage{
+			TokenName:   "Vite Token",
+			TokenSymbol: "VITE",
+			Decimals:    18,
+			TotalSupply: totalSupply.String(),
+			Owner:       ledger.GenesisAccountAddress,
+		},
+		Balances: []*config.GenesisBalance{{
+			Address: ledger.GenesisAccountAddress,
+			Amount:  totalSupply.String(),
+		}},
+		ConsensusGroups: []*config.GenesisConsensusGroup{{
+			Gid:                  types.SNAPSHOT_GID,
+			NodeCount:            25,
+			Interval:             1,
+			PerCount:             3,
+			RandCount:            2,
+			RandRank:             100,
+			CountingTokenId:      ledger.ViteTokenId,
+			Owner:                ledger.GenesisAccountAddress,
+			RegisterPledgeAmount: registerPledgeAmount.String(),
+			RegisterPledgeToken:  ledger.ViteTokenId,
+			RegisterPledgeHeight: 3600 * 24 * 90,
+		}, {
+			Gid:                  types.DELEGATE_GID,
+			NodeCount:            25,
+			Interval:             3,
+			PerCount:             1,
+			RandCount:            2,
+			RandRank:             100,
+			CountingTokenId:      ledger.ViteTokenId,
+			Owner:                ledger.GenesisAccountAddress,
+			RegisterPledgeAmount: registerPledgeAmount.String(),
+			RegisterPledgeToken:  ledger.ViteTokenId,
+			RegisterPledgeHeight: 3600 * 24 * 90,
+		}},
+		Votes: []*config.GenesisVote{},
+	}
+
+	for index, addrStr := range defaultGenesisProducers {
+		addr, _ := types.HexToAddress(addrStr)
+		cfg.Registrations = append(cfg.Registrations, &config.GenesisRegistration{
+			Gid:        types.SNAPSHOT_GID,
+			Name:       "s" + strconv.Itoa(index+1),
+			NodeAddr:   addr,
+			PledgeAddr: addr,
+		})
+	}
+	return cfg
+}
 
-	GenesisMintageBlock, GenesisMintageBlockVC = genesisMintageBlock()
+// DevGenesisConfig returns the spec of dev mode, addr owns the total supply and is the only producer
+func DevGenesisConfig(addr types.Address) *config.Genesis {
+	cfg := DefaultGenesisConfig()
+	cfg.Mintage.Owner = addr
+	cfg.Balances = []*config.GenesisBalance{{
+		Address: addr,
+		Amount:  cfg.Mintage.TotalSupply,
+	}}
+	for _, group := range cfg.ConsensusGroups {
+		group.NodeCount = 1
+		group.Interval = 1
+		group.PerCount = 1
+		group.RandCount = 0
+		group.Owner = addr
+	}
+	cfg.Registrations = []*config.GenesisRegistration{{
+		Gid:        types.SNAPSHOT_GID,
+		Name:       "s1",
+		NodeAddr:   addr,
+		PledgeAddr: addr,
+	}}
+	return cfg
+}
 
-	GenesisMintageSendBlock, GenesisMintageSendBlockVC = genesisMintageSendBlock()
+// fillGenesisConfig returns a copy of cfg whose nil sections are filled with the default
+func fillGenesisConfig(cfg *config.Genesis) *config.Genesis {
+	defaultCfg := DefaultGenesisConfig()
+	if cfg == nil {
+		return defaultCfg
+	}
 
-	GenesisConsensusGroupBlock, GenesisConsensusGroupBlockVC = genesisConsensusGroupBlock()
+	filled := *cfg
+	if filled.Timestamp == 0 {
+		filled.Timestamp = defaultCfg.Timestamp
+	}
+	if filled.Mintage == nil {
+		filled.Mintage = defaultCfg.Mintage
+	}
+	if filled.Balances == nil {
+		filled.Balances = []*config.GenesisBalance{{
+			Address: filled.Mintage.Owner,
+			Amount:  filled.Mintage.TotalSupply,
+		}}
+	}
+	if filled.ConsensusGroups == nil {
+		filled.ConsensusGroups = defaultCfg.ConsensusGroups
+	}
+	if filled.Registrations == nil {
+		filled.Registrations = defaultCfg.Registrations
+	}
+	if filled.Votes == nil {
+		filled.Votes = defaultCfg.Votes
+	}
+	return &filled
+}
 
-	GenesisRegisterBlock, GenesisRegisterBlockVC = genesisRegisterBlock()
+// genesisSpecHash returns the hash of cfg, nil if cfg is the default spec so that
+// the mainnet genesis snapshot block is kept unchanged. ContractParams is omitted if nil,
+// so the hashes of the specs without it are kept too.
+func genesisSpecHash(cfg *config.Genesis) (*types.Hash, error) {
+	buf, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultBuf, err := json.Marshal(DefaultGenesisConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	if string(buf) == string(defaultBuf) {
+		return nil, nil
+	}
+
+	hash := types.DataHash(buf)
+	return &hash, nil
+}
+
+func parseGenesisAmount(name string, amount string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, errors.New(fmt.Sprintf("%s %s is not a valid amount", name, amount))
+	}
+	return value, nil
+}
+
+func checkGenesisConfig(cfg *config.Genesis) error {
+	supply, err := parseGenesisAmount("TotalSupply", cfg.Mintage.TotalSupply)
+	if err != nil {
+		return err
+	}
+
+	sum := big.NewInt(0)
+	for _, balance := range cfg.Balances {
+		amount, err := parseGenesisAmount("Balance of "+balance.Address.String(), balance.Amount)
+		if err != nil {
+			return err
+		}
+		sum.Add(sum, amount)
+	}
+	if sum.Cmp(supply) != 0 {
+		return errors.New("sum of Balances is not equal to TotalSupply")
+	}
+
+	groups := make(map[types.Gid]struct{})
+	for _, group := range cfg.ConsensusGroups {
+		if _, ok := groups[group.Gid]; ok {
+			return errors.New("duplicated consensus group " + group.Gid.String())
+		}
+		if _, err := parseGenesisAmount("RegisterPledgeAmount", group.RegisterPledgeAmount); err != nil {
+			return err
+		}
+		groups[group.Gid] = struct{}{}
+	}
+	if _, ok := groups[types.SNAPSHOT_GID]; !ok {
+		return errors.New("snapshot consensus group is missing")
+	}
+
+	names := make(map[types.Gid]map[string]struct{})
+	for _, registration := range cfg.Registrations {
+		if _, ok := groups[registration.Gid]; !ok {
+			return errors.New("consensus group of registration " + registration.Name + " is missing")
+		}
+		if registration.Name == "" {
+			return errors.New("registration name is empty")
+		}
+		if names[registration.Gid] == nil {
+			names[registration.Gid] = make(map[string]struct{})
+		}
+		if _, ok := names[registration.Gid][registration.Name]; ok {
+			return errors.New("duplicated registration " + registration.Name)
+		}
+		names[registration.Gid][registration.Name] = struct{}{}
+	}
+
+	for _, vote := range cfg.Votes {
+		if _, ok := names[vote.Gid][vote.NodeName]; !ok {
+			return errors.New("vote of " + vote.Addr.String() + " is for an unregistered node " + vote.NodeName)
+		}
+	}
+
+	if cfg.ContractParams != nil {
+		if _, err := contracts.NewContractsParams(cfg.ContractParams); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InitGenesis builds the genesis blocks from cfg, the mainnet genesis blocks are built if cfg is nil.
+// It must be called before the chain is initialized.
+func InitGenesis(cfg *config.Genesis) error {
+	cfg = fillGenesisConfig(cfg)
+	if err := checkGenesisConfig(cfg); err != nil {
+		return err
+	}
+
+	specHash, err := genesisSpecHash(cfg)
+	if err != nil {
+		return err
+	}
+
+	genesisTimestamp = time.Unix(cfg.Timestamp, 0)
+
+	GenesisSnapshotBlock = genesisSnapshotBlock(specHash)
+
+	if GenesisMintageBlock, GenesisMintageBlockVC, err = genesisMintageBlock(cfg); err != nil {
+		return err
+	}
+
+	GenesisMintageSendBlocks = genesisMintageSendBlocks(cfg)
+	GenesisMintageSendBlock = *GenesisMintageSendBlocks[0].AccountBlock
+	GenesisMintageSendBlockVC = GenesisMintageSendBlocks[0].VmContext
+
+	if GenesisConsensusGroupBlock, GenesisConsensusGroupBlockVC, err = genesisConsensusGroupBlock(cfg); err != nil {
+		return err
+	}
+
+	if GenesisRegisterBlock, GenesisRegisterBlockVC, err = genesisRegisterBlock(cfg); err != nil {
+		return err
+	}
+
+	if GenesisVoteBlock, GenesisVoteBlockVC, err = genesisVoteBlock(cfg); err != nil {
+		return err
+	}
 
 	SecondSnapshotBlock = secondSnapshotBlock()
+	return nil
 }
 
-var genesisTrieNodePool = trie.NewTrieNodePool()
-var genesisTimestamp = time.Unix(1541650394, 0)
+// GenesisAccountBlocks returns the account blocks confirmed by SecondSnapshotBlock, in insertion order
+func GenesisAccountBlocks() [][]*vm_context.VmAccountBlock {
+	blocksList := [][]*vm_context.VmAccountBlock{
+		append([]*vm_context.VmAccountBlock{{
+			AccountBlock: &GenesisMintageBlock,
+			VmContext:    GenesisMintageBlockVC,
+		}}, GenesisMintageSendBlocks...),
+		{{
+			AccountBlock: &GenesisConsensusGroupBlock,
+			VmContext:    GenesisConsensusGroupBlockVC,
+		}},
+		{{
+			AccountBlock: &GenesisRegisterBlock,
+			VmContext:    GenesisRegisterBlockVC,
+		}},
+	}
 
-func genesisSnapshotBlock() ledger.SnapshotBlock {
+	if GenesisVoteBlock != nil {
+		blocksList = append(blocksList, []*vm_context.VmAccountBlock{{
+			AccountBlock: GenesisVoteBlock,
+			VmContext:    GenesisVoteBlockVC,
+		}})
+	}
+	return blocksList
+}
+
+func genesisSnapshotBlock(specHash *types.Hash) ledger.SnapshotBlock {
 	genesisSnapshotBlock := ledger.SnapshotBlock{
 		Height:    1,
 		Timestamp: &genesisTimestamp,
 	}
 	stateTrie := trie.NewTrie(nil, nil, nil)
 	stateTrie.SetValue([]byte("vite"), []byte("create something cool"))
+	if specHash != nil {
+		// bind the genesis hash to the spec, private networks with the same timestamp are told apart
+		stateTrie.SetValue([]byte("genesis"), specHash.Bytes())
+	}
 
 	genesisSnapshotBlock.StateTrie = stateTrie
 	genesisSnapshotBlock.StateHash = *stateTrie.Hash()
@@ -72,27 +384,18 @@ func secondSnapshotBlock() ledger.SnapshotBlock {
 		PrevHash:  GenesisSnapshotBlock.Hash,
 	}
 
-	snapshotContent := ledger.SnapshotContent{
-		GenesisMintageSendBlock.AccountAddress: &ledger.HashHeight{
-			Hash:   GenesisMintageSendBlock.Hash,
-			Height: GenesisMintageSendBlock.Height,
-		},
-		GenesisConsensusGroupBlock.AccountAddress: &ledger.HashHeight{
-			Hash:   GenesisConsensusGroupBlock.Hash,
-			Height: GenesisConsensusGroupBlock.Height,
-		},
-		GenesisRegisterBlock.AccountAddress: &ledger.HashHeight{
-			Hash:   GenesisRegisterBlock.Hash,
-			Height: GenesisRegisterBlock.Height,
-		},
+	snapshotContent := ledger.SnapshotContent{}
+	stateTrie := trie.NewTrie(nil, nil, nil)
+	for _, blocks := range GenesisAccountBlocks() {
+		latestBlock := blocks[len(blocks)-1].AccountBlock
+		snapshotContent[latestBlock.AccountAddress] = &ledger.HashHeight{
+			Hash:   latestBlock.Hash,
+			Height: latestBlock.Height,
+		}
+		stateTrie.SetValue(latestBlock.AccountAddress.Bytes(), latestBlock.StateHash.Bytes())
 	}
 
 	genesisSnapshotBlock.SnapshotContent = snapshotContent
-	stateTrie := trie.NewTrie(nil, nil, nil)
-	stateTrie.SetValue(GenesisMintageSendBlock.AccountAddress.Bytes(), GenesisMintageSendBlock.StateHash.Bytes())
-	stateTrie.SetValue(GenesisConsensusGroupBlock.AccountAddress.Bytes(), GenesisConsensusGroupBlock.StateHash.Bytes())
-	stateTrie.SetValue(GenesisRegisterBlock.AccountAddress.Bytes(), GenesisRegisterBlock.StateHash.Bytes())
-
 	genesisSnapshotBlock.StateHash = *stateTrie.Hash()
 	genesisSnapshotBlock.StateTrie = stateTrie
 	genesisSnapshotBlock.Hash = genesisSnapshotBlock.ComputeHash()
@@ -100,9 +403,7 @@ func secondSnapshotBlock() ledger.SnapshotBlock {
 	return genesisSnapshotBlock
 }
 
-var totalSupply = new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e9))
-
-func genesisMintageBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
+func genesisMintageBlock(cfg *config.Genesis) (ledger.AccountBlock, vmctxt_interface.VmDatabase, error) {
 	timestamp := genesisTimestamp.Add(time.Second * 10)
 	block := ledger.AccountBlock{
 		BlockType:      ledger.BlockTypeReceive,
@@ -116,40 +417,54 @@ func genesisMintageBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
 	}
 
 	vmContext := vm_context.NewEmptyVmContextByTrie(trie.NewTrie(nil, nil, genesisTrieNodePool))
-	tokenName := "Vite Token"
-	tokenSymbol := "VITE"
-	decimals := uint8(18)
-	mintageData, _ := abi.ABIMintage.PackVariable(abi.VariableNameMintage, tokenName, tokenSymbol, totalSupply, decimals, ledger.GenesisAccountAddress, big.NewInt(0), uint64(0))
+	mintage := cfg.Mintage
+	supply, _ := new(big.Int).SetString(mintage.TotalSupply, 10)
+	mintageData, err := abi.ABIMintage.PackVariable(abi.VariableNameMintage, mintage.TokenName, mintage.TokenSymbol, supply, mintage.Decimals, mintage.Owner, big.NewInt(0), uint64(0))
+	if err != nil {
+		return block, nil, err
+	}
 
 	vmContext.SetStorage(abi.GetMintageKey(ledger.ViteTokenId), mintageData)
 
 	block.StateHash = *vmContext.GetStorageHash()
 	block.Hash = block.ComputeHash()
 
-	return block, vmContext
+	return block, vmContext, nil
 }
 
-func genesisMintageSendBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
+func genesisMintageSendBlocks(cfg *config.Genesis) []*vm_context.VmAccountBlock {
 	timestamp := genesisTimestamp.Add(time.Second * 12)
-	block := ledger.AccountBlock{
-		BlockType:      ledger.BlockTypeSendReward,
-		PrevHash:       GenesisMintageBlock.Hash,
-		Height:         2,
-		AccountAddress: abi.AddressMintage,
-		ToAddress:      ledger.GenesisAccountAddress,
-		Amount:         totalSupply,
-		TokenId:        ledger.ViteTokenId,
-		Fee:            big.NewInt(0),
-		StateHash:      GenesisMintageBlock.StateHash,
-		SnapshotHash:   GenesisSnapshotBlock.Hash,
-		Timestamp:      &timestamp,
+
+	var blocks []*vm_context.VmAccountBlock
+	prevBlock := &GenesisMintageBlock
+	for _, balance := range cfg.Balances {
+		amount, _ := new(big.Int).SetString(balance.Amount, 10)
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendReward,
+			PrevHash:       prevBlock.Hash,
+			Height:         prevBlock.Height + 1,
+			AccountAddress: abi.AddressMintage,
+			ToAddress:      balance.Address,
+			Amount:         amount,
+			TokenId:        ledger.ViteTokenId,
+			Fee:            big.NewInt(0),
+			StateHash:      GenesisMintageBlock.StateHash,
+			SnapshotHash:   GenesisSnapshotBlock.Hash,
+			Timestamp:      &timestamp,
+		}
+		block.Hash = block.ComputeHash()
+
+		blocks = append(blocks, &vm_context.VmAccountBlock{
+			AccountBlock: block,
+			VmContext:    GenesisMintageBlockVC.CopyAndFreeze(),
+		})
+		prevBlock = block
 	}
-	block.Hash = block.ComputeHash()
 
-	return block, GenesisMintageBlockVC.CopyAndFreeze()
+	return blocks
 }
 
-func genesisConsensusGroupBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
+func genesisConsensusGroupBlock(cfg *config.Genesis) (ledger.AccountBlock, vmctxt_interface.VmDatabase, error) {
 	timestamp := genesisTimestamp.Add(time.Second * 10)
 
 	block := ledger.AccountBlock{
@@ -163,49 +478,42 @@ func genesisConsensusGroupBlock() (ledger.AccountBlock, vmctxt_interface.VmDatab
 		Timestamp:    &timestamp,
 	}
 
-	conditionRegisterData, _ := abi.ABIConsensusGroup.PackVariable(abi.VariableNameConditionRegisterOfPledge, new(big.Int).Mul(big.NewInt(5e5), big.NewInt(1e18)), ledger.ViteTokenId, uint64(3600*24*90))
-
-	snapshotConsensusGroupData, _ := abi.ABIConsensusGroup.PackVariable(abi.VariableNameConsensusGroupInfo,
-		uint8(25),
-		int64(1),
-		int64(3),
-		uint8(2),
-		uint8(100),
-		ledger.ViteTokenId,
-		uint8(1),
-		conditionRegisterData,
-		uint8(1),
-		[]byte{},
-		ledger.GenesisAccountAddress,
-		big.NewInt(0),
-		uint64(1))
-
-	commonConsensusGroupData, _ := abi.ABIConsensusGroup.PackVariable(abi.VariableNameConsensusGroupInfo,
-		uint8(25),
-		int64(3),
-		int64(1),
-		uint8(2),
-		uint8(100),
-		ledger.ViteTokenId,
-		uint8(1),
-		conditionRegisterData,
-		uint8(1),
-		[]byte{},
-		ledger.GenesisAccountAddress,
-		big.NewInt(0),
-		uint64(1))
-
 	vmContext := vm_context.NewEmptyVmContextByTrie(trie.NewTrie(nil, nil, genesisTrieNodePool))
-	vmContext.SetStorage(abi.GetConsensusGroupKey(types.SNAPSHOT_GID), snapshotConsensusGroupData)
-	vmContext.SetStorage(abi.GetConsensusGroupKey(types.DELEGATE_GID), commonConsensusGroupData)
+	for _, group := range cfg.ConsensusGroups {
+		pledgeAmount, _ := new(big.Int).SetString(group.RegisterPledgeAmount, 10)
+		conditionRegisterData, err := abi.ABIConsensusGroup.PackVariable(abi.VariableNameConditionRegisterOfPledge, pledgeAmount, group.RegisterPledgeToken, group.RegisterPledgeHeight)
+		if err != nil {
+			return block, nil, err
+		}
+
+		consensusGroupData, err := abi.ABIConsensusGroup.PackVariable(abi.VariableNameConsensusGroupInfo,
+			group.NodeCount,
+			group.Interval,
+			group.PerCount,
+			group.RandCount,
+			group.RandRank,
+			group.CountingTokenId,
+			uint8(1),
+			conditionRegisterData,
+			uint8(1),
+			[]byte{},
+			group.Owner,
+			big.NewInt(0),
+			uint64(1))
+		if err != nil {
+			return block, nil, err
+		}
+
+		vmContext.SetStorage(abi.GetConsensusGroupKey(group.Gid), consensusGroupData)
+	}
 
 	block.StateHash = *vmContext.GetStorageHash()
 	block.Hash = block.ComputeHash()
 
-	return block, vmContext
+	return block, vmContext, nil
 }
 
-func genesisRegisterBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
+func genesisRegisterBlock(cfg *config.Genesis) (ledger.AccountBlock, vmctxt_interface.VmDatabase, error) {
 	timestamp := genesisTimestamp.Add(time.Second * 10)
 
 	block := ledger.AccountBlock{
@@ -219,51 +527,56 @@ func genesisRegisterBlock() (ledger.AccountBlock, vmctxt_interface.VmDatabase) {
 		Timestamp:    &timestamp,
 	}
 
-	addrStrList := []string{
-		"vite_0acbb1335822c8df4488f3eea6e9000eabb0f19d8802f57c87",
-		"vite_14edbc9214bd1e5f6082438f707d10bf43463a6d599a4f2d08",
-		"vite_1630f8c0cf5eda3ce64bd49a0523b826f67b19a33bc2a5dcfb",
-		"vite_1b1dfa00323aea69465366d839703547fec5359d6c795c8cef",
-		"vite_27a258dd1ed0ce0de3f4abd019adacd1b4b163b879389d3eca",
-		"vite_31a02e4f4b536e2d6d9bde23910cdffe72d3369ef6fe9b9239",
-		"vite_383fedcbd5e3f52196a4e8a1392ed3ddc4d4360e4da9b8494e",
-		"vite_41ba695ff63caafd5460dcf914387e95ca3a900f708ac91f06",
-		"vite_545c8e4c74e7bb6911165e34cbfb83bc513bde3623b342d988",
-		"vite_5a1b5ece654138d035bdd9873c1892fb5817548aac2072992e",
-		"vite_70cfd586185e552635d11f398232344f97fc524fa15952006d",
-		"vite_76df2a0560694933d764497e1b9b11f9ffa1524b170f55dda0",
-		"vite_7b76ca2433c7ddb5a5fa315ca861e861d432b8b05232526767",
-		"vite_7caaee1d51abad4047a58f629f3e8e591247250dad8525998a",
-		"vite_826a1ab4c85062b239879544dc6b67e3b5ce32d0a1eba21461",
-		"vite_89007189ad81c6ee5cdcdc2600a0f0b6846e0a1aa9a58e5410",
-		"vite_9abcb7324b8d9029e4f9effe76f7336bfd28ed33cb5b877c8d",
-		"vite_af60cf485b6cc2280a12faac6beccfef149597ea518696dcf3",
-		"vite_c1090802f735dfc279a6c24aacff0e3e4c727934e547c24e5e",
-		"vite_c10ae7a14649800b85a7eaaa8bd98c99388712412b41908cc0",
-		"vite_d45ac37f6fcdb1c362a33abae4a7d324a028aa49aeea7e01cb",
-		"vite_d8974670af8e1f3c4378d01d457be640c58644bc0fa87e3c30",
-		"vite_e289d98f33c3ef5f1b41048c2cb8b389142f033d1df9383818",
-		"vite_f53dcf7d40b582cd4b806d2579c6dd7b0b131b96c2b2ab5218",
-		"vite_fac06662d84a7bea269265e78ea2d9151921ba2fae97595608",
-	}
-	var addrList []types.Address
-
-	for _, addrStr := range addrStrList {
-		addr, _ := types.HexToAddress(addrStr)
-		addrList = append(addrList, addr)
+	vmContext := vm_context.NewEmptyVmContextByTrie(trie.NewTrie(nil, nil, genesisTrieNodePool))
+	for _, registration := range cfg.Registrations {
+		registerData, err := abi.ABIRegister.PackVariable(abi.VariableNameRegistration, registration.Name, registration.NodeAddr, registration.PledgeAddr, helper.Big0, uint64(1), uint64(0), uint64(0), []types.Address{registration.NodeAddr})
+		if err != nil {
+			return block, nil, err
+		}
+		vmContext.SetStorage(abi.GetRegisterKey(registration.Name, registration.Gid), registerData)
+
+		hisNameData, err := abi.ABIRegister.PackVariable(abi.VariableNameHisName, registration.Name)
+		if err != nil {
+			return block, nil, err
+		}
+		vmContext.SetStorage(abi.GetHisNameKey(registration.NodeAddr, registration.Gid), hisNameData)
+	}
+
+	block.StateHash = *vmContext.GetStorageHash()
+	block.Hash = block.ComputeHash()
+
+	return block, vmContext, nil
+}
+
+func genesisVoteBlock(cfg *config.Genesis) (*ledger.AccountBlock, vmctxt_interface.VmDatabase, error) {
+	if len(cfg.Votes) <= 0 {
+		return nil, nil, nil
+	}
+
+	timestamp := genesisTimestamp.Add(time.Second * 10)
+
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		Height:         1,
+		AccountAddress: abi.AddressVote,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+
+		SnapshotHash: GenesisSnapshotBlock.Hash,
+		Timestamp:    &timestamp,
 	}
 
 	vmContext := vm_context.NewEmptyVmContextByTrie(trie.NewTrie(nil, nil, genesisTrieNodePool))
-	for index, addr := range addrList {
-		nodeName := "s" + strconv.Itoa(index+1)
-		registerData, _ := abi.ABIRegister.PackVariable(abi.VariableNameRegistration, nodeName, addr, addr, helper.Big0, uint64(1), uint64(0), uint64(0), []types.Address{addr})
-		vmContext.SetStorage(abi.GetRegisterKey(nodeName, types.SNAPSHOT_GID), registerData)
-		hisNameData, _ := abi.ABIRegister.PackVariable(abi.VariableNameHisName, nodeName)
-		vmContext.SetStorage(abi.GetHisNameKey(addr, types.SNAPSHOT_GID), hisNameData)
+	for _, vote := range cfg.Votes {
+		voteData, err := abi.ABIVote.PackVariable(abi.VariableNameVoteStatus, vote.NodeName)
+		if err != nil {
+			return nil, nil, err
+		}
+		vmContext.SetStorage(abi.GetVoteKey(vote.Addr, vote.Gid), voteData)
 	}
 
 	block.StateHash = *vmContext.GetStorageHash()
 	block.Hash = block.ComputeHash()
 
-	return block, vmContext
+	return block, vmContext, nil
 }
@@ -3,6 +3,10 @@ package chain
 import (
 	"fmt"
 	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
 )
 
 func TestGenesis(t *testing.T) {
@@ -27,3 +31,92 @@ func TestGenesis(t *testing.T) {
 
 	fmt.Printf("%+v\n", GenesisSnapshotBlock)
 }
+
+func TestInitGenesis(t *testing.T) {
+	defaultHash := GenesisSnapshotBlock.Hash
+	defer InitGenesis(nil)
+
+	if err := InitGenesis(&config.Genesis{}); err != nil {
+		t.Fatal(err)
+	}
+	if GenesisSnapshotBlock.Hash != defaultHash {
+		t.Fatal("empty spec should build the default genesis")
+	}
+
+	cfg := DefaultGenesisConfig()
+	cfg.Mintage.TotalSupply = "1000"
+	cfg.Balances = []*config.GenesisBalance{
+		{Address: ledger.GenesisAccountAddress, Amount: "600"},
+		{Address: cfg.Registrations[0].NodeAddr, Amount: "400"},
+	}
+	cfg.Votes = []*config.GenesisVote{
+		{Gid: types.SNAPSHOT_GID, Addr: ledger.GenesisAccountAddress, NodeName: cfg.Registrations[0].Name},
+	}
+	if err := InitGenesis(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if GenesisSnapshotBlock.Hash == defaultHash {
+		t.Fatal("custom spec should change the genesis hash")
+	}
+	if len(GenesisMintageSendBlocks) != 2 || GenesisVoteBlock == nil {
+		t.Fatal("mintage send blocks or vote block is missing")
+	}
+	if len(SecondSnapshotBlock.SnapshotContent) != 4 {
+		t.Fatal("second snapshot block should confirm 4 accounts")
+	}
+
+	cfg.Balances[1].Amount = "500"
+	if err := InitGenesis(cfg); err == nil {
+		t.Fatal("balances exceeding total supply should be rejected")
+	}
+}
+
+func TestGenesisContractParams(t *testing.T) {
+	defaultHash := GenesisSnapshotBlock.Hash
+	defer InitGenesis(nil)
+
+	cfg := DefaultGenesisConfig()
+	cfg.ContractParams = &config.GenesisContractParams{
+		MinPledgeHeight:                  1,
+		CreateConsensusGroupPledgeHeight: 1,
+		MintagePledgeHeight:              1,
+		RewardEndTimeLimit:               75,
+		RewardTimeUnit:                   75 * 2,
+		PledgeAmountMin:                  "10",
+		MintageFee:                       "1000",
+		MintagePledgeAmount:              "100000",
+		CreateConsensusGroupPledgeAmount: "1000",
+		RewardPerBlock:                   "1",
+	}
+	if err := InitGenesis(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if GenesisSnapshotBlock.Hash == defaultHash {
+		t.Fatal("contract params should change the genesis hash")
+	}
+
+	cfg.ContractParams.MintageFee = "-1"
+	if err := InitGenesis(cfg); err == nil {
+		t.Fatal("negative mintage fee should be rejected")
+	}
+
+	cfg.ContractParams = nil
+	if err := InitGenesis(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if GenesisSnapshotBlock.Hash != defaultHash {
+		t.Fatal("spec without contract params should build the default genesis")
+	}
+}
+
+func TestDevGenesisConfig(t *testing.T) {
+	defer InitGenesis(nil)
+
+	addr := DefaultGenesisConfig().Registrations[0].NodeAddr
+	if err := InitGenesis(DevGenesisConfig(addr)); err != nil {
+		t.Fatal(err)
+	}
+	if GenesisMintageSendBlock.ToAddress != addr {
+		t.Fatal("dev account should own the total supply")
+	}
+}
@@ -42,6 +42,7 @@ type Chain interface {
 	Init()
 	Compressor() *compress.Compressor
 	ChainDb() *chain_db.ChainDb
+	Load()
 	Start()
 	Destroy()
 	Stop()
@@ -71,6 +72,7 @@ type Chain interface {
 	GetRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error)
 	GetVoteMap(snapshotHash types.Hash, gid types.Gid) ([]*types.VoteInfo, error)
 	KafkaSender() *sender.KafkaSender
+	TrieGcStatus() *TrieGcStatus
 
 	// Pledge amount
 	GetPledgeAmount(snapshotHash types.Hash, beneficial types.Address) (*big.Int, error)
@@ -89,6 +91,10 @@ type Chain interface {
 	GetSubLedgerByHash(startBlockHash *types.Hash, count uint64, forward bool) ([]*ledger.CompressedFileMeta, [][2]uint64, error)
 	GetConfirmSubLedger(fromHeight uint64, toHeight uint64) ([]*ledger.SnapshotBlock, map[types.Address][]*ledger.AccountBlock, error)
 	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+	GetVmLogs(filter *VmLogFilter) ([]*VmLogResult, error)
+	GetVmLogStart() (uint64, error)
+	GetTransfers(filter *TransferFilter) ([]*Transfer, error)
+	GetTxHistoryStart() (uint64, error)
 	UnRegister(listenerId uint64)
 	RegisterInsertAccountBlocks(processor InsertProcessorFunc) uint64
 	RegisterInsertAccountBlocksSuccess(processor InsertProcessorFuncSuccess) uint64
@@ -101,6 +107,15 @@ type Chain interface {
 	GetStateTrie(stateHash *types.Hash) *trie.Trie
 	NewStateTrie() *trie.Trie
 
+	// trie nodes
+	GetTrieNodeData(hash *types.Hash) ([]byte, error)
+	GetTrieRefValue(hash *types.Hash) ([]byte, error)
+	NewTrieSync(root types.Hash, onLeaf trie.LeafCallback) *trie.Sync
+
+	// db check
+	CheckDb() ([]*DbProblem, error)
+	RepairDb(problems []*DbProblem) error
+
 	// Be
 	GetLatestBlockEventId() (uint64, error)
 	GetEvent(eventId uint64) (byte, []types.Hash, error)
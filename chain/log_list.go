@@ -1,10 +1,47 @@
 package chain
 
 import (
+	"errors"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 )
 
+type VmLogFilter struct {
+	// Logs of any account are matched if empty
+	AddrList []types.Address
+	// Topics[i] is the set of allowed topics at position i, any topic is allowed if empty
+	Topics [][]types.Hash
+	// Range of the snapshot height which confirms the account block
+	FromSnapshotHeight uint64
+	ToSnapshotHeight   uint64
+	// ErrTooManyVmLogs is returned if more logs are matched, 0 is unlimited
+	MaxCount int
+}
+
+var (
+	ErrTooManyVmLogs    = errors.New("too many vm logs or account blocks match the filter")
+	ErrBeforeVmLogStart = errors.New("vm logs before the vm log index is created are not indexed")
+)
+
+const (
+	// account blocks with logs read at a time when scanning the account index
+	vmLogScanCount = 100
+	// ErrTooManyVmLogs is returned if more account blocks with logs are looked up by a filter
+	maxVmLogScanBlocks = 10000
+)
+
+type VmLogResult struct {
+	Log                *ledger.VmLog
+	LogIndex           int
+	AccountAddress     types.Address
+	AccountBlockHash   types.Hash
+	AccountBlockHeight uint64
+	SnapshotHeight     uint64
+}
+
 func (c *chain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
 	vmLogList, err := c.chainDb.Ac.GetVmLogList(logListHash)
 	if err != nil {
@@ -14,3 +51,260 @@ func (c *chain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
 
 	return vmLogList, nil
 }
+
+// GetVmLogStart returns the lowest snapshot height from which the logs can be looked up,
+// it is the height the vm log index is created at
+func (c *chain) GetVmLogStart() (uint64, error) {
+	return c.chainDb.Ac.GetVmLogIndexStart()
+}
+
+// GetVmLogs returns logs of the confirmed account blocks matching filter, ordered by snapshot height.
+// FromSnapshotHeight must not be lower than the height the vm log index is created at.
+func (c *chain) GetVmLogs(filter *VmLogFilter) ([]*VmLogResult, error) {
+	if filter.FromSnapshotHeight > filter.ToSnapshotHeight {
+		return nil, errors.New("fromSnapshotHeight is greater than toSnapshotHeight")
+	}
+	start, err := c.chainDb.Ac.GetVmLogIndexStart()
+	if err != nil {
+		c.log.Error("GetVmLogIndexStart failed, error is "+err.Error(), "method", "GetVmLogs")
+		return nil, err
+	}
+	if filter.FromSnapshotHeight < start {
+		return nil, ErrBeforeVmLogStart
+	}
+
+	blockList, err := c.getVmLogBlockList(filter)
+	if err != nil {
+		if err != ErrTooManyVmLogs {
+			c.log.Error("getVmLogBlockList failed, error is "+err.Error(), "method", "GetVmLogs")
+		}
+		return nil, err
+	}
+
+	results := make([]*VmLogResult, 0)
+	for _, logBlock := range blockList {
+		block, err := c.GetAccountBlockByHash(&logBlock.hash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil || block.LogHash == nil {
+			continue
+		}
+
+		logList, err := c.GetVmLogList(block.LogHash)
+		if err != nil {
+			return nil, err
+		}
+		for index, vmLog := range logList {
+			if !filter.MatchTopics(vmLog) {
+				continue
+			}
+			results = append(results, &VmLogResult{
+				Log:                vmLog,
+				LogIndex:           index,
+				AccountAddress:     block.AccountAddress,
+				AccountBlockHash:   block.Hash,
+				AccountBlockHeight: block.Height,
+				SnapshotHeight:     logBlock.confirmHeight,
+			})
+		}
+
+		if filter.MaxCount > 0 && len(results) > filter.MaxCount {
+			return nil, ErrTooManyVmLogs
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].SnapshotHeight < results[j].SnapshotHeight
+	})
+	return results, nil
+}
+
+// vmLogBlock is an account block with logs, confirmed in the snapshot height range of the filter
+type vmLogBlock struct {
+	hash          types.Hash
+	confirmHeight uint64
+}
+
+func (filter *VmLogFilter) matchHeight(confirmHeight uint64) bool {
+	return confirmHeight > 0 && confirmHeight >= filter.FromSnapshotHeight && confirmHeight <= filter.ToSnapshotHeight
+}
+
+// getVmLogBlockList looks up candidate account blocks by address index, or by topic index if no address is given.
+// ErrTooManyVmLogs is returned if more than maxVmLogScanBlocks blocks are looked up.
+func (c *chain) getVmLogBlockList(filter *VmLogFilter) ([]*vmLogBlock, error) {
+	scanned := 0
+	if len(filter.AddrList) > 0 {
+		var blockList []*vmLogBlock
+		for _, addr := range filter.AddrList {
+			accountBlockList, err := c.getVmLogBlockListByAddress(&addr, filter, &scanned)
+			if err != nil {
+				return nil, err
+			}
+			blockList = append(blockList, accountBlockList...)
+		}
+		return blockList, nil
+	}
+
+	for _, topicSet := range filter.Topics {
+		if len(topicSet) <= 0 {
+			continue
+		}
+
+		var hashList []types.Hash
+		hashSet := make(map[types.Hash]struct{})
+		for _, topic := range topicSet {
+			topicHashList, err := c.chainDb.Ac.GetVmLogBlockHashListByTopic(&topic)
+			if err != nil {
+				return nil, err
+			}
+			for _, hash := range topicHashList {
+				if _, ok := hashSet[hash]; !ok {
+					hashSet[hash] = struct{}{}
+					hashList = append(hashList, hash)
+				}
+			}
+			// the topic index isn't ordered by snapshot height, so the range doesn't narrow it
+			if len(hashList) > maxVmLogScanBlocks {
+				return nil, ErrTooManyVmLogs
+			}
+		}
+
+		var blockList []*vmLogBlock
+		for _, hash := range hashList {
+			confirmHeight, err := c.getVmLogConfirmHeight(&hash, &scanned)
+			if err != nil {
+				return nil, err
+			}
+			if filter.matchHeight(confirmHeight) {
+				blockList = append(blockList, &vmLogBlock{hash: hash, confirmHeight: confirmHeight})
+			}
+		}
+		return blockList, nil
+	}
+
+	return nil, errors.New("at least one address or topic is required")
+}
+
+// getVmLogBlockListByAddress reads the account index from the latest block, until the blocks are confirmed before FromSnapshotHeight
+func (c *chain) getVmLogBlockListByAddress(addr *types.Address, filter *VmLogFilter, scanned *int) ([]*vmLogBlock, error) {
+	account, err := c.chainDb.Account.GetAccountByAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	latestBlock, err := c.chainDb.Ac.GetLatestBlock(account.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	if latestBlock == nil {
+		return nil, nil
+	}
+
+	var blockList []*vmLogBlock
+	for endHeight := latestBlock.Height; endHeight > 0; {
+		startHeight := uint64(1)
+		if endHeight > vmLogScanCount {
+			startHeight = endHeight - vmLogScanCount + 1
+		}
+
+		hashList, err := c.chainDb.Ac.GetVmLogBlockHashListByAccountId(account.AccountId, startHeight, endHeight)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(hashList) - 1; i >= 0; i-- {
+			confirmHeight, err := c.getVmLogConfirmHeight(&hashList[i], scanned)
+			if err != nil {
+				return nil, err
+			}
+			// the blocks are confirmed in order of height
+			if confirmHeight > 0 && confirmHeight < filter.FromSnapshotHeight {
+				return reverseVmLogBlockList(blockList), nil
+			}
+			if filter.matchHeight(confirmHeight) {
+				blockList = append(blockList, &vmLogBlock{hash: hashList[i], confirmHeight: confirmHeight})
+			}
+		}
+		endHeight = startHeight - 1
+	}
+	return reverseVmLogBlockList(blockList), nil
+}
+
+// getVmLogConfirmHeight counts the account blocks looked up by a filter
+func (c *chain) getVmLogConfirmHeight(hash *types.Hash, scanned *int) (uint64, error) {
+	*scanned++
+	if *scanned > maxVmLogScanBlocks {
+		return 0, ErrTooManyVmLogs
+	}
+	return c.chainDb.Ac.GetConfirmHeight(hash)
+}
+
+// initVmLogIndex records the snapshot height from which the blocks with logs are all indexed. The db created
+// before the index has the blocks inserted then unindexed, so the unconfirmed blocks are indexed, and the confirmed
+// ones above the latest snapshot block are all indexed. A new db is indexed from genesis, see initData.
+func (c *chain) initVmLogIndex() error {
+	start, err := c.chainDb.Ac.GetVmLogIndexStart()
+	if err != nil {
+		return err
+	}
+	if start > 0 {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	maxAccountId, err := c.chainDb.Account.GetLastAccountId()
+	if err != nil {
+		return err
+	}
+	for accountId := uint64(1); accountId <= maxAccountId; accountId++ {
+		blocks, err := c.chainDb.Ac.GetUnConfirmAccountBlocks(accountId, 0)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			if block.LogHash == nil {
+				continue
+			}
+			logList, err := c.chainDb.Ac.GetVmLogList(block.LogHash)
+			if err != nil {
+				return err
+			}
+			c.chainDb.Ac.WriteVmLogIndex(batch, accountId, block, logList)
+		}
+	}
+
+	c.chainDb.Ac.WriteVmLogIndexStart(batch, c.latestSnapshotBlock.Height+1)
+	return c.chainDb.Commit(batch)
+}
+
+// reverseVmLogBlockList orders the blocks of an account by height
+func reverseVmLogBlockList(blockList []*vmLogBlock) []*vmLogBlock {
+	for i, j := 0, len(blockList)-1; i < j; i, j = i+1, j-1 {
+		blockList[i], blockList[j] = blockList[j], blockList[i]
+	}
+	return blockList
+}
+
+func (filter *VmLogFilter) MatchTopics(vmLog *ledger.VmLog) bool {
+	if len(filter.Topics) > len(vmLog.Topics) {
+		return false
+	}
+	for i, topicSet := range filter.Topics {
+		if len(topicSet) <= 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range topicSet {
+			if topic == vmLog.Topics[i] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
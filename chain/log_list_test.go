@@ -2,11 +2,14 @@ package chain
 
 import (
 	"fmt"
+	"github.com/vitelabs/go-vite/chain_db/database"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm_context"
+	"io/ioutil"
 	"math/big"
+	"os"
 	"testing"
 	"time"
 )
@@ -57,7 +60,10 @@ func sendViteBlock() (*vm_context.VmAccountBlock, error) {
 	})
 
 	sendBlock.LogHash = vmContext.GetLogListHash()
-	sendBlock.StateHash = *vmContext.GetStorageHash()
+	// the trie is empty if the genesis account has no balance in the test db
+	if stateHash := vmContext.GetStorageHash(); stateHash != nil {
+		sendBlock.StateHash = *stateHash
+	}
 	sendBlock.Hash = sendBlock.ComputeHash()
 	return &vm_context.VmAccountBlock{
 		AccountBlock: sendBlock,
@@ -83,3 +89,165 @@ func TestGetVmLogList(t *testing.T) {
 		fmt.Printf("%d: %+v\n", index, log)
 	}
 }
+
+func TestGetVmLogs(t *testing.T) {
+	chainInstance := getChainInstance()
+	// two blocks with logs to check MaxCount
+	var sendBlock *ledger.AccountBlock
+	for i := 0; i < 2; i++ {
+		blocks, err := sendViteBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := chainInstance.InsertAccountBlocks([]*vm_context.VmAccountBlock{
+			blocks,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sendBlock = blocks.AccountBlock
+	}
+
+	// the test db may be created before the vm log index
+	start, err := chainInstance.GetVmLogStart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := &VmLogFilter{
+		AddrList:           []types.Address{ledger.GenesisAccountAddress},
+		FromSnapshotHeight: start,
+		ToSnapshotHeight:   chainInstance.GetLatestSnapshotBlock().Height + 1,
+	}
+	findLog := func(logs []*VmLogResult) *VmLogResult {
+		for _, log := range logs {
+			if log.AccountBlockHash == sendBlock.Hash {
+				return log
+			}
+		}
+		return nil
+	}
+
+	logs, err := chainInstance.GetVmLogs(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findLog(logs) != nil {
+		t.Fatal("logs of the unconfirmed block should not be returned")
+	}
+
+	snapshotBlock, err := newSnapshotBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chainInstance.InsertSnapshotBlock(snapshotBlock); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err = chainInstance.GetVmLogs(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := findLog(logs)
+	if log == nil {
+		t.Fatal("logs of the confirmed block should be returned")
+	}
+	if log.SnapshotHeight != snapshotBlock.Height || log.AccountAddress != ledger.GenesisAccountAddress ||
+		log.AccountBlockHeight != sendBlock.Height || log.LogIndex != 0 || string(log.Log.Data) != "Yes, I am log" {
+		t.Fatalf("unexpected log %+v %+v", log, log.Log)
+	}
+	for i := 1; i < len(logs); i++ {
+		if logs[i].SnapshotHeight < logs[i-1].SnapshotHeight {
+			t.Fatal("logs should be ordered by snapshot height")
+		}
+	}
+
+	// by topic, and out of the snapshot height range
+	topicFilter := &VmLogFilter{
+		Topics:             [][]types.Hash{{log.Log.Topics[0]}, {log.Log.Topics[1]}},
+		FromSnapshotHeight: snapshotBlock.Height,
+		ToSnapshotHeight:   snapshotBlock.Height,
+	}
+	if logs, err := chainInstance.GetVmLogs(topicFilter); err != nil || findLog(logs) == nil {
+		t.Fatalf("logs should be found by topics, error %v", err)
+	}
+	topicFilter.FromSnapshotHeight, topicFilter.ToSnapshotHeight = snapshotBlock.Height+1, snapshotBlock.Height+1
+	if logs, err := chainInstance.GetVmLogs(topicFilter); err != nil || findLog(logs) != nil {
+		t.Fatalf("logs should not be found out of the range, error %v", err)
+	}
+	topicFilter.Topics = [][]types.Hash{{log.Log.Topics[1]}}
+	topicFilter.FromSnapshotHeight, topicFilter.ToSnapshotHeight = snapshotBlock.Height, snapshotBlock.Height
+	if logs, err := chainInstance.GetVmLogs(topicFilter); err != nil || findLog(logs) != nil {
+		t.Fatalf("logs should not be matched by the topic at another position, error %v", err)
+	}
+
+	filter.MaxCount = 1
+	if _, err := chainInstance.GetVmLogs(filter); err != ErrTooManyVmLogs {
+		t.Fatalf("ErrTooManyVmLogs should be returned, got %v", err)
+	}
+}
+
+func TestGetVmLogsIndexCreatedLater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vm_log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newTxHistoryChain(t, dir, false)
+	if start, err := c.GetVmLogStart(); err != nil || start != 1 {
+		t.Fatalf("a new db should be indexed from genesis, got %d, error %v", start, err)
+	}
+
+	// a db created before the index has no start recorded
+	insertTestSnapshotBlock(t, c)
+	key, _ := database.EncodeKey(database.DBKP_VM_LOG_INDEX_START)
+	if err := c.chainDb.Db().Delete(key, nil); err != nil {
+		t.Fatal(err)
+	}
+	c.Stop()
+	c.Destroy()
+
+	c = newTxHistoryChain(t, dir, false)
+	defer c.Destroy()
+	defer c.Stop()
+	start := c.GetLatestSnapshotBlock().Height + 1
+	if recorded, err := c.GetVmLogStart(); err != nil || recorded != start {
+		t.Fatalf("logs should be looked up from %d, got %d, error %v", start, recorded, err)
+	}
+
+	filter := &VmLogFilter{
+		AddrList:           []types.Address{ledger.GenesisAccountAddress},
+		FromSnapshotHeight: start - 1,
+		ToSnapshotHeight:   start + 10,
+	}
+	if _, err := c.GetVmLogs(filter); err != ErrBeforeVmLogStart {
+		t.Fatalf("logs before the index is created should be refused, got %v", err)
+	}
+	filter.FromSnapshotHeight = start
+	if _, err := c.GetVmLogs(filter); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVmLogFilterMatchTopics(t *testing.T) {
+	topic1, _ := types.HexToHash("1e7f1b0e23a05127e38dca416cf5f4968189e8bd3385c3a1bf554393b0ca8b58")
+	topic2, _ := types.HexToHash("706b00a2ae1725fb5d90b3b7a76d76c922eb075be485749f987af7aa46a66785")
+	vmLog := &ledger.VmLog{Topics: []types.Hash{topic1, topic2}}
+
+	tests := []struct {
+		topics [][]types.Hash
+		result bool
+	}{
+		{nil, true},
+		{[][]types.Hash{{topic1}}, true},
+		{[][]types.Hash{{topic2}}, false},
+		{[][]types.Hash{{}, {topic1, topic2}}, true},
+		{[][]types.Hash{{topic1}, {topic1}}, false},
+		{[][]types.Hash{{topic1}, {topic2}, {}}, false},
+	}
+	for i, test := range tests {
+		filter := &VmLogFilter{Topics: test.topics}
+		if result := filter.MatchTopics(vmLog); result != test.result {
+			t.Fatalf("%v: match topics error, expected %v, got %v", i, test.result, result)
+		}
+	}
+}
@@ -49,6 +49,7 @@ type dsbssListener struct {
 	processor  DeleteSnapshotBlocksSuccess
 }
 
+// eventManager doesn't hold the lock when calling the listeners, so they can register and unregister listeners
 type eventManager struct {
 	iabsEventListener  []iabsListener
 	iabssEventListener []iabssListener
@@ -71,7 +72,11 @@ func newEventManager() *eventManager {
 }
 
 func (em *eventManager) triggerInsertAccountBlocks(batch *leveldb.Batch, blocks []*vm_context.VmAccountBlock) error {
-	for _, listener := range em.iabsEventListener {
+	em.lock.Lock()
+	listeners := em.iabsEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		if err := listener.processor(batch, blocks); err != nil {
 			return err
 		}
@@ -79,13 +84,21 @@ func (em *eventManager) triggerInsertAccountBlocks(batch *leveldb.Batch, blocks
 	return nil
 }
 func (em *eventManager) triggerInsertAccountBlocksSuccess(blocks []*vm_context.VmAccountBlock) {
-	for _, listener := range em.iabssEventListener {
+	em.lock.Lock()
+	listeners := em.iabssEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		listener.processor(blocks)
 	}
 }
 
 func (em *eventManager) triggerDeleteAccountBlocks(batch *leveldb.Batch, subLedger map[types.Address][]*ledger.AccountBlock) error {
-	for _, listener := range em.dabsEventListener {
+	em.lock.Lock()
+	listeners := em.dabsEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		if err := listener.processor(batch, subLedger); err != nil {
 			return err
 		}
@@ -94,19 +107,31 @@ func (em *eventManager) triggerDeleteAccountBlocks(batch *leveldb.Batch, subLedg
 }
 
 func (em *eventManager) triggerDeleteAccountBlocksSuccess(subLedger map[types.Address][]*ledger.AccountBlock) {
-	for _, listener := range em.dabssEventListener {
+	em.lock.Lock()
+	listeners := em.dabssEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		listener.processor(subLedger)
 	}
 }
 
 func (em *eventManager) triggerInsertSnapshotBlocksSuccess(snapshotBlocks []*ledger.SnapshotBlock) {
-	for _, listener := range em.isbssEventListener {
+	em.lock.Lock()
+	listeners := em.isbssEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		listener.processor(snapshotBlocks)
 	}
 }
 
 func (em *eventManager) triggerDeleteSnapshotBlocksSuccess(snapshotBlocks []*ledger.SnapshotBlock) {
-	for _, listener := range em.dsbssEventListener {
+	em.lock.Lock()
+	listeners := em.dsbssEventListener
+	em.lock.Unlock()
+
+	for _, listener := range listeners {
 		listener.processor(snapshotBlocks)
 	}
 }
@@ -149,50 +174,52 @@ func (em *eventManager) register(actionId uint8, processor interface{}) uint64 {
 		})
 	}
 
-	return 0
+	em.maxListenerId = nextListenerId
+	return nextListenerId
 }
 
+// unRegister copies the listener list instead of modifying it in place, the triggers iterate the old list without lock
 func (em *eventManager) unRegister(listenerId uint64) {
 	em.lock.Lock()
 	defer em.lock.Unlock()
 
 	for index, listener := range em.iabsEventListener {
 		if listener.listenerId == listenerId {
-			em.iabsEventListener = append(em.iabsEventListener[:index], em.iabsEventListener[index+1:]...)
+			em.iabsEventListener = append(em.iabsEventListener[:index:index], em.iabsEventListener[index+1:]...)
 			return
 		}
 	}
 
 	for index, listener := range em.iabssEventListener {
 		if listener.listenerId == listenerId {
-			em.iabssEventListener = append(em.iabssEventListener[:index], em.iabssEventListener[index+1:]...)
+			em.iabssEventListener = append(em.iabssEventListener[:index:index], em.iabssEventListener[index+1:]...)
 			return
 		}
 	}
 
 	for index, listener := range em.dabsEventListener {
 		if listener.listenerId == listenerId {
-			em.dabsEventListener = append(em.dabsEventListener[:index], em.dabsEventListener[index+1:]...)
+			em.dabsEventListener = append(em.dabsEventListener[:index:index], em.dabsEventListener[index+1:]...)
 			return
 		}
 	}
 	for index, listener := range em.dabssEventListener {
 		if listener.listenerId == listenerId {
-			em.dabssEventListener = append(em.dabssEventListener[:index], em.dabssEventListener[index+1:]...)
+			em.dabssEventListener = append(em.dabssEventListener[:index:index], em.dabssEventListener[index+1:]...)
 			return
 		}
 	}
 
 	for index, listener := range em.isbssEventListener {
 		if listener.listenerId == listenerId {
-			em.isbssEventListener = append(em.isbssEventListener[:index], em.isbssEventListener[index+1:]...)
+			em.isbssEventListener = append(em.isbssEventListener[:index:index], em.isbssEventListener[index+1:]...)
 			return
 		}
 	}
 
 	for index, listener := range em.dsbssEventListener {
 		if listener.listenerId == listenerId {
-			em.dsbssEventListener = append(em.dsbssEventListener[:index], em.dsbssEventListener[index+1:]...)
+			em.dsbssEventListener = append(em.dsbssEventListener[:index:index], em.dsbssEventListener[index+1:]...)
 			return
 		}
 	}
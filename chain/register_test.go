@@ -0,0 +1,85 @@
+package chain
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestEventManagerRegister(t *testing.T) {
+	em := newEventManager()
+
+	var calls1, calls2 int
+	id1 := em.register(InsertSnapshotBlocksSuccessEvent, InsertSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) { calls1++ }))
+	id2 := em.register(InsertSnapshotBlocksSuccessEvent, InsertSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) { calls2++ }))
+	id3 := em.register(DeleteSnapshotBlocksSuccessEvent, DeleteSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) {}))
+	if id1 == 0 || id1 == id2 || id2 == id3 || id1 == id3 {
+		t.Fatalf("listener ids should be unique and non-zero: %d %d %d", id1, id2, id3)
+	}
+
+	em.triggerInsertSnapshotBlocksSuccess(nil)
+	if calls1 != 1 || calls2 != 1 {
+		t.Fatalf("both listeners should be called once, got %d %d", calls1, calls2)
+	}
+
+	em.unRegister(id1)
+	em.triggerInsertSnapshotBlocksSuccess(nil)
+	if calls1 != 1 || calls2 != 2 {
+		t.Fatalf("only the second listener should be called, got %d %d", calls1, calls2)
+	}
+
+	em.unRegister(id2)
+	em.unRegister(id3)
+	if len(em.isbssEventListener) != 0 || len(em.dsbssEventListener) != 0 {
+		t.Fatal("all listeners should be removed")
+	}
+}
+
+func TestEventManagerUnRegisterInListener(t *testing.T) {
+	em := newEventManager()
+
+	var calls int
+	var id uint64
+	id = em.register(InsertSnapshotBlocksSuccessEvent, InsertSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) {
+		calls++
+		em.unRegister(id)
+	}))
+	em.register(InsertSnapshotBlocksSuccessEvent, InsertSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) { calls++ }))
+
+	em.triggerInsertSnapshotBlocksSuccess(nil)
+	if calls != 2 {
+		t.Fatalf("listeners of the trigger should all be called, got %d", calls)
+	}
+	em.triggerInsertSnapshotBlocksSuccess(nil)
+	if calls != 3 {
+		t.Fatalf("the unregistered listener should not be called, got %d", calls)
+	}
+}
+
+func TestEventManagerConcurrent(t *testing.T) {
+	em := newEventManager()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := em.register(InsertSnapshotBlocksSuccessEvent, InsertSnapshotBlocksSuccess(func([]*ledger.SnapshotBlock) {}))
+				em.unRegister(id)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				em.triggerInsertSnapshotBlocksSuccess(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(em.isbssEventListener) != 0 {
+		t.Fatalf("all listeners should be removed, %d left", len(em.isbssEventListener))
+	}
+}
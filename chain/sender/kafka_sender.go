@@ -3,6 +3,7 @@ package sender
 import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/log15"
 	"os"
 	"sync"
@@ -11,12 +12,17 @@ import (
 const (
 	DBKP_PRODUCER          = byte(1)
 	DBKP_PRODUCER_HAS_SEND = byte(2)
+	DBKP_SINK              = byte(3)
+	DBKP_SINK_HAS_SEND     = byte(4)
 )
 
 type KafkaSender struct {
 	producers    []*Producer
 	runProducers []*Producer
 
+	sinks    []*SinkRunner
+	runSinks []*SinkRunner
+
 	chain Chain
 	db    *leveldb.DB
 
@@ -51,6 +57,13 @@ func NewKafkaSender(chain Chain, dirName string) (*KafkaSender, error) {
 
 	sender.producers = producers
 
+	sinks, readSinksErr := sender.readSinksFromDb()
+	if readSinksErr != nil {
+		return nil, readSinksErr
+	}
+
+	sender.sinks = sinks
+
 	return sender, nil
 }
 
@@ -112,6 +125,10 @@ func (sender *KafkaSender) StopAll() {
 	for _, runProducer := range sender.runProducers {
 		runProducer.Stop()
 	}
+
+	for _, runSink := range sender.runSinks {
+		runSink.Stop()
+	}
 }
 
 func (sender *KafkaSender) SetHasSend(producerId uint8, hasSend uint64) {
@@ -188,3 +205,111 @@ func (sender *KafkaSender) readProducersFromDb() ([]*Producer, error) {
 
 	return producers, nil
 }
+
+func (sender *KafkaSender) StartSink(cfg *config.Sink) error {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	for _, runSink := range sender.runSinks {
+		if runSink.IsSame(cfg) {
+			// has run
+			return nil
+		}
+	}
+
+	sink, err := sender.getSink(cfg)
+	if err != nil {
+		return err
+	}
+
+	if startErr := sink.Start(); startErr != nil {
+		return startErr
+	}
+
+	sender.runSinks = append(sender.runSinks, sink)
+
+	return nil
+}
+
+func (sender *KafkaSender) StopSinkById(sinkId uint8) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+
+	for index, runSink := range sender.runSinks {
+		if runSink.SinkId() == sinkId {
+			runSink.Stop()
+			sender.runSinks = append(sender.runSinks[:index], sender.runSinks[index+1:]...)
+			return
+		}
+	}
+}
+
+func (sender *KafkaSender) SetSinkHasSend(sinkId uint8, hasSend uint64) {
+	for _, sink := range sender.sinks {
+		if sink.sinkId == sinkId {
+			sink.SetHasSend(hasSend)
+			return
+		}
+	}
+}
+
+func (sender *KafkaSender) Sinks() []*SinkRunner {
+	return sender.sinks
+}
+
+func (sender *KafkaSender) RunSinks() []*SinkRunner {
+	return sender.runSinks
+}
+
+func (sender *KafkaSender) getSink(cfg *config.Sink) (*SinkRunner, error) {
+	for _, sink := range sender.sinks {
+		if sink.IsSame(cfg) {
+			return sink, nil
+		}
+	}
+
+	newSink, newErr := NewSinkRunner(byte(len(sender.sinks)+1), cfg, sender.chain, sender.db)
+	if newErr != nil {
+		return nil, newErr
+	}
+
+	if writeErr := sender.writeSinkToDb(newSink); writeErr != nil {
+		return nil, writeErr
+	}
+
+	sender.sinks = append(sender.sinks, newSink)
+	return newSink, nil
+}
+
+func (sender *KafkaSender) writeSinkToDb(sink *SinkRunner) error {
+	key := append([]byte{DBKP_SINK}, sink.sinkId)
+	buf, sErr := sink.Serialize()
+
+	if sErr != nil {
+		return sErr
+	}
+
+	return sender.db.Put(key, buf, nil)
+}
+
+func (sender *KafkaSender) readSinksFromDb() ([]*SinkRunner, error) {
+	iter := sender.db.NewIterator(util.BytesPrefix([]byte{DBKP_SINK}), nil)
+	defer iter.Release()
+
+	var sinks []*SinkRunner
+	for iter.Next() {
+		sinkId := uint8(iter.Key()[1])
+
+		sink, err := NewSinkRunnerFromDb(sinkId, iter.Value(), sender.chain, sender.db)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if iterErr := iter.Error(); iterErr != nil && iterErr != leveldb.ErrNotFound {
+		return nil, iterErr
+	}
+
+	return sinks, nil
+}
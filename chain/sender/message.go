@@ -0,0 +1,187 @@
+package sender
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_context"
+)
+
+func getParsedData(block *ledger.AccountBlock) (string, error) {
+	if len(block.Data) <= 0 {
+		return "", nil
+	}
+
+	switch block.ToAddress.String() {
+	case abi.AddressMintage.String():
+		tokenInfo := new(types.TokenInfo)
+		token := abi.ABIMintage.UnpackVariable(tokenInfo, abi.VariableNameMintage, block.Data)
+		tokenBytes, err := json.Marshal(token)
+		return string(tokenBytes), err
+	}
+
+	return "", nil
+}
+
+// newEventMessage reads the block event of eventId and converts it to a message,
+// message is nil if there is no event or the blocks of the event are not found.
+func newEventMessage(chain Chain, eventId uint64) (*message, error) {
+	eventType, blockHashList, err := chain.GetEvent(eventId)
+	if err != nil {
+		return nil, errors.New("GetEvent failed, error is " + err.Error())
+	}
+
+	m := &message{
+		EventId: eventId,
+	}
+
+	switch eventType {
+	// AddAccountBlocksEvent     = byte(1)
+	case byte(1):
+		m.MsgType = "InsertAccountBlocks"
+		var blocks []*MqAccountBlock
+		for _, blockHash := range blockHashList {
+			block, err := chain.GetAccountBlockByHash(&blockHash)
+			if err != nil {
+				return nil, errors.New("GetAccountBlockByHash failed, error is " + err.Error())
+			}
+			if block != nil {
+				// Wrap block
+				mqAccountBlock := &MqAccountBlock{}
+				mqAccountBlock.AccountBlock = *block
+
+				var sendBlock *ledger.AccountBlock
+
+				var tokenTypeId *types.TokenTypeId
+				if block.IsReceiveBlock() {
+					var err error
+					sendBlock, err = chain.GetAccountBlockByHash(&block.FromBlockHash)
+
+					if err != nil {
+						return nil, errors.New("Get send account block failed, error is " + err.Error())
+					}
+
+					if sendBlock != nil {
+						tokenTypeId = &sendBlock.TokenId
+						// set token id
+						mqAccountBlock.Amount = sendBlock.Amount
+						mqAccountBlock.TokenId = sendBlock.TokenId
+						mqAccountBlock.FromAddress = sendBlock.AccountAddress
+						mqAccountBlock.ToAddress = mqAccountBlock.AccountAddress
+						mqAccountBlock.SendData = sendBlock.Data
+					}
+				} else {
+					tokenTypeId = &block.TokenId
+					mqAccountBlock.FromAddress = mqAccountBlock.AccountAddress
+
+					var err error
+					mqAccountBlock.ParsedData, err = getParsedData(block)
+					if err != nil {
+						return nil, errors.New("GetParsedData failed, error is " + err.Error())
+					}
+
+				}
+
+				balance := big.NewInt(0)
+
+				if tokenTypeId != nil {
+					vc, newVcErr := vm_context.NewVmContext(chain, nil, &block.Hash, &block.AccountAddress)
+					if newVcErr != nil {
+						return nil, errors.New("NewVmContext failed, error is " + newVcErr.Error())
+					}
+					balance = vc.GetBalance(nil, tokenTypeId)
+				}
+
+				mqAccountBlock.Balance = balance
+				mqAccountBlock.Timestamp = block.Timestamp.Unix()
+				blocks = append(blocks, mqAccountBlock)
+			}
+		}
+
+		if len(blocks) <= 0 {
+			return nil, nil
+		}
+
+		buf, jsonErr := json.Marshal(blocks)
+		if jsonErr != nil {
+			return nil, errors.New("[InsertAccountBlocks] json.Marshal failed, error is " + jsonErr.Error())
+		}
+		m.Data += string(buf)
+
+		// DeleteAccountBlocksEvent  = byte(2)
+	case byte(2):
+		m.MsgType = "DeleteAccountBlocks"
+
+		buf, jsonErr := json.Marshal(blockHashList)
+		if jsonErr != nil {
+			return nil, errors.New("[DeleteAccountBlocks] json.Marshal failed, error is " + jsonErr.Error())
+		}
+		m.Data = string(buf)
+
+		// AddSnapshotBlocksEvent    = byte(3)
+	case byte(3):
+		m.MsgType = "InsertSnapshotBlocks"
+		var blocks []*MqSnapshotBlock
+		for _, blockHash := range blockHashList {
+			block, err := chain.GetSnapshotBlockByHash(&blockHash)
+			if err != nil {
+				return nil, errors.New("GetSnapshotBlockByHash failed, error is " + err.Error())
+			}
+			if block != nil {
+				mqSnapshotBlock := &MqSnapshotBlock{}
+				mqSnapshotBlock.SnapshotBlock = block
+				subLedger, err := chain.GetConfirmSubLedgerBySnapshotBlocks([]*ledger.SnapshotBlock{block})
+				if err != nil {
+					return nil, errors.New("GetConfirmSubLedgerBySnapshotBlocks failed, error is " + err.Error())
+				}
+
+				mqSnapshotBlock.MqSnapshotContent = make(MqSnapshotContent)
+				for addr, blocks := range subLedger {
+					mqSnapshotBlock.MqSnapshotContent[addr] = &MqSnapshotContentItem{
+						Start: &ledger.HashHeight{
+							Hash:   blocks[0].Hash,
+							Height: blocks[0].Height,
+						},
+						End: &ledger.HashHeight{
+							Hash:   blocks[len(blocks)-1].Hash,
+							Height: blocks[len(blocks)-1].Height,
+						},
+					}
+				}
+
+				mqSnapshotBlock.Producer = mqSnapshotBlock.SnapshotBlock.Producer()
+				mqSnapshotBlock.Timestamp = block.Timestamp.Unix()
+				blocks = append(blocks, mqSnapshotBlock)
+			}
+		}
+
+		if len(blocks) <= 0 {
+			return nil, nil
+		}
+
+		buf, jsonErr := json.Marshal(blocks)
+		if jsonErr != nil {
+			return nil, errors.New("[InsertSnapshotBlocks] json.Marshal failed, error is " + jsonErr.Error())
+		}
+		m.Data += string(buf)
+
+		// DeleteSnapshotBlocksEvent = byte(4)
+	case byte(4):
+		m.MsgType = "DeleteSnapshotBlocks"
+		buf, jsonErr := json.Marshal(blockHashList)
+		if jsonErr != nil {
+			return nil, errors.New("[DeleteSnapshotBlocks] json.Marshal failed, error is " + jsonErr.Error())
+		}
+		m.Data = string(buf)
+
+		// No event
+	default:
+		return nil, nil
+	}
+
+	return m, nil
+}
@@ -3,7 +3,6 @@ package sender
 import (
 	"encoding/binary"
 	"encoding/json"
-	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"math/big"
 	"sync"
 	"time"
@@ -16,7 +15,6 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vitepb"
-	"github.com/vitelabs/go-vite/vm_context"
 )
 
 const (
@@ -269,22 +267,6 @@ func (producer *Producer) Stop() {
 	producer.status = STOPPED
 }
 
-func (producer *Producer) getParsedData(block *ledger.AccountBlock) (string, error) {
-	if len(block.Data) <= 0 {
-		return "", nil
-	}
-
-	switch block.ToAddress.String() {
-	case abi.AddressMintage.String():
-		tokenInfo := new(types.TokenInfo)
-		token := abi.ABIMintage.UnpackVariable(tokenInfo, abi.VariableNameMintage, block.Data)
-		tokenBytes, err := json.Marshal(token)
-		return string(tokenBytes), err
-	}
-
-	return "", nil
-}
-
 func (producer *Producer) send() {
 	producer.hasSendLock.Lock()
 	defer producer.hasSendLock.Unlock()
@@ -317,169 +299,14 @@ func (producer *Producer) send() {
 
 		j := i + 1
 		for ; j-i <= producer.concurrency && j <= end; j++ {
-			eventType, blockHashList, err := producer.chain.GetEvent(j)
+			m, err := newEventMessage(producer.chain, j)
 			if err != nil {
-				producer.log.Error("Get event failed, error is "+err.Error(), "method", "send")
+				producer.log.Error("newEventMessage failed, error is "+err.Error(), "method", "send")
 				return
 			}
 
-			m := &message{
-				EventId: j,
-			}
-
-			switch eventType {
-			// AddAccountBlocksEvent     = byte(1)
-			case byte(1):
-				m.MsgType = "InsertAccountBlocks"
-				var blocks []*MqAccountBlock
-				for _, blockHash := range blockHashList {
-					block, err := producer.chain.GetAccountBlockByHash(&blockHash)
-					if err != nil {
-						producer.log.Error("GetAccountBlockByHash failed, error is "+err.Error(), "method", "send")
-						return
-					}
-					if block != nil {
-						// Wrap block
-						mqAccountBlock := &MqAccountBlock{}
-						mqAccountBlock.AccountBlock = *block
-
-						var sendBlock *ledger.AccountBlock
-
-						var tokenTypeId *types.TokenTypeId
-						if block.IsReceiveBlock() {
-							var err error
-							sendBlock, err = producer.chain.GetAccountBlockByHash(&block.FromBlockHash)
-
-							if err != nil {
-								producer.log.Error("Get send account block failed, error is "+err.Error(), "method", "send")
-								return
-							}
-
-							if sendBlock != nil {
-								tokenTypeId = &sendBlock.TokenId
-								// set token id
-								mqAccountBlock.Amount = sendBlock.Amount
-								mqAccountBlock.TokenId = sendBlock.TokenId
-								mqAccountBlock.FromAddress = sendBlock.AccountAddress
-								mqAccountBlock.ToAddress = mqAccountBlock.AccountAddress
-								mqAccountBlock.SendData = sendBlock.Data
-							}
-						} else {
-							tokenTypeId = &block.TokenId
-							mqAccountBlock.FromAddress = mqAccountBlock.AccountAddress
-
-							var err error
-							mqAccountBlock.ParsedData, err = producer.getParsedData(block)
-							if err != nil {
-								producer.log.Error("GetParsedData failed, error is "+err.Error(), "method", "send")
-								return
-							}
-
-						}
-
-						balance := big.NewInt(0)
-
-						if tokenTypeId != nil {
-							vc, newVcErr := vm_context.NewVmContext(producer.chain, nil, &block.Hash, &block.AccountAddress)
-							if newVcErr != nil {
-								producer.log.Error("NewVmContext failed, error is "+newVcErr.Error(), "method", "send")
-								return
-							}
-							balance = vc.GetBalance(nil, tokenTypeId)
-						}
-
-						mqAccountBlock.Balance = balance
-						mqAccountBlock.Timestamp = block.Timestamp.Unix()
-						blocks = append(blocks, mqAccountBlock)
-					}
-				}
-
-				if len(blocks) <= 0 {
-					producer.hasSend = j
-					continue
-				}
-
-				buf, jsonErr := json.Marshal(blocks)
-				if jsonErr != nil {
-					producer.log.Error("[InsertAccountBlocks] json.Marshal failed, error is "+jsonErr.Error(), "method", "send")
-					return
-				}
-				m.Data += string(buf)
-
-				// DeleteAccountBlocksEvent  = byte(2)
-			case byte(2):
-				m.MsgType = "DeleteAccountBlocks"
-
-				buf, jsonErr := json.Marshal(blockHashList)
-				if jsonErr != nil {
-					producer.log.Error("[DeleteAccountBlocks] json.Marshal failed, error is "+jsonErr.Error(), "method", "send")
-					return
-				}
-				m.Data = string(buf)
-
-				// AddSnapshotBlocksEvent    = byte(3)
-			case byte(3):
-				m.MsgType = "InsertSnapshotBlocks"
-				var blocks []*MqSnapshotBlock
-				for _, blockHash := range blockHashList {
-					block, err := producer.chain.GetSnapshotBlockByHash(&blockHash)
-					if err != nil {
-						producer.log.Error("GetSnapshotBlockByHash failed, error is "+err.Error(), "method", "send")
-						return
-					}
-					if block != nil {
-						mqSnapshotBlock := &MqSnapshotBlock{}
-						mqSnapshotBlock.SnapshotBlock = block
-						subLedger, err := producer.chain.GetConfirmSubLedgerBySnapshotBlocks([]*ledger.SnapshotBlock{block})
-						if err != nil {
-							producer.log.Error("GetConfirmSubLedgerBySnapshotBlocks failed, error is "+err.Error(), "method", "send")
-							return
-						}
-
-						mqSnapshotBlock.MqSnapshotContent = make(MqSnapshotContent)
-						for addr, blocks := range subLedger {
-							mqSnapshotBlock.MqSnapshotContent[addr] = &MqSnapshotContentItem{
-								Start: &ledger.HashHeight{
-									Hash:   blocks[0].Hash,
-									Height: blocks[0].Height,
-								},
-								End: &ledger.HashHeight{
-									Hash:   blocks[len(blocks)-1].Hash,
-									Height: blocks[len(blocks)-1].Height,
-								},
-							}
-						}
-
-						mqSnapshotBlock.Producer = mqSnapshotBlock.SnapshotBlock.Producer()
-						mqSnapshotBlock.Timestamp = block.Timestamp.Unix()
-						blocks = append(blocks, mqSnapshotBlock)
-					}
-				}
-
-				if len(blocks) <= 0 {
-					producer.hasSend = j
-					continue
-				}
-
-				buf, jsonErr := json.Marshal(blocks)
-				if jsonErr != nil {
-					producer.log.Error("[InsertSnapshotBlocks] json.Marshal failed, error is "+jsonErr.Error(), "method", "send")
-					return
-				}
-				m.Data += string(buf)
-
-				// DeleteSnapshotBlocksEvent = byte(4)
-			case byte(4):
-				m.MsgType = "DeleteSnapshotBlocks"
-				buf, jsonErr := json.Marshal(blockHashList)
-				if jsonErr != nil {
-					producer.log.Error("[DeleteSnapshotBlocks] json.Marshal failed, error is "+jsonErr.Error(), "method", "send")
-					return
-				}
-				m.Data = string(buf)
-
-				// No event
-			default:
+			// No event or no block
+			if m == nil {
 				producer.hasSend = j
 				continue
 			}
@@ -0,0 +1,36 @@
+package sender
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/config"
+)
+
+const (
+	SinkTypeFile    = "file"
+	SinkTypeWebhook = "webhook"
+	SinkTypeUnix    = "unix"
+)
+
+// Sink delivers event messages to a destination other than kafka
+type Sink interface {
+	// Send delivers msgList in order, none of msgList is treated as delivered if an error is returned
+	Send(msgList [][]byte) error
+	Close() error
+}
+
+func NewSink(cfg *config.Sink) (Sink, error) {
+	if cfg.Target == "" {
+		return nil, errors.New("sink target is empty")
+	}
+
+	switch cfg.Type {
+	case SinkTypeFile:
+		return NewFileSink(cfg.Target, defaultFileSinkMaxSize)
+	case SinkTypeWebhook:
+		return NewWebhookSink(cfg.Target), nil
+	case SinkTypeUnix:
+		return NewUnixSink(cfg.Target), nil
+	}
+	return nil, errors.New("unknown sink type " + cfg.Type)
+}
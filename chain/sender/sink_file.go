@@ -0,0 +1,117 @@
+package sender
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultFileSinkMaxSize = int64(64 * 1024 * 1024)
+	fileSinkName           = "events.jsonl"
+)
+
+// FileSink writes one message per line to dir/events.jsonl, the file is renamed with
+// a timestamp suffix and a new one is created when its size exceeds maxSize
+type FileSink struct {
+	dir     string
+	maxSize int64
+
+	file *os.File
+	size int64
+
+	// written is the part of the last failed batch already in the file, it is skipped
+	// when the batch is sent again so that a short write doesn't duplicate it
+	written []byte
+}
+
+func NewFileSink(dir string, maxSize int64) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	sink := &FileSink{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+func (sink *FileSink) open() error {
+	file, err := os.OpenFile(filepath.Join(sink.dir, fileSinkName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	sink.file = file
+	sink.size = info.Size()
+	return nil
+}
+
+func (sink *FileSink) rotate() error {
+	if err := sink.file.Close(); err != nil {
+		return err
+	}
+
+	rotatedName := fmt.Sprintf("events-%s.jsonl", time.Now().Format("20060102150405.000000000"))
+	if err := os.Rename(filepath.Join(sink.dir, fileSinkName), filepath.Join(sink.dir, rotatedName)); err != nil {
+		return err
+	}
+	return sink.open()
+}
+
+func (sink *FileSink) Send(msgList [][]byte) error {
+	// don't rotate in the middle of a partially written batch
+	if len(sink.written) == 0 && sink.size >= sink.maxSize {
+		if err := sink.rotate(); err != nil {
+			return err
+		}
+	}
+
+	var buf []byte
+	for _, msg := range msgList {
+		buf = append(buf, msg...)
+		buf = append(buf, '\n')
+	}
+
+	start := 0
+	if len(sink.written) > 0 {
+		if bytes.HasPrefix(buf, sink.written) {
+			start = len(sink.written)
+		} else {
+			// a different batch, end the partial line before writing it
+			n, err := sink.file.Write([]byte{'\n'})
+			sink.size += int64(n)
+			if err != nil {
+				return err
+			}
+			sink.written = sink.written[:0]
+		}
+	}
+
+	n, err := sink.file.Write(buf[start:])
+	sink.size += int64(n)
+	if err != nil {
+		if start+n > 0 {
+			sink.written = append(sink.written[:0], buf[:start+n]...)
+		}
+		return err
+	}
+	sink.written = sink.written[:0]
+	return sink.file.Sync()
+}
+
+func (sink *FileSink) Close() error {
+	return sink.file.Close()
+}
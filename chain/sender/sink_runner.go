@@ -0,0 +1,290 @@
+package sender
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	sinkSendInterval     = 500 * time.Millisecond
+	sinkMaxRetryInterval = 30 * time.Second
+)
+
+// sinkRetryInterval is the interval before the next send, it doubles after each consecutive failure up to sinkMaxRetryInterval
+func sinkRetryInterval(failures int) time.Duration {
+	interval := sinkSendInterval
+	for i := 0; i < failures && interval < sinkMaxRetryInterval; i++ {
+		interval *= 2
+	}
+	if interval > sinkMaxRetryInterval {
+		interval = sinkMaxRetryInterval
+	}
+	return interval
+}
+
+// SinkRunner reads block events from the chain and delivers them to a sink,
+// the id of the last delivered event is persisted so that it resumes after restart
+type SinkRunner struct {
+	sinkId uint8
+	db     *leveldb.DB
+	cfg    *config.Sink
+
+	hasSendLock      sync.RWMutex
+	hasSend          uint64
+	dbHasSend        uint64
+	dbRecordInterval uint64
+
+	termination chan int
+
+	status     int
+	statusLock sync.Mutex
+	log        log15.Logger
+
+	wg sync.WaitGroup
+
+	sink      Sink
+	chain     Chain
+	batchSize uint64
+}
+
+func NewSinkRunnerFromDb(sinkId uint8, buf []byte, chain Chain, db *leveldb.DB) (*SinkRunner, error) {
+	runner := &SinkRunner{}
+	if dsErr := runner.Deserialize(buf); dsErr != nil {
+		return nil, dsErr
+	}
+
+	if err := runner.init(sinkId, chain, db); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+func NewSinkRunner(sinkId uint8, cfg *config.Sink, chain Chain, db *leveldb.DB) (*SinkRunner, error) {
+	runner := &SinkRunner{
+		cfg: cfg,
+	}
+
+	if err := runner.init(sinkId, chain, db); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+func (runner *SinkRunner) init(sinkId uint8, chain Chain, db *leveldb.DB) error {
+	runner.sinkId = sinkId
+	runner.batchSize = 100
+
+	runner.chain = chain
+	runner.db = db
+	runner.dbRecordInterval = 100
+	runner.log = log15.New("module", "sender/sink", "type", runner.cfg.Type)
+
+	hasSend, err := runner.getHasSend()
+	if err != nil {
+		return err
+	}
+
+	runner.hasSendLock.Lock()
+
+	runner.hasSend = hasSend
+	runner.dbHasSend = hasSend
+
+	runner.hasSendLock.Unlock()
+
+	return nil
+}
+
+func (runner *SinkRunner) SetHasSend(hasSend uint64) {
+	runner.hasSendLock.Lock()
+	defer runner.hasSendLock.Unlock()
+
+	runner.hasSend = hasSend
+	runner.saveHasSend()
+}
+
+func (runner *SinkRunner) SinkId() uint8 {
+	return runner.sinkId
+}
+
+func (runner *SinkRunner) Type() string {
+	return runner.cfg.Type
+}
+
+func (runner *SinkRunner) Target() string {
+	return runner.cfg.Target
+}
+
+func (runner *SinkRunner) HasSend() uint64 {
+	return runner.hasSend
+}
+
+func (runner *SinkRunner) Status() int {
+	return runner.status
+}
+
+func (runner *SinkRunner) IsSame(cfg *config.Sink) bool {
+	return runner.cfg.Type == cfg.Type && runner.cfg.Target == cfg.Target
+}
+
+func (runner *SinkRunner) Deserialize(buffer []byte) error {
+	cfg := &config.Sink{}
+	if err := json.Unmarshal(buffer, cfg); err != nil {
+		return err
+	}
+
+	runner.cfg = cfg
+	return nil
+}
+
+func (runner *SinkRunner) Serialize() ([]byte, error) {
+	return json.Marshal(runner.cfg)
+}
+
+func (runner *SinkRunner) Start() error {
+	runner.statusLock.Lock()
+	defer runner.statusLock.Unlock()
+	if runner.status == RUNNING {
+		return nil
+	}
+
+	sink, err := NewSink(runner.cfg)
+	if err != nil {
+		return err
+	}
+
+	runner.sink = sink
+	runner.status = RUNNING
+	runner.termination = make(chan int)
+
+	runner.wg.Add(1)
+	common.Go(func() {
+		defer runner.wg.Done()
+		failures := 0
+		for {
+			if err := runner.send(); err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+
+			select {
+			case <-runner.termination:
+				if closeErr := runner.sink.Close(); closeErr != nil {
+					runner.log.Error("sink close failed, error is "+closeErr.Error(), "method", "Start")
+				}
+				runner.sink = nil
+				return
+			case <-time.After(sinkRetryInterval(failures)):
+			}
+		}
+	})
+	return nil
+}
+
+func (runner *SinkRunner) Stop() {
+	runner.statusLock.Lock()
+	defer runner.statusLock.Unlock()
+	if runner.status == STOPPED {
+		return
+	}
+
+	runner.termination <- 1
+	runner.wg.Wait()
+
+	runner.status = STOPPED
+}
+
+// send delivers the events after hasSend, the failed batch is sent again in the next call
+func (runner *SinkRunner) send() error {
+	runner.hasSendLock.Lock()
+	defer runner.hasSendLock.Unlock()
+
+	end, err := runner.chain.GetLatestBlockEventId()
+	if err != nil {
+		runner.log.Error("GetLatestBlockEventId failed, error is "+err.Error(), "method", "send")
+		return err
+	}
+
+	defer func() {
+		if runner.hasSend > runner.dbHasSend {
+			if err := runner.saveHasSend(); err != nil {
+				runner.log.Error("saveHasSend failed, error is "+err.Error(), "method", "send")
+			}
+		}
+	}()
+
+	for runner.hasSend < end {
+		if runner.hasSend > runner.dbHasSend &&
+			runner.hasSend-runner.dbHasSend >= runner.dbRecordInterval {
+			if err := runner.saveHasSend(); err != nil {
+				runner.log.Error("saveHasSend failed, error is "+err.Error(), "method", "send")
+			}
+		}
+
+		var msgList [][]byte
+		j := runner.hasSend + 1
+		for ; j-runner.hasSend <= runner.batchSize && j <= end; j++ {
+			m, err := newEventMessage(runner.chain, j)
+			if err != nil {
+				runner.log.Error("newEventMessage failed, error is "+err.Error(), "method", "send")
+				return err
+			}
+
+			// No event or no block
+			if m == nil {
+				continue
+			}
+
+			buf, jsonErr := json.Marshal(m)
+			if jsonErr != nil {
+				runner.log.Error("json.Marshal failed, error is "+jsonErr.Error(), "method", "send")
+				return jsonErr
+			}
+			msgList = append(msgList, buf)
+		}
+
+		if len(msgList) > 0 {
+			if sendErr := runner.sink.Send(msgList); sendErr != nil {
+				runner.log.Error("sink send failed, error is "+sendErr.Error(), "method", "send")
+				return sendErr
+			}
+		}
+
+		runner.hasSend = j - 1
+	}
+	return nil
+}
+
+func (runner *SinkRunner) saveHasSend() error {
+	key := append([]byte{DBKP_SINK_HAS_SEND}, runner.sinkId)
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, runner.hasSend)
+
+	if err := runner.db.Put(key, buf, nil); err != nil {
+		return err
+	}
+
+	runner.dbHasSend = runner.hasSend
+	return nil
+}
+
+func (runner *SinkRunner) getHasSend() (uint64, error) {
+	key := append([]byte{DBKP_SINK_HAS_SEND}, runner.sinkId)
+
+	value, err := runner.db.Get(key, nil)
+	if err != nil {
+		if err != leveldb.ErrNotFound {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	return binary.BigEndian.Uint64(value), nil
+}
@@ -0,0 +1,237 @@
+package sender
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_context"
+)
+
+// testChain serves delete events, which are converted to messages without reading blocks
+type testChain struct {
+	vm_context.Chain
+
+	latestEventId uint64
+	eventErr      error
+}
+
+func (c *testChain) GetLatestBlockEventId() (uint64, error) {
+	return c.latestEventId, nil
+}
+
+func (c *testChain) GetEvent(eventId uint64) (byte, []types.Hash, error) {
+	if c.eventErr != nil {
+		return 0, nil, c.eventErr
+	}
+	// every third event doesn't exist
+	if eventId%3 == 0 {
+		return 0, nil, nil
+	}
+	return byte(2), []types.Hash{testEventHash(eventId)}, nil
+}
+
+func testEventHash(eventId uint64) types.Hash {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, eventId)
+	return types.DataHash(buf)
+}
+
+func (c *testChain) GetConfirmSubLedgerBySnapshotBlocks(snapshotBlocks []*ledger.SnapshotBlock) (map[types.Address][]*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+// testSink records the delivered messages, it fails the next failures calls of Send
+type testSink struct {
+	msgList   [][]byte
+	batchList []int
+	failures  int
+}
+
+func (sink *testSink) Send(msgList [][]byte) error {
+	if sink.failures > 0 {
+		sink.failures--
+		return errors.New("sink is down")
+	}
+	sink.msgList = append(sink.msgList, msgList...)
+	sink.batchList = append(sink.batchList, len(msgList))
+	return nil
+}
+
+func (sink *testSink) Close() error {
+	return nil
+}
+
+func newTestSinkRunner(t *testing.T, chain Chain) (*SinkRunner, *leveldb.DB) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner, err := NewSinkRunner(1, &config.Sink{Type: SinkTypeFile, Target: t.Name()}, chain, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runner, db
+}
+
+// checkEventIds checks msgList contains the events in [1, end] in order, except the ones don't exist
+func checkEventIds(t *testing.T, msgList [][]byte, end uint64) {
+	var eventIds []uint64
+	for eventId := uint64(1); eventId <= end; eventId++ {
+		if eventId%3 != 0 {
+			eventIds = append(eventIds, eventId)
+		}
+	}
+	if len(msgList) != len(eventIds) {
+		t.Fatalf("expected %d messages, got %d", len(eventIds), len(msgList))
+	}
+
+	for i, buf := range msgList {
+		m := &message{}
+		if err := json.Unmarshal(buf, m); err != nil {
+			t.Fatal(err)
+		}
+		if m.MsgType != "DeleteAccountBlocks" || m.EventId != eventIds[i] {
+			t.Fatalf("expected event %d, got %s %d", eventIds[i], m.MsgType, m.EventId)
+		}
+
+		var hashList []types.Hash
+		if err := json.Unmarshal([]byte(m.Data), &hashList); err != nil {
+			t.Fatal(err)
+		}
+		if len(hashList) != 1 || hashList[0] != testEventHash(m.EventId) {
+			t.Fatalf("unexpected data of event %d: %s", m.EventId, m.Data)
+		}
+	}
+}
+
+func TestSinkRunnerSend(t *testing.T) {
+	chain := &testChain{latestEventId: 250}
+	runner, db := newTestSinkRunner(t, chain)
+	defer db.Close()
+	sink := &testSink{}
+	runner.sink = sink
+
+	if err := runner.send(); err != nil {
+		t.Fatal(err)
+	}
+	if runner.HasSend() != 250 {
+		t.Fatalf("hasSend should be 250, got %d", runner.HasSend())
+	}
+	checkEventIds(t, sink.msgList, 250)
+	for _, n := range sink.batchList {
+		if n > int(runner.batchSize) {
+			t.Fatalf("batch of %d messages is larger than %d", n, runner.batchSize)
+		}
+	}
+
+	// the offset is persisted
+	reloaded, err := NewSinkRunner(1, runner.cfg, chain, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.HasSend() != 250 {
+		t.Fatalf("persisted hasSend should be 250, got %d", reloaded.HasSend())
+	}
+}
+
+func TestSinkRunnerRetry(t *testing.T) {
+	chain := &testChain{latestEventId: 150}
+	runner, db := newTestSinkRunner(t, chain)
+	defer db.Close()
+	sink := &testSink{failures: 1}
+	runner.sink = sink
+
+	if err := runner.send(); err == nil {
+		t.Fatal("the failure of the sink should be returned")
+	}
+	if runner.HasSend() != 0 || len(sink.msgList) != 0 {
+		t.Fatalf("nothing should be delivered, hasSend %d", runner.HasSend())
+	}
+
+	// the second batch fails after the first one is delivered
+	sink.failures = 0
+	chain.latestEventId = 100
+	if err := runner.send(); err != nil {
+		t.Fatal(err)
+	}
+	chain.latestEventId = 150
+	sink.failures = 1
+	if err := runner.send(); err == nil {
+		t.Fatal("the failure of the sink should be returned")
+	}
+	if runner.HasSend() != 100 {
+		t.Fatalf("hasSend should stay at 100, got %d", runner.HasSend())
+	}
+
+	if err := runner.send(); err != nil {
+		t.Fatal(err)
+	}
+	if runner.HasSend() != 150 {
+		t.Fatalf("hasSend should be 150, got %d", runner.HasSend())
+	}
+	// no message is lost or duplicated
+	checkEventIds(t, sink.msgList, 150)
+}
+
+func TestSinkRunnerEventError(t *testing.T) {
+	chain := &testChain{latestEventId: 10, eventErr: errors.New("db is closed")}
+	runner, db := newTestSinkRunner(t, chain)
+	defer db.Close()
+	sink := &testSink{}
+	runner.sink = sink
+
+	if err := runner.send(); err == nil {
+		t.Fatal("the failure of GetEvent should be returned")
+	}
+	if runner.HasSend() != 0 || len(sink.msgList) != 0 {
+		t.Fatalf("nothing should be delivered, hasSend %d", runner.HasSend())
+	}
+}
+
+func TestSinkRunnerSerialize(t *testing.T) {
+	runner, db := newTestSinkRunner(t, &testChain{})
+	defer db.Close()
+	runner.SetHasSend(42)
+
+	buf, err := runner.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewSinkRunnerFromDb(runner.SinkId(), buf, &testChain{}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.IsSame(runner.cfg) || reloaded.HasSend() != 42 {
+		t.Fatalf("unexpected runner %s %s %d", reloaded.Type(), reloaded.Target(), reloaded.HasSend())
+	}
+
+	if _, err := NewSinkRunnerFromDb(2, []byte("{"), &testChain{}, db); err == nil {
+		t.Fatal("corrupted runner should be rejected")
+	}
+}
+
+func TestSinkRetryInterval(t *testing.T) {
+	tests := []struct {
+		failures int
+		interval time.Duration
+	}{
+		{0, sinkSendInterval},
+		{1, 2 * sinkSendInterval},
+		{3, 8 * sinkSendInterval},
+		{10, sinkMaxRetryInterval},
+		{1000, sinkMaxRetryInterval},
+	}
+	for _, test := range tests {
+		if interval := sinkRetryInterval(test.failures); interval != test.interval {
+			t.Errorf("%d failures, expected %s, got %s", test.failures, test.interval, interval)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package sender
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/config"
+)
+
+func TestNewSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		cfg config.Sink
+		ok  bool
+	}{
+		{config.Sink{Type: SinkTypeFile, Target: dir}, true},
+		{config.Sink{Type: SinkTypeWebhook, Target: "http://127.0.0.1:1/events"}, true},
+		{config.Sink{Type: SinkTypeUnix, Target: filepath.Join(dir, "events.sock")}, true},
+		{config.Sink{Type: SinkTypeFile}, false},
+		{config.Sink{Type: "kafka", Target: dir}, false},
+	}
+	for _, test := range tests {
+		sink, err := NewSink(&test.cfg)
+		if (err == nil) != test.ok {
+			t.Errorf("%s %s, expected ok %v, got error %v", test.cfg.Type, test.cfg.Target, test.ok, err)
+		}
+		if sink != nil {
+			sink.Close()
+		}
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send([][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+	// the file exceeds maxSize, so it is rotated before the next write
+	if err := sink.Send([][]byte{[]byte(`{"c":3}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reopened sink appends to the current file
+	sink, err = NewFileSink(dir, defaultFileSinkMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send([][]byte{[]byte(`{"d":4}`)}); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected the current file and a rotated one, got %d files", len(files))
+	}
+	var rotated, current []byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if file.Name() == fileSinkName {
+			current = data
+		} else if strings.HasPrefix(file.Name(), "events-") {
+			rotated = data
+		}
+	}
+	if string(rotated) != "{\"a\":1}\n{\"b\":2}\n" || string(current) != "{\"c\":3}\n{\"d\":4}\n" {
+		t.Fatalf("unexpected files, rotated %q, current %q", rotated, current)
+	}
+}
+
+func TestFileSinkShortWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink, err := NewFileSink(dir, defaultFileSinkMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	// a short write left the first line and a part of the second one in the file
+	written := []byte("{\"a\":1}\n{\"b\"")
+	if _, err := sink.file.Write(written); err != nil {
+		t.Fatal(err)
+	}
+	sink.written = written
+
+	// the batch is sent again, only the remainder is written
+	if err := sink.Send([][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send([][]byte{[]byte(`{"c":3}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, fileSinkName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n" {
+		t.Fatalf("unexpected file %q", data)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var bodies [][]json.RawMessage
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+		var body []json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("body should be a json array: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL)
+	if err := sink.Send([][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 || len(bodies[0]) != 2 || string(bodies[0][1]) != `{"b":2}` {
+		t.Fatalf("unexpected bodies %s", bodies)
+	}
+
+	status = http.StatusInternalServerError
+	if err := sink.Send([][]byte{[]byte(`{"c":3}`)}); err == nil {
+		t.Fatal("non 2xx status should fail")
+	}
+
+	server.Close()
+	if err := sink.Send([][]byte{[]byte(`{"c":3}`)}); err == nil {
+		t.Fatal("unreachable webhook should fail")
+	}
+}
+
+func TestUnixSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.sock")
+
+	sink := NewUnixSink(path)
+	defer sink.Close()
+	if err := sink.Send([][]byte{[]byte(`{"a":1}`)}); err == nil {
+		t.Fatal("send should fail without a listener")
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+			conn.Close()
+		}
+	}()
+
+	// the connection is established on the next send
+	if err := sink.Send([][]byte{[]byte(`{"a":1}`), []byte(`{"b":2}`)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`{"a":1}`, `{"b":2}`} {
+		select {
+		case line := <-lines:
+			if line != expected {
+				t.Fatalf("expected %s, got %s", expected, line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message is not received")
+		}
+	}
+}
+
+func TestSinkRunnerStartStop(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := &testChain{latestEventId: 5}
+	runner, db := newTestSinkRunner(t, chain)
+	defer db.Close()
+	runner.cfg = &config.Sink{Type: SinkTypeFile, Target: dir}
+
+	if err := runner.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if runner.Status() != RUNNING {
+		t.Fatal("runner should be running")
+	}
+	hasSend := func() uint64 {
+		runner.hasSendLock.RLock()
+		defer runner.hasSendLock.RUnlock()
+		return runner.hasSend
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for hasSend() < 5 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	runner.Stop()
+	if runner.Status() != STOPPED || runner.sink != nil {
+		t.Fatal("runner should be stopped and the sink closed")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, fileSinkName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msgList [][]byte
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		msgList = append(msgList, []byte(line))
+	}
+	checkEventIds(t, msgList, 5)
+}
@@ -0,0 +1,54 @@
+package sender
+
+import (
+	"net"
+	"time"
+)
+
+const unixSinkWriteTimeout = 10 * time.Second
+
+// UnixSink streams messages line by line to a unix domain socket, the connection is
+// established lazily and rebuilt on the next Send after a failure
+type UnixSink struct {
+	path string
+	conn net.Conn
+}
+
+func NewUnixSink(path string) *UnixSink {
+	return &UnixSink{
+		path: path,
+	}
+}
+
+func (sink *UnixSink) Send(msgList [][]byte) error {
+	if sink.conn == nil {
+		conn, err := net.Dial("unix", sink.path)
+		if err != nil {
+			return err
+		}
+		sink.conn = conn
+	}
+
+	var buf []byte
+	for _, msg := range msgList {
+		buf = append(buf, msg...)
+		buf = append(buf, '\n')
+	}
+
+	sink.conn.SetWriteDeadline(time.Now().Add(unixSinkWriteTimeout))
+	if _, err := sink.conn.Write(buf); err != nil {
+		sink.conn.Close()
+		sink.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (sink *UnixSink) Close() error {
+	if sink.conn == nil {
+		return nil
+	}
+	err := sink.conn.Close()
+	sink.conn = nil
+	return err
+}
@@ -0,0 +1,46 @@
+package sender
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const webhookSinkTimeout = 10 * time.Second
+
+// WebhookSink posts messages as a json array to url, any status other than 2xx is treated as failure
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: webhookSinkTimeout},
+	}
+}
+
+func (sink *WebhookSink) Send(msgList [][]byte) error {
+	body := append([]byte{'['}, bytes.Join(msgList, []byte{','})...)
+	body = append(body, ']')
+
+	resp, err := sink.client.Post(sink.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responds with status %s", resp.Status)
+	}
+	return nil
+}
+
+func (sink *WebhookSink) Close() error {
+	return nil
+}
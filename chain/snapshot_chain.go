@@ -43,6 +43,17 @@ func (c *chain) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) error {
 
 	batch := new(leveldb.Batch)
 
+	// Trie nodes must not be swept between being saved and being committed
+	saveLocked := c.trieGc != nil
+	if saveLocked {
+		c.trieGc.lockSave()
+		defer func() {
+			if saveLocked {
+				c.trieGc.unlockSave()
+			}
+		}()
+	}
+
 	// Check and create account
 	address := types.PubkeyToAddress(snapshotBlock.PublicKey)
 	account, getErr := c.chainDb.Account.GetAccountByAddress(&address)
@@ -100,6 +111,14 @@ func (c *chain) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) error {
 		return err
 	}
 
+	// Save tx history index
+	if c.cfg.TxHistoryIndex {
+		if err := c.writeConfirmedTxHistory(batch, snapshotBlock); err != nil {
+			c.log.Error("writeConfirmedTxHistory failed, error is "+err.Error(), "method", "InsertSnapshotBlock")
+			return err
+		}
+	}
+
 	// Save snapshot hash index
 	c.chainDb.Sc.WriteSnapshotHash(batch, &snapshotBlock.Hash, snapshotBlock.Height)
 
@@ -126,6 +145,13 @@ func (c *chain) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) error {
 		return err
 	}
 
+	if saveLocked {
+		c.trieGc.addRoots([]*types.Hash{snapshotBlock.StateTrie.Hash()}, true)
+
+		c.trieGc.unlockSave()
+		saveLocked = false
+	}
+
 	// After write db
 	trieSaveCallback()
 
@@ -419,6 +445,12 @@ func (c *chain) getNeedSnapshotMapByDeleteSubLedger(deleteSubLedger map[types.Ad
 
 // Contains to height
 func (c *chain) DeleteSnapshotBlocksToHeight(toHeight uint64) ([]*ledger.SnapshotBlock, map[types.Address][]*ledger.AccountBlock, error) {
+	return c.deleteSnapshotBlocksToHeight(toHeight, false)
+}
+
+// deleteSnapshotBlocksToHeight allows the account blocks in the deleted snapshot content to be missing if repair is true,
+// the broken blocks may have been deleted by RepairDb
+func (c *chain) deleteSnapshotBlocksToHeight(toHeight uint64, repair bool) ([]*ledger.SnapshotBlock, map[types.Address][]*ledger.AccountBlock, error) {
 	if toHeight <= 0 || toHeight > c.GetLatestSnapshotBlock().Height {
 		return nil, nil, nil
 	}
@@ -448,6 +480,18 @@ func (c *chain) DeleteSnapshotBlocksToHeight(toHeight uint64) ([]*ledger.Snapsho
 		min := changeRangeItem[0].Height
 		max := changeRangeItem[1].Height
 
+		account, err := c.GetAccount(&addr)
+		if err != nil {
+			c.log.Error("GetAccount failed, error is "+err.Error(), "method", "DeleteSnapshotBlocksToHeight")
+			return nil, nil, err
+		}
+
+		// Delete tx history index of the send blocks confirmed by the deleted snapshot blocks, the deleted blocks included
+		if err := c.chainDb.Ac.DeleteConfirmedTxHistory(batch, account.AccountId, max, toHeight); err != nil {
+			c.log.Error("DeleteConfirmedTxHistory failed, error is "+err.Error(), "method", "DeleteSnapshotBlocksToHeight")
+			return nil, nil, err
+		}
+
 		if blockHeightItem, ok := blockHeightMap[addr]; ok {
 			if min > blockHeightItem {
 				continue
@@ -455,18 +499,20 @@ func (c *chain) DeleteSnapshotBlocksToHeight(toHeight uint64) ([]*ledger.Snapsho
 			max = blockHeightItem
 		}
 
-		account, err := c.GetAccount(&addr)
-		if err != nil {
-			c.log.Error("GetAccount failed, error is "+err.Error(), "method", "DeleteSnapshotBlocksToHeight")
-			return nil, nil, err
-		}
-
 		for i := min; i <= max; i++ {
 			blockHash, blockHashErr := c.chainDb.Ac.GetHashByHeight(account.AccountId, i)
 			if blockHashErr != nil {
 				c.log.Error("GetHashByHeight failed, error is "+blockHashErr.Error(), "method", "DeleteSnapshotBlocksToHeight")
 				return nil, nil, blockHashErr
 			}
+			if blockHash == nil {
+				if repair {
+					continue
+				}
+				err := errors.New("account block is missing")
+				c.log.Error("GetHashByHeight failed, error is "+err.Error(), "method", "DeleteSnapshotBlocksToHeight")
+				return nil, nil, err
+			}
 
 			// Get be snapshot
 			beSnapshot, getBeSnapshotErr := c.chainDb.Ac.GetBeSnapshot(blockHash)
@@ -498,6 +544,14 @@ func (c *chain) DeleteSnapshotBlocksToHeight(toHeight uint64) ([]*ledger.Snapsho
 					c.log.Error("GetBlockByHeight failed, error is "+blockErr.Error(), "method", "DeleteSnapshotBlocksToHeight")
 					return nil, nil, err
 				}
+				if lastBlock == nil {
+					if repair {
+						continue
+					}
+					err := errors.New("account block is missing")
+					c.log.Error("GetBlockByHeight failed, error is "+err.Error(), "method", "DeleteSnapshotBlocksToHeight")
+					return nil, nil, err
+				}
 
 				c.completeBlock(lastBlock, account)
 				needAddBlocks[account.AccountAddress] = lastBlock
@@ -0,0 +1,463 @@
+package chain
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/trie"
+)
+
+const (
+	// Account blocks may refer to any snapshot block in the last trieGcReferHeights heights and read states at it,
+	// it is verifier.TimeOutHeight, which can`t be imported by the chain
+	trieGcReferHeights = 24 * 30 * 3600
+	// Snapshot blocks rolled back by forks and RepairDb are deleted to a height at most this far below the latest,
+	// the blocks referring to the snapshot blocks before it are verified again then
+	trieGcRollbackHeights = 24 * 3600
+
+	// A window shorter than this would make valid blocks unverifiable, or unverifiable after a rollback
+	MinTrieGcRetainHeights = trieGcReferHeights + trieGcRollbackHeights
+
+	trieGcMarkBatch  = 100
+	trieGcSweepChunk = 1000
+	trieGcSweepPause = 10 * time.Millisecond
+)
+
+const (
+	TrieGcStageIdle  = "idle"
+	TrieGcStageMark  = "mark"
+	TrieGcStageSweep = "sweep"
+)
+
+var errTrieGcStopped = errors.New("trie gc is stopped")
+
+// TrieGcStatus is the progress of state pruning, counters without the Total prefix belong to
+// the current round, or the last round if Stage is idle
+type TrieGcStatus struct {
+	RetainHeights uint64
+	Stage         string
+	Rounds        uint64
+
+	RetainFromHeight uint64
+	RetainToHeight   uint64
+
+	MarkedNodes      uint64
+	MarkedRefValues  uint64
+	ScannedNodes     uint64
+	DeletedNodes     uint64
+	DeletedRefValues uint64
+
+	TotalDeletedNodes     uint64
+	TotalDeletedRefValues uint64
+
+	LastRoundTime *time.Time
+	LastError     string
+}
+
+type trieGcRoot struct {
+	hash       types.Hash
+	isSnapshot bool
+}
+
+// trieGc keeps the account-state tries of the last retainHeights snapshot heights and the genesis,
+// and deletes trie nodes which are not reachable from them by mark-and-sweep in the background.
+type trieGc struct {
+	chain *chain
+	log   log15.Logger
+
+	retainHeights   uint64
+	tickerDuration  time.Duration
+	lastRoundHeight uint64
+
+	// Inserting blocks checks which trie nodes exist and commits the new ones under the read lock,
+	// the sweeper deletes a chunk of nodes under the write lock.
+	saveLock sync.RWMutex
+
+	// roots committed while a round is running, they are marked before every chunk is deleted
+	pendingLock  sync.Mutex
+	collecting   bool
+	pendingRoots []trieGcRoot
+
+	status     TrieGcStatus
+	statusLock sync.RWMutex
+
+	term chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newTrieGc(chain *chain, retainHeights uint64) *trieGc {
+	if retainHeights < MinTrieGcRetainHeights {
+		retainHeights = MinTrieGcRetainHeights
+	}
+
+	return &trieGc{
+		chain:          chain,
+		log:            log15.New("module", "trieGc"),
+		retainHeights:  retainHeights,
+		tickerDuration: time.Minute,
+		status: TrieGcStatus{
+			RetainHeights: retainHeights,
+			Stage:         TrieGcStageIdle,
+		},
+	}
+}
+
+func (gc *trieGc) Start() {
+	gc.term = make(chan struct{})
+	gc.wg.Add(1)
+	common.Go(func() {
+		defer gc.wg.Done()
+
+		ticker := time.NewTicker(gc.tickerDuration)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-gc.term:
+				return
+			case <-ticker.C:
+				latestHeight := gc.chain.GetLatestSnapshotBlock().Height
+				if latestHeight < gc.lastRoundHeight+gc.retainHeights/2 {
+					continue
+				}
+
+				if err := gc.RunRound(); err != nil && err != errTrieGcStopped {
+					gc.log.Error("RunRound failed, error is "+err.Error(), "method", "Start")
+				}
+			}
+		}
+	})
+}
+
+func (gc *trieGc) Stop() {
+	close(gc.term)
+	gc.wg.Wait()
+}
+
+func (gc *trieGc) stopped() bool {
+	select {
+	case <-gc.term:
+		return true
+	default:
+		return false
+	}
+}
+
+func (gc *trieGc) Status() *TrieGcStatus {
+	gc.statusLock.RLock()
+	defer gc.statusLock.RUnlock()
+
+	status := gc.status
+	return &status
+}
+
+func (gc *trieGc) updateStatus(update func(status *TrieGcStatus)) {
+	gc.statusLock.Lock()
+	defer gc.statusLock.Unlock()
+
+	update(&gc.status)
+}
+
+func (gc *trieGc) lockSave() {
+	gc.saveLock.RLock()
+}
+
+func (gc *trieGc) unlockSave() {
+	gc.saveLock.RUnlock()
+}
+
+// addRoots is called after the tries of roots are committed
+func (gc *trieGc) addRoots(roots []*types.Hash, isSnapshot bool) {
+	gc.pendingLock.Lock()
+	defer gc.pendingLock.Unlock()
+
+	if !gc.collecting {
+		return
+	}
+	for _, root := range roots {
+		if root != nil {
+			gc.pendingRoots = append(gc.pendingRoots, trieGcRoot{hash: *root, isSnapshot: isSnapshot})
+		}
+	}
+}
+
+func (gc *trieGc) setCollecting(collecting bool) {
+	gc.pendingLock.Lock()
+	defer gc.pendingLock.Unlock()
+
+	gc.collecting = collecting
+	gc.pendingRoots = nil
+}
+
+func (gc *trieGc) takePendingRoots() []trieGcRoot {
+	gc.pendingLock.Lock()
+	defer gc.pendingLock.Unlock()
+
+	roots := gc.pendingRoots
+	gc.pendingRoots = nil
+	return roots
+}
+
+// markStateTrie marks a snapshot state trie and the account storage tries whose roots are its values
+func (gc *trieGc) markStateTrie(set *trie.MarkSet, stateHash *types.Hash) error {
+	var accountRoots []types.Hash
+	if err := set.Mark(stateHash, func(value []byte) {
+		if root, err := types.BytesToHash(value); err == nil {
+			accountRoots = append(accountRoots, root)
+		}
+	}); err != nil {
+		return err
+	}
+
+	for _, root := range accountRoots {
+		if err := set.Mark(&root, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (gc *trieGc) markPendingRoots(set *trie.MarkSet) error {
+	for _, root := range gc.takePendingRoots() {
+		var err error
+		if root.isSnapshot {
+			err = gc.markStateTrie(set, &root.hash)
+		} else {
+			err = set.Mark(&root.hash, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunRound marks the tries which are retained and deletes the others, it returns when the round finishes
+func (gc *trieGc) RunRound() (returnErr error) {
+	latestHeight := gc.chain.GetLatestSnapshotBlock().Height
+	if latestHeight <= gc.retainHeights+2 {
+		return nil
+	}
+	fromHeight := latestHeight - gc.retainHeights + 1
+
+	gc.setCollecting(true)
+	defer gc.setCollecting(false)
+
+	gc.updateStatus(func(status *TrieGcStatus) {
+		status.Stage = TrieGcStageMark
+		status.RetainFromHeight = fromHeight
+		status.RetainToHeight = latestHeight
+		status.MarkedNodes = 0
+		status.MarkedRefValues = 0
+		status.ScannedNodes = 0
+		status.DeletedNodes = 0
+		status.DeletedRefValues = 0
+		status.LastError = ""
+	})
+	defer func() {
+		now := time.Now()
+		gc.updateStatus(func(status *TrieGcStatus) {
+			status.Stage = TrieGcStageIdle
+			status.LastRoundTime = &now
+			if returnErr != nil {
+				status.LastError = returnErr.Error()
+			} else {
+				status.Rounds++
+			}
+		})
+	}()
+
+	set, err := gc.mark(fromHeight, latestHeight)
+	if err != nil {
+		return err
+	}
+
+	gc.updateStatus(func(status *TrieGcStatus) {
+		status.Stage = TrieGcStageSweep
+		status.MarkedNodes = uint64(set.NodeCount())
+		status.MarkedRefValues = uint64(set.RefValueCount())
+	})
+
+	if err := gc.sweep(set, database.DBKP_TRIE_NODE); err != nil {
+		return err
+	}
+	if err := gc.sweep(set, database.DBKP_TRIE_REF_VALUE); err != nil {
+		return err
+	}
+
+	gc.lastRoundHeight = latestHeight
+
+	status := gc.Status()
+	gc.log.Info("Trie gc round finished", "fromHeight", fromHeight, "toHeight", latestHeight,
+		"deletedNodes", status.DeletedNodes, "deletedRefValues", status.DeletedRefValues)
+	return nil
+}
+
+func (gc *trieGc) mark(fromHeight, toHeight uint64) (*trie.MarkSet, error) {
+	set := trie.NewMarkSet(gc.chain.chainDb.Db())
+
+	// genesis
+	for _, blocks := range GenesisAccountBlocks() {
+		for _, block := range blocks {
+			if err := set.Mark(&block.AccountBlock.StateHash, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	genesisBlocks, err := gc.chain.GetSnapshotBlocksByHeight(1, 2, true, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range genesisBlocks {
+		if err := gc.markStateTrie(set, &block.StateHash); err != nil {
+			return nil, err
+		}
+	}
+
+	// retained snapshot heights and the account blocks confirmed by them
+	for height := fromHeight; height <= toHeight; height += trieGcMarkBatch {
+		if gc.stopped() {
+			return nil, errTrieGcStopped
+		}
+
+		endHeight := height + trieGcMarkBatch - 1
+		if endHeight > toHeight {
+			endHeight = toHeight
+		}
+		snapshotBlocks, subLedger, err := gc.chain.GetConfirmSubLedger(height, endHeight)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, block := range snapshotBlocks {
+			if err := gc.markStateTrie(set, &block.StateHash); err != nil {
+				return nil, err
+			}
+		}
+		for _, blocks := range subLedger {
+			for _, block := range blocks {
+				if err := set.Mark(&block.StateHash, nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		gc.updateStatus(func(status *TrieGcStatus) {
+			status.MarkedNodes = uint64(set.NodeCount())
+		})
+	}
+
+	// unconfirmed account blocks
+	for addr := range gc.chain.GetNeedSnapshotContent() {
+		for _, block := range gc.chain.GetUnConfirmAccountBlocks(&addr) {
+			if err := set.Mark(&block.StateHash, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return set, nil
+}
+
+func (gc *trieGc) sweep(set *trie.MarkSet, prefix byte) error {
+	db := gc.chain.chainDb.Db()
+	prefixKey, _ := database.EncodeKey(prefix)
+
+	iter := db.NewIterator(util.BytesPrefix(prefixKey), nil)
+	defer iter.Release()
+
+	for exhausted := false; !exhausted; {
+		if gc.stopped() {
+			return errTrieGcStopped
+		}
+
+		var candidates []types.Hash
+		scanned := uint64(0)
+		for len(candidates) < trieGcSweepChunk {
+			if !iter.Next() {
+				exhausted = true
+				break
+			}
+			scanned++
+
+			hash, err := types.BytesToHash(iter.Key()[1:])
+			if err != nil {
+				continue
+			}
+			if !gc.isMarked(set, prefix, &hash) {
+				candidates = append(candidates, hash)
+			}
+		}
+		if err := iter.Error(); err != nil {
+			return err
+		}
+
+		deleted, err := gc.deleteChunk(set, prefix, candidates)
+		if err != nil {
+			return err
+		}
+
+		gc.updateStatus(func(status *TrieGcStatus) {
+			if prefix == database.DBKP_TRIE_NODE {
+				status.ScannedNodes += scanned
+				status.DeletedNodes += deleted
+				status.TotalDeletedNodes += deleted
+			} else {
+				status.DeletedRefValues += deleted
+				status.TotalDeletedRefValues += deleted
+			}
+		})
+
+		time.Sleep(trieGcSweepPause)
+	}
+	return nil
+}
+
+func (gc *trieGc) isMarked(set *trie.MarkSet, prefix byte, hash *types.Hash) bool {
+	if prefix == database.DBKP_TRIE_NODE {
+		return set.HasNode(hash)
+	}
+	return set.HasRefValue(hash)
+}
+
+// deleteChunk deletes the candidates which are still garbage after the roots committed during the round are marked
+func (gc *trieGc) deleteChunk(set *trie.MarkSet, prefix byte, candidates []types.Hash) (uint64, error) {
+	gc.saveLock.Lock()
+	defer gc.saveLock.Unlock()
+
+	if err := gc.markPendingRoots(set); err != nil {
+		return 0, err
+	}
+
+	batch := new(leveldb.Batch)
+	var deleted []types.Hash
+	for i := range candidates {
+		if gc.isMarked(set, prefix, &candidates[i]) {
+			continue
+		}
+		key, _ := database.EncodeKey(prefix, candidates[i].Bytes())
+		batch.Delete(key)
+		deleted = append(deleted, candidates[i])
+	}
+
+	if len(deleted) == 0 {
+		return 0, nil
+	}
+
+	if err := gc.chain.chainDb.Commit(batch); err != nil {
+		return 0, err
+	}
+
+	// Trie.Save skips the nodes in the pool, so the deleted ones must be evicted to be saved again
+	if prefix == database.DBKP_TRIE_NODE && gc.chain.trieNodePool != nil {
+		gc.chain.trieNodePool.Delete(deleted)
+	}
+	return uint64(len(deleted)), nil
+}
@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/trie"
+)
+
+func TestTrieGc(t *testing.T) {
+	// the minimum is too long to be tested, the window is shortened after the gc is created
+	const retainHeights = 100
+
+	c := getChainInstance().(*chain)
+	makeBlocks(c, c.GetLatestSnapshotBlock().Height+retainHeights+10)
+
+	gc := newTrieGc(c, retainHeights)
+	if gc.retainHeights != MinTrieGcRetainHeights {
+		t.Fatalf("retain heights should be at least %d, got %d", MinTrieGcRetainHeights, gc.retainHeights)
+	}
+	gc.retainHeights = retainHeights
+	gc.term = make(chan struct{})
+
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	fromHeight := latestHeight - retainHeights + 1
+
+	// nodes are skipped by MarkSet if they are missing, so a pruned state has fewer nodes
+	stateNodeCount := func(height uint64) int {
+		block, err := c.GetSnapshotBlockByHeight(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		set := trie.NewMarkSet(c.chainDb.Db())
+		if err := gc.markStateTrie(set, &block.StateHash); err != nil {
+			t.Fatal(err)
+		}
+		return set.NodeCount()
+	}
+
+	counts := make(map[uint64]int)
+	for height := fromHeight; height <= latestHeight; height++ {
+		counts[height] = stateNodeCount(height)
+	}
+	oldCount := stateNodeCount(fromHeight - 1)
+
+	if err := gc.RunRound(); err != nil {
+		t.Fatal(err)
+	}
+
+	status := gc.Status()
+	if status.Rounds != 1 || status.Stage != TrieGcStageIdle {
+		t.Fatalf("round is not finished, status is %+v", status)
+	}
+	if status.RetainFromHeight != fromHeight || status.RetainToHeight != latestHeight {
+		t.Fatalf("retain window is wrong, status is %+v", status)
+	}
+	if status.DeletedNodes == 0 {
+		t.Fatalf("no node is deleted, status is %+v", status)
+	}
+
+	for height, count := range counts {
+		if stateNodeCount(height) != count {
+			t.Fatalf("state of snapshot block %d is pruned", height)
+		}
+	}
+	if stateNodeCount(fromHeight-1) >= oldCount {
+		t.Fatalf("state of snapshot block %d is not pruned", fromHeight-1)
+	}
+
+	// blocks can still be inserted after pruning
+	makeBlocks(c, latestHeight+2)
+	if c.GetLatestSnapshotBlock().Height != latestHeight+2 {
+		t.Fatal("insert blocks failed after pruning")
+	}
+}
@@ -0,0 +1,22 @@
+package chain
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/trie"
+)
+
+// GetTrieNodeData return the serialized trie node of hash, nil if it doesn't exist
+func (c *chain) GetTrieNodeData(hash *types.Hash) ([]byte, error) {
+	return trie.NodeData(c.chainDb.Db(), hash)
+}
+
+// GetTrieRefValue return the ref value of the hash node, nil if it doesn't exist
+func (c *chain) GetTrieRefValue(hash *types.Hash) ([]byte, error) {
+	return trie.RefValue(c.chainDb.Db(), hash)
+}
+
+// NewTrieSync downloads the trie of root into the chain db, trie nodes are written
+// out of the trie gc, so it must be used on a chain without trie gc, eg. by the light client
+func (c *chain) NewTrieSync(root types.Hash, onLeaf trie.LeafCallback) *trie.Sync {
+	return trie.NewSync(c.chainDb.Db(), root, onLeaf)
+}
@@ -0,0 +1,354 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	ErrTxHistoryIndexDisabled = errors.New("tx history index is disabled")
+	ErrBeforeTxHistoryStart   = errors.New("transfers before the tx history index is enabled are not indexed")
+)
+
+// blocks of the account chain read at a time when looking up the sent transfers
+const txHistoryScanCount = 100
+
+type TransferFilter struct {
+	Addr types.Address
+	// Transfers of any token are matched if nil
+	TokenId *types.TokenTypeId
+	// Range of the snapshot height which confirms the send block
+	FromSnapshotHeight uint64
+	ToSnapshotHeight   uint64
+	// Transfers after it are matched if not nil, Height is the snapshot height and Hash is the send block hash
+	// of the last transfer in the previous page
+	After *ledger.HashHeight
+	// At most Count transfers are returned, 0 is unlimited
+	Count int
+}
+
+// Transfer is a send block sent from or to the address
+type Transfer struct {
+	SendBlock *ledger.AccountBlock
+	// nil if the send block isn't received
+	ReceiveBlockHash *types.Hash
+	SnapshotHeight   uint64
+}
+
+// GetTxHistoryStart returns the lowest snapshot height from which the transfers can be looked up,
+// it is the height the tx history index is enabled at
+func (c *chain) GetTxHistoryStart() (uint64, error) {
+	if !c.cfg.TxHistoryIndex {
+		return 0, ErrTxHistoryIndexDisabled
+	}
+	return c.chainDb.Ac.GetTxHistoryStart()
+}
+
+// GetTransfers returns the confirmed transfers sent from or to filter.Addr, ordered by snapshot height and send block hash.
+// The transfers to the address are looked up by the tx history index, so it must be enabled,
+// and FromSnapshotHeight must not be lower than the height it is enabled at.
+func (c *chain) GetTransfers(filter *TransferFilter) ([]*Transfer, error) {
+	if !c.cfg.TxHistoryIndex {
+		return nil, ErrTxHistoryIndexDisabled
+	}
+	if filter.FromSnapshotHeight > filter.ToSnapshotHeight {
+		return nil, errors.New("fromSnapshotHeight is greater than toSnapshotHeight")
+	}
+	start, err := c.chainDb.Ac.GetTxHistoryStart()
+	if err != nil {
+		c.log.Error("GetTxHistoryStart failed, error is "+err.Error(), "method", "GetTransfers")
+		return nil, err
+	}
+	if filter.FromSnapshotHeight < start {
+		return nil, ErrBeforeTxHistoryStart
+	}
+
+	page := &transferPage{filter: filter}
+	if err := c.getReceivedTransfers(page); err != nil {
+		c.log.Error("getReceivedTransfers failed, error is "+err.Error(), "method", "GetTransfers")
+		return nil, err
+	}
+	if err := c.getSentTransfers(page); err != nil {
+		c.log.Error("getSentTransfers failed, error is "+err.Error(), "method", "GetTransfers")
+		return nil, err
+	}
+
+	for _, transfer := range page.transfers {
+		receiveHash, err := c.chainDb.Ac.GetTxHistoryReceiveHash(&transfer.SendBlock.ToAddress, &transfer.SendBlock.Hash)
+		if err != nil {
+			c.log.Error("GetTxHistoryReceiveHash failed, error is "+err.Error(), "method", "GetTransfers")
+			return nil, err
+		}
+		transfer.ReceiveBlockHash = receiveHash
+	}
+	return page.transfers, nil
+}
+
+// matchHeight checks the snapshot height confirms the send block, 0 means it isn't confirmed
+func (filter *TransferFilter) matchHeight(confirmHeight uint64) bool {
+	return confirmHeight > 0 && confirmHeight >= filter.FromSnapshotHeight && confirmHeight <= filter.ToSnapshotHeight
+}
+
+// matchAfter checks the transfer is after filter.After
+func (filter *TransferFilter) matchAfter(confirmHeight uint64, sendHash types.Hash) bool {
+	return filter.After == nil || transferLess(filter.After.Height, filter.After.Hash, confirmHeight, sendHash)
+}
+
+func (filter *TransferFilter) matchToken(block *ledger.AccountBlock) bool {
+	return filter.TokenId == nil || block.TokenId == *filter.TokenId
+}
+
+func transferLess(heightA uint64, hashA types.Hash, heightB uint64, hashB types.Hash) bool {
+	if heightA != heightB {
+		return heightA < heightB
+	}
+	return bytes.Compare(hashA.Bytes(), hashB.Bytes()) < 0
+}
+
+// transferPage keeps the first filter.Count matched transfers in order
+type transferPage struct {
+	filter    *TransferFilter
+	transfers []*Transfer
+}
+
+func (page *transferPage) full() bool {
+	return page.filter.Count > 0 && len(page.transfers) >= page.filter.Count
+}
+
+// maxHeight is the highest snapshot height of the transfers which may be added to the page
+func (page *transferPage) maxHeight() uint64 {
+	if page.full() {
+		return page.transfers[len(page.transfers)-1].SnapshotHeight
+	}
+	return page.filter.ToSnapshotHeight
+}
+
+func (page *transferPage) match(confirmHeight uint64, sendHash types.Hash) bool {
+	if !page.filter.matchHeight(confirmHeight) || !page.filter.matchAfter(confirmHeight, sendHash) {
+		return false
+	}
+	if page.full() {
+		last := page.transfers[len(page.transfers)-1]
+		return transferLess(confirmHeight, sendHash, last.SnapshotHeight, last.SendBlock.Hash)
+	}
+	return true
+}
+
+// add inserts the transfer in order, the transfer sent to self is added once
+func (page *transferPage) add(transfer *Transfer) {
+	i := sort.Search(len(page.transfers), func(i int) bool {
+		return !transferLess(page.transfers[i].SnapshotHeight, page.transfers[i].SendBlock.Hash, transfer.SnapshotHeight, transfer.SendBlock.Hash)
+	})
+	if i < len(page.transfers) && page.transfers[i].SendBlock.Hash == transfer.SendBlock.Hash {
+		return
+	}
+
+	page.transfers = append(page.transfers, nil)
+	copy(page.transfers[i+1:], page.transfers[i:])
+	page.transfers[i] = transfer
+	if page.filter.Count > 0 && len(page.transfers) > page.filter.Count {
+		page.transfers = page.transfers[:page.filter.Count]
+	}
+}
+
+// getReceivedTransfers looks up the send blocks to the address by the index, which is keyed by the snapshot height
+// confirming them, from the first height of the page until the send blocks are above it
+func (c *chain) getReceivedTransfers(page *transferPage) error {
+	fromSnapshotHeight := page.filter.FromSnapshotHeight
+	if page.filter.After != nil && page.filter.After.Height > fromSnapshotHeight {
+		fromSnapshotHeight = page.filter.After.Height
+	}
+
+	return c.chainDb.Ac.IterateTxHistory(&page.filter.Addr, fromSnapshotHeight, func(confirmHeight uint64, sendHash types.Hash) (bool, error) {
+		if confirmHeight > page.maxHeight() {
+			return false, nil
+		}
+		if !page.match(confirmHeight, sendHash) {
+			return true, nil
+		}
+
+		block, err := c.GetAccountBlockByHash(&sendHash)
+		if err != nil {
+			return false, err
+		}
+		if block != nil && page.filter.matchToken(block) {
+			page.add(&Transfer{
+				SendBlock:      block,
+				SnapshotHeight: confirmHeight,
+			})
+		}
+		return true, nil
+	})
+}
+
+// getSentTransfers reads the account chain from the first block confirmed in the range, until the blocks are above the page
+func (c *chain) getSentTransfers(page *transferPage) error {
+	filter := page.filter
+	account, err := c.chainDb.Account.GetAccountByAddress(&filter.Addr)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return nil
+	}
+	latestBlock, err := c.chainDb.Ac.GetLatestBlock(account.AccountId)
+	if err != nil {
+		return err
+	}
+	if latestBlock == nil {
+		return nil
+	}
+
+	fromSnapshotHeight := filter.FromSnapshotHeight
+	if filter.After != nil && filter.After.Height > fromSnapshotHeight {
+		fromSnapshotHeight = filter.After.Height
+	}
+	startHeight, err := c.searchConfirmedAfter(account.AccountId, latestBlock.Height, fromSnapshotHeight)
+	if err != nil {
+		return err
+	}
+
+	for ; startHeight <= latestBlock.Height; startHeight += txHistoryScanCount {
+		endHeight := startHeight + txHistoryScanCount - 1
+		if endHeight > latestBlock.Height {
+			endHeight = latestBlock.Height
+		}
+
+		blockList, err := c.chainDb.Ac.GetBlockListByAccountId(account.AccountId, startHeight, endHeight, true)
+		if err != nil {
+			return err
+		}
+		for _, block := range blockList {
+			confirmHeight, err := c.chainDb.Ac.GetConfirmHeight(&block.Hash)
+			if err != nil {
+				return err
+			}
+			// the blocks are confirmed in order of height
+			if confirmHeight == 0 || confirmHeight > page.maxHeight() {
+				return nil
+			}
+
+			if !block.IsSendBlock() || !page.match(confirmHeight, block.Hash) || !filter.matchToken(block) {
+				continue
+			}
+
+			c.completeBlock(block, account)
+			page.add(&Transfer{
+				SendBlock:      block,
+				SnapshotHeight: confirmHeight,
+			})
+		}
+	}
+	return nil
+}
+
+// searchConfirmedAfter returns the lowest height of the account chain which is unconfirmed or confirmed not before snapshotHeight,
+// it is latestHeight + 1 if there is none
+func (c *chain) searchConfirmedAfter(accountId, latestHeight, snapshotHeight uint64) (uint64, error) {
+	low, high := uint64(1), latestHeight+1
+	for low < high {
+		mid := low + (high-low)/2
+		hash, err := c.chainDb.Ac.GetHashByHeight(accountId, mid)
+		if err != nil {
+			return 0, err
+		}
+		if hash == nil {
+			return 0, errors.New("account block is missing")
+		}
+		confirmHeight, err := c.chainDb.Ac.GetConfirmHeight(hash)
+		if err != nil {
+			return 0, err
+		}
+
+		if confirmHeight == 0 || confirmHeight >= snapshotHeight {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+// writeConfirmedTxHistory indexes the send blocks confirmed by the snapshot block, they are the unconfirmed blocks
+// up to the ones in the snapshot content
+func (c *chain) writeConfirmedTxHistory(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock) error {
+	for addr, hashHeight := range snapshotBlock.SnapshotContent {
+		account, err := c.chainDb.Account.GetAccountByAddress(&addr)
+		if err != nil {
+			return err
+		}
+		if account == nil {
+			return errors.New("account of the snapshot content is missing")
+		}
+
+		blocks, err := c.chainDb.Ac.GetUnConfirmAccountBlocks(account.AccountId, hashHeight.Height+1)
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			if block.IsSendBlock() {
+				c.chainDb.Ac.WriteTxHistoryIndex(batch, block, snapshotBlock.Height)
+			}
+		}
+	}
+	return nil
+}
+
+// initTxHistoryIndex records the snapshot height from which the transfers are all indexed. When the index is
+// enabled for an existing db, the unconfirmed receive blocks are indexed, and the send blocks are indexed when they
+// are confirmed, so the transfers above the latest snapshot block are all indexed.
+// The record is removed when the index is disabled, blocks inserted then are not indexed.
+func (c *chain) initTxHistoryIndex() error {
+	start, err := c.chainDb.Ac.GetTxHistoryStart()
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	if !c.cfg.TxHistoryIndex {
+		if start == 0 {
+			return nil
+		}
+		c.chainDb.Ac.DeleteTxHistoryStart(batch)
+		return c.chainDb.Commit(batch)
+	}
+	if start > 0 {
+		return nil
+	}
+
+	maxAccountId, err := c.chainDb.Account.GetLastAccountId()
+	if err != nil {
+		return err
+	}
+	for accountId := uint64(1); accountId <= maxAccountId; accountId++ {
+		blocks, err := c.chainDb.Ac.GetUnConfirmAccountBlocks(accountId, 0)
+		if err != nil {
+			return err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		addr, err := c.chainDb.Account.GetAddressById(accountId)
+		if err != nil {
+			return err
+		}
+		if addr == nil {
+			return errors.New("address of the account is missing")
+		}
+		for _, block := range blocks {
+			if block.IsReceiveBlock() {
+				block.AccountAddress = *addr
+				c.chainDb.Ac.WriteTxHistoryIndex(batch, block, 0)
+			}
+		}
+	}
+
+	c.chainDb.Ac.WriteTxHistoryStart(batch, c.latestSnapshotBlock.Height+1)
+	return c.chainDb.Commit(batch)
+}
@@ -0,0 +1,281 @@
+package chain
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_context"
+)
+
+func TestTransferFilterMatch(t *testing.T) {
+	block := &ledger.AccountBlock{TokenId: ledger.ViteTokenId}
+	otherTokenId := ledger.ViteTokenId
+	otherTokenId[0]++
+
+	tests := []struct {
+		filter        *TransferFilter
+		confirmHeight uint64
+		result        bool
+	}{
+		{&TransferFilter{FromSnapshotHeight: 1, ToSnapshotHeight: 10}, 5, true},
+		{&TransferFilter{FromSnapshotHeight: 1, ToSnapshotHeight: 10}, 0, false},
+		{&TransferFilter{FromSnapshotHeight: 5, ToSnapshotHeight: 5}, 5, true},
+		{&TransferFilter{FromSnapshotHeight: 6, ToSnapshotHeight: 10}, 5, false},
+		{&TransferFilter{FromSnapshotHeight: 1, ToSnapshotHeight: 4}, 5, false},
+		{&TransferFilter{TokenId: &ledger.ViteTokenId, FromSnapshotHeight: 1, ToSnapshotHeight: 10}, 5, true},
+		{&TransferFilter{TokenId: &otherTokenId, FromSnapshotHeight: 1, ToSnapshotHeight: 10}, 5, false},
+	}
+	for i, test := range tests {
+		if result := test.filter.matchHeight(test.confirmHeight) && test.filter.matchToken(block); result != test.result {
+			t.Fatalf("%v: match transfer error, expected %v, got %v", i, test.result, result)
+		}
+	}
+}
+
+func newTxHistoryChain(t *testing.T, dir string, index bool) *chain {
+	c := NewChain(&config.Config{
+		DataDir: dir,
+		Chain:   &config.Chain{TxHistoryIndex: index},
+	}).(*chain)
+	c.Init()
+	c.Start()
+	return c
+}
+
+// insertTestBlock fills the height, the prev hash and the hash of the block and inserts it
+func insertTestBlock(t *testing.T, c *chain, block *ledger.AccountBlock) *ledger.AccountBlock {
+	vmContext, err := vm_context.NewVmContext(c, nil, nil, &block.AccountAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latestBlock, err := c.GetLatestAccountBlock(&block.AccountAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Height = 1
+	if latestBlock != nil {
+		block.Height = latestBlock.Height + 1
+		block.PrevHash = latestBlock.Hash
+	}
+	now := time.Now()
+	block.Timestamp = &now
+	block.SnapshotHash = c.GetLatestSnapshotBlock().Hash
+	block.Amount = big.NewInt(1)
+	block.Fee = big.NewInt(0)
+	if block.TokenId == (types.TokenTypeId{}) {
+		block.TokenId = ledger.ViteTokenId
+	}
+	if stateHash := vmContext.GetStorageHash(); stateHash != nil {
+		block.StateHash = *stateHash
+	}
+	block.Hash = block.ComputeHash()
+
+	if err := c.InsertAccountBlocks([]*vm_context.VmAccountBlock{{AccountBlock: block, VmContext: vmContext}}); err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+func insertTestSnapshotBlock(t *testing.T, c *chain) *ledger.SnapshotBlock {
+	latestBlock := c.GetLatestSnapshotBlock()
+	now := time.Now()
+	block := &ledger.SnapshotBlock{
+		Height:          latestBlock.Height + 1,
+		PrevHash:        latestBlock.Hash,
+		Timestamp:       &now,
+		SnapshotContent: c.GetNeedSnapshotContent(),
+	}
+	trie, err := c.GenStateTrie(latestBlock.StateHash, block.SnapshotContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.StateTrie = trie
+	block.StateHash = *trie.Hash()
+	block.Hash = block.ComputeHash()
+
+	if err := c.InsertSnapshotBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+func transferHashes(transfers []*Transfer) []types.Hash {
+	hashes := make([]types.Hash, len(transfers))
+	for i, transfer := range transfers {
+		hashes[i] = transfer.SendBlock.Hash
+	}
+	return hashes
+}
+
+// sortTransfers sorts the send blocks in the order they are returned
+func sortTransfers(snapshotHeight uint64, blocks ...*ledger.AccountBlock) []types.Hash {
+	sort.Slice(blocks, func(i, j int) bool {
+		return transferLess(snapshotHeight, blocks[i].Hash, snapshotHeight, blocks[j].Hash)
+	})
+	hashes := make([]types.Hash, len(blocks))
+	for i, block := range blocks {
+		hashes[i] = block.Hash
+	}
+	return hashes
+}
+
+func TestGetTransfers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tx_history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newTxHistoryChain(t, dir, true)
+	defer c.Destroy()
+	defer c.Stop()
+
+	addr := ledger.GenesisAccountAddress
+	other, _ := types.HexToAddress("vite_39f1ede9ab4979b8a77167bfade02a3b4df0c413ad048cb999")
+
+	sent := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: addr, ToAddress: other})
+	received := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeReceive, AccountAddress: other, FromBlockHash: sent.Hash})
+	receivedFrom := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: addr})
+	self := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: addr, ToAddress: addr})
+
+	// above the genesis transfers
+	from := c.GetLatestSnapshotBlock().Height + 1
+	filter := &TransferFilter{Addr: addr, FromSnapshotHeight: from, ToSnapshotHeight: from + 10}
+	if transfers, err := c.GetTransfers(filter); err != nil || len(transfers) != 0 {
+		t.Fatalf("unconfirmed transfers should not be returned, got %v, error %v", transfers, err)
+	}
+
+	snapshotBlock := insertTestSnapshotBlock(t, c)
+	later := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: addr, ToAddress: other})
+	laterSnapshotBlock := insertTestSnapshotBlock(t, c)
+
+	// the transfer sent to self is returned once
+	expected := append(sortTransfers(snapshotBlock.Height, sent, receivedFrom, self), later.Hash)
+	transfers, err := c.GetTransfers(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashes := transferHashes(transfers); !reflect.DeepEqual(hashes, expected) {
+		t.Fatalf("expected transfers %v, got %v", expected, hashes)
+	}
+	for _, transfer := range transfers {
+		height := snapshotBlock.Height
+		if transfer.SendBlock.Hash == later.Hash {
+			height = laterSnapshotBlock.Height
+		}
+		if transfer.SnapshotHeight != height {
+			t.Fatalf("transfer %s should be confirmed at %d, got %d", transfer.SendBlock.Hash, height, transfer.SnapshotHeight)
+		}
+		if transfer.SendBlock.Hash == sent.Hash {
+			if transfer.ReceiveBlockHash == nil || *transfer.ReceiveBlockHash != received.Hash {
+				t.Fatalf("receive block of the sent transfer should be %s, got %v", received.Hash, transfer.ReceiveBlockHash)
+			}
+		} else if transfer.ReceiveBlockHash != nil {
+			t.Fatalf("transfer %s isn't received, got %s", transfer.SendBlock.Hash, transfer.ReceiveBlockHash)
+		}
+	}
+
+	// pages
+	var paged []*Transfer
+	filter.Count = 2
+	for {
+		page, err := c.GetTransfers(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > filter.Count {
+			t.Fatalf("at most %d transfers should be returned, got %d", filter.Count, len(page))
+		}
+		paged = append(paged, page...)
+		if len(page) < filter.Count {
+			break
+		}
+		last := page[len(page)-1]
+		filter.After = &ledger.HashHeight{Height: last.SnapshotHeight, Hash: last.SendBlock.Hash}
+	}
+	if hashes := transferHashes(paged); !reflect.DeepEqual(hashes, expected) {
+		t.Fatalf("expected paged transfers %v, got %v", expected, hashes)
+	}
+
+	// the other address, by the range of snapshot height
+	otherFilter := &TransferFilter{Addr: other, FromSnapshotHeight: laterSnapshotBlock.Height, ToSnapshotHeight: laterSnapshotBlock.Height}
+	if transfers, err := c.GetTransfers(otherFilter); err != nil || !reflect.DeepEqual(transferHashes(transfers), []types.Hash{later.Hash}) {
+		t.Fatalf("expected transfer %s, got %v, error %v", later.Hash, transfers, err)
+	}
+	otherFilter.FromSnapshotHeight = from
+	otherFilter.ToSnapshotHeight = snapshotBlock.Height
+	expected = sortTransfers(snapshotBlock.Height, sent, receivedFrom)
+	if transfers, err := c.GetTransfers(otherFilter); err != nil || !reflect.DeepEqual(transferHashes(transfers), expected) {
+		t.Fatalf("expected transfers %v, got %v, error %v", expected, transfers, err)
+	}
+
+	otherTokenId := ledger.ViteTokenId
+	otherTokenId[0]++
+	otherFilter.TokenId = &otherTokenId
+	if transfers, err := c.GetTransfers(otherFilter); err != nil || len(transfers) != 0 {
+		t.Fatalf("transfers of other tokens should not be returned, got %v, error %v", transfers, err)
+	}
+
+	// the transfer is removed from the index with the snapshot block confirming it, and indexed again when it is confirmed again
+	if _, _, err := c.DeleteSnapshotBlocksToHeight(laterSnapshotBlock.Height); err != nil {
+		t.Fatal(err)
+	}
+	if problems := checkDbProblems(t, c); len(problems) > 0 {
+		t.Fatalf("chain db should be consistent after the rollback: %v", problems)
+	}
+	otherFilter = &TransferFilter{Addr: other, FromSnapshotHeight: laterSnapshotBlock.Height, ToSnapshotHeight: laterSnapshotBlock.Height + 1}
+	if transfers, err := c.GetTransfers(otherFilter); err != nil || len(transfers) != 0 {
+		t.Fatalf("the transfer unconfirmed by the rollback should not be returned, got %v, error %v", transfers, err)
+	}
+	insertTestSnapshotBlock(t, c)
+	insertTestSnapshotBlock(t, c)
+	if transfers, err := c.GetTransfers(otherFilter); err != nil || len(transfers) != 1 || transfers[0].SnapshotHeight != laterSnapshotBlock.Height {
+		t.Fatalf("expected transfer %s confirmed at %d again, got %v, error %v", later.Hash, laterSnapshotBlock.Height, transfers, err)
+	}
+}
+
+func TestGetTransfersIndexEnabledLater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tx_history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	addr := ledger.GenesisAccountAddress
+	other, _ := types.HexToAddress("vite_39f1ede9ab4979b8a77167bfade02a3b4df0c413ad048cb999")
+
+	c := newTxHistoryChain(t, dir, false)
+	insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: addr})
+	insertTestSnapshotBlock(t, c)
+	// unconfirmed when the index is enabled
+	unconfirmed := insertTestBlock(t, c, &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: addr})
+	c.Stop()
+	c.Destroy()
+
+	c = newTxHistoryChain(t, dir, true)
+	defer c.Destroy()
+	defer c.Stop()
+	start := c.GetLatestSnapshotBlock().Height + 1
+	if recorded, err := c.GetTxHistoryStart(); err != nil || recorded != start {
+		t.Fatalf("transfers should be looked up from %d, got %d, error %v", start, recorded, err)
+	}
+
+	filter := &TransferFilter{Addr: addr, FromSnapshotHeight: start - 1, ToSnapshotHeight: start + 10}
+	if _, err := c.GetTransfers(filter); err != ErrBeforeTxHistoryStart {
+		t.Fatalf("transfers before the index is enabled should be refused, got %v", err)
+	}
+
+	insertTestSnapshotBlock(t, c)
+	filter.FromSnapshotHeight = start
+	if transfers, err := c.GetTransfers(filter); err != nil || !reflect.DeepEqual(transferHashes(transfers), []types.Hash{unconfirmed.Hash}) {
+		t.Fatalf("the block unconfirmed when the index is enabled should be returned, got %v, error %v", transfers, err)
+	}
+}
@@ -336,6 +336,276 @@ func (ac *AccountChain) DeleteVmLogList(batch *leveldb.Batch, logListHash *types
 	batch.Delete(key)
 }
 
+// WriteVmLogIndex indexes the account block by its account and by every topic of its logs
+func (ac *AccountChain) WriteVmLogIndex(batch *leveldb.Batch, accountId uint64, block *ledger.AccountBlock, logList ledger.VmLogList) {
+	accountKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_ACCOUNT, accountId, block.Height, block.Hash.Bytes())
+	batch.Put(accountKey, []byte{})
+
+	for _, topic := range logTopicSet(logList) {
+		topicKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_TOPIC, topic.Bytes(), accountId, block.Height, block.Hash.Bytes())
+		batch.Put(topicKey, []byte{})
+	}
+}
+
+func (ac *AccountChain) DeleteVmLogIndex(batch *leveldb.Batch, accountId uint64, block *ledger.AccountBlock, logList ledger.VmLogList) {
+	accountKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_ACCOUNT, accountId, block.Height, block.Hash.Bytes())
+	batch.Delete(accountKey)
+
+	for _, topic := range logTopicSet(logList) {
+		topicKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_TOPIC, topic.Bytes(), accountId, block.Height, block.Hash.Bytes())
+		batch.Delete(topicKey)
+	}
+}
+
+// HasVmLogIndex checks all the keys WriteVmLogIndex writes exist
+func (ac *AccountChain) HasVmLogIndex(accountId uint64, block *ledger.AccountBlock, logList ledger.VmLogList) (bool, error) {
+	accountKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_ACCOUNT, accountId, block.Height, block.Hash.Bytes())
+	keys := [][]byte{accountKey}
+	for _, topic := range logTopicSet(logList) {
+		topicKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_TOPIC, topic.Bytes(), accountId, block.Height, block.Hash.Bytes())
+		keys = append(keys, topicKey)
+	}
+
+	for _, key := range keys {
+		if ok, err := ac.db.Has(key, nil); err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// WriteVmLogIndexStart records the snapshot height from which the confirmed blocks with logs are all indexed
+func (ac *AccountChain) WriteVmLogIndexStart(batch *leveldb.Batch, snapshotHeight uint64) {
+	key, _ := database.EncodeKey(database.DBKP_VM_LOG_INDEX_START)
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, snapshotHeight)
+	batch.Put(key, value)
+}
+
+// GetVmLogIndexStart returns 0 if the start isn't recorded, the db is created before the vm log index then
+func (ac *AccountChain) GetVmLogIndexStart() (uint64, error) {
+	key, _ := database.EncodeKey(database.DBKP_VM_LOG_INDEX_START)
+	value, err := ac.db.Get(key, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, errors.New("invalid vm log index start")
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
+// GetVmLogBlockHashListByAccountId returns hashes of the account blocks with logs in [startHeight, endHeight], ordered by height
+func (ac *AccountChain) GetVmLogBlockHashListByAccountId(accountId, startHeight, endHeight uint64) ([]types.Hash, error) {
+	startKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_ACCOUNT, accountId, startHeight)
+	limitKey, _ := database.EncodeKey(database.DBKP_LOG_INDEX_ACCOUNT, accountId, endHeight+1)
+
+	return ac.getVmLogBlockHashList(&util.Range{Start: startKey, Limit: limitKey})
+}
+
+// GetVmLogBlockHashListByTopic returns hashes of the account blocks with logs containing topic
+func (ac *AccountChain) GetVmLogBlockHashListByTopic(topic *types.Hash) ([]types.Hash, error) {
+	key, _ := database.EncodeKey(database.DBKP_LOG_INDEX_TOPIC, topic.Bytes())
+
+	return ac.getVmLogBlockHashList(util.BytesPrefix(key))
+}
+
+func (ac *AccountChain) getVmLogBlockHashList(slice *util.Range) ([]types.Hash, error) {
+	iter := ac.db.NewIterator(slice, nil)
+	defer iter.Release()
+
+	var hashList []types.Hash
+	for iter.Next() {
+		key := iter.Key()
+		hash, err := types.BytesToHash(key[len(key)-types.HashSize:])
+		if err != nil {
+			return nil, err
+		}
+		hashList = append(hashList, hash)
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+
+	return hashList, nil
+}
+
+func logTopicSet(logList ledger.VmLogList) []types.Hash {
+	var topicList []types.Hash
+	topicSet := make(map[types.Hash]struct{})
+	for _, vmLog := range logList {
+		for _, topic := range vmLog.Topics {
+			if _, ok := topicSet[topic]; !ok {
+				topicSet[topic] = struct{}{}
+				topicList = append(topicList, topic)
+			}
+		}
+	}
+	return topicList
+}
+
+// txHistoryKey is [DBKP_TX_HISTORY.toAddress.confirmHeight.sendBlockHash] for the send block, so the send blocks to an address
+// are read in order of the snapshot height which confirms them. The receive block is [DBKP_TX_HISTORY_RECEIVE.address.sendBlockHash.receiveBlockHash].
+func txHistoryKey(block *ledger.AccountBlock, confirmHeight uint64) []byte {
+	if block.IsSendBlock() {
+		key, _ := database.EncodeKey(database.DBKP_TX_HISTORY, block.ToAddress.Bytes(), confirmHeight, block.Hash.Bytes())
+		return key
+	}
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY_RECEIVE, block.AccountAddress.Bytes(), block.FromBlockHash.Bytes(), block.Hash.Bytes())
+	return key
+}
+
+// WriteTxHistoryIndex indexes the send block by its ToAddress, and the receive block by its address and the send block hash.
+// confirmHeight is the height of the snapshot block which confirms the send block, the send block is indexed when it is confirmed.
+// It is ignored for the receive block, which is indexed when it is inserted.
+func (ac *AccountChain) WriteTxHistoryIndex(batch *leveldb.Batch, block *ledger.AccountBlock, confirmHeight uint64) {
+	batch.Put(txHistoryKey(block, confirmHeight), []byte{})
+}
+
+// HasTxHistoryIndex checks the key WriteTxHistoryIndex writes exists
+func (ac *AccountChain) HasTxHistoryIndex(block *ledger.AccountBlock, confirmHeight uint64) (bool, error) {
+	return ac.db.Has(txHistoryKey(block, confirmHeight), nil)
+}
+
+func (ac *AccountChain) DeleteTxHistoryIndex(batch *leveldb.Batch, block *ledger.AccountBlock, confirmHeight uint64) {
+	batch.Delete(txHistoryKey(block, confirmHeight))
+}
+
+// DeleteConfirmedTxHistory deletes the tx history index of the send blocks of the account not above height, which are
+// confirmed by the snapshot blocks from snapshotHeight. It is called before the snapshot blocks are deleted.
+func (ac *AccountChain) DeleteConfirmedTxHistory(batch *leveldb.Batch, accountId, height, snapshotHeight uint64) error {
+	startKey, _ := database.EncodeKey(database.DBKP_ACCOUNTBLOCK, accountId, 1)
+	endKey, _ := database.EncodeKey(database.DBKP_ACCOUNTBLOCK, accountId, height+1)
+
+	iter := ac.db.NewIterator(&util.Range{Start: startKey, Limit: endKey}, nil)
+	defer iter.Release()
+
+	// the block is confirmed by the first snapshot block which confirms a block from it
+	var confirmHeight uint64
+	for iterOk := iter.Last(); iterOk; iterOk = iter.Prev() {
+		blockHash := getAccountBlockHash(iter.Key())
+
+		var err error
+		if confirmHeight == 0 {
+			confirmHeight, err = ac.GetConfirmHeight(blockHash)
+		} else {
+			var beSnapshot uint64
+			if beSnapshot, err = ac.GetBeSnapshot(blockHash); beSnapshot > 0 {
+				confirmHeight = beSnapshot
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if confirmHeight == 0 {
+			continue
+		}
+		if confirmHeight < snapshotHeight {
+			break
+		}
+
+		block := &ledger.AccountBlock{}
+		if err := block.DbDeserialize(iter.Value()); err != nil {
+			return err
+		}
+		if block.IsSendBlock() {
+			block.Hash = *blockHash
+			ac.DeleteTxHistoryIndex(batch, block, confirmHeight)
+		}
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// IterateTxHistory calls fn with the send blocks to addr in order of the snapshot height which confirms them,
+// from fromSnapshotHeight. It stops if fn returns false or an error.
+func (ac *AccountChain) IterateTxHistory(addr *types.Address, fromSnapshotHeight uint64, fn func(confirmHeight uint64, sendHash types.Hash) (bool, error)) error {
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY, addr.Bytes())
+	slice := util.BytesPrefix(key)
+	slice.Start, _ = database.EncodeKey(database.DBKP_TX_HISTORY, addr.Bytes(), fromSnapshotHeight)
+
+	iter := ac.db.NewIterator(slice, nil)
+	defer iter.Release()
+
+	offset := 1 + types.AddressSize
+	for iter.Next() {
+		key := iter.Key()
+		confirmHeight := binary.BigEndian.Uint64(key[offset : offset+8])
+		sendHash, err := types.BytesToHash(key[offset+8:])
+		if err != nil {
+			return err
+		}
+
+		next, err := fn(confirmHeight, sendHash)
+		if err != nil || !next {
+			return err
+		}
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// GetTxHistoryReceiveHash returns the hash of the receive block of the send block, nil if it isn't received
+func (ac *AccountChain) GetTxHistoryReceiveHash(toAddr *types.Address, sendHash *types.Hash) (*types.Hash, error) {
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY_RECEIVE, toAddr.Bytes(), sendHash.Bytes())
+
+	iter := ac.db.NewIterator(util.BytesPrefix(key), nil)
+	defer iter.Release()
+
+	if iter.Next() {
+		receiveHash, err := types.BytesToHash(iter.Key()[len(key):])
+		if err != nil {
+			return nil, err
+		}
+		return &receiveHash, nil
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// WriteTxHistoryStart records the snapshot height from which the confirmed blocks are all indexed
+func (ac *AccountChain) WriteTxHistoryStart(batch *leveldb.Batch, snapshotHeight uint64) {
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY_START)
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, snapshotHeight)
+	batch.Put(key, value)
+}
+
+func (ac *AccountChain) DeleteTxHistoryStart(batch *leveldb.Batch) {
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY_START)
+	batch.Delete(key)
+}
+
+// GetTxHistoryStart returns 0 if the tx history index is not enabled
+func (ac *AccountChain) GetTxHistoryStart() (uint64, error) {
+	key, _ := database.EncodeKey(database.DBKP_TX_HISTORY_START)
+	value, err := ac.db.Get(key, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(value) != 8 {
+		return 0, errors.New("invalid tx history start")
+	}
+	return binary.BigEndian.Uint64(value), nil
+}
+
 func (ac *AccountChain) GetBlockByHeight(accountId uint64, height uint64) (*ledger.AccountBlock, error) {
 	key, _ := database.EncodeKey(database.DBKP_ACCOUNTBLOCK, accountId, height)
 
@@ -370,7 +640,7 @@ func (ac *AccountChain) GetContractGid(accountId uint64) (*types.Gid, error) {
 	}
 
 	fromBlock, getBlockErr := ac.GetBlock(&genesisBlock.FromBlockHash)
-	if getBlockErr == nil {
+	if getBlockErr != nil {
 		return nil, getBlockErr
 	}
 
@@ -427,6 +697,8 @@ func (ac *AccountChain) deleteChain(batch *leveldb.Batch, accountId uint64, toHe
 	iter := ac.db.NewIterator(&util.Range{Start: startKey, Limit: endKey}, nil)
 	defer iter.Release()
 
+	var accountAddress *types.Address
+	var unconfirmedSends []*ledger.AccountBlock
 	for iter.Next() {
 
 		deleteBlock := &ledger.AccountBlock{}
@@ -436,11 +708,41 @@ func (ac *AccountChain) deleteChain(batch *leveldb.Batch, accountId uint64, toHe
 
 		deleteBlock.Hash = *getAccountBlockHash(iter.Key())
 
-		// Delete vm log list
+		// Delete vm log list and its index
 		if deleteBlock.LogHash != nil {
+			logList, err := ac.GetVmLogList(deleteBlock.LogHash)
+			if err != nil {
+				return nil, err
+			}
+			ac.DeleteVmLogIndex(batch, accountId, deleteBlock, logList)
 			ac.DeleteVmLogList(batch, deleteBlock.LogHash)
 		}
 
+		// Delete tx history index, the receive block is indexed by its address, and the send block by the snapshot height
+		// which confirms it, that is the snapshot height of the first block from it which has one
+		if deleteBlock.IsReceiveBlock() {
+			if accountAddress == nil {
+				var err error
+				if accountAddress, err = NewAccount(ac.db).GetAddressById(accountId); err != nil {
+					return nil, err
+				}
+			}
+			deleteBlock.AccountAddress = *accountAddress
+			ac.DeleteTxHistoryIndex(batch, deleteBlock, 0)
+		} else {
+			unconfirmedSends = append(unconfirmedSends, deleteBlock)
+		}
+		beSnapshot, err := ac.GetBeSnapshot(&deleteBlock.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if beSnapshot > 0 {
+			for _, sendBlock := range unconfirmedSends {
+				ac.DeleteTxHistoryIndex(batch, sendBlock, beSnapshot)
+			}
+			unconfirmedSends = unconfirmedSends[:0]
+		}
+
 		// Delete block
 		ac.DeleteBlock(batch, accountId, deleteBlock.Height, &deleteBlock.Hash)
 
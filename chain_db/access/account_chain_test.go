@@ -0,0 +1,201 @@
+package access
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestTxHistoryIndex(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ac := NewAccountChain(db)
+
+	fromAddr, _ := types.BytesToAddress([]byte("from_address_bytes_1"))
+	toAddr, _ := types.BytesToAddress([]byte("to_address_bytes_0001"))
+	now := time.Now()
+	newBlock := func(blockType byte, addr types.Address, height uint64, hash string) *ledger.AccountBlock {
+		return &ledger.AccountBlock{
+			BlockType:      blockType,
+			Hash:           types.DataHash([]byte(hash)),
+			Height:         height,
+			AccountAddress: addr,
+			Amount:         big.NewInt(1),
+			Fee:            big.NewInt(0),
+			Timestamp:      &now,
+		}
+	}
+
+	// send1 is received, send2 is onroad, send1 is confirmed by snapshot block 3 and send2 by 5, receive1 is unconfirmed
+	send1 := newBlock(ledger.BlockTypeSendCall, fromAddr, 1, "send1")
+	send1.ToAddress = toAddr
+	send2 := newBlock(ledger.BlockTypeSendCall, fromAddr, 2, "send2")
+	send2.ToAddress = toAddr
+	receive1 := newBlock(ledger.BlockTypeReceive, toAddr, 1, "receive1")
+	receive1.FromBlockHash = send1.Hash
+
+	confirmHeights := map[types.Hash]uint64{send1.Hash: 3, send2.Hash: 5}
+
+	batch := new(leveldb.Batch)
+	NewAccount(db).WriteAccountIndex(batch, 1, &fromAddr)
+	NewAccount(db).WriteAccountIndex(batch, 2, &toAddr)
+	for _, block := range []*ledger.AccountBlock{send1, send2, receive1} {
+		accountId := uint64(1)
+		if block.AccountAddress == toAddr {
+			accountId = 2
+		}
+		if err := ac.WriteBlock(batch, accountId, block); err != nil {
+			t.Fatal(err)
+		}
+		meta := &ledger.AccountBlockMeta{AccountId: accountId, Height: block.Height}
+		if err := ac.WriteBlockMeta(batch, &block.Hash, meta); err != nil {
+			t.Fatal(err)
+		}
+		if confirmHeight := confirmHeights[block.Hash]; confirmHeight > 0 {
+			if err := ac.WriteBeSnapshot(batch, &block.Hash, confirmHeight); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ac.WriteTxHistoryIndex(batch, block, confirmHeights[block.Hash])
+	}
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sendHashList := func(addr *types.Address, fromSnapshotHeight uint64) []types.Hash {
+		var list []types.Hash
+		if err := ac.IterateTxHistory(addr, fromSnapshotHeight, func(confirmHeight uint64, sendHash types.Hash) (bool, error) {
+			if confirmHeight != confirmHeights[sendHash] {
+				t.Fatalf("%s should be confirmed by %d, got %d", sendHash, confirmHeights[sendHash], confirmHeight)
+			}
+			list = append(list, sendHash)
+			return true, nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+	if list := sendHashList(&toAddr, 0); len(list) != 2 || list[0] != send1.Hash || list[1] != send2.Hash {
+		t.Fatalf("the send blocks should be ordered by the snapshot height confirming them, got %v", list)
+	}
+	if list := sendHashList(&toAddr, 4); len(list) != 1 || list[0] != send2.Hash {
+		t.Fatalf("the send blocks confirmed below the height should be skipped, got %v", list)
+	}
+	if list := sendHashList(&fromAddr, 0); len(list) != 0 {
+		t.Fatalf("blocks sent from the address should not be indexed by it, got %v", list)
+	}
+
+	read := 0
+	if err := ac.IterateTxHistory(&toAddr, 0, func(uint64, types.Hash) (bool, error) {
+		read++
+		return false, nil
+	}); err != nil || read != 1 {
+		t.Fatalf("iterating should stop at the first block, read %d, error %v", read, err)
+	}
+
+	if receiveHash, err := ac.GetTxHistoryReceiveHash(&toAddr, &send1.Hash); err != nil || receiveHash == nil || *receiveHash != receive1.Hash {
+		t.Fatalf("unexpected receive hash %v of send1, error %v", receiveHash, err)
+	}
+	if receiveHash, err := ac.GetTxHistoryReceiveHash(&toAddr, &send2.Hash); err != nil || receiveHash != nil {
+		t.Fatalf("send2 should not be received, got %v, error %v", receiveHash, err)
+	}
+
+	// roll back the receive block, then snapshot block 5, then the send blocks
+	batch = new(leveldb.Batch)
+	if _, err := ac.Delete(batch, map[uint64]uint64{2: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list := sendHashList(&toAddr, 0); len(list) != 2 {
+		t.Fatalf("the send blocks should be kept in the index, got %v", list)
+	}
+	if receiveHash, err := ac.GetTxHistoryReceiveHash(&toAddr, &send1.Hash); err != nil || receiveHash != nil {
+		t.Fatalf("the receive block should be removed from the index, got %v, error %v", receiveHash, err)
+	}
+
+	batch = new(leveldb.Batch)
+	if err := ac.DeleteConfirmedTxHistory(batch, 1, 2, 4); err != nil {
+		t.Fatal(err)
+	}
+	ac.DeleteBeSnapshot(batch, &send2.Hash)
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list := sendHashList(&toAddr, 0); len(list) != 1 || list[0] != send1.Hash {
+		t.Fatalf("send2 should be removed from the index with the snapshot block, got %v", list)
+	}
+
+	batch = new(leveldb.Batch)
+	if _, err := ac.Delete(batch, map[uint64]uint64{1: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if list := sendHashList(&toAddr, 0); len(list) != 0 {
+		t.Fatalf("send1 should be removed from the index with the block, got %v", list)
+	}
+}
+
+func TestTxHistoryStart(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ac := NewAccountChain(db)
+
+	if start, err := ac.GetTxHistoryStart(); err != nil || start != 0 {
+		t.Fatalf("start should not be recorded, got %d, error %v", start, err)
+	}
+
+	batch := new(leveldb.Batch)
+	ac.WriteTxHistoryStart(batch, 1000)
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if start, err := ac.GetTxHistoryStart(); err != nil || start != 1000 {
+		t.Fatalf("expected start 1000, got %d, error %v", start, err)
+	}
+
+	batch = new(leveldb.Batch)
+	ac.DeleteTxHistoryStart(batch)
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if start, err := ac.GetTxHistoryStart(); err != nil || start != 0 {
+		t.Fatalf("start should be deleted, got %d, error %v", start, err)
+	}
+}
+
+func TestVmLogIndexStart(t *testing.T) {
+	db, err := leveldb.Open(storage.NewMemStorage(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ac := NewAccountChain(db)
+
+	if start, err := ac.GetVmLogIndexStart(); err != nil || start != 0 {
+		t.Fatalf("start should not be recorded, got %d, error %v", start, err)
+	}
+
+	batch := new(leveldb.Batch)
+	ac.WriteVmLogIndexStart(batch, 1000)
+	if err := db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	if start, err := ac.GetVmLogIndexStart(); err != nil || start != 1000 {
+		t.Fatalf("expected start 1000, got %d, error %v", start, err)
+	}
+}
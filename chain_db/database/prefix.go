@@ -34,4 +34,16 @@ const (
 	DBKP_BLOCK_EVENT = byte(16)
 
 	DBKP_BE_SNAPSHOT = byte(17)
+
+	DBKP_LOG_INDEX_ACCOUNT = byte(18)
+
+	DBKP_LOG_INDEX_TOPIC = byte(19)
+
+	DBKP_TX_HISTORY = byte(20)
+
+	DBKP_TX_HISTORY_RECEIVE = byte(22)
+
+	DBKP_TX_HISTORY_START = byte(23)
+
+	DBKP_VM_LOG_INDEX_START = byte(24)
 )
@@ -1,10 +1,36 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"github.com/vitelabs/go-vite/ledger"
+	"os"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/config"
 )
 
+// Print the hashes of the genesis snapshot blocks built from a genesis spec file,
+// the mainnet genesis is used if no file is given.
+//
+//	get_genesis_hash [-genesis genesis.json]
 func main() {
-	fmt.Println(ledger.GetSnapshotGenesisBlock().ComputeHash())
+	genesisFile := flag.String("genesis", "", "json spec file of the genesis blocks")
+	flag.Parse()
+
+	var genesis *config.Genesis
+	if *genesisFile != "" {
+		var err error
+		if genesis, err = config.LoadGenesis(*genesisFile); err != nil {
+			fmt.Println("LoadGenesis failed, error is " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if err := chain.InitGenesis(genesis); err != nil {
+		fmt.Println("InitGenesis failed, error is " + err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Println("genesis snapshot block:", chain.GenesisSnapshotBlock.Hash)
+	fmt.Println("second snapshot block:", chain.SecondSnapshotBlock.Hash)
 }
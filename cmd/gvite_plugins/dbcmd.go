@@ -0,0 +1,76 @@
+package gvite_plugins
+
+import (
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:     "db",
+		Usage:    "Chain database maintenance commands",
+		Category: "LEDGER COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Action: utils.MigrateFlags(dbCheckAction),
+				Name:   "check",
+				Usage:  "Check the chain database for inconsistencies",
+				Flags:  utils.MergeFlags(ledgerFlags, []cli.Flag{utils.DbRepairFlag}),
+				Description: `
+Walk the accounts, account chains, block metas, snapshot contents, log lists and onroad blocks,
+and report the inconsistencies left by a crash. With --repair the indexes derived from blocks
+are rebuilt, and the chain is rolled back to the last snapshot block which confirms no broken
+blocks. Every table is read once, a summary of every account block is kept in memory meanwhile.
+The node must not be running.`,
+			},
+		},
+	}
+)
+
+// dbCheckAction opens the chain without the onroad and the background workers of the chain,
+// the repair must not run with the trie gc or be exported to the sinks meanwhile
+func dbCheckAction(ctx *cli.Context) error {
+	n := nodemanager.FullNodeMaker{}.MakeNode(ctx)
+	c, err := n.OpenChain()
+	if err != nil {
+		return err
+	}
+	defer n.CloseChain(c)
+
+	problems, err := c.CheckDb()
+	if err != nil {
+		return err
+	}
+	printDbProblems(problems)
+
+	if len(problems) == 0 || !ctx.Bool(utils.DbRepairFlag.Name) {
+		return nil
+	}
+
+	if err := c.RepairDb(problems); err != nil {
+		return err
+	}
+
+	if problems, err = c.CheckDb(); err != nil {
+		return err
+	}
+	fmt.Printf("Repaired, the latest snapshot height is %d\n", c.GetLatestSnapshotBlock().Height)
+	printDbProblems(problems)
+
+	return nil
+}
+
+func printDbProblems(problems []*chain.DbProblem) {
+	for _, p := range problems {
+		if p.RollbackHeight > 0 {
+			fmt.Printf("%s (rollback to snapshot height %d)\n", p, p.RollbackHeight-1)
+		} else {
+			fmt.Println(p)
+		}
+	}
+	fmt.Printf("%d problems found\n", len(problems))
+}
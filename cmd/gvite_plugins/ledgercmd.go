@@ -0,0 +1,503 @@
+package gvite_plugins
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/compress"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vm_context"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// snapshot blocks read from chain at a time by export
+const exportBatch = 100
+
+var (
+	ledgerFlags = utils.MergeFlags(configFlags, generalFlags, p2pFlags, logFlags, vmFlags)
+
+	exportCommand = cli.Command{
+		Action:    utils.MigrateFlags(exportAction),
+		Name:      "export",
+		Usage:     "Export snapshot blocks and their confirmed account blocks to a file",
+		ArgsUsage: "<file>",
+		Flags:     utils.MergeFlags(ledgerFlags, []cli.Flag{utils.ExportFromFlag, utils.ExportToFlag}),
+		Category:  "LEDGER COMMANDS",
+		Description: `
+The blocks are written in the format of the ledger compressor, the account blocks confirmed
+by a batch of snapshot blocks are written before them. The node must not be running.`,
+	}
+
+	importCommand = cli.Command{
+		Action:    utils.MigrateFlags(importAction),
+		Name:      "import",
+		Usage:     "Import the blocks exported by the export command",
+		ArgsUsage: "<file>",
+		Flags:     ledgerFlags,
+		Category:  "LEDGER COMMANDS",
+		Description: `
+Every block is verified as it is received from the network before being inserted.
+Blocks already in the chain are skipped, so an interrupted import can be resumed by
+running the same command again. The node must not be running.`,
+	}
+)
+
+// openLedger prepares the node without starting the network, so the chain is only written by the command.
+// onroad is started to keep the onroad blocks of the inserted account blocks, its workers wait for the network.
+func openLedger(ctx *cli.Context) (*node.Node, error) {
+	n := nodemanager.FullNodeMaker{}.MakeNode(ctx)
+	if err := n.Prepare(); err != nil {
+		return nil, err
+	}
+
+	n.Vite().OnRoad().Start()
+	n.Vite().Chain().Start()
+	if err := n.Vite().Consensus().Init(); err != nil {
+		closeLedger(n)
+		return nil, err
+	}
+
+	return n, nil
+}
+
+func closeLedger(n *node.Node) {
+	n.Vite().Chain().Stop()
+	n.Vite().OnRoad().Stop()
+	n.Vite().Chain().Destroy()
+	n.WalletManager().Stop()
+}
+
+func exportAction(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("the export file is required")
+	}
+
+	n, err := openLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLedger(n)
+
+	c := n.Vite().Chain()
+
+	from, to := ctx.Uint64(utils.ExportFromFlag.Name), ctx.Uint64(utils.ExportToFlag.Name)
+	if latest := c.GetLatestSnapshotBlock().Height; to == 0 || to > latest {
+		to = latest
+	}
+	if from == 0 || from > to {
+		return fmt.Errorf("invalid snapshot heights from %d to %d", from, to)
+	}
+
+	file, err := os.Create(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err = exportLedger(writer, c, from, to); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// exportLedger writes the snapshot blocks from..to and the account blocks confirmed by them
+func exportLedger(writer io.Writer, c chain.Chain, from, to uint64) error {
+	next := from
+	return compress.BlockFormatter(writer, func(uint64, uint64) ([]ledger.Block, error) {
+		if next > to {
+			return nil, io.EOF
+		}
+
+		end := next + exportBatch - 1
+		if end > to {
+			end = to
+		}
+
+		snapshotBlocks, subLedger, err := c.GetConfirmSubLedger(next, end)
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Printf("Export snapshot blocks %d-%d\n", next, end)
+		next = end + 1
+
+		return exportBlocks(snapshotBlocks, subLedger), nil
+	})
+}
+
+// exportBlocks puts the account blocks before the snapshot blocks confirm them,
+// the sub ledger may contain unconfirmed blocks if it reaches the latest snapshot block, they are dropped.
+func exportBlocks(snapshotBlocks []*ledger.SnapshotBlock, subLedger map[types.Address][]*ledger.AccountBlock) []ledger.Block {
+	confirmed := make(map[types.Address]uint64)
+	for _, snapshotBlock := range snapshotBlocks {
+		for addr, hashHeight := range snapshotBlock.SnapshotContent {
+			if hashHeight.Height > confirmed[addr] {
+				confirmed[addr] = hashHeight.Height
+			}
+		}
+	}
+
+	blocks := make([]ledger.Block, 0, len(snapshotBlocks))
+	for addr, accountBlocks := range subLedger {
+		for _, accountBlock := range accountBlocks {
+			if accountBlock.Height <= confirmed[addr] {
+				blocks = append(blocks, accountBlock)
+			}
+		}
+	}
+	for _, snapshotBlock := range snapshotBlocks {
+		blocks = append(blocks, snapshotBlock)
+	}
+
+	return blocks
+}
+
+func importAction(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("the import file is required")
+	}
+
+	file, err := os.Open(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := openLedger(ctx)
+	if err != nil {
+		return err
+	}
+	defer closeLedger(n)
+
+	v := n.Vite()
+	imp := newLedgerImporter(v.Chain(), &nodeVerifier{
+		sVerifier: v.SnapshotVerifier(),
+		aVerifier: v.AccountVerifier(),
+	})
+
+	if err = importLedger(bufio.NewReader(file), imp); err != nil {
+		return err
+	}
+
+	fmt.Printf("Import %d snapshot blocks and %d account blocks, the latest snapshot height is %d\n",
+		imp.snapshotCount, imp.accountCount, v.Chain().GetLatestSnapshotBlock().Height)
+
+	return nil
+}
+
+// importLedger inserts all blocks parsed from reader, it fails at the first bad block
+func importLedger(r io.Reader, imp *ledgerImporter) error {
+	reader := &stopReader{reader: r}
+	compress.BlockParser(reader, 0, func(block ledger.Block, err error) {
+		if reader.err != nil {
+			return
+		}
+		if err == nil {
+			err = imp.add(block)
+		}
+		reader.err = err
+	})
+
+	if reader.err != nil {
+		return reader.err
+	}
+
+	return imp.finish()
+}
+
+// stopReader fails all reads after err is set, so compress.BlockParser stops at the first bad block
+type stopReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *stopReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+// ledgerVerifier verifies the blocks parsed by import as they are received from the network
+type ledgerVerifier interface {
+	verifySnapshotBlock(block *ledger.SnapshotBlock) error
+
+	// pending is true if the blocks referred are not in chain, vmBlocks are the block and the send blocks
+	// generated by it if it's a contract receive block
+	verifyAccountBlock(block *ledger.AccountBlock) (vmBlocks []*vm_context.VmAccountBlock, pending bool, err error)
+}
+
+// nodeVerifier is the ledgerVerifier of the verifiers of the node
+type nodeVerifier struct {
+	sVerifier *verifier.SnapshotVerifier
+	aVerifier *verifier.AccountVerifier
+}
+
+func (v *nodeVerifier) verifySnapshotBlock(block *ledger.SnapshotBlock) error {
+	if err := v.sVerifier.VerifyNetSb(block); err != nil {
+		return fmt.Errorf("snapshot block %s/%d is invalid: %v", block.Hash, block.Height, err)
+	}
+
+	if stat := v.sVerifier.VerifyReferred(block); stat.VerifyResult() != verifier.SUCCESS {
+		return fmt.Errorf("snapshot block %s/%d verify failed: %s", block.Hash, block.Height, stat.ErrMsg())
+	}
+
+	return nil
+}
+
+func (v *nodeVerifier) verifyAccountBlock(block *ledger.AccountBlock) ([]*vm_context.VmAccountBlock, bool, error) {
+	if err := v.aVerifier.VerifyNetAb(block); err != nil {
+		return nil, false, fmt.Errorf("account block %s/%d is invalid: %v", block.Hash, block.Height, err)
+	}
+
+	result, stat := v.aVerifier.VerifyReferred(block)
+	switch result {
+	case verifier.PENDING:
+		return nil, true, nil
+	case verifier.FAIL:
+		return nil, false, fmt.Errorf("account block %s/%d verify failed: %s", block.Hash, block.Height, stat.ErrMsg())
+	}
+
+	vmBlocks, err := v.aVerifier.VerifyforVM(block)
+	if err != nil {
+		return nil, false, fmt.Errorf("account block %s/%d verify failed: %v", block.Hash, block.Height, err)
+	}
+
+	return vmBlocks, false, nil
+}
+
+// ledgerImporter holds the blocks parsed from file until they can be verified in order,
+// a snapshot block is inserted after all account blocks it confirms have been inserted.
+type ledgerImporter struct {
+	chain    chain.Chain
+	verifier ledgerVerifier
+
+	snapshotBlocks map[uint64]*ledger.SnapshotBlock
+	accountBlocks  map[types.Address]map[uint64]*ledger.AccountBlock
+
+	snapshotCount uint64
+	accountCount  uint64
+}
+
+func newLedgerImporter(c chain.Chain, verifier ledgerVerifier) *ledgerImporter {
+	return &ledgerImporter{
+		chain:          c,
+		verifier:       verifier,
+		snapshotBlocks: make(map[uint64]*ledger.SnapshotBlock),
+		accountBlocks:  make(map[types.Address]map[uint64]*ledger.AccountBlock),
+	}
+}
+
+func (imp *ledgerImporter) add(block ledger.Block) error {
+	switch block := block.(type) {
+	case *ledger.SnapshotBlock:
+		if block.Height > imp.chain.GetLatestSnapshotBlock().Height {
+			imp.snapshotBlocks[block.Height] = block
+			break
+		}
+
+		// imported before
+		current, err := imp.chain.GetSnapshotBlockByHeight(block.Height)
+		if err != nil {
+			return err
+		}
+		if current == nil || current.Hash != block.Hash {
+			return fmt.Errorf("snapshot block %s/%d is different from the chain", block.Hash, block.Height)
+		}
+
+	case *ledger.AccountBlock:
+		current, err := imp.chain.GetAccountBlockByHash(&block.Hash)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			break
+		}
+
+		blocks, ok := imp.accountBlocks[block.AccountAddress]
+		if !ok {
+			blocks = make(map[uint64]*ledger.AccountBlock)
+			imp.accountBlocks[block.AccountAddress] = blocks
+		}
+		blocks[block.Height] = block
+
+	default:
+		return errors.New("unknown block type")
+	}
+
+	return imp.insert()
+}
+
+// finish return error if some blocks parsed can`t be inserted, eg. the file is truncated
+func (imp *ledgerImporter) finish() error {
+	if len(imp.snapshotBlocks) > 0 {
+		next := imp.chain.GetLatestSnapshotBlock().Height + 1
+		if block, ok := imp.snapshotBlocks[next]; ok {
+			return fmt.Errorf("account blocks confirmed by snapshot block %s/%d are missing", block.Hash, block.Height)
+		}
+		return fmt.Errorf("snapshot block %d is missing", next)
+	}
+
+	// export writes confirmed account blocks only
+	for addr, blocks := range imp.accountBlocks {
+		if len(blocks) > 0 {
+			return fmt.Errorf("%d account blocks of %s are not confirmed by snapshot blocks in the file", len(blocks), addr)
+		}
+	}
+
+	return nil
+}
+
+// insert the following snapshot blocks as many as possible
+func (imp *ledgerImporter) insert() error {
+	for {
+		block, ok := imp.snapshotBlocks[imp.chain.GetLatestSnapshotBlock().Height+1]
+		if !ok {
+			return nil
+		}
+
+		if ready, err := imp.ready(block); err != nil || !ready {
+			return err
+		}
+
+		if err := imp.insertAccountBlocks(block); err != nil {
+			return err
+		}
+
+		if err := imp.insertSnapshotBlock(block); err != nil {
+			return err
+		}
+
+		delete(imp.snapshotBlocks, block.Height)
+	}
+}
+
+// accountHeight return the height of the latest account block in chain, 0 if the account doesn't exist
+func (imp *ledgerImporter) accountHeight(addr *types.Address) (uint64, error) {
+	block, err := imp.chain.GetLatestAccountBlock(addr)
+	if err != nil || block == nil {
+		return 0, err
+	}
+	return block.Height, nil
+}
+
+// ready return whether all account blocks confirmed by the snapshot block have been parsed
+func (imp *ledgerImporter) ready(block *ledger.SnapshotBlock) (bool, error) {
+	for addr, hashHeight := range block.SnapshotContent {
+		height, err := imp.accountHeight(&addr)
+		if err != nil {
+			return false, err
+		}
+
+		for height++; height <= hashHeight.Height; height++ {
+			if _, ok := imp.accountBlocks[addr][height]; !ok {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// insertAccountBlocks insert the account blocks confirmed by the snapshot block, blocks refer to
+// blocks of other accounts are pending until the referred blocks have been inserted
+func (imp *ledgerImporter) insertAccountBlocks(block *ledger.SnapshotBlock) error {
+	for {
+		done, progress := true, false
+
+		for addr, hashHeight := range block.SnapshotContent {
+			height, err := imp.accountHeight(&addr)
+			if err != nil {
+				return err
+			}
+
+			for height++; height <= hashHeight.Height; height++ {
+				pending, err := imp.insertAccountBlock(imp.accountBlocks[addr][height])
+				if err != nil {
+					return err
+				}
+				if pending {
+					break
+				}
+
+				progress = true
+				height, err = imp.accountHeight(&addr)
+				if err != nil {
+					return err
+				}
+			}
+
+			if height <= hashHeight.Height {
+				done = false
+			}
+		}
+
+		if done {
+			return nil
+		}
+		if !progress {
+			return fmt.Errorf("account blocks confirmed by snapshot block %s/%d can`t be verified", block.Hash, block.Height)
+		}
+	}
+}
+
+// insertAccountBlock return true if the blocks referred are not in chain,
+// send blocks generated by a contract receive block are inserted together with it.
+func (imp *ledgerImporter) insertAccountBlock(block *ledger.AccountBlock) (pending bool, err error) {
+	vmBlocks, pending, err := imp.verifier.verifyAccountBlock(block)
+	if err != nil || pending {
+		return pending, err
+	}
+
+	blocks := imp.accountBlocks[block.AccountAddress]
+	for _, vmBlock := range vmBlocks[1:] {
+		generated := vmBlock.AccountBlock
+		if parsed, ok := blocks[generated.Height]; !ok || parsed.Hash != generated.Hash {
+			return false, fmt.Errorf("account block %s/%d generated by %s is different from the file", generated.Hash, generated.Height, block.Hash)
+		}
+	}
+
+	if err = imp.chain.InsertAccountBlocks(vmBlocks); err != nil {
+		return false, err
+	}
+
+	for _, vmBlock := range vmBlocks {
+		delete(blocks, vmBlock.AccountBlock.Height)
+	}
+	imp.accountCount += uint64(len(vmBlocks))
+
+	return false, nil
+}
+
+func (imp *ledgerImporter) insertSnapshotBlock(block *ledger.SnapshotBlock) error {
+	if err := imp.verifier.verifySnapshotBlock(block); err != nil {
+		return err
+	}
+
+	if err := imp.chain.InsertSnapshotBlock(block); err != nil {
+		return err
+	}
+	imp.snapshotCount++
+
+	if imp.snapshotCount%1000 == 0 {
+		fmt.Printf("Import snapshot block %d\n", block.Height)
+	}
+
+	return nil
+}
@@ -0,0 +1,374 @@
+package gvite_plugins
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/compress"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_context"
+)
+
+// memChain keeps the blocks in memory, blocks are inserted if they are linked to the latest ones
+type memChain struct {
+	chain.Chain
+
+	snapshotBlocks []*ledger.SnapshotBlock // from genesis
+	accountBlocks  map[types.Address][]*ledger.AccountBlock
+	byHash         map[types.Hash]*ledger.AccountBlock
+}
+
+func newMemChain(genesis *ledger.SnapshotBlock) *memChain {
+	return &memChain{
+		snapshotBlocks: []*ledger.SnapshotBlock{genesis},
+		accountBlocks:  make(map[types.Address][]*ledger.AccountBlock),
+		byHash:         make(map[types.Hash]*ledger.AccountBlock),
+	}
+}
+
+func (c *memChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.snapshotBlocks[len(c.snapshotBlocks)-1]
+}
+
+func (c *memChain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == 0 || height > uint64(len(c.snapshotBlocks)) {
+		return nil, nil
+	}
+	return c.snapshotBlocks[height-1], nil
+}
+
+func (c *memChain) GetAccountBlockByHash(hash *types.Hash) (*ledger.AccountBlock, error) {
+	return c.byHash[*hash], nil
+}
+
+func (c *memChain) GetLatestAccountBlock(addr *types.Address) (*ledger.AccountBlock, error) {
+	blocks := c.accountBlocks[*addr]
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return blocks[len(blocks)-1], nil
+}
+
+func (c *memChain) InsertAccountBlocks(vmBlocks []*vm_context.VmAccountBlock) error {
+	for _, vmBlock := range vmBlocks {
+		block := vmBlock.AccountBlock
+		blocks := c.accountBlocks[block.AccountAddress]
+
+		var prevHash types.Hash
+		if len(blocks) > 0 {
+			prevHash = blocks[len(blocks)-1].Hash
+		}
+		if block.Height != uint64(len(blocks))+1 || block.PrevHash != prevHash {
+			return fmt.Errorf("account block %s/%d is not linked", block.Hash, block.Height)
+		}
+
+		c.accountBlocks[block.AccountAddress] = append(blocks, block)
+		c.byHash[block.Hash] = block
+	}
+	return nil
+}
+
+func (c *memChain) InsertSnapshotBlock(block *ledger.SnapshotBlock) error {
+	latest := c.GetLatestSnapshotBlock()
+	if block.Height != latest.Height+1 || block.PrevHash != latest.Hash {
+		return fmt.Errorf("snapshot block %s/%d is not linked", block.Hash, block.Height)
+	}
+	c.snapshotBlocks = append(c.snapshotBlocks, block)
+	return nil
+}
+
+// GetConfirmSubLedger returns all account blocks not confirmed before fromHeight, including the unconfirmed ones
+func (c *memChain) GetConfirmSubLedger(fromHeight uint64, toHeight uint64) ([]*ledger.SnapshotBlock, map[types.Address][]*ledger.AccountBlock, error) {
+	confirmed := make(map[types.Address]uint64)
+	for _, block := range c.snapshotBlocks[:fromHeight-1] {
+		for addr, hashHeight := range block.SnapshotContent {
+			confirmed[addr] = hashHeight.Height
+		}
+	}
+
+	subLedger := make(map[types.Address][]*ledger.AccountBlock)
+	for addr, blocks := range c.accountBlocks {
+		if uint64(len(blocks)) > confirmed[addr] {
+			subLedger[addr] = blocks[confirmed[addr]:]
+		}
+	}
+
+	return c.snapshotBlocks[fromHeight-1 : toHeight], subLedger, nil
+}
+
+// hashVerifier checks the hashes only, the blocks of the test are not signed
+type hashVerifier struct{}
+
+func (hashVerifier) verifySnapshotBlock(block *ledger.SnapshotBlock) error {
+	if block.ComputeHash() != block.Hash {
+		return fmt.Errorf("snapshot block %s/%d is invalid", block.Hash, block.Height)
+	}
+	return nil
+}
+
+func (hashVerifier) verifyAccountBlock(block *ledger.AccountBlock) ([]*vm_context.VmAccountBlock, bool, error) {
+	if block.ComputeHash() != block.Hash {
+		return nil, false, fmt.Errorf("account block %s/%d is invalid", block.Hash, block.Height)
+	}
+	return []*vm_context.VmAccountBlock{{AccountBlock: block}}, false, nil
+}
+
+// mockLedger returns a chain of height snapshot blocks, account blocks of two accounts are confirmed
+// every 2 and 3 snapshot blocks, and the first account has an unconfirmed block
+func mockLedger(height uint64) *memChain {
+	timestamp := time.Unix(1540000000, 0)
+	genesis := &ledger.SnapshotBlock{Height: 1, Timestamp: &timestamp}
+	genesis.Hash = genesis.ComputeHash()
+
+	c := newMemChain(genesis)
+	addrs := []types.Address{{1}, {2}}
+
+	appendAccountBlock := func(addr types.Address, timestamp *time.Time) *ledger.AccountBlock {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			Height:         1,
+			AccountAddress: addr,
+			ToAddress:      addrs[0],
+			Amount:         big.NewInt(int64(len(c.byHash))),
+			TokenId:        ledger.ViteTokenId,
+			Fee:            big.NewInt(0),
+			Timestamp:      timestamp,
+		}
+		if latest, _ := c.GetLatestAccountBlock(&addr); latest != nil {
+			block.Height = latest.Height + 1
+			block.PrevHash = latest.Hash
+		}
+		block.Hash = block.ComputeHash()
+		c.InsertAccountBlocks([]*vm_context.VmAccountBlock{{AccountBlock: block}})
+		return block
+	}
+
+	for h := uint64(2); h <= height; h++ {
+		prev := c.GetLatestSnapshotBlock()
+		timestamp := prev.Timestamp.Add(time.Second)
+
+		content := make(ledger.SnapshotContent)
+		for i, addr := range addrs {
+			if h%uint64(i+2) == 0 {
+				block := appendAccountBlock(addr, &timestamp)
+				content[addr] = &ledger.HashHeight{Hash: block.Hash, Height: block.Height}
+			}
+		}
+
+		block := &ledger.SnapshotBlock{
+			PrevHash:        prev.Hash,
+			Height:          h,
+			Timestamp:       &timestamp,
+			SnapshotContent: content,
+		}
+		block.Hash = block.ComputeHash()
+		c.InsertSnapshotBlock(block)
+	}
+
+	appendAccountBlock(addrs[0], c.GetLatestSnapshotBlock().Timestamp)
+
+	return c
+}
+
+func exportMockLedger(t *testing.T, c *memChain) []byte {
+	buf := new(bytes.Buffer)
+	if err := exportLedger(buf, c, 2, c.GetLatestSnapshotBlock().Height); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkImported checks the chain has the blocks of source confirmed by snapshot blocks
+func checkImported(t *testing.T, source, c *memChain) {
+	if len(c.snapshotBlocks) != len(source.snapshotBlocks) {
+		t.Fatalf("expected %d snapshot blocks, got %d", len(source.snapshotBlocks), len(c.snapshotBlocks))
+	}
+	for i, block := range c.snapshotBlocks {
+		if block.Hash != source.snapshotBlocks[i].Hash || len(block.SnapshotContent) != len(source.snapshotBlocks[i].SnapshotContent) {
+			t.Fatalf("snapshot block %d is different", block.Height)
+		}
+	}
+
+	for addr, blocks := range source.accountBlocks {
+		// the unconfirmed block is not exported
+		if addr == (types.Address{1}) {
+			blocks = blocks[:len(blocks)-1]
+		}
+		if len(c.accountBlocks[addr]) != len(blocks) {
+			t.Fatalf("expected %d account blocks of %s, got %d", len(blocks), addr, len(c.accountBlocks[addr]))
+		}
+		for i, block := range c.accountBlocks[addr] {
+			if block.Hash != blocks[i].Hash || block.Amount.Cmp(blocks[i].Amount) != 0 {
+				t.Fatalf("account block %d of %s is different", block.Height, addr)
+			}
+		}
+	}
+}
+
+func TestLedgerRoundTrip(t *testing.T) {
+	// more than one export batch
+	source := mockLedger(2*exportBatch + 50)
+	data := exportMockLedger(t, source)
+
+	c := newMemChain(source.snapshotBlocks[0])
+	imp := newLedgerImporter(c, hashVerifier{})
+	if err := importLedger(bytes.NewReader(data), imp); err != nil {
+		t.Fatal(err)
+	}
+	checkImported(t, source, c)
+	if imp.snapshotCount != uint64(len(source.snapshotBlocks)-1) {
+		t.Fatalf("expected %d snapshot blocks imported, got %d", len(source.snapshotBlocks)-1, imp.snapshotCount)
+	}
+
+	// blocks in chain are skipped
+	imp = newLedgerImporter(c, hashVerifier{})
+	if err := importLedger(bytes.NewReader(data), imp); err != nil {
+		t.Fatal(err)
+	}
+	if imp.snapshotCount != 0 || imp.accountCount != 0 {
+		t.Fatalf("nothing should be imported again, got %d snapshot blocks and %d account blocks", imp.snapshotCount, imp.accountCount)
+	}
+	checkImported(t, source, c)
+}
+
+// lastBlockSize returns the size of the last block in the exported data, which is the latest snapshot block
+func lastBlockSize(t *testing.T, c *memChain) int {
+	data, err := c.GetLatestSnapshotBlock().Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 4 + 1 + len(data)
+}
+
+func TestLedgerImportTruncated(t *testing.T) {
+	source := mockLedger(20)
+	data := exportMockLedger(t, source)
+
+	// in the middle of the last block
+	c := newMemChain(source.snapshotBlocks[0])
+	if err := importLedger(bytes.NewReader(data[:len(data)-3]), newLedgerImporter(c, hashVerifier{})); err != io.ErrUnexpectedEOF {
+		t.Fatalf("partial block should be refused, got %v", err)
+	}
+
+	// the last snapshot block is missing, the account blocks confirmed by it are left
+	truncated := data[:len(data)-lastBlockSize(t, source)]
+	err := importLedger(bytes.NewReader(truncated), newLedgerImporter(c, hashVerifier{}))
+	if err == nil || !strings.Contains(err.Error(), "not confirmed") {
+		t.Fatalf("account blocks without the snapshot block should be refused, got %v", err)
+	}
+	if c.GetLatestSnapshotBlock().Height != source.GetLatestSnapshotBlock().Height-1 {
+		t.Fatalf("snapshot blocks before the missing one should be imported, latest is %d", c.GetLatestSnapshotBlock().Height)
+	}
+
+	// resumed by importing the whole file
+	if err := importLedger(bytes.NewReader(data), newLedgerImporter(c, hashVerifier{})); err != nil {
+		t.Fatal(err)
+	}
+	checkImported(t, source, c)
+}
+
+func TestLedgerImportCorrupted(t *testing.T) {
+	source := mockLedger(20)
+	data := exportMockLedger(t, source)
+
+	// unknown block type
+	corrupted := append([]byte{}, data...)
+	corrupted[4] = 9
+	c := newMemChain(source.snapshotBlocks[0])
+	if err := importLedger(bytes.NewReader(corrupted), newLedgerImporter(c, hashVerifier{})); err == nil {
+		t.Fatal("unknown block type should be refused")
+	}
+
+	// garbage payload
+	corrupted = append([]byte{}, data[:5]...)
+	binary.BigEndian.PutUint32(corrupted, 8)
+	corrupted = append(corrupted, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+	if err := importLedger(bytes.NewReader(corrupted), newLedgerImporter(c, hashVerifier{})); err == nil {
+		t.Fatal("garbage block should be refused")
+	}
+	if len(c.snapshotBlocks) != 1 || len(c.byHash) != 0 {
+		t.Fatal("nothing should be imported from the corrupted files")
+	}
+
+	// a block is tampered
+	snapshotBlocks, subLedger, _ := source.GetConfirmSubLedger(2, 20)
+	blocks := exportBlocks(snapshotBlocks, subLedger)
+	for i, block := range blocks {
+		if block, ok := block.(*ledger.AccountBlock); ok && block.AccountAddress == (types.Address{2}) && block.Height == 1 {
+			tampered := *block
+			tampered.Amount = big.NewInt(1e18)
+			blocks[i] = &tampered
+		}
+	}
+	buf := new(bytes.Buffer)
+	err := compress.BlockFormatter(buf, func(uint64, uint64) ([]ledger.Block, error) {
+		return blocks, io.EOF
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = newMemChain(source.snapshotBlocks[0])
+	err = importLedger(buf, newLedgerImporter(c, hashVerifier{}))
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Fatalf("tampered block should be refused, got %v", err)
+	}
+	// snapshot blocks before the one confirms the tampered block are imported
+	if c.GetLatestSnapshotBlock().Height != 2 {
+		t.Fatalf("import should stop at the tampered block, latest is %d", c.GetLatestSnapshotBlock().Height)
+	}
+}
+
+func TestLedgerImportDifferentChain(t *testing.T) {
+	source := mockLedger(20)
+	data := exportMockLedger(t, source)
+
+	// the second snapshot block is different
+	c := newMemChain(source.snapshotBlocks[0])
+	other := *source.snapshotBlocks[1]
+	other.StateHash = types.Hash{1}
+	other.SnapshotContent = nil
+	other.Hash = other.ComputeHash()
+	c.InsertSnapshotBlock(&other)
+
+	err := importLedger(bytes.NewReader(data), newLedgerImporter(c, hashVerifier{}))
+	if err == nil || !strings.Contains(err.Error(), "different from the chain") {
+		t.Fatalf("file of another chain should be refused, got %v", err)
+	}
+}
+
+func TestExportBlocks(t *testing.T) {
+	source := mockLedger(10)
+	snapshotBlocks, subLedger, _ := source.GetConfirmSubLedger(2, 10)
+
+	blocks := exportBlocks(snapshotBlocks, subLedger)
+	accountCount := 0
+	for i, block := range blocks {
+		switch block := block.(type) {
+		case *ledger.AccountBlock:
+			if i >= len(blocks)-len(snapshotBlocks) {
+				t.Fatal("account blocks should be written before the snapshot blocks")
+			}
+			if block.Hash == source.accountBlocks[types.Address{1}][len(source.accountBlocks[types.Address{1}])-1].Hash {
+				t.Fatal("unconfirmed account block should be dropped")
+			}
+			accountCount++
+		case *ledger.SnapshotBlock:
+		default:
+			t.Fatal(errors.New("unknown block type"))
+		}
+	}
+
+	// 5 blocks of the first account and 3 blocks of the second one
+	if accountCount != 8 {
+		t.Fatalf("expected 8 confirmed account blocks, got %d", accountCount)
+	}
+}
@@ -32,12 +32,17 @@ var (
 	generalFlags = []cli.Flag{
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
+		utils.GenesisFileFlag,
+		utils.PruneHeightsFlag,
+		utils.TxHistoryIndexFlag,
 	}
 
 	//p2p
 	p2pFlags = []cli.Flag{
 		utils.DevNetFlag,
 		utils.TestNetFlag,
+		utils.DevFlag,
+		utils.DevIntervalFlag,
 		utils.MainNetFlag,
 		utils.IdentityFlag,
 		utils.NetworkIdFlag,
@@ -96,13 +101,16 @@ var (
 	//Net
 	netFlags = []cli.Flag{
 		utils.SingleFlag,
-		utils.FilePortFlag,
+		utils.LightFlag,
 	}
 
 	//Stat
 	statFlags = []cli.Flag{
 		utils.PProfEnabledFlag,
 		utils.PProfPortFlag,
+		utils.MetricsEnabledFlag,
+		utils.MetricsListenAddrFlag,
+		utils.MetricsPortFlag,
 	}
 )
 
@@ -128,6 +136,9 @@ func init() {
 		licenseCommand,
 		consoleCommand,
 		attachCommand,
+		exportCommand,
+		importCommand,
+		dbCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -71,6 +71,28 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.KeyStoreDir = keyStoreDir
 	}
 
+	//Chain
+	if genesisFile := ctx.GlobalString(utils.GenesisFileFlag.Name); len(genesisFile) > 0 {
+		cfg.GenesisFile = genesisFile
+	}
+
+	if ctx.GlobalIsSet(utils.PruneHeightsFlag.Name) {
+		cfg.PruneHeights = ctx.GlobalUint64(utils.PruneHeightsFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.TxHistoryIndexFlag.Name) {
+		cfg.TxHistoryIndex = ctx.GlobalBool(utils.TxHistoryIndexFlag.Name)
+	}
+
+	//Dev
+	if ctx.GlobalIsSet(utils.DevFlag.Name) {
+		cfg.Dev = ctx.GlobalBool(utils.DevFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.DevIntervalFlag.Name) {
+		cfg.DevInterval = ctx.GlobalInt64(utils.DevIntervalFlag.Name)
+	}
+
 	//Network Config
 	if identity := ctx.GlobalString(utils.IdentityFlag.Name); len(identity) > 0 {
 		cfg.Identity = identity
@@ -158,8 +180,21 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.Single = ctx.GlobalBool(utils.SingleFlag.Name)
 	}
 
-	if ctx.GlobalIsSet(utils.FilePortFlag.Name) {
-		cfg.FilePort = ctx.GlobalInt(utils.FilePortFlag.Name)
+	if ctx.GlobalIsSet(utils.LightFlag.Name) {
+		cfg.Light = ctx.GlobalBool(utils.LightFlag.Name)
+	}
+
+	//Metrics
+	if ctx.GlobalIsSet(utils.MetricsEnabledFlag.Name) {
+		cfg.MetricsEnabled = ctx.GlobalBool(utils.MetricsEnabledFlag.Name)
+	}
+
+	if metricsHost := ctx.GlobalString(utils.MetricsListenAddrFlag.Name); len(metricsHost) > 0 {
+		cfg.MetricsHost = metricsHost
+	}
+
+	if ctx.GlobalIsSet(utils.MetricsPortFlag.Name) {
+		cfg.MetricsPort = ctx.GlobalInt(utils.MetricsPortFlag.Name)
 	}
 }
 
@@ -177,6 +212,15 @@ func overrideNodeConfigs(ctx *cli.Context, cfg *node.Config) {
 		cfg.LogLevel = "info"
 	}
 
+	if cfg.Dev {
+		cfg.NetSelect = "dev"
+		//dataDir override, dev mode never shares data with real networks
+		cfg.DataDir = filepath.Join(cfg.DataDir, "devmode")
+		cfg.KeyStoreDir = filepath.Join(cfg.KeyStoreDir, "devmode", "wallet")
+		cfg.DataDirPathAbs()
+		return
+	}
+
 	if ctx.GlobalBool(utils.MainNetFlag.Name) || cfg.NetID == 1 {
 		cfg.NetSelect = "main"
 		if cfg.NetID != 1 {
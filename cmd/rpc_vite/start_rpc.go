@@ -13,8 +13,8 @@ func StartAllRpcEndpoint(vite *vite.Vite, dataDir string) {
 	ipcapiURL := filepath.Join(dataDir, common.DefaultIpcFile())
 	fmt.Println(ipcapiURL)
 	go rpc.StartIPCEndpoint(ipcapiURL, rpcapi.GetAllApis(vite))
-	go rpc.StartWSEndpoint(common.DefaultWSEndpoint(), rpcapi.GetPublicApis(vite), nil, []string{"*"}, true)
-	go rpc.StartHTTPEndpoint(common.DefaultHttpEndpoint(), rpcapi.GetPublicApis(vite), nil, []string{"*"}, nil, rpc.DefaultHTTPTimeouts, true)
+	go rpc.StartWSEndpoint(common.DefaultWSEndpoint(), rpcapi.GetPublicApis(vite), nil, []string{"*"}, true, nil)
+	go rpc.StartHTTPEndpoint(common.DefaultHttpEndpoint(), rpcapi.GetPublicApis(vite), nil, []string{"*"}, nil, rpc.DefaultHTTPTimeouts, true, nil, nil)
 	c := make(chan int)
 	<-c
 }
@@ -34,7 +34,7 @@ func main() {
 	//}()
 
 	var coinBaseAddr string
-	var port, fport uint
+	var port uint
 	var privateKey string
 	var dir string
 
@@ -43,7 +43,6 @@ func main() {
 	cfg := new(config.Config)
 	flag.StringVar(&coinBaseAddr, "p", "", "")
 	flag.UintVar(&port, "port", 8483, "tcp listen")
-	flag.UintVar(&fport, "fport", 8484, "file server listen")
 	flag.StringVar(&privateKey, "priv", "", "p2p server privateKey")
 	flag.StringVar(&dir, "dir", common.DefaultDataDir(), "data dirname")
 	flag.Parse()
@@ -165,7 +164,7 @@ func main() {
 	var p2p *p2p.Server
 	var err error
 
-	node, p2p, err = startNode(w, coinbase, dir, port, fport, privateKey)
+	node, p2p, err = startNode(w, coinbase, dir, port, privateKey)
 	{
 		autoCmd := &ishell.Cmd{
 			Name: "node",
@@ -179,7 +178,7 @@ func main() {
 					c.Println("node has started.")
 					return
 				}
-				node, p2p, err = startNode(w, coinbase, dir, port, fport, privateKey)
+				node, p2p, err = startNode(w, coinbase, dir, port, privateKey)
 				if err != nil {
 					c.Err(err)
 					return
@@ -740,7 +739,7 @@ func main() {
 	// run shell
 	shell.Run()
 }
-func startNode(w *wallet.Manager, tmp *types.Address, baseDir string, port, fport uint, priv string) (*vite.Vite, *p2p.Server, error) {
+func startNode(w *wallet.Manager, tmp *types.Address, baseDir string, port uint, priv string) (*vite.Vite, *p2p.Server, error) {
 	var prv ed25519.PrivateKey
 
 	if priv != "" {
@@ -778,8 +777,7 @@ func startNode(w *wallet.Manager, tmp *types.Address, baseDir string, port, fpor
 		},
 		Vm: &config.Vm{IsVmTest: true, IsUseVmTestParam: true},
 		Net: &config.Net{
-			Single:   false,
-			FilePort: uint16(fport),
+			Single: false,
 		},
 		LogLevel: "debug",
 	}
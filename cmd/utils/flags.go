@@ -22,6 +22,21 @@ var (
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
 
+	GenesisFileFlag = cli.StringFlag{
+		Name:  "genesisfile",
+		Usage: "Json spec file of the genesis blocks (default = mainnet genesis)",
+	}
+
+	PruneHeightsFlag = cli.Uint64Flag{
+		Name:  "pruneheights",
+		Usage: "Keep the account-state tries of the latest N snapshot heights and prune older ones (0 = no pruning, minimum 2678400)",
+	}
+
+	TxHistoryIndexFlag = cli.BoolFlag{
+		Name:  "txhistoryindex",
+		Usage: "Index transfers by the receiving address, blocks inserted before enabling it aren't indexed",
+	}
+
 	// Network Settings
 	TestNetFlag = cli.BoolFlag{
 		Name:  "testnet",
@@ -38,6 +53,16 @@ var (
 		Usage: "Rinkeby network: pre-configured proof-of-authority prod network",
 	}
 
+	DevFlag = cli.BoolFlag{
+		Name:  "dev",
+		Usage: "Single node developer mode: the dev account is funded and produces snapshot blocks on demand",
+	}
+
+	DevIntervalFlag = cli.Int64Flag{
+		Name:  "devinterval",
+		Usage: "Interval in seconds of producing snapshot blocks in dev mode (0 = on demand only)",
+	}
+
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity", //mapping:p2p.Name
 		Usage: "Custom node name",
@@ -161,9 +186,27 @@ var (
 		Usage: "Enable the NodeServer single ",
 	}
 
-	FilePortFlag = cli.IntFlag{
-		Name:  "fileport",
-		Usage: "File transfer listening port",
+	LightFlag = cli.BoolFlag{
+		Name:  "light",
+		Usage: "Run as a light client, follow snapshot headers and the account chains of LightAddresses only",
+	}
+
+	//Export
+	ExportFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The first snapshot height to export",
+		Value: 1,
+	}
+
+	ExportToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The last snapshot height to export (0 = the latest snapshot block)",
+	}
+
+	//Db
+	DbRepairFlag = cli.BoolFlag{
+		Name:  "repair",
+		Usage: "Repair the inconsistencies found by rolling back to the last consistent snapshot block and rebuilding indexes",
 	}
 
 	//Stat
@@ -176,6 +219,19 @@ var (
 		Name:  "pprofport",
 		Usage: "pporof visit `port`, you can visit the address[http://localhost:`port`/debug/pprof]",
 	}
+
+	MetricsEnabledFlag = cli.BoolFlag{
+		Name:  "metrics",
+		Usage: "Enable the metrics server, Prometheus can scrape the address[http://localhost:48133/metrics]",
+	}
+	MetricsListenAddrFlag = cli.StringFlag{
+		Name:  "metricsaddr",
+		Usage: "Metrics server listening interface",
+	}
+	MetricsPortFlag = cli.IntFlag{
+		Name:  "metricsport",
+		Usage: "Metrics server listening port",
+	}
 )
 
 // This allows the use of the existing configuration functionality.
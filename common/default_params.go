@@ -14,6 +14,9 @@ const (
 	DefaultWSHost   = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort   = 31420       // Default TCP port for the websocket RPC server
 	DefaultP2PPort  = 8483
+
+	DefaultMetricsHost = "localhost" // Default host interface for the metrics server
+	DefaultMetricsPort = 48133       // Default TCP port for the metrics server
 )
 
 // DefaultDataDir is  $HOME/viteisbest/
@@ -97,7 +97,15 @@ func BlockParser(reader io.Reader, blockNum uint64, processor blockProcessor) {
 			}
 		}
 
-		if rErr == io.EOF || (blockNum > 0 && blockParser.hasReadBlocks >= blockNum) {
+		if rErr == io.EOF {
+			// the stream is truncated
+			if len(blockParser.currentBlockSizeBuffer) > 0 {
+				processor(nil, io.ErrUnexpectedEOF)
+			}
+			return
+		}
+
+		if blockNum > 0 && blockParser.hasReadBlocks >= blockNum {
 			return
 		}
 	}
@@ -5,7 +5,26 @@ type KafkaProducer struct {
 	Topic      string
 }
 
+type Sink struct {
+	// Type is one of "file", "webhook" and "unix"
+	Type string
+	// Target is the directory of file sink, the url of webhook sink, or the socket path of unix sink
+	Target string
+}
+
 type Chain struct {
 	KafkaProducers []*KafkaProducer
+	Sinks          []*Sink
 	OpenBlackBlock bool
+
+	// PruneHeights is the number of latest snapshot heights whose account-state tries are kept,
+	// older tries are pruned in the background. 0 disables pruning, it is at least chain.MinTrieGcRetainHeights
+	PruneHeights uint64
+
+	// TxHistoryIndex indexes the send and receive blocks by the address they transfer to,
+	// only the blocks inserted while it is enabled are indexed
+	TxHistoryIndex bool
+
+	// Genesis is the spec of the genesis blocks, the mainnet genesis is used if nil
+	Genesis *Genesis
 }
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// Genesis is the spec of the genesis blocks. A nil section is filled with the
+// mainnet default, amounts are decimal strings in the smallest unit of the token.
+type Genesis struct {
+	// Timestamp of the genesis snapshot block, in unix seconds
+	Timestamp int64 `json:"Timestamp"`
+
+	Mintage *GenesisMintage `json:"Mintage"`
+
+	// Balances are sent from the mintage contract, their sum must equal the total supply
+	Balances []*GenesisBalance `json:"Balances"`
+
+	ConsensusGroups []*GenesisConsensusGroup `json:"ConsensusGroups"`
+
+	Registrations []*GenesisRegistration `json:"Registrations"`
+
+	Votes []*GenesisVote `json:"Votes"`
+
+	// ContractParams replace the params of the built-in contracts chosen by IsUseVmTestParam if not nil
+	ContractParams *GenesisContractParams `json:"ContractParams,omitempty"`
+}
+
+type GenesisMintage struct {
+	TokenName   string        `json:"TokenName"`
+	TokenSymbol string        `json:"TokenSymbol"`
+	Decimals    uint8         `json:"Decimals"`
+	TotalSupply string        `json:"TotalSupply"`
+	Owner       types.Address `json:"Owner"`
+}
+
+type GenesisBalance struct {
+	Address types.Address `json:"Address"`
+	Amount  string        `json:"Amount"`
+}
+
+type GenesisConsensusGroup struct {
+	Gid             types.Gid         `json:"Gid"`
+	NodeCount       uint8             `json:"NodeCount"`
+	Interval        int64             `json:"Interval"`
+	PerCount        int64             `json:"PerCount"`
+	RandCount       uint8             `json:"RandCount"`
+	RandRank        uint8             `json:"RandRank"`
+	CountingTokenId types.TokenTypeId `json:"CountingTokenId"`
+	Owner           types.Address     `json:"Owner"`
+
+	// register condition of pledge
+	RegisterPledgeAmount string            `json:"RegisterPledgeAmount"`
+	RegisterPledgeToken  types.TokenTypeId `json:"RegisterPledgeToken"`
+	RegisterPledgeHeight uint64            `json:"RegisterPledgeHeight"`
+}
+
+type GenesisRegistration struct {
+	Gid        types.Gid     `json:"Gid"`
+	Name       string        `json:"Name"`
+	NodeAddr   types.Address `json:"NodeAddr"`
+	PledgeAddr types.Address `json:"PledgeAddr"`
+}
+
+type GenesisVote struct {
+	Gid      types.Gid     `json:"Gid"`
+	Addr     types.Address `json:"Addr"`
+	NodeName string        `json:"NodeName"`
+}
+
+type GenesisContractParams struct {
+	// heights are counted in snapshot blocks
+	MinPledgeHeight                  uint64 `json:"MinPledgeHeight"`
+	CreateConsensusGroupPledgeHeight uint64 `json:"CreateConsensusGroupPledgeHeight"`
+	MintagePledgeHeight              uint64 `json:"MintagePledgeHeight"`
+	// in seconds
+	RewardEndTimeLimit uint64 `json:"RewardEndTimeLimit"`
+	RewardTimeUnit     uint64 `json:"RewardTimeUnit"`
+
+	PledgeAmountMin                  string `json:"PledgeAmountMin"`
+	MintageFee                       string `json:"MintageFee"`
+	MintagePledgeAmount              string `json:"MintagePledgeAmount"`
+	CreateConsensusGroupPledgeAmount string `json:"CreateConsensusGroupPledgeAmount"`
+	RewardPerBlock                   string `json:"RewardPerBlock"`
+}
+
+func LoadGenesis(file string) (*Genesis, error) {
+	text, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	genesis := &Genesis{}
+	if err := json.Unmarshal(text, genesis); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
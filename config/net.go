@@ -2,9 +2,17 @@ package config
 
 type Net struct {
 	Single       bool     `json:"Single"`
-	FilePort     uint16   `json:"Port"`
 	Topology     []string `json:"Topology"`
 	Topic        string   `json:"Topic"`
 	Interval     int64    `json:"Interval"`
 	TopoDisabled bool     `json:"TopoDisabled"`
+
+	// light client, follow snapshot headers and the account chains of LightAddresses only
+	Light          bool     `json:"Light"`
+	LightAddresses []string `json:"LightAddresses"`
+
+	// reputation, zero values use the defaults
+	Penalties   map[string]int `json:"Penalties"`   // offence name to penalty, eg. {"timeout": 10}
+	BanScore    int            `json:"BanScore"`    // peer will be banned when its score reach BanScore
+	BanDuration int64          `json:"BanDuration"` // second
 }
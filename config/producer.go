@@ -4,6 +4,11 @@ type Producer struct {
 	Producer         bool   `json:"Producer"`
 	Coinbase         string `json:"Coinbase"`
 	EntropyStorePath string `json:"EntropyStorePath"`
+
+	// Dev is the single node mode, coinbase produces snapshot blocks whenever account blocks are pending
+	Dev bool `json:"Dev"`
+	// DevInterval is the interval in seconds of producing snapshot blocks in dev mode, 0 for on demand only
+	DevInterval int64 `json:"DevInterval"`
 }
 
 //func MergeMinerConfig(cfg *Miner) *Miner {
@@ -348,7 +348,7 @@ func copyMap(m *sync.Map) map[string]*subscribeEvent {
 }
 
 func (self *committee) event(e *subscribeEvent, result *electionResult) {
-	self.wg.Add(1)
+	// wg is added by update before the goroutine starts
 	defer self.wg.Done()
 	if e.addr == nil {
 		// all
@@ -0,0 +1,85 @@
+package ed25519
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519/internal/edwards25519"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/curve25519"
+)
+
+// X25519Size is the size, in bytes, of X25519 scalars and points.
+const X25519Size = 32
+
+var errX25519LowOrder = errors.New("x25519: low order point")
+var errX25519InvalidKey = errors.New("x25519: invalid public key")
+
+// ToX25519 returns the X25519 scalar of priv, it is the clamped secret scalar of the Ed25519 key,
+// so the X25519 public key derived from it is PublicKey.ToX25519 of the same key pair.
+func (priv PrivateKey) ToX25519() []byte {
+	if l := len(priv); l != PrivateKeySize {
+		panic("ed25519: bad private key length: " + strconv.Itoa(l))
+	}
+
+	digest := blake2b.Sum512(priv[:32])
+	digest[0] &= 248
+	digest[31] &= 127
+	digest[31] |= 64
+
+	scalar := make([]byte, X25519Size)
+	copy(scalar, digest[:32])
+	return scalar
+}
+
+// ToX25519 converts the Edwards point pub to the Montgomery u-coordinate: u = (1 + y) / (1 - y)
+func (pub PublicKey) ToX25519() ([]byte, error) {
+	if l := len(pub); l != PublicKeySize {
+		return nil, errX25519InvalidKey
+	}
+
+	var A edwards25519.ExtendedGroupElement
+	var pubBytes [32]byte
+	copy(pubBytes[:], pub)
+	if !A.FromBytes(&pubBytes) {
+		return nil, errX25519InvalidKey
+	}
+
+	// y = Y / Z
+	var y, one, n, d, u edwards25519.FieldElement
+	edwards25519.FeInvert(&d, &A.Z)
+	edwards25519.FeMul(&y, &A.Y, &d)
+
+	edwards25519.FeOne(&one)
+	edwards25519.FeAdd(&n, &one, &y)
+	edwards25519.FeSub(&d, &one, &y)
+	edwards25519.FeInvert(&d, &d)
+	edwards25519.FeMul(&u, &n, &d)
+
+	var out [32]byte
+	edwards25519.FeToBytes(&out, &u)
+	return out[:], nil
+}
+
+// X25519 multiplies point by scalar as defined in RFC 7748, it returns an error if the result is zero,
+// which means point is of low order.
+func X25519(scalar, point []byte) ([]byte, error) {
+	if len(scalar) != X25519Size || len(point) != X25519Size {
+		return nil, errors.New("x25519: bad scalar or point length")
+	}
+
+	var dst, k, u [32]byte
+	copy(k[:], scalar)
+	copy(u[:], point)
+	curve25519.ScalarMult(&dst, &k, &u)
+
+	var acc byte
+	for _, v := range dst {
+		acc |= v
+	}
+	if acc == 0 {
+		return nil, errX25519LowOrder
+	}
+
+	return dst[:], nil
+}
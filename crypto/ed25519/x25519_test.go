@@ -0,0 +1,74 @@
+package ed25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// test vectors from RFC 7748 section 5.2
+func TestX25519(t *testing.T) {
+	vectors := []struct {
+		scalar, point, out string
+	}{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	}
+
+	for _, v := range vectors {
+		scalar, _ := hex.DecodeString(v.scalar)
+		point, _ := hex.DecodeString(v.point)
+		out, err := X25519(scalar, point)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(out) != v.out {
+			t.Fatalf("should be %s, got %x", v.out, out)
+		}
+	}
+
+	if _, err := X25519(make([]byte, X25519Size), make([]byte, X25519Size)); err != errX25519LowOrder {
+		t.Fatalf("zero point should be rejected: %v", err)
+	}
+}
+
+func TestPrivateKey_ToX25519(t *testing.T) {
+	pubA, privA, _ := GenerateKey(nil)
+	pubB, privB, _ := GenerateKey(nil)
+
+	base := make([]byte, X25519Size)
+	base[0] = 9
+
+	uA, err := pubA.ToX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := X25519(privA.ToX25519(), base); !bytes.Equal(u, uA) {
+		t.Fatalf("x25519 public key should be %x, got %x", uA, u)
+	}
+
+	uB, err := pubB.ToX25519()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s1, err := X25519(privA.ToX25519(), uB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := X25519(privB.ToX25519(), uA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s1, s2) {
+		t.Fatalf("shared secret not equal: %x %x", s1, s2)
+	}
+}
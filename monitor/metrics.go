@@ -0,0 +1,369 @@
+package monitor
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// DefBuckets are the default histogram buckets in seconds, from 1ms to 10s
+var DefBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// DefaultRegistry collects the metrics of the node, it is exposed by Handler
+var DefaultRegistry = NewRegistry()
+
+// float64 updated atomically
+type atomicFloat struct {
+	bits uint64
+}
+
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		if atomic.CompareAndSwapUint64(&f.bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (f *atomicFloat) set(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+func (f *atomicFloat) get() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// Counter is a value only increases, it is exposed as `name_total`
+type Counter struct {
+	v atomicFloat
+}
+
+func (c *Counter) Inc() {
+	c.v.add(1)
+}
+
+// Add adds v to the counter, negative v is ignored
+func (c *Counter) Add(v float64) {
+	if v > 0 {
+		c.v.add(v)
+	}
+}
+
+func (c *Counter) Value() float64 {
+	return c.v.get()
+}
+
+// Gauge is a value can go up and down
+type Gauge struct {
+	v atomicFloat
+}
+
+func (g *Gauge) Set(v float64) {
+	g.v.set(v)
+}
+
+func (g *Gauge) Add(v float64) {
+	g.v.add(v)
+}
+
+func (g *Gauge) Inc() {
+	g.v.add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.v.add(-1)
+}
+
+func (g *Gauge) Value() float64 {
+	return g.v.get()
+}
+
+// Histogram counts observed values in buckets, buckets are upper bounds in increasing order
+type Histogram struct {
+	buckets []float64
+	counts  []uint64 // not cumulative, the last one is +Inf
+	count   uint64
+	sum     atomicFloat
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	atomic.AddUint64(&h.counts[i], 1)
+	h.sum.add(v)
+	atomic.AddUint64(&h.count, 1)
+}
+
+// ObserveSince observes the seconds elapsed since start
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+// Count returns the number of observed values
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+func (h *Histogram) Sum() float64 {
+	return h.sum.get()
+}
+
+// cumulative counts of buckets, the last one is +Inf
+func (h *Histogram) cumulative() []uint64 {
+	counts := make([]uint64, len(h.counts))
+	var total uint64
+	for i := range h.counts {
+		total += atomic.LoadUint64(&h.counts[i])
+		counts[i] = total
+	}
+	return counts
+}
+
+// family is a metric with its labeled children
+type family struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+
+	mu       sync.RWMutex
+	children map[string]*child
+	newValue func() interface{}
+
+	fn func() float64 // gauge evaluated on collecting
+}
+
+type child struct {
+	labelValues []string
+	value       interface{} // *Counter, *Gauge or *Histogram
+}
+
+func (f *family) with(labelValues []string) interface{} {
+	if len(labelValues) != len(f.labelNames) {
+		panic("metric " + f.name + " expects " + strings.Join(f.labelNames, ",") + " labels")
+	}
+	key := strings.Join(labelValues, "\xff")
+
+	f.mu.RLock()
+	c, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return c.value
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok = f.children[key]; !ok {
+		c = &child{
+			labelValues: append([]string(nil), labelValues...),
+			value:       f.newValue(),
+		}
+		f.children[key] = c
+	}
+	return c.value
+}
+
+// sorted children to make the output stable
+func (f *family) sortedChildren() []*child {
+	f.mu.RLock()
+	children := make([]*child, 0, len(f.children))
+	for _, c := range f.children {
+		children = append(children, c)
+	}
+	f.mu.RUnlock()
+
+	sort.Slice(children, func(i, j int) bool {
+		a, b := children[i].labelValues, children[j].labelValues
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+	return children
+}
+
+// CounterVec is a set of counters partitioned by label values
+type CounterVec struct {
+	f *family
+}
+
+func (v *CounterVec) WithLabelValues(labelValues ...string) *Counter {
+	return v.f.with(labelValues).(*Counter)
+}
+
+// GaugeVec is a set of gauges partitioned by label values
+type GaugeVec struct {
+	f *family
+}
+
+func (v *GaugeVec) WithLabelValues(labelValues ...string) *Gauge {
+	return v.f.with(labelValues).(*Gauge)
+}
+
+// HistogramVec is a set of histograms partitioned by label values
+type HistogramVec struct {
+	f *family
+}
+
+func (v *HistogramVec) WithLabelValues(labelValues ...string) *Histogram {
+	return v.f.with(labelValues).(*Histogram)
+}
+
+// Registry holds metrics by name. Metrics are registered once and the same metric is returned if
+// it is registered again, so they can be declared as package variables.
+type Registry struct {
+	mu       sync.RWMutex
+	families map[string]*family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.families[f.name]; ok {
+		if old.typ != f.typ || len(old.labelNames) != len(f.labelNames) || (old.fn == nil) != (f.fn == nil) {
+			panic("metric " + f.name + " is registered with a different type")
+		}
+		if f.fn != nil {
+			old.fn = f.fn
+		}
+		return old
+	}
+
+	f.children = make(map[string]*child)
+	r.families[f.name] = f
+	return f
+}
+
+// Unregister removes the metric, eg. the gauges evaluated on a stopped module
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.families, name)
+}
+
+func (r *Registry) sortedFamilies() []*family {
+	r.mu.RLock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+	return families
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{r.register(&family{
+		name:       name,
+		help:       help,
+		typ:        typeCounter,
+		labelNames: labelNames,
+		newValue:   func() interface{} { return &Counter{} },
+	})}
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{r.register(&family{
+		name:       name,
+		help:       help,
+		typ:        typeGauge,
+		labelNames: labelNames,
+		newValue:   func() interface{} { return &Gauge{} },
+	})}
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+// NewGaugeFunc registers a gauge whose value is fn() on collecting, fn replaces the registered one
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&family{
+		name: name,
+		help: help,
+		typ:  typeGauge,
+		fn:   fn,
+	})
+}
+
+// NewHistogramVec registers histograms with buckets, DefBuckets is used if buckets is nil
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	return &HistogramVec{r.register(&family{
+		name:       name,
+		help:       help,
+		typ:        typeHistogram,
+		labelNames: labelNames,
+		newValue:   func() interface{} { return newHistogram(buckets) },
+	})}
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labelNames...)
+}
+
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labelNames...)
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, fn)
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets)
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labelNames...)
+}
+
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
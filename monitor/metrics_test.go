@@ -0,0 +1,86 @@
+package monitor
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	counter := r.NewCounterVec("test_requests", "Requests handled", "method")
+	counter.WithLabelValues("b").Inc()
+	counter.WithLabelValues(`a"b`).Add(2)
+	counter.WithLabelValues("b").Add(-1) // ignored
+
+	// registered again by another module
+	if r.NewCounterVec("test_requests", "Requests handled", "method").WithLabelValues("b").Value() != 1 {
+		t.Fatal("the registered counter should be returned")
+	}
+
+	gauge := r.NewGauge("test_queue", "")
+	gauge.Set(5)
+	gauge.Dec()
+
+	height := uint64(10)
+	r.NewGaugeFunc("test_height", "Latest height", func() float64 {
+		return float64(height)
+	})
+	height++
+
+	h := r.NewHistogram("test_latency_seconds", "Latency", []float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.1)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	buf := new(bytes.Buffer)
+	if err := r.WriteOpenMetrics(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# TYPE test_height gauge
+# HELP test_height Latest height
+test_height 11
+# TYPE test_latency_seconds histogram
+# HELP test_latency_seconds Latency
+test_latency_seconds_bucket{le="0.1"} 2
+test_latency_seconds_bucket{le="1"} 3
+test_latency_seconds_bucket{le="+Inf"} 4
+test_latency_seconds_count 4
+test_latency_seconds_sum 3.65
+# TYPE test_queue gauge
+test_queue 4
+# TYPE test_requests counter
+# HELP test_requests Requests handled
+test_requests_total{method="a\"b"} 2
+test_requests_total{method="b"} 1
+# EOF
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	r.Unregister("test_height")
+	buf.Reset()
+	r.WriteOpenMetrics(buf)
+	if strings.Contains(buf.String(), "test_height") {
+		t.Fatal("unregistered metric should not be written")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	LogEvent("test", "handler")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != OpenMetricsContentType {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), `gvite_monitor_events_total{group="test",name="handler"} 1`) {
+		t.Fatalf("logged event should be exported:\n%s", w.Body.String())
+	}
+}
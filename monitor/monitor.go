@@ -43,6 +43,20 @@ var m *monitor
 
 var logger log15.Logger
 
+// the logged values are exported by the registry too
+var (
+	eventCount = NewCounterVec("gvite_monitor_events", "Number of the events logged by LogEvent, LogTime and LogDuration", "group", "name")
+	eventSum   = NewCounterVec("gvite_monitor_event_values", "Sum of the values logged, nanoseconds for LogTime", "group", "name")
+
+	// the counters of an event by its key, not to look up the vectors on every log
+	eventCounters sync.Map
+)
+
+type eventCounter struct {
+	count *Counter
+	sum   *Counter
+}
+
 type monitor struct {
 	ms sync.Map
 	r  *ring
@@ -101,6 +115,16 @@ func LogDuration(t string, name string, duration int64) {
 
 func log(t string, name string, i int64) {
 	k := key(t, name)
+	counter, ok := eventCounters.Load(k)
+	if !ok {
+		counter, _ = eventCounters.LoadOrStore(k, &eventCounter{
+			count: eventCount.WithLabelValues(t, name),
+			sum:   eventSum.WithLabelValues(t, name),
+		})
+	}
+	counter.(*eventCounter).count.Inc()
+	counter.(*eventCounter).sum.Add(float64(i))
+
 	value, ok := m.ms.Load(k)
 	if ok {
 		value.(*Msg).add(i)
@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// OpenMetricsContentType is the content type of the text exposition format
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, value string) {
+	w.WriteString(name)
+	if len(labelNames) > 0 {
+		w.WriteByte('{')
+		for i, labelName := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(labelName)
+			w.WriteString(`="`)
+			w.WriteString(labelValueEscaper.Replace(labelValues[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(value)
+	w.WriteByte('\n')
+}
+
+func (f *family) write(w *bufio.Writer) {
+	w.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+	if f.help != "" {
+		w.WriteString("# HELP " + f.name + " " + strings.Replace(f.help, "\n", `\n`, -1) + "\n")
+	}
+
+	if f.fn != nil {
+		writeSample(w, f.name, nil, nil, formatFloat(f.fn()))
+		return
+	}
+
+	for _, c := range f.sortedChildren() {
+		switch v := c.value.(type) {
+		case *Counter:
+			writeSample(w, f.name+"_total", f.labelNames, c.labelValues, formatFloat(v.Value()))
+		case *Gauge:
+			writeSample(w, f.name, f.labelNames, c.labelValues, formatFloat(v.Value()))
+		case *Histogram:
+			labelNames := append(append([]string(nil), f.labelNames...), "le")
+			labelValues := append(append([]string(nil), c.labelValues...), "")
+
+			counts := v.cumulative()
+			for i, count := range counts {
+				if i < len(v.buckets) {
+					labelValues[len(labelValues)-1] = formatFloat(v.buckets[i])
+				} else {
+					labelValues[len(labelValues)-1] = "+Inf"
+				}
+				writeSample(w, f.name+"_bucket", labelNames, labelValues, strconv.FormatUint(count, 10))
+			}
+			// count is the +Inf bucket, so they are consistent during observing
+			writeSample(w, f.name+"_count", f.labelNames, c.labelValues, strconv.FormatUint(counts[len(counts)-1], 10))
+			writeSample(w, f.name+"_sum", f.labelNames, c.labelValues, formatFloat(v.Sum()))
+		}
+	}
+}
+
+// WriteOpenMetrics writes all metrics in the OpenMetrics text format
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range r.sortedFamilies() {
+		f.write(bw)
+	}
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+// Handler serves the metrics for Prometheus scraping
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+		r.WriteOpenMetrics(w)
+	})
+}
+
+// Handler serves the metrics of DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/p2p"
 	"github.com/vitelabs/go-vite/p2p/network"
+	"github.com/vitelabs/go-vite/rpcapi"
 	"github.com/vitelabs/go-vite/wallet"
 )
 
@@ -26,9 +27,21 @@ type Config struct {
 	// template：["broker1,broker2,...|topic",""]
 	KafkaProducers []string `json:"KafkaProducers"`
 
+	// template：["file|/path/to/dir", "webhook|http://127.0.0.1:8080/events", "unix|/path/to/socket"]
+	Sinks []string `json:"Sinks"`
+
 	// chain
 	OpenBlackBlock bool `json:"OpenBlackBlock"`
 
+	// json spec of the genesis blocks, the mainnet genesis is used if empty
+	GenesisFile string `json:"GenesisFile"`
+
+	// keep the account-state tries of the latest N snapshot heights, 0 disables pruning
+	PruneHeights uint64 `json:"PruneHeights"`
+
+	// index the transfers by the receiving address for ledger_getTransfersByAddress
+	TxHistoryIndex bool `json:"TxHistoryIndex"`
+
 	// p2p
 	NetSelect            string
 	Identity             string   `json:"Identity"`
@@ -38,9 +51,12 @@ type Config struct {
 	MaxPendingPeers      uint     `json:"MaxPendingPeers"`
 	BootNodes            []string `json:"BootNodes"`
 	StaticNodes          []string `json:"StaticNodes"`
+	TrustedNodes         []string `json:"TrustedNodes"` // NodeIDs can be connected even if peers are too many
 	Port                 uint     `json:"Port"`
 	NetID                uint     `json:"NetID"`
 	Discovery            bool     `json:"Discovery"`
+	Whitelist            []string `json:"Whitelist"` // NodeIDs or IPs can't be banned
+	Blacklist            []string `json:"Blacklist"` // NodeIDs or IPs never be connected
 
 	//producer
 	EntropyStorePath     string `json:"EntropyStorePath"`
@@ -49,6 +65,10 @@ type Config struct {
 	MinerEnabled         bool   `json:"Miner"`
 	MinerInterval        int    `json:"MinerInterval"`
 
+	// dev mode: single node, the dev key produces snapshot blocks on demand
+	Dev         bool  `json:"Dev"`
+	DevInterval int64 `json:"DevInterval"`
+
 	//rpc
 	RPCEnabled bool `json:"RPCEnabled"`
 	IPCEnabled bool `json:"IPCEnabled"`
@@ -71,6 +91,9 @@ type Config struct {
 
 	PowServerUrl string `json:"PowServerUrl”`
 
+	// authentication, permissions and rate limits of HTTP and WS, nil allows all requests
+	RPCAccess *rpcapi.AccessConfig `json:"RPCAccess"`
+
 	//Log level
 	LogLevel    string `json:"LogLevel"`
 	ErrorLogDir string `json:"ErrorLogDir"`
@@ -81,11 +104,27 @@ type Config struct {
 
 	//Net TODO: cmd after ？
 	Single                 bool     `json:"Single"`
-	FilePort               int      `json:"FilePort"`
 	Topology               []string `json:"Topology"`
 	TopologyTopic          string   `json:"TopologyTopic"`
 	TopologyReportInterval int      `json:"TopologyReportInterval"`
 	TopoDisabled           bool     `json:"TopoDisabled"`
+	Light                  bool     `json:"Light"`
+	LightAddresses         []string `json:"LightAddresses"`
+
+	// peer reputation
+	Penalties   map[string]int `json:"Penalties"`
+	BanScore    int            `json:"BanScore"`
+	BanDuration int64          `json:"BanDuration"` // second
+
+	// thresholds of /ready on the HTTP-RPC endpoint
+	ReadyMaxSnapshotAge int64  `json:"ReadyMaxSnapshotAge"` // second, 0 disables the check
+	ReadyMinPeers       uint   `json:"ReadyMinPeers"`       // not checked in single mode
+	ReadyMaxMissedSlots uint32 `json:"ReadyMaxMissedSlots"` // checked if the node is a producer
+
+	// metrics are served at http://MetricsHost:MetricsPort/metrics
+	MetricsEnabled bool   `json:"MetricsEnabled"`
+	MetricsHost    string `json:"MetricsHost"`
+	MetricsPort    int    `json:"MetricsPort"`
 }
 
 func (c *Config) makeWalletConfig() *wallet.Config {
@@ -105,12 +144,16 @@ func (c *Config) makeViteConfig() *config.Config {
 
 func (c *Config) makeNetConfig() *config.Net {
 	return &config.Net{
-		Single:       c.Single,
-		FilePort:     uint16(c.FilePort),
-		Topology:     c.Topology,
-		Topic:        c.TopologyTopic,
-		Interval:     int64(c.TopologyReportInterval),
-		TopoDisabled: c.TopoDisabled,
+		Single:         c.Single || c.Dev,
+		Topology:       c.Topology,
+		Topic:          c.TopologyTopic,
+		Interval:       int64(c.TopologyReportInterval),
+		TopoDisabled:   c.TopoDisabled,
+		Light:          c.Light,
+		LightAddresses: c.LightAddresses,
+		Penalties:      c.Penalties,
+		BanScore:       c.BanScore,
+		BanDuration:    c.BanDuration,
 	}
 }
 
@@ -126,6 +169,8 @@ func (c *Config) makeMinerConfig() *config.Producer {
 		Producer:         c.MinerEnabled,
 		Coinbase:         c.CoinBase,
 		EntropyStorePath: c.EntropyStorePath,
+		Dev:              c.Dev,
+		DevInterval:      c.DevInterval,
 	}
 }
 
@@ -141,16 +186,48 @@ func (c *Config) makeP2PConfig() *p2p.Config {
 		PrivateKey:      c.GetPrivateKey(),
 		BootNodes:       c.BootNodes,
 		StaticNodes:     c.StaticNodes,
+		TrustedNodes:    c.TrustedNodes,
 		Discovery:       c.Discovery,
+		Whitelist:       c.Whitelist,
+		Blacklist:       c.Blacklist,
 	}
 }
 
 func (c *Config) makeChainConfig() *config.Chain {
 
+	// init sinks
+	var sinks []*config.Sink
+	for _, sink := range c.Sinks {
+		splitSink := strings.SplitN(sink, "|", 2)
+		if len(splitSink) != 2 || splitSink[1] == "" {
+			log.Warn(fmt.Sprintf("Sinks is setting error，The program will skip %s and continue processing", sink))
+			continue
+		}
+
+		sinks = append(sinks, &config.Sink{
+			Type:   splitSink[0],
+			Target: splitSink[1],
+		})
+	}
+
+	// load genesis
+	var genesis *config.Genesis
+	if c.GenesisFile != "" {
+		var err error
+		genesis, err = config.LoadGenesis(c.GenesisFile)
+		if err != nil {
+			log.Crit(fmt.Sprintf("GenesisFile %s can not be loaded, error is %s", c.GenesisFile, err.Error()))
+		}
+	}
+
 	if len(c.KafkaProducers) == 0 {
 		return &config.Chain{
 			KafkaProducers: nil,
+			Sinks:          sinks,
 			OpenBlackBlock: c.OpenBlackBlock,
+			PruneHeights:   c.PruneHeights,
+			TxHistoryIndex: c.TxHistoryIndex,
+			Genesis:        genesis,
 		}
 	}
 
@@ -178,7 +255,11 @@ func (c *Config) makeChainConfig() *config.Chain {
 END:
 	return &config.Chain{
 		KafkaProducers: kafkaProducers,
+		Sinks:          sinks,
 		OpenBlackBlock: c.OpenBlackBlock,
+		PruneHeights:   c.PruneHeights,
+		TxHistoryIndex: c.TxHistoryIndex,
+		Genesis:        genesis,
 	}
 }
 
@@ -196,6 +277,13 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+func (c *Config) MetricsEndpoint() string {
+	if !c.MetricsEnabled || c.MetricsHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.MetricsHost, c.MetricsPort)
+}
+
 func (c *Config) SetPrivateKey(privateKey string) {
 	c.PrivateKey = privateKey
 }
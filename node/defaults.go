@@ -15,6 +15,11 @@ var DefaultNodeConfig = Config{
 	KeyStoreDir:          DefaultDataDir(),
 	HttpPort:             common.DefaultHTTPPort,
 	WSPort:               common.DefaultWSPort,
+	MetricsHost:          common.DefaultMetricsHost,
+	MetricsPort:          common.DefaultMetricsPort,
+	ReadyMaxSnapshotAge:  60,
+	ReadyMinPeers:        1,
+	ReadyMaxMissedSlots:  3,
 	PrivateKey:           "",
 	MaxPeers:             0,
 	MaxPassivePeersRatio: 0,
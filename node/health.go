@@ -0,0 +1,128 @@
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vitelabs/go-vite/vite/net"
+)
+
+// HealthStatus is reported by /health and /ready on the HTTP-RPC endpoint
+type HealthStatus struct {
+	Ready   bool     `json:"ready"`
+	Reasons []string `json:"reasons,omitempty"` // why the node isn't ready
+
+	SyncState      string `json:"syncState"`
+	SnapshotHeight uint64 `json:"snapshotHeight"`
+	SnapshotAge    int64  `json:"snapshotAge"` // second, wall clock minus the timestamp of the latest snapshot block
+	Peers          uint   `json:"peers"`
+
+	// leveldb write stalls of the chain db since it was opened
+	WriteDelayCount int32  `json:"writeDelayCount"`
+	WriteDelay      string `json:"writeDelay"`
+	WritePaused     bool   `json:"writePaused"`
+
+	Producer *ProducerHealth `json:"producer,omitempty"` // nil if the node isn't a producer
+}
+
+type ProducerHealth struct {
+	MissedSlots uint32 `json:"missedSlots"` // slots missed since the last snapshot block produced
+	LastSlot    int64  `json:"lastSlot"`    // unix time of the end of the last slot, 0 if no slot has come
+}
+
+func (node *Node) healthHandlers() map[string]http.Handler {
+	// the status is read from the full chain and net
+	if node.viteServer.Light() != nil {
+		return nil
+	}
+	return map[string]http.Handler{
+		"/health": http.HandlerFunc(node.serveHealth),
+		"/ready":  http.HandlerFunc(node.serveReady),
+	}
+}
+
+// serveHealth reports the status, the node is alive if it responds
+func (node *Node) serveHealth(w http.ResponseWriter, r *http.Request) {
+	writeHealthStatus(w, http.StatusOK, node.healthStatus())
+}
+
+// serveReady responds 503 if the status doesn't meet the thresholds in Config
+func (node *Node) serveReady(w http.ResponseWriter, r *http.Request) {
+	writeReadyStatus(w, node.healthStatus())
+}
+
+func writeReadyStatus(w http.ResponseWriter, status *HealthStatus) {
+	if status.Ready {
+		writeHealthStatus(w, http.StatusOK, status)
+	} else {
+		writeHealthStatus(w, http.StatusServiceUnavailable, status)
+	}
+}
+
+func writeHealthStatus(w http.ResponseWriter, code int, status *HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+func (node *Node) healthStatus() *HealthStatus {
+	status := &HealthStatus{}
+
+	syncState := node.viteServer.Net().SyncState()
+	status.SyncState = syncState.String()
+
+	latest := node.viteServer.Chain().GetLatestSnapshotBlock()
+	status.SnapshotHeight = latest.Height
+	status.SnapshotAge = int64(time.Since(*latest.Timestamp) / time.Second)
+
+	status.Peers = node.p2pServer.PeersCount()
+
+	if writeDelay, err := node.viteServer.Chain().ChainDb().Db().GetProperty("leveldb.writedelay"); err == nil {
+		fmt.Sscanf(writeDelay, "DelayN:%d Delay:%s Paused:%t", &status.WriteDelayCount, &status.WriteDelay, &status.WritePaused)
+	}
+
+	if producer := node.viteServer.Producer(); producer != nil {
+		schedule := producer.Schedule()
+		status.Producer = &ProducerHealth{
+			MissedSlots: schedule.MissedSlots,
+		}
+		if !schedule.LastSlot.IsZero() {
+			status.Producer.LastSlot = schedule.LastSlot.Unix()
+		}
+	}
+
+	status.check(node.config, syncState)
+	return status
+}
+
+// check sets Ready and Reasons by the thresholds in cfg
+func (status *HealthStatus) check(cfg *Config, syncState net.SyncState) {
+	status.Reasons = nil
+	notReady := func(format string, args ...interface{}) {
+		status.Reasons = append(status.Reasons, fmt.Sprintf(format, args...))
+	}
+
+	if syncState != net.Syncdone {
+		notReady("sync state is %s", syncState)
+	}
+
+	if cfg.ReadyMaxSnapshotAge > 0 && status.SnapshotAge > cfg.ReadyMaxSnapshotAge {
+		notReady("latest snapshot block is %ds old, more than %ds", status.SnapshotAge, cfg.ReadyMaxSnapshotAge)
+	}
+
+	if !cfg.Single && !cfg.Dev && status.Peers < cfg.ReadyMinPeers {
+		notReady("%d peers connected, less than %d", status.Peers, cfg.ReadyMinPeers)
+	}
+
+	if status.WritePaused {
+		notReady("chain db writes are paused by compaction")
+	}
+
+	if status.Producer != nil && status.Producer.MissedSlots > cfg.ReadyMaxMissedSlots {
+		notReady("producer missed %d slots, more than %d", status.Producer.MissedSlots, cfg.ReadyMaxMissedSlots)
+	}
+
+	status.Ready = len(status.Reasons) == 0
+}
@@ -0,0 +1,109 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vitelabs/go-vite/vite/net"
+)
+
+func TestHealthStatusCheck(t *testing.T) {
+	cfg := &Config{
+		ReadyMaxSnapshotAge: 60,
+		ReadyMinPeers:       2,
+		ReadyMaxMissedSlots: 3,
+	}
+	single := *cfg
+	single.Single = true
+	dev := *cfg
+	dev.Dev = true
+	noAge := *cfg
+	noAge.ReadyMaxSnapshotAge = 0
+
+	tests := []struct {
+		name      string
+		cfg       *Config
+		syncState net.SyncState
+		status    HealthStatus
+		reasons   int
+	}{
+		{"ready", cfg, net.Syncdone, HealthStatus{Peers: 2}, 0},
+		{"not started", cfg, net.SyncNotStart, HealthStatus{Peers: 2}, 1},
+		{"syncing", cfg, net.Syncing, HealthStatus{Peers: 2}, 1},
+		{"downloaded", cfg, net.SyncDownloaded, HealthStatus{Peers: 2}, 1},
+		{"sync error", cfg, net.Syncerr, HealthStatus{Peers: 2}, 1},
+		{"no peer", cfg, net.Syncdone, HealthStatus{}, 1},
+		{"too few peers", cfg, net.Syncdone, HealthStatus{Peers: 1}, 1},
+		{"more peers", cfg, net.Syncdone, HealthStatus{Peers: 10}, 0},
+		{"single", &single, net.Syncdone, HealthStatus{}, 0},
+		{"dev", &dev, net.Syncdone, HealthStatus{}, 0},
+		{"syncing without peers", cfg, net.Syncing, HealthStatus{}, 2},
+		{"snapshot age at threshold", cfg, net.Syncdone, HealthStatus{Peers: 2, SnapshotAge: 60}, 0},
+		{"stale snapshot", cfg, net.Syncdone, HealthStatus{Peers: 2, SnapshotAge: 61}, 1},
+		{"age check disabled", &noAge, net.Syncdone, HealthStatus{Peers: 2, SnapshotAge: 3600}, 0},
+		{"write paused", cfg, net.Syncdone, HealthStatus{Peers: 2, WritePaused: true}, 1},
+		{"producer", cfg, net.Syncdone, HealthStatus{Peers: 2, Producer: &ProducerHealth{MissedSlots: 3}}, 0},
+		{"producer missed slots", cfg, net.Syncdone, HealthStatus{Peers: 2, Producer: &ProducerHealth{MissedSlots: 4}}, 1},
+	}
+
+	for _, test := range tests {
+		status := test.status
+		status.check(test.cfg, test.syncState)
+		if len(status.Reasons) != test.reasons || status.Ready != (test.reasons == 0) {
+			t.Errorf("%s: expected %d reasons, got ready %v, reasons %v", test.name, test.reasons, status.Ready, status.Reasons)
+		}
+	}
+}
+
+func TestHealthStatusTransition(t *testing.T) {
+	cfg := &Config{ReadyMinPeers: 1}
+	status := &HealthStatus{}
+
+	steps := []struct {
+		syncState net.SyncState
+		peers     uint
+		ready     bool
+	}{
+		{net.SyncNotStart, 0, false},
+		{net.Syncing, 1, false},
+		{net.Syncdone, 1, true},
+		// reasons of the previous check are not kept
+		{net.Syncdone, 0, false},
+		{net.Syncdone, 3, true},
+		{net.Syncing, 3, false},
+		{net.Syncdone, 3, true},
+	}
+
+	for i, step := range steps {
+		status.Peers = step.peers
+		status.check(cfg, step.syncState)
+		if status.Ready != step.ready {
+			t.Fatalf("step %d: %s with %d peers, expected ready %v, reasons %v", i, step.syncState, step.peers, step.ready, status.Reasons)
+		}
+	}
+}
+
+func TestWriteReadyStatus(t *testing.T) {
+	for _, ready := range []bool{true, false} {
+		w := httptest.NewRecorder()
+		writeReadyStatus(w, &HealthStatus{Ready: ready, SyncState: net.Syncdone.String()})
+
+		code := http.StatusOK
+		if !ready {
+			code = http.StatusServiceUnavailable
+		}
+		if w.Code != code {
+			t.Fatalf("ready %v: expected status code %d, got %d", ready, code, w.Code)
+		}
+
+		status := new(HealthStatus)
+		if err := json.NewDecoder(w.Body).Decode(status); err != nil {
+			t.Fatal(err)
+		}
+		if status.Ready != ready || status.SyncState != net.Syncdone.String() {
+			t.Fatalf("unexpected body %+v", status)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/vitelabs/go-vite/monitor"
+)
+
+// startMetrics serves the metrics registered in monitor at /metrics in the OpenMetrics text format.
+func (node *Node) startMetrics() error {
+	// Short circuit if the metrics endpoint isn't being exposed
+	if node.metricsEndpoint == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", node.metricsEndpoint)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", monitor.Handler())
+	node.metricsServer = &http.Server{Handler: mux}
+	go node.metricsServer.Serve(listener)
+
+	log.Info("Metrics endpoint opened", "url", fmt.Sprintf("http://%s/metrics", listener.Addr()))
+	return nil
+}
+
+// stopMetrics terminates the metrics endpoint.
+func (node *Node) stopMetrics() {
+	if node.metricsServer != nil {
+		node.metricsServer.Close()
+		node.metricsServer = nil
+		log.Info("Metrics endpoint closed", "url", fmt.Sprintf("http://%s/metrics", node.metricsEndpoint))
+	}
+}
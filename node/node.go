@@ -3,10 +3,12 @@ package node
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
 
+	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/cmd/utils/flock"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/log15"
@@ -56,6 +58,9 @@ type Node struct {
 	wsListener net.Listener
 	wsHandler  *rpc.Server
 
+	metricsEndpoint string
+	metricsServer   *http.Server
+
 	// Channel to wait for termination notifications
 	stop            chan struct{}
 	lock            sync.RWMutex
@@ -72,6 +77,8 @@ func New(conf *Config) (*Node, error) {
 		httpEndpoint: conf.HTTPEndpoint(),
 		wsEndpoint:   conf.WSEndpoint(),
 		stop:         make(chan struct{}),
+
+		metricsEndpoint: conf.MetricsEndpoint(),
 	}, nil
 }
 
@@ -132,7 +139,11 @@ func (node *Node) Prepare() error {
 	}
 
 	//Protocols setting, maybe should move into module.Start()
-	node.p2pServer.Protocols = append(node.p2pServer.Protocols, node.viteServer.Net().Protocols()...)
+	if light := node.viteServer.Light(); light != nil {
+		node.p2pServer.Protocols = append(node.p2pServer.Protocols, light.Protocols()...)
+	} else {
+		node.p2pServer.Protocols = append(node.p2pServer.Protocols, node.viteServer.Net().Protocols()...)
+	}
 
 	//init rpc_PowServerUrl
 	remote.InitRawUrl(node.Config().PowServerUrl)
@@ -146,6 +157,53 @@ func (node *Node) Prepare() error {
 	return nil
 }
 
+// OpenChain locks the data dir and opens the chain without the network and the other modules. The trie gc, the exporters
+// and the compressor are not started, so the chain is only written by the caller. It is used by the commands maintaining
+// the chain db, the chain is closed by CloseChain.
+func (node *Node) OpenChain() (chain.Chain, error) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+
+	if node.walletManager != nil {
+		return nil, ErrNodeRunning
+	}
+	if err := node.openDataDir(); err != nil {
+		return nil, err
+	}
+
+	// the wallet provides the dev genesis
+	node.walletManager = wallet.New(node.walletConfig)
+	if err := node.startWallet(); err != nil {
+		log.Error(fmt.Sprintf("startWallet error: %v", err))
+		return nil, err
+	}
+
+	cfg := *node.viteConfig
+	chainCfg := *cfg.Chain
+	chainCfg.KafkaProducers = nil
+	chainCfg.Sinks = nil
+	chainCfg.PruneHeights = 0
+	cfg.Chain = &chainCfg
+
+	c := chain.NewChain(&cfg)
+	c.Init()
+	c.Load()
+	return c, nil
+}
+
+// CloseChain closes the chain opened by OpenChain and unlocks the data dir
+func (node *Node) CloseChain(c chain.Chain) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+
+	c.Destroy()
+	node.stopWallet()
+	if node.instanceDirLock != nil {
+		node.instanceDirLock.Release()
+		node.instanceDirLock = nil
+	}
+}
+
 func (node *Node) Start() error {
 	node.lock.Lock()
 	defer node.lock.Unlock()
@@ -171,6 +229,13 @@ func (node *Node) Start() error {
 		return err
 	}
 
+	//metrics start
+	log.Info(fmt.Sprintf("Begin Start Metrics... "))
+	if err := node.startMetrics(); err != nil {
+		log.Error(fmt.Sprintf("Node startMetrics error: %v", err))
+		return err
+	}
+
 	return nil
 }
 
@@ -204,6 +269,10 @@ func (node *Node) Stop() error {
 		log.Error(fmt.Sprintf("Node stopRPC error: %v", err))
 	}
 
+	//metrics
+	log.Info(fmt.Sprintf("Begin Stop Metrics... "))
+	node.stopMetrics()
+
 	// Release instance directory lock.
 	log.Info(fmt.Sprintf("Begin relaeck dataDir lock... "))
 	if node.instanceDirLock != nil {
@@ -248,6 +317,10 @@ func (node *Node) WalletManager() *wallet.Manager {
 //wallet start
 func (node *Node) startWallet() error {
 	node.walletManager.Start()
+	//dev mode, the dev key is the producer and owns the genesis balance
+	if node.config.Dev {
+		return node.startDevWallet()
+	}
 	//unlock account
 	if node.config.EntropyStorePath != "" {
 
@@ -274,6 +347,27 @@ func (node *Node) startWallet() error {
 	return nil
 }
 
+func (node *Node) startDevWallet() error {
+	entropyStoreManager, err := node.walletManager.UnlockDevEntropyStore()
+	if err != nil {
+		log.Error(fmt.Sprintf("node.walletManager.UnlockDevEntropyStore error: %v", err))
+		return err
+	}
+
+	devAddr := entropyStoreManager.GetPrimaryAddr()
+	log.Warn(fmt.Sprintf("Dev mode is enabled, the dev account is %v", devAddr))
+
+	producerConfig := node.viteConfig.Producer
+	producerConfig.Producer = true
+	producerConfig.Coinbase = "0:" + devAddr.String()
+	producerConfig.EntropyStorePath = entropyStoreManager.GetEntropyStoreFile()
+
+	if node.viteConfig.Chain.Genesis == nil {
+		node.viteConfig.Chain.Genesis = chain.DevGenesisConfig(devAddr)
+	}
+	return nil
+}
+
 func (node *Node) startVite() error {
 	return node.viteServer.Start(node.p2pServer)
 }
@@ -296,12 +390,20 @@ func (node *Node) startRPC() error {
 		}
 	}
 
+	guard, err := node.rpcGuard()
+	if err != nil {
+		node.stopInProcess()
+		node.stopIPC()
+		return err
+	}
+
 	if node.config.RPCEnabled {
 		apis := rpcapi.GetPublicApis(node.viteServer)
-		if len(node.config.PublicModules) != 0 {
+		// the modules besides the light ones need the full chain
+		if len(node.config.PublicModules) != 0 && node.viteServer.Light() == nil {
 			apis = rpcapi.GetApis(node.viteServer, node.config.PublicModules...)
 		}
-		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll); err != nil {
+		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll, node.healthHandlers(), guard); err != nil {
 			node.stopInProcess()
 			node.stopIPC()
 			return err
@@ -310,10 +412,11 @@ func (node *Node) startRPC() error {
 
 	if node.config.WSEnabled {
 		apis := rpcapi.GetPublicApis(node.viteServer)
-		if len(node.config.PublicModules) != 0 {
+		// the modules besides the light ones need the full chain
+		if len(node.config.PublicModules) != 0 && node.viteServer.Light() == nil {
 			apis = rpcapi.GetApis(node.viteServer, node.config.PublicModules...)
 		}
-		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll); err != nil {
+		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll, guard); err != nil {
 			node.stopInProcess()
 			node.stopIPC()
 			node.stopHTTP()
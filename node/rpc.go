@@ -4,21 +4,31 @@ import (
 	"fmt"
 	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/rpcapi"
+	"net/http"
 	"strings"
 )
 
 //In-proc apis
 func (node *Node) GetInProcessApis() []rpc.API {
-	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx")
+	if node.viteServer.Light() != nil {
+		return append(rpcapi.GetLightApis(node.viteServer), rpcapi.GetApi(node.viteServer, "wallet"))
+	}
+	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "private_net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx")
 }
 
 //Ipc apis
 func (node *Node) GetIpcApis() []rpc.API {
-	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx")
+	if node.viteServer.Light() != nil {
+		return append(rpcapi.GetLightApis(node.viteServer), rpcapi.GetApi(node.viteServer, "wallet"))
+	}
+	return rpcapi.GetApis(node.viteServer, "ledger", "wallet", "private_onroad", "net", "private_net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx", "subscribe")
 }
 
 //Http apis
 func (node *Node) GetHttpApis() []rpc.API {
+	if node.viteServer.Light() != nil {
+		return rpcapi.GetLightApis(node.viteServer)
+	}
 	apiModules := []string{"ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "pow", "tx"}
 	if node.Config().NetID > 1 {
 		apiModules = append(apiModules, "testapi")
@@ -28,13 +38,28 @@ func (node *Node) GetHttpApis() []rpc.API {
 
 //WS apis
 func (node *Node) GetWSApis() []rpc.API {
-	apiModules := []string{"ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "pow", "tx"}
+	if node.viteServer.Light() != nil {
+		return rpcapi.GetLightApis(node.viteServer)
+	}
+	apiModules := []string{"ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "pow", "tx", "subscribe"}
 	if node.Config().NetID > 1 {
 		apiModules = append(apiModules, "testapi")
 	}
 	return rpcapi.GetApis(node.viteServer, apiModules...)
 }
 
+// rpcGuard checks the HTTP and WS requests by Config.RPCAccess, IPC and in-proc are trusted
+func (node *Node) rpcGuard() (rpc.Guard, error) {
+	if node.config.RPCAccess == nil {
+		return nil, nil
+	}
+	guard, err := rpcapi.NewAccessGuard(node.config.RPCAccess)
+	if err != nil {
+		return nil, err
+	}
+	return guard, nil
+}
+
 // startIPC initializes and starts the IPC RPC endpoint.
 func (node *Node) startIPC(apis []rpc.API) error {
 	if node.ipcEndpoint == "" {
@@ -64,13 +89,13 @@ func (node *Node) stopIPC() {
 	}
 }
 
-// startHTTP initializes and starts the HTTP RPC endpoint.
-func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool) error {
+// startHTTP initializes and starts the HTTP RPC endpoint, handlers serve the paths besides the RPC.
+func (node *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors []string, vhosts []string, timeouts rpc.HTTPTimeouts, exposeAll bool, handlers map[string]http.Handler, guard rpc.Guard) error {
 	// Short circuit if the HTTP endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll)
+	listener, handler, err := rpc.StartHTTPEndpoint(endpoint, apis, modules, cors, vhosts, timeouts, exposeAll, handlers, guard)
 	if err != nil {
 		return err
 	}
@@ -98,12 +123,12 @@ func (node *Node) stopHTTP() {
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
-func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool) error {
+func (node *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrigins []string, exposeAll bool, guard rpc.Guard) error {
 	// Short circuit if the WS endpoint isn't being exposed
 	if endpoint == "" {
 		return nil
 	}
-	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll)
+	listener, handler, err := rpc.StartWSEndpoint(endpoint, apis, modules, wsOrigins, exposeAll, guard)
 	if err != nil {
 		return err
 	}
@@ -9,11 +9,14 @@ import (
 	"github.com/vitelabs/go-vite/common/math"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/monitor"
 	"github.com/vitelabs/go-vite/onroad/model"
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"strconv"
 )
 
+var contractTasks = monitor.NewGaugeVec("gvite_onroad_contract_tasks", "Contract addresses waiting to receive onroad blocks", "gid")
+
 type ContractWorker struct {
 	manager *Manager
 
@@ -118,6 +121,7 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 
 			w.ctpMutex.Lock()
 			heap.Push(&w.contractTaskPQueue, c)
+			w.updateTaskGauge()
 			w.ctpMutex.Unlock()
 
 			w.NewOnroadTxAlarm()
@@ -232,6 +236,7 @@ func (w *ContractWorker) getAndSortAllAddrQuota() {
 	}
 
 	heap.Init(&w.contractTaskPQueue)
+	w.updateTaskGauge()
 }
 
 func (w *ContractWorker) NewOnroadTxAlarm() {
@@ -245,17 +250,24 @@ func (w *ContractWorker) pushContractTask(t *contractTask) {
 	w.ctpMutex.Lock()
 	defer w.ctpMutex.Unlock()
 	heap.Push(&w.contractTaskPQueue, t)
+	w.updateTaskGauge()
 }
 
 func (w *ContractWorker) popContractTask() *contractTask {
 	w.ctpMutex.Lock()
 	defer w.ctpMutex.Unlock()
 	if w.contractTaskPQueue.Len() > 0 {
+		defer w.updateTaskGauge()
 		return heap.Pop(&w.contractTaskPQueue).(*contractTask)
 	}
 	return nil
 }
 
+// updateTaskGauge exports the length of contractTaskPQueue, it is called after the queue is modified
+func (w *ContractWorker) updateTaskGauge() {
+	contractTasks.WithLabelValues(w.gid.String()).Set(float64(w.contractTaskPQueue.Len()))
+}
+
 func (w *ContractWorker) addIntoBlackList(addr types.Address) {
 	w.blackListMutex.Lock()
 	defer w.blackListMutex.Unlock()
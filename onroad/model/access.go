@@ -195,6 +195,16 @@ func (access *UAccess) GetOnroadHashs(index, num, count uint64, addr *types.Addr
 	return hashList, nil
 }
 
+// GetOnroadHashsAfter returns at most count onroad hashs of addr in order of hash, they are after the hash if it isn't nil
+func (access *UAccess) GetOnroadHashsAfter(addr *types.Address, after *types.Hash, count uint64) ([]*types.Hash, error) {
+	hashList, err := access.store.GetHashsAfter(addr, after, count)
+	if err != nil {
+		access.log.Error("GetHashsAfter", "error", err)
+		return nil, err
+	}
+	return hashList, nil
+}
+
 func (access *UAccess) GetOnroadBlocks(index, num, count uint64, addr *types.Address) (blockList []*ledger.AccountBlock, err error) {
 	hashList, err := access.GetOnroadHashs(index, num, count, addr)
 	if err != nil {
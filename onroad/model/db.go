@@ -93,6 +93,40 @@ func (ucf *OnroadSet) GetHashList(addr *types.Address) (hashs []*types.Hash, err
 	return hashs, nil
 }
 
+// GetHashsAfter returns at most count hashs of addr in order of hash, they are after the hash if it isn't nil
+func (ucf *OnroadSet) GetHashsAfter(addr *types.Address, after *types.Hash, count uint64) (hashs []*types.Hash, err error) {
+	key, err := database.EncodeKey(database.DBKP_ONROADMETA, addr.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	r := util.BytesPrefix(key)
+	if after != nil {
+		afterKey, err := database.EncodeKey(database.DBKP_ONROADMETA, addr.Bytes(), after.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		// the least key greater than afterKey
+		r.Start = append(afterKey, 0)
+	}
+
+	iter := ucf.db().NewIterator(r, nil)
+	defer iter.Release()
+
+	for uint64(len(hashs)) < count && iter.Next() {
+		key := iter.Key()
+		hash, err := types.BytesToHash(key[1+types.AddressSize:])
+		if err != nil {
+			continue
+		}
+		hashs = append(hashs, &hash)
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return hashs, nil
+}
+
 func (ucf *OnroadSet) WriteMeta(batch *leveldb.Batch, addr *types.Address, hash *types.Hash) error {
 	value := []byte{byte(0)}
 
@@ -0,0 +1,190 @@
+package p2p
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+// BanInfo describe a banned node
+type BanInfo struct {
+	ID    string    `json:"id"`
+	Until time.Time `json:"until"`
+}
+
+// nodeList is a set of NodeIDs and IPs, parsed from config
+type nodeList struct {
+	ids map[discovery.NodeID]struct{}
+	ips map[string]struct{}
+}
+
+// parseNodeList parse items of NodeID hex string or IP
+func parseNodeList(items []string, log log15.Logger) *nodeList {
+	l := &nodeList{
+		ids: make(map[discovery.NodeID]struct{}),
+		ips: make(map[string]struct{}),
+	}
+
+	for _, item := range items {
+		if id, err := discovery.HexStr2NodeID(item); err == nil {
+			l.ids[id] = struct{}{}
+		} else if ip := net.ParseIP(item); ip != nil {
+			l.ips[ip.String()] = struct{}{}
+		} else {
+			log.Warn(fmt.Sprintf("%s is neither NodeID nor IP", item))
+		}
+	}
+
+	return l
+}
+
+func (l *nodeList) has(id discovery.NodeID, ip net.IP) bool {
+	if _, ok := l.ids[id]; ok {
+		return true
+	}
+
+	if ip != nil {
+		_, ok := l.ips[ip.String()]
+		return ok
+	}
+
+	return false
+}
+
+// banList records nodes banned temporarily, whitelist can't be banned, blacklist is banned forever
+type banList struct {
+	lock      sync.RWMutex
+	bans      map[discovery.NodeID]time.Time
+	whitelist *nodeList
+	blacklist *nodeList
+}
+
+func newBanList(whitelist, blacklist []string, log log15.Logger) *banList {
+	return &banList{
+		bans:      make(map[discovery.NodeID]time.Time),
+		whitelist: parseNodeList(whitelist, log),
+		blacklist: parseNodeList(blacklist, log),
+	}
+}
+
+// ban return false if id is in whitelist
+// ban the node until the deadline, ip is the address of the connected peer, nil if unknown
+func (b *banList) ban(id discovery.NodeID, ip net.IP, until time.Time) bool {
+	if b.whitelist.has(id, ip) {
+		return false
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if old, ok := b.bans[id]; !ok || old.Before(until) {
+		b.bans[id] = until
+	}
+
+	return true
+}
+
+func (b *banList) unban(id discovery.NodeID) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, ok := b.bans[id]
+	delete(b.bans, id)
+
+	return ok
+}
+
+func (b *banList) banned(id discovery.NodeID, ip net.IP) bool {
+	if b.blacklist.has(id, ip) {
+		return true
+	}
+
+	if b.whitelist.has(id, ip) {
+		return false
+	}
+
+	b.lock.RLock()
+	until, ok := b.bans[id]
+	b.lock.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	if time.Now().Before(until) {
+		return true
+	}
+
+	b.lock.Lock()
+	if until, ok = b.bans[id]; ok && !time.Now().Before(until) {
+		delete(b.bans, id)
+	}
+	b.lock.Unlock()
+
+	return false
+}
+
+func (b *banList) info() (bans []*BanInfo) {
+	now := time.Now()
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	for id, until := range b.bans {
+		if now.Before(until) {
+			bans = append(bans, &BanInfo{
+				ID:    id.String(),
+				Until: until,
+			})
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Until.Before(bans[j].Until)
+	})
+
+	return
+}
+
+// Ban disconnect the node and refuse it during duration, the ban will be persisted if discovery is enabled.
+// Nodes in Whitelist can't be banned, return false.
+func (svr *Server) Ban(id discovery.NodeID, duration time.Duration) bool {
+	var ip net.IP
+	if p := svr.peers.Get(id); p != nil {
+		ip = p.IP()
+	}
+
+	until := time.Now().Add(duration)
+	if !svr.bans.ban(id, ip, until) {
+		return false
+	}
+
+	svr.log.Warn(fmt.Sprintf("ban node %s until %s", id, until))
+
+	if svr.discv != nil {
+		svr.discv.Ban(id, until)
+	}
+
+	svr.disconnect(id, DiscBanned)
+
+	return true
+}
+
+// Unban return false if id is not banned
+func (svr *Server) Unban(id discovery.NodeID) bool {
+	if svr.discv != nil {
+		svr.discv.Unban(id)
+	}
+
+	return svr.bans.unban(id)
+}
+
+// Bans return nodes banned now, nodes in Blacklist are not included
+func (svr *Server) Bans() []*BanInfo {
+	return svr.bans.info()
+}
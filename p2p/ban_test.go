@@ -0,0 +1,57 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+func TestBanList(t *testing.T) {
+	var white, black, other discovery.NodeID
+	rand.Read(white[:])
+	rand.Read(black[:])
+	rand.Read(other[:])
+
+	b := newBanList([]string{white.String(), "127.0.0.2"}, []string{black.String(), "10.0.0.1", "invalid"}, log15.New("module", "test"))
+
+	if b.ban(white, nil, time.Now().Add(time.Hour)) {
+		t.Fatal("whitelist should not be banned")
+	}
+	if b.banned(white, nil) {
+		t.Fatal("whitelist should not be banned")
+	}
+
+	if !b.banned(black, nil) || !b.banned(other, net.ParseIP("10.0.0.1")) {
+		t.Fatal("blacklist should be banned")
+	}
+
+	if b.banned(other, nil) {
+		t.Fatal("should not be banned")
+	}
+	if b.ban(other, net.ParseIP("127.0.0.2"), time.Now().Add(time.Hour)) {
+		t.Fatal("peer with whitelist IP should not be banned")
+	}
+	if !b.ban(other, nil, time.Now().Add(time.Hour)) || !b.banned(other, nil) {
+		t.Fatal("should be banned")
+	}
+	if b.banned(other, net.ParseIP("127.0.0.2")) {
+		t.Fatal("whitelist IP should not be banned")
+	}
+	if info := b.info(); len(info) != 1 || info[0].ID != other.String() {
+		t.Fatalf("should be 1 ban, got %v", info)
+	}
+
+	if !b.unban(other) || b.banned(other, nil) {
+		t.Fatal("should be unbanned")
+	}
+
+	// expired
+	b.ban(other, nil, time.Now().Add(-time.Second))
+	if b.banned(other, nil) || len(b.info()) != 0 {
+		t.Fatal("ban should be expired")
+	}
+}
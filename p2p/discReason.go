@@ -27,6 +27,7 @@ const (
 	DiscResponseTimeout
 	DiscUnKnownProtocol
 	DiscHandshakeFail
+	DiscBanned
 )
 
 var discReasonStr = [...]string{
@@ -47,10 +48,11 @@ var discReasonStr = [...]string{
 	DiscResponseTimeout:     "wait response timeout",
 	DiscUnKnownProtocol:     "missing protocol handler",
 	DiscHandshakeFail:       "p2p handshake error",
+	DiscBanned:              "node is banned",
 }
 
 func (d DiscReason) String() string {
-	if d > DiscHandshakeFail {
+	if d > DiscBanned {
 		return "unknown disc reason"
 	}
 	return discReasonStr[d]
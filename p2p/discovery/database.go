@@ -23,6 +23,7 @@ const (
 	dbDiscvPing     = dbDiscvRoot + ":ping"
 	dbDiscvPong     = dbDiscvRoot + ":pong"
 	dbDiscvFindFail = dbDiscvRoot + ":findfail"
+	dbDiscvBan      = dbDiscvRoot + ":ban"
 )
 
 var (
@@ -32,6 +33,7 @@ var (
 	dbDiscvPingBytes     = []byte(dbDiscvPing)     // store the last time ping received from node
 	dbDiscvPongBytes     = []byte(dbDiscvPong)     // store the last time pong received from node
 	dbDiscvFindFailBytes = []byte(dbDiscvFindFail) // store the fail times node respond our findnode message
+	dbDiscvBanBytes      = []byte(dbDiscvBan)      // store the time until which node is banned
 )
 
 func newDB(path string, version int, id NodeID) (db *nodeDB, err error) {
@@ -121,7 +123,7 @@ func parseKey(key []byte) (id NodeID, field []byte) {
 		idLength := len(id)
 		headLength := prefixLen + idLength
 
-		copy(id[:], key[idLength:headLength])
+		copy(id[:], key[prefixLen:headLength])
 		return id, key[headLength:]
 	}
 
@@ -266,6 +268,44 @@ func (db *nodeDB) setFindNodeFails(id NodeID, fails int) error {
 	return db.storeInt64(genKey(id, dbDiscvFindFailBytes), int64(fails))
 }
 
+// get the time until which id is banned
+func (db *nodeDB) getBan(id NodeID) time.Time {
+	return time.Unix(db.retrieveInt64(genKey(id, dbDiscvBanBytes)), 0)
+}
+
+// ban id until the specific time
+func (db *nodeDB) setBan(id NodeID, until time.Time) error {
+	return db.storeInt64(genKey(id, dbDiscvBanBytes), until.Unix())
+}
+
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.db.Delete(genKey(id, dbDiscvBanBytes), nil)
+}
+
+// retrieve all nodes banned now, expired bans will be deleted
+func (db *nodeDB) bans() map[NodeID]time.Time {
+	now := time.Now()
+	bans := make(map[NodeID]time.Time)
+
+	it := db.db.NewIterator(util.BytesPrefix(dbItemPrefix), nil)
+	defer it.Release()
+
+	for it.Next() {
+		id, field := parseKey(it.Key())
+		if !bytes.Equal(field, dbDiscvBanBytes) {
+			continue
+		}
+
+		if until := time.Unix(decodeVarint(it.Value()), 0); until.After(now) {
+			bans[id] = until
+		} else {
+			db.deleteBan(id)
+		}
+	}
+
+	return bans
+}
+
 func (db *nodeDB) cleanStaleNodes() {
 	now := time.Now()
 
@@ -277,6 +317,10 @@ func (db *nodeDB) cleanStaleNodes() {
 		if !bytes.Equal(field, dbDiscvRootBytes) {
 			continue
 		}
+		// keep the ban record
+		if db.getBan(id).After(now) {
+			continue
+		}
 		if lastpong := db.getLastPong(id); now.Sub(lastpong) > tExpire {
 			db.deleteNode(id)
 		}
@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestNodeDB_bans(t *testing.T) {
+	var self, id, expired NodeID
+	rand.Read(self[:])
+	rand.Read(id[:])
+	rand.Read(expired[:])
+
+	db, err := newMemDB(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.close()
+
+	until := time.Now().Add(time.Hour)
+	db.setBan(id, until)
+	db.setBan(expired, time.Now().Add(-time.Hour))
+
+	bans := db.bans()
+	if len(bans) != 1 || bans[id].Unix() != until.Unix() {
+		t.Fatalf("should be 1 ban, got %v", bans)
+	}
+
+	if db.getBan(expired).Unix() != 0 {
+		t.Fatal("expired ban should be deleted")
+	}
+
+	db.deleteBan(id)
+	if len(db.bans()) != 0 {
+		t.Fatal("ban should be deleted")
+	}
+}
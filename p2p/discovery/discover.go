@@ -136,6 +136,36 @@ func (d *Discovery) Mark(id NodeID, lifetime int64) {
 	// todo
 }
 
+// Ban persist the ban of id, it will be loaded by Bans after restart
+func (d *Discovery) Ban(id NodeID, until time.Time) {
+	if d.db == nil {
+		return
+	}
+
+	if err := d.db.setBan(id, until); err != nil {
+		d.log.Error(fmt.Sprintf("store ban of %s error: %v", id, err))
+	}
+}
+
+func (d *Discovery) Unban(id NodeID) {
+	if d.db == nil {
+		return
+	}
+
+	if err := d.db.deleteBan(id); err != nil {
+		d.log.Error(fmt.Sprintf("delete ban of %s error: %v", id, err))
+	}
+}
+
+// Bans return nodes banned now and the time until which they are banned
+func (d *Discovery) Bans() map[NodeID]time.Time {
+	if d.db == nil {
+		return nil
+	}
+
+	return d.db.bans()
+}
+
 func (d *Discovery) Need(n uint) {
 	nodes := make([]*Node, n)
 	i := d.RandomNodes(nodes)
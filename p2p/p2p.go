@@ -32,6 +32,9 @@ type Discovery interface {
 	Block(id discovery.NodeID, ip net.IP)
 	Need(n uint)
 	Nodes() []*discovery.Node
+	Ban(id discovery.NodeID, until time.Time)
+	Unban(id discovery.NodeID)
+	Bans() map[discovery.NodeID]time.Time
 }
 
 type Config struct {
@@ -46,28 +49,40 @@ type Config struct {
 	PrivateKey      ed25519.PrivateKey // use for encrypt message, the corresponding public key use for NodeID
 	Protocols       []*Protocol        // protocols server supported
 	BootNodes       []string           // nodes as discovery seed
-	StaticNodes     []string           // nodes to connect
+	StaticNodes     []string           // nodes to connect, always be redialed
+	TrustedNodes    []string           // NodeIDs can be connected even if peers are too many
+	Whitelist       []string           // NodeIDs or IPs can't be banned
+	Blacklist       []string           // NodeIDs or IPs never be connected
+}
+
+// discRequest disconnect the peer in Server.loop
+type discRequest struct {
+	id     discovery.NodeID
+	reason DiscReason
 }
 
 type Server struct {
 	*Config
-	addr        *net.TCPAddr
-	StaticNodes []*discovery.Node
-
-	running   int32          // atomic
-	wg        sync.WaitGroup // Wait for all jobs done
-	term      chan struct{}
-	pending   chan struct{} // how many connection can wait for handshake
-	addPeer   chan *transport
-	delPeer   chan *Peer
-	discv     Discovery
-	handshake *Handshake
-	peers     *PeerSet
-	blockList *block.Set
-	self      *discovery.Node
-	ln        net.Listener
-	nodeChan  chan *discovery.Node // sub discovery nodes
-	log       log15.Logger
+	addr *net.TCPAddr
+
+	running    int32          // atomic
+	wg         sync.WaitGroup // Wait for all jobs done
+	term       chan struct{}
+	pending    chan struct{} // how many connection can wait for handshake
+	addPeer    chan *transport
+	delPeer    chan *Peer
+	discv      Discovery
+	handshake  *Handshake
+	peers      *PeerSet
+	blockList  *block.Set
+	bans       *banList
+	peerList   *peerList            // static and trusted nodes
+	dialStatic chan *discovery.Node // static nodes added at runtime
+	discPeer   chan discRequest     // disconnect banned or removed peers
+	self       *discovery.Node
+	ln         net.Listener
+	nodeChan   chan *discovery.Node // sub discovery nodes
+	log        log15.Logger
 
 	dialer *net.Dialer
 }
@@ -96,19 +111,23 @@ func New(cfg *Config) (svr *Server, err error) {
 	}
 
 	svr = &Server{
-		Config:      cfg,
-		addr:        tcpAddr,
-		StaticNodes: parseNodes(cfg.StaticNodes),
-		peers:       NewPeerSet(),
-		pending:     make(chan struct{}, cfg.MaxPendingPeers),
-		addPeer:     make(chan *transport, 1),
-		delPeer:     make(chan *Peer, 1),
-		blockList:   block.New(100),
-		self:        node,
-		nodeChan:    make(chan *discovery.Node, 10),
-		log:         log15.New("module", "p2p/server"),
-		dialer:      &net.Dialer{Timeout: 3 * time.Second},
-	}
+		Config:     cfg,
+		addr:       tcpAddr,
+		peers:      NewPeerSet(),
+		pending:    make(chan struct{}, cfg.MaxPendingPeers),
+		addPeer:    make(chan *transport, 1),
+		delPeer:    make(chan *Peer, 1),
+		blockList:  block.New(100),
+		dialStatic: make(chan *discovery.Node, 10),
+		discPeer:   make(chan discRequest, 10),
+		self:       node,
+		nodeChan:   make(chan *discovery.Node, 10),
+		log:        log15.New("module", "p2p/server"),
+		dialer:     &net.Dialer{Timeout: 3 * time.Second},
+	}
+
+	svr.bans = newBanList(cfg.Whitelist, cfg.Blacklist, svr.log)
+	svr.peerList = newPeerList(cfg.StaticNodes, cfg.TrustedNodes, cfg.DataDir, svr.log)
 
 	if cfg.Discovery {
 		// udp discover
@@ -176,6 +195,11 @@ func (svr *Server) Start() error {
 			svr.ln.Close()
 			return err
 		}
+
+		// bans persisted
+		for id, until := range svr.discv.Bans() {
+			svr.bans.ban(id, nil, until)
+		}
 	}
 
 	svr.wg.Add(1)
@@ -189,6 +213,10 @@ func (svr *Server) Start() error {
 	svr.wg.Add(1)
 	common.Go(svr.loop)
 
+	monitor.NewGaugeFunc("gvite_p2p_peers", "Peers connected", func() float64 {
+		return float64(svr.PeersCount())
+	})
+
 	svr.log.Info("p2p server started")
 	return nil
 }
@@ -205,6 +233,8 @@ func (svr *Server) Stop() {
 
 		close(svr.term)
 
+		monitor.Unregister("gvite_p2p_peers")
+
 		if svr.ln != nil {
 			svr.ln.Close()
 		}
@@ -246,20 +276,42 @@ func (svr *Server) dialLoop() {
 	defer svr.wg.Done()
 
 	// connect to static node first
-	for _, node := range svr.StaticNodes {
-		svr.dial(node.ID, node.TCPAddr(), static)
-	}
+	svr.redialStatic()
+
+	ticker := time.NewTicker(staticRedialInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-svr.term:
 			return
+		case <-ticker.C:
+			svr.redialStatic()
+		case node := <-svr.dialStatic:
+			svr.dial(node.ID, node.TCPAddr(), static)
 		case node := <-svr.nodeChan:
 			svr.dial(node.ID, node.TCPAddr(), outbound)
 		}
 	}
 }
 
+// redialStatic dial static nodes not connected
+func (svr *Server) redialStatic() {
+	for _, node := range svr.peerList.staticNodes() {
+		if !svr.peers.Has(node.ID) {
+			svr.dial(node.ID, node.TCPAddr(), static)
+		}
+	}
+}
+
+// disconnect the peer if it is connected
+func (svr *Server) disconnect(id discovery.NodeID, reason DiscReason) {
+	select {
+	case svr.discPeer <- discRequest{id, reason}:
+	default:
+	}
+}
+
 // when peer is disconnected, maybe we want to reconnect it.
 // we can get ID and addr only from peer, but not Node
 // so dial(id, addr, flag) not dial(Node, flag)
@@ -269,7 +321,7 @@ func (svr *Server) dial(id discovery.NodeID, addr *net.TCPAddr, flag connFlag) {
 	//	return
 	//}
 
-	if err := svr.checkConn(id, flag); err != nil {
+	if err := svr.checkConn(id, addr.IP, flag); err != nil {
 		return
 	}
 
@@ -360,13 +412,24 @@ func (svr *Server) setupConn(c net.Conn, flag connFlag, id discovery.NodeID) {
 	}
 
 	if head.Version < Version {
+		// old peers are waiting for the plaintext handshake, tell them why
+		if m, e := PackMsg(baseProtocolCmdSet, discCmd, 0, DiscIncompatibleVersion); e == nil {
+			WriteMsg(c, m)
+		}
 		c.Close()
-		svr.log.Warn(fmt.Sprintf("different Version %s: our %s, their %s", c.RemoteAddr(), Version, head.Version))
+		svr.log.Warn(fmt.Sprintf("different Version %s: our %d, their %d", c.RemoteAddr(), Version, head.Version))
+		return
+	}
+
+	sc, auth, err := secureHandshake(c, svr.PrivateKey, id)
+	if err != nil {
+		c.Close()
+		svr.log.Warn(fmt.Sprintf("secure handshake with %s error: %v", c.RemoteAddr(), err))
 		return
 	}
 
 	ts := &transport{
-		Conn:  c,
+		Conn:  sc,
 		flags: flag,
 	}
 
@@ -382,7 +445,7 @@ func (svr *Server) setupConn(c net.Conn, flag connFlag, id discovery.NodeID) {
 	if err != nil {
 		ts.Close()
 		svr.log.Warn(fmt.Sprintf("handshake with %s error: %v", c.RemoteAddr(), err))
-	} else if !id.IsZero() && their.ID != id {
+	} else if their.ID != auth.ID {
 		ts.Close()
 		svr.log.Warn(fmt.Sprintf("unmatched id"))
 	} else {
@@ -402,17 +465,21 @@ func (svr *Server) setupConn(c net.Conn, flag connFlag, id discovery.NodeID) {
 	}
 }
 
-func (svr *Server) checkConn(id discovery.NodeID, flag connFlag) error {
+func (svr *Server) checkConn(id discovery.NodeID, ip net.IP, flag connFlag) error {
 	if id == svr.self.ID {
 		return DiscSelf
 	}
 
+	if svr.bans.banned(id, ip) {
+		return DiscBanned
+	}
+
 	if svr.peers.Has(id) {
 		return DiscAlreadyConnected
 	}
 
-	// static can be connected even if peers too many
-	if flag == static {
+	// static and trusted can be connected even if peers too many
+	if flag.is(static) || svr.peerList.isStatic(id) || svr.peerList.isTrusted(id) {
 		return nil
 	}
 
@@ -420,7 +487,7 @@ func (svr *Server) checkConn(id discovery.NodeID, flag connFlag) error {
 		return DiscTooManyPeers
 	}
 
-	if flag.is(inbound) && uint(svr.peers.inbound) >= svr.maxInboundPeers() {
+	if flag.is(inbound) && uint(svr.peers.Inbound()) >= svr.maxInboundPeers() {
 		return DiscTooManyInboundPeers
 	}
 
@@ -437,7 +504,7 @@ loop:
 		case <-svr.term:
 			break loop
 		case c := <-svr.addPeer:
-			err := svr.checkConn(c.remoteID, c.flags)
+			err := svr.checkConn(c.remoteID, c.remoteIP, c.flags)
 
 			if err == nil {
 				if p, err := NewPeer(c, svr.Protocols); err == nil {
@@ -467,13 +534,23 @@ loop:
 			monitor.LogDuration("p2p/peer", "count", int64(peersCount))
 			monitor.LogEvent("p2p/peer", "delete")
 
-			if p.ts.is(static) {
-				svr.dial(p.ID(), p.RemoteAddr(), static)
+			// static nodes removed at runtime will not be redialed
+			if node := svr.peerList.getStatic(p.ID()); node != nil {
+				select {
+				case svr.dialStatic <- node:
+				default:
+					// will be dialed at next redial
+				}
 			}
 
 			if peersCount == 0 && svr.discv != nil {
 				svr.discv.Need(svr.MaxPeers)
 			}
+
+		case req := <-svr.discPeer:
+			if p := svr.peers.Get(req.id); p != nil {
+				p.Disconnect(req.reason)
+			}
 		}
 	}
 
@@ -435,7 +435,9 @@ func (p *Peer) Info() *PeerInfo {
 }
 
 // @section PeerSet
+// PeerSet is read by the dial goroutine and the apis, written by Server.loop
 type PeerSet struct {
+	rw       sync.RWMutex
 	peers    map[discovery.NodeID]*Peer
 	inbound  int
 	outbound int
@@ -449,6 +451,9 @@ func NewPeerSet() *PeerSet {
 }
 
 func (s *PeerSet) Add(p *Peer) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
 	s.peers[p.ID()] = p
 	if p.ts.is(inbound) {
 		s.inbound++
@@ -460,6 +465,12 @@ func (s *PeerSet) Add(p *Peer) {
 }
 
 func (s *PeerSet) Del(p *Peer) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	if _, ok := s.peers[p.ID()]; !ok {
+		return
+	}
 	delete(s.peers, p.ID())
 
 	if p.ts.is(inbound) {
@@ -471,29 +482,57 @@ func (s *PeerSet) Del(p *Peer) {
 	s.size--
 }
 
+func (s *PeerSet) Get(id discovery.NodeID) *Peer {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	return s.peers[id]
+}
+
 func (s *PeerSet) Has(id discovery.NodeID) bool {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
 	_, ok := s.peers[id]
 	return ok
 }
 
 func (s *PeerSet) Size() uint {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
 	return s.size
 }
 
+func (s *PeerSet) Inbound() int {
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	return s.inbound
+}
+
 func (s *PeerSet) Info() []*PeerInfo {
-	info := make([]*PeerInfo, s.Size())
-	i := 0
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+
+	info := make([]*PeerInfo, 0, len(s.peers))
 	for _, p := range s.peers {
-		info[i] = p.Info()
-		i++
+		info = append(info, p.Info())
 	}
 
 	return info
 }
 
+// Traverse fn is called without holding the lock, so it can modify the set
 func (s *PeerSet) Traverse(fn func(id discovery.NodeID, p *Peer)) {
+	s.rw.RLock()
+	peers := make(map[discovery.NodeID]*Peer, len(s.peers))
 	for id, p := range s.peers {
-		id, p := id, p
+		peers[id] = p
+	}
+	s.rw.RUnlock()
+
+	for id, p := range peers {
 		fn(id, p)
 	}
 }
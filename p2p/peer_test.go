@@ -0,0 +1,80 @@
+package p2p
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+func mockSetPeer(flag connFlag) *Peer {
+	var id discovery.NodeID
+	rand.Read(id[:])
+
+	return &Peer{
+		ts: &transport{
+			flags:    flag,
+			remoteID: id,
+		},
+	}
+}
+
+func TestPeerSet(t *testing.T) {
+	s := NewPeerSet()
+
+	in, out := mockSetPeer(inbound), mockSetPeer(outbound)
+	s.Add(in)
+	s.Add(out)
+	if s.Size() != 2 || s.Inbound() != 1 || !s.Has(in.ID()) || s.Get(out.ID()) != out {
+		t.Fatalf("should have 2 peers, 1 inbound, got %d, %d", s.Size(), s.Inbound())
+	}
+
+	s.Del(in)
+	s.Del(in)
+	if s.Size() != 1 || s.Inbound() != 0 || s.Has(in.ID()) {
+		t.Fatalf("should have 1 outbound peer, got %d, %d", s.Size(), s.Inbound())
+	}
+
+	// delete in Traverse
+	s.Traverse(func(id discovery.NodeID, p *Peer) {
+		s.Del(p)
+	})
+	if s.Size() != 0 {
+		t.Fatalf("should be empty, got %d", s.Size())
+	}
+}
+
+// the set is written by Server.loop and read by the dial goroutine, run with -race
+func TestPeerSet_Concurrent(t *testing.T) {
+	s := NewPeerSet()
+	peers := make([]*Peer, 100)
+	for i := range peers {
+		peers[i] = mockSetPeer(outbound)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, p := range peers {
+			s.Add(p)
+		}
+		for _, p := range peers {
+			s.Del(p)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.Has(peers[i%len(peers)].ID())
+			s.Size()
+			s.Inbound()
+		}
+	}()
+	wg.Wait()
+
+	if s.Size() != 0 {
+		t.Fatalf("should be empty, got %d", s.Size())
+	}
+}
@@ -0,0 +1,257 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+// auth message is sent after headMsg, it carries the node ID, an ephemeral x25519 key and a nonce,
+// ephemeral key and nonce are signed by the node key.
+type authMsg struct {
+	ID        discovery.NodeID
+	Ephemeral [32]byte
+	Nonce     [32]byte
+	Signature [64]byte
+}
+
+const authMsgLen = 160 // id[32] + ephemeral[32] + nonce[32] + signature[64]
+
+const maxFrameSize = 1 << 16 // max plaintext bytes of one encrypted frame
+const frameHeadLen = 4       // ciphertext length
+
+var errAuthVerify = errors.New("signature of auth message verify failed")
+var errAuthEphemeral = errors.New("ephemeral key is the same as ours")
+var errFrameTooLarge = errors.New("encrypted frame is too large")
+var errFrameAuth = errors.New("encrypted frame authentication failed")
+
+func (a *authMsg) signed() []byte {
+	return append(a.Ephemeral[:], a.Nonce[:]...)
+}
+
+func readAuth(conn net.Conn) (auth *authMsg, err error) {
+	packet := make([]byte, authMsgLen)
+	if _, err = io.ReadFull(conn, packet); err != nil {
+		return
+	}
+
+	auth = new(authMsg)
+	copy(auth.ID[:], packet[:32])
+	copy(auth.Ephemeral[:], packet[32:64])
+	copy(auth.Nonce[:], packet[64:96])
+	copy(auth.Signature[:], packet[96:])
+
+	return
+}
+
+func writeAuth(conn net.Conn, auth *authMsg) error {
+	packet := make([]byte, 0, authMsgLen)
+	packet = append(packet, auth.ID[:]...)
+	packet = append(packet, auth.Ephemeral[:]...)
+	packet = append(packet, auth.Nonce[:]...)
+	packet = append(packet, auth.Signature[:]...)
+
+	if n, err := conn.Write(packet); err != nil {
+		return err
+	} else if n != authMsgLen {
+		return fmt.Errorf("write incomplete authMsg %d/%d", n, authMsgLen)
+	}
+
+	return nil
+}
+
+// secureHandshake authenticates the remote node and derives the session keys, both ephemeral and static
+// (converted from the ed25519 node key) x25519 secrets are mixed in, so only the owner of the remote ID
+// can decrypt the frames. expect is the ID we dialed, it is zero for inbound connections.
+func secureHandshake(conn net.Conn, key ed25519.PrivateKey, expect discovery.NodeID) (sc *secureConn, their *authMsg, err error) {
+	ephemeral := make([]byte, ed25519.X25519Size)
+	if _, err = io.ReadFull(crand.Reader, ephemeral); err != nil {
+		return
+	}
+
+	base := make([]byte, ed25519.X25519Size)
+	base[0] = 9
+	ephemeralPub, err := ed25519.X25519(ephemeral, base)
+	if err != nil {
+		return
+	}
+
+	our := new(authMsg)
+	copy(our.ID[:], key.PubByte())
+	copy(our.Ephemeral[:], ephemeralPub)
+	if _, err = io.ReadFull(crand.Reader, our.Nonce[:]); err != nil {
+		return
+	}
+	copy(our.Signature[:], ed25519.Sign(key, our.signed()))
+
+	send := make(chan error, 1)
+	common.Go(func() {
+		send <- writeAuth(conn, our)
+	})
+
+	if their, err = readAuth(conn); err != nil {
+		return
+	}
+
+	if err = <-send; err != nil {
+		return
+	}
+
+	if !expect.IsZero() && their.ID != expect {
+		return nil, nil, DiscInvalidIdentity
+	}
+
+	if !ed25519.Verify(their.ID[:], their.signed(), their.Signature[:]) {
+		return nil, nil, errAuthVerify
+	}
+
+	if our.Ephemeral == their.Ephemeral {
+		return nil, nil, errAuthEphemeral
+	}
+
+	theirStatic, err := ed25519.PublicKey(their.ID[:]).ToX25519()
+	if err != nil {
+		return
+	}
+
+	ee, err := ed25519.X25519(ephemeral, their.Ephemeral[:])
+	if err != nil {
+		return
+	}
+	ss, err := ed25519.X25519(key.ToX25519(), theirStatic)
+	if err != nil {
+		return
+	}
+
+	// both sides must use the same salt, order nonces by ephemeral keys
+	var salt []byte
+	if bytes.Compare(our.Ephemeral[:], their.Ephemeral[:]) < 0 {
+		salt = append(our.Nonce[:], their.Nonce[:]...)
+	} else {
+		salt = append(their.Nonce[:], our.Nonce[:]...)
+	}
+	secret := kdf(salt, append(ee, ss...))
+
+	// frames we write are decrypted by the key derived from our ephemeral key, and vice versa
+	w, err := newAEAD(kdf(secret, our.Ephemeral[:]))
+	if err != nil {
+		return
+	}
+	r, err := newAEAD(kdf(secret, their.Ephemeral[:]))
+	if err != nil {
+		return
+	}
+
+	return &secureConn{
+		Conn: conn,
+		w:    w,
+		r:    r,
+	}, their, nil
+}
+
+func kdf(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// secureConn encrypts the stream into AEAD frames: ciphertextLength[4] + ciphertext,
+// the nonce is a counter of each direction, and the length is authenticated as additional data.
+type secureConn struct {
+	net.Conn
+
+	wlock  sync.Mutex
+	w      cipher.AEAD
+	wnonce uint64
+
+	rlock  sync.Mutex
+	r      cipher.AEAD
+	rnonce uint64
+	rbuf   []byte
+}
+
+func counterNonce(aead cipher.AEAD, counter uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], counter)
+	return nonce
+}
+
+func (c *secureConn) Write(b []byte) (n int, err error) {
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	for len(b) > 0 {
+		size := len(b)
+		if size > maxFrameSize {
+			size = maxFrameSize
+		}
+
+		frame := make([]byte, frameHeadLen, frameHeadLen+size+c.w.Overhead())
+		binary.BigEndian.PutUint32(frame, uint32(size+c.w.Overhead()))
+		frame = c.w.Seal(frame, counterNonce(c.w, c.wnonce), b[:size], frame[:frameHeadLen])
+		c.wnonce++
+
+		if _, err = c.Conn.Write(frame); err != nil {
+			return
+		}
+
+		n += size
+		b = b[size:]
+	}
+
+	return
+}
+
+func (c *secureConn) Read(b []byte) (n int, err error) {
+	c.rlock.Lock()
+	defer c.rlock.Unlock()
+
+	if len(c.rbuf) == 0 {
+		head := make([]byte, frameHeadLen)
+		if _, err = io.ReadFull(c.Conn, head); err != nil {
+			return
+		}
+
+		size := binary.BigEndian.Uint32(head)
+		if size > uint32(maxFrameSize+c.r.Overhead()) {
+			return 0, errFrameTooLarge
+		}
+
+		frame := make([]byte, size)
+		if _, err = io.ReadFull(c.Conn, frame); err != nil {
+			return
+		}
+
+		if c.rbuf, err = c.r.Open(frame[:0], counterNonce(c.r, c.rnonce), frame, head); err != nil {
+			return 0, errFrameAuth
+		}
+		c.rnonce++
+	}
+
+	n = copy(b, c.rbuf)
+	c.rbuf = c.rbuf[n:]
+
+	return
+}
@@ -0,0 +1,95 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+type secureResult struct {
+	conn *secureConn
+	auth *authMsg
+	err  error
+}
+
+func mockSecurePair(expect func(pub ed25519.PublicKey) discovery.NodeID) (a, b secureResult, pubA, pubB ed25519.PublicKey) {
+	pubA, privA, _ := ed25519.GenerateKey(nil)
+	pubB, privB, _ := ed25519.GenerateKey(nil)
+
+	c1, c2 := net.Pipe()
+
+	done := make(chan secureResult, 1)
+	go func() {
+		var r secureResult
+		r.conn, r.auth, r.err = secureHandshake(c2, privB, discovery.ZERO_NODE_ID)
+		done <- r
+	}()
+
+	a.conn, a.auth, a.err = secureHandshake(c1, privA, expect(pubB))
+	if a.err != nil {
+		c1.Close()
+	}
+	b = <-done
+
+	return
+}
+
+func TestSecureHandshake(t *testing.T) {
+	a, b, pubA, pubB := mockSecurePair(func(pub ed25519.PublicKey) (id discovery.NodeID) {
+		copy(id[:], pub)
+		return
+	})
+	if a.err != nil || b.err != nil {
+		t.Fatal(a.err, b.err)
+	}
+	defer a.conn.Close()
+
+	if !bytes.Equal(a.auth.ID[:], pubB) || !bytes.Equal(b.auth.ID[:], pubA) {
+		t.Fatal("remote ID should be authenticated")
+	}
+
+	// larger than one frame
+	payload := make([]byte, maxFrameSize*2+100)
+	rand.Read(payload)
+
+	go func() {
+		msg := NewMsg()
+		msg.CmdSet = 1
+		msg.Cmd = 2
+		msg.Id = 3
+		msg.Payload = payload
+		WriteMsg(a.conn, msg)
+	}()
+
+	msg, err := ReadMsg(b.conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.CmdSet != 1 || msg.Cmd != 2 || msg.Id != 3 || !bytes.Equal(msg.Payload, payload) {
+		t.Fatal("message not equal")
+	}
+
+	// frames can't be decrypted by the key of the other direction
+	go func() {
+		a.conn.Write([]byte("hello"))
+	}()
+	buf := make([]byte, 1024)
+	if _, err = (&secureConn{Conn: b.conn.Conn, r: b.conn.w}).Read(buf); err != errFrameAuth {
+		t.Fatalf("frame should not be decrypted: %v", err)
+	}
+}
+
+func TestSecureHandshake_Unexpected(t *testing.T) {
+	a, _, _, _ := mockSecurePair(func(pub ed25519.PublicKey) (id discovery.NodeID) {
+		rand.Read(id[:])
+		return
+	})
+
+	if a.err != DiscInvalidIdentity {
+		t.Fatalf("should be invalid identity: %v", a.err)
+	}
+}
@@ -0,0 +1,247 @@
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+const peerListFileName = "peers.json"
+
+// redial static nodes not connected at every staticRedialInterval
+const staticRedialInterval = 30 * time.Second
+
+// peerListFile is the persisted format of peerList
+type peerListFile struct {
+	Static  []string `json:"static"`  // node URLs
+	Trusted []string `json:"trusted"` // NodeIDs
+}
+
+// peerList records static nodes and trusted nodes.
+// static nodes are always redialed, trusted nodes can be connected even if peers are too many.
+// nodes added at runtime are persisted in file, so they are kept across restarts.
+type peerList struct {
+	lock    sync.RWMutex
+	static  map[discovery.NodeID]*discovery.Node
+	trusted map[discovery.NodeID]struct{}
+	file    string
+}
+
+// newPeerList merge nodes from config and nodes persisted in dir,
+// nodes from config are always loaded, even if they were removed at runtime
+func newPeerList(static, trusted []string, dir string, log log15.Logger) *peerList {
+	l := &peerList{
+		static:  make(map[discovery.NodeID]*discovery.Node),
+		trusted: make(map[discovery.NodeID]struct{}),
+	}
+
+	if dir != "" {
+		l.file = filepath.Join(dir, peerListFileName)
+	}
+
+	var saved peerListFile
+	if l.file != "" {
+		if data, err := ioutil.ReadFile(l.file); err == nil {
+			if err = json.Unmarshal(data, &saved); err != nil {
+				log.Error(fmt.Sprintf("parse %s error: %v", l.file, err))
+			}
+		} else if !os.IsNotExist(err) {
+			log.Error(fmt.Sprintf("read %s error: %v", l.file, err))
+		}
+	}
+
+	for _, node := range parseNodes(append(static, saved.Static...)) {
+		l.static[node.ID] = node
+	}
+
+	for _, str := range append(trusted, saved.Trusted...) {
+		if id, err := discovery.HexStr2NodeID(str); err == nil {
+			l.trusted[id] = struct{}{}
+		} else {
+			log.Warn(fmt.Sprintf("trusted node %s is not NodeID", str))
+		}
+	}
+
+	return l
+}
+
+// must be called with lock
+func (l *peerList) save() error {
+	if l.file == "" {
+		return nil
+	}
+
+	var saved peerListFile
+	for _, node := range l.static {
+		saved.Static = append(saved.Static, node.String())
+	}
+	for id := range l.trusted {
+		saved.Trusted = append(saved.Trusted, id.String())
+	}
+	sort.Strings(saved.Static)
+	sort.Strings(saved.Trusted)
+
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(l.file, data, 0600)
+}
+
+func (l *peerList) addStatic(node *discovery.Node) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.static[node.ID] = node
+	return l.save()
+}
+
+// removeStatic return false if id is not static
+func (l *peerList) removeStatic(id discovery.NodeID) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.static[id]; !ok {
+		return false, nil
+	}
+
+	delete(l.static, id)
+	return true, l.save()
+}
+
+func (l *peerList) addTrusted(id discovery.NodeID) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.trusted[id] = struct{}{}
+	return l.save()
+}
+
+// removeTrusted return false if id is not trusted
+func (l *peerList) removeTrusted(id discovery.NodeID) (bool, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.trusted[id]; !ok {
+		return false, nil
+	}
+
+	delete(l.trusted, id)
+	return true, l.save()
+}
+
+func (l *peerList) isStatic(id discovery.NodeID) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.static[id]
+	return ok
+}
+
+func (l *peerList) getStatic(id discovery.NodeID) *discovery.Node {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	return l.static[id]
+}
+
+func (l *peerList) isTrusted(id discovery.NodeID) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	_, ok := l.trusted[id]
+	return ok
+}
+
+func (l *peerList) staticNodes() (nodes []*discovery.Node) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	nodes = make([]*discovery.Node, 0, len(l.static))
+	for _, node := range l.static {
+		nodes = append(nodes, node)
+	}
+
+	return
+}
+
+func (l *peerList) trustedNodes() (ids []discovery.NodeID) {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	ids = make([]discovery.NodeID, 0, len(l.trusted))
+	for id := range l.trusted {
+		ids = append(ids, id)
+	}
+
+	return
+}
+
+// AddStaticPeer parse the node URL, the node will be dialed immediately and always be redialed after disconnected
+func (svr *Server) AddStaticPeer(nodeURL string) error {
+	node, err := discovery.ParseNode(nodeURL)
+	if err != nil {
+		return err
+	}
+
+	if err = svr.peerList.addStatic(node); err != nil {
+		return err
+	}
+
+	select {
+	case svr.dialStatic <- node:
+	default:
+		// will be dialed at next redial
+	}
+
+	return nil
+}
+
+// RemoveStaticPeer return false if id is not static, the connected peer will be disconnected
+func (svr *Server) RemoveStaticPeer(id discovery.NodeID) (bool, error) {
+	ok, err := svr.peerList.removeStatic(id)
+	if ok {
+		svr.disconnect(id, DiscRequested)
+	}
+
+	return ok, err
+}
+
+// StaticPeers return URLs of static nodes
+func (svr *Server) StaticPeers() (urls []string) {
+	for _, node := range svr.peerList.staticNodes() {
+		urls = append(urls, node.String())
+	}
+	sort.Strings(urls)
+
+	return
+}
+
+// AddTrustedPeer make the node can be connected even if peers are too many
+func (svr *Server) AddTrustedPeer(id discovery.NodeID) error {
+	return svr.peerList.addTrusted(id)
+}
+
+// RemoveTrustedPeer return false if id is not trusted
+func (svr *Server) RemoveTrustedPeer(id discovery.NodeID) (bool, error) {
+	return svr.peerList.removeTrusted(id)
+}
+
+// TrustedPeers return NodeIDs of trusted nodes
+func (svr *Server) TrustedPeers() (ids []string) {
+	for _, id := range svr.peerList.trustedNodes() {
+		ids = append(ids, id.String())
+	}
+	sort.Strings(ids)
+
+	return
+}
@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+const staticURL = "vnode://33e43481729850fc66cef7f42abebd8cb2f1c74f0b09a5bf03da34780a0a5606@150.109.40.224:8483"
+const trustedID = "7194af5b7032cb470c41b313e2675e2c3ba3377e66617247012b8d638552fb17"
+
+func TestPeerList_Persist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	log := log15.New("module", "test")
+
+	l := newPeerList([]string{staticURL}, nil, dir, log)
+	static, _ := discovery.ParseNode(staticURL)
+	if !l.isStatic(static.ID) {
+		t.Fatal("static node from config should be loaded")
+	}
+
+	added, _ := discovery.ParseNode("vnode://087c45631c3ec9a5dbd1189084ee40c8c4c0f36731ef2c2cb7987da421d08ba9@150.109.104.203:8483")
+	if err = l.addStatic(added); err != nil {
+		t.Fatal(err)
+	}
+	trusted, _ := discovery.HexStr2NodeID(trustedID)
+	if err = l.addTrusted(trusted); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	l = newPeerList(nil, nil, dir, log)
+	if !l.isStatic(static.ID) || !l.isStatic(added.ID) || len(l.staticNodes()) != 2 {
+		t.Fatal("static nodes should be persisted")
+	}
+	if node := l.getStatic(added.ID); node.TCPAddr().String() != "150.109.104.203:8483" {
+		t.Fatalf("wrong static node address %s", node.TCPAddr())
+	}
+	if !l.isTrusted(trusted) {
+		t.Fatal("trusted node should be persisted")
+	}
+
+	if ok, err := l.removeStatic(added.ID); !ok || err != nil {
+		t.Fatal("static node should be removed", err)
+	}
+	if ok, _ := l.removeStatic(added.ID); ok {
+		t.Fatal("static node has been removed")
+	}
+	if ok, err := l.removeTrusted(trusted); !ok || err != nil {
+		t.Fatal("trusted node should be removed", err)
+	}
+
+	l = newPeerList(nil, nil, dir, log)
+	if l.isStatic(added.ID) || l.isTrusted(trusted) || !l.isStatic(static.ID) {
+		t.Fatal("removed nodes should not be persisted")
+	}
+}
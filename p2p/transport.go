@@ -14,7 +14,8 @@ import (
 
 type P2PVersion = uint32
 
-const Version P2PVersion = 1
+// Version 2 encrypts the connection after headMsg, see secureHandshake
+const Version P2PVersion = 2
 
 const baseProtocolCmdSet = 0
 const handshakeCmd = 0
@@ -1,10 +1,12 @@
 package p2p
 
 import (
-	"fmt"
+	"bytes"
 	"math/rand"
 	"net"
 	"testing"
+
+	"github.com/vitelabs/go-vite/p2p/network"
 )
 
 func mockPayload() ([]byte, int) {
@@ -25,127 +27,138 @@ func mockPayload() ([]byte, int) {
 	return buf, size
 }
 
-func mockMsg() (*Msg, error) {
+func mockMsg() *Msg {
 	msg := NewMsg()
 
-	msg.CmdSet = rand.Uint64()
-	msg.Cmd = rand.Uint32()
+	msg.CmdSet = rand.Uint32()
+	msg.Cmd = uint16(rand.Uint32())
 	msg.Id = rand.Uint64()
 
-	payload, size := mockPayload()
-	msg.Payload = payload
-	msg.Size = uint32(size)
+	msg.Payload, _ = mockPayload()
 
-	return msg, nil
+	return msg
 }
 
-func TestAsyncMsgConn_Start(t *testing.T) {
-	errch := make(chan error)
-	const addr = "localhost:8888"
+func TestReadWriteMsg(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	const total = 100
+	sent := make(chan *Msg, total)
+	errch := make(chan error, 1)
 
 	go func() {
-		ln, err := net.Listen("tcp", addr)
-		if err != nil {
-			t.Error(err)
+		for i := 0; i < total; i++ {
+			msg := mockMsg()
+			// msg is recycled after written
+			sent <- &Msg{
+				CmdSet:  msg.CmdSet,
+				Cmd:     msg.Cmd,
+				Id:      msg.Id,
+				Payload: msg.Payload,
+			}
+			if err := WriteMsg(c1, msg); err != nil {
+				errch <- err
+				return
+			}
 		}
+	}()
 
-		conn, err := ln.Accept()
+	for i := 0; i < total; i++ {
+		msg, err := ReadMsg(c2)
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
 		}
 
-		if err = handleConn(conn, "server"); err != nil {
-			t.Error(err)
-			errch <- err
+		var expect *Msg
+		select {
+		case err = <-errch:
+			t.Fatal(err)
+		case expect = <-sent:
 		}
-	}()
 
-	go func() {
-		conn, err := net.Dial("tcp", addr)
-		if err != nil {
-			t.Error(err)
+		if msg.CmdSet != expect.CmdSet || msg.Cmd != expect.Cmd || msg.Id != expect.Id {
+			t.Fatalf("message %d should be %d/%d/%d, got %d/%d/%d", i, expect.CmdSet, expect.Cmd, expect.Id, msg.CmdSet, msg.Cmd, msg.Id)
 		}
-
-		if err = handleConn(conn, "client"); err != nil {
-			t.Error(err)
-			errch <- err
+		if !bytes.Equal(msg.Payload, expect.Payload) {
+			t.Fatalf("payload of message %d is different", i)
 		}
-	}()
-
-	fmt.Println("test fail", <-errch)
+	}
 }
 
-func handleConn(conn net.Conn, mark string) error {
-	errch := make(chan error)
+func TestWriteMsg_Invalid(t *testing.T) {
+	buf := new(bytes.Buffer)
 
-	c := NewAsyncMsgConn(conn)
+	if err := WriteMsg(buf, NewMsg()); err != errMsgNull {
+		t.Fatalf("empty message should not be written: %v", err)
+	}
 
-	c.handler = func(msg *Msg) {
-		fmt.Println(mark, "receive", msg.CmdSet, msg.Cmd, msg.Id, msg.Size)
+	msg := NewMsg()
+	msg.Payload = make([]byte, maxPayloadSize+1)
+	if err := WriteMsg(buf, msg); err != errMsgTooLarge {
+		t.Fatalf("too large message should not be written: %v", err)
 	}
 
-	c.Start()
+	if buf.Len() != 0 {
+		t.Fatalf("nothing should be written, got %d bytes", buf.Len())
+	}
+}
 
-	go func() {
-		select {
-		case err := <-c.errch:
-			fmt.Println(mark, "error", err)
-			errch <- err
-		}
-	}()
+func TestReadMsg_TooLarge(t *testing.T) {
+	msg := mockMsg()
+	buf := new(bytes.Buffer)
+	if err := WriteMsg(buf, msg); err != nil {
+		t.Fatal(err)
+	}
 
-	for {
-		msg, err := mockMsg()
-		if err != nil {
-			continue
-		}
+	// size of payload is at [14:18] of the header
+	data := buf.Bytes()
+	data[14], data[15], data[16], data[17] = 0xff, 0xff, 0xff, 0xff
 
-		err = c.SendMsg(msg)
-		if err != nil {
-			fmt.Println(mark, "send error", err)
-			errch <- err
-		} else {
-			fmt.Println(mark, "send", msg.CmdSet, msg.Cmd, msg.Id, msg.Size)
-		}
+	if _, err := ReadMsg(bytes.NewReader(data)); err != errMsgTooLarge {
+		t.Fatalf("too large message should not be read: %v", err)
 	}
-
-	err := <-errch
-	c.Close()
-	return err
 }
 
-func TestAsyncMsgConn_Error(t *testing.T) {
-	errch := make(chan error)
-	const addr = "localhost:8890"
+func TestHeadShake(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
 
+	type result struct {
+		head *headMsg
+		err  error
+	}
+	done := make(chan result, 1)
 	go func() {
-		ln, err := net.Listen("tcp", addr)
-		if err != nil {
-			t.Error(err)
-		}
-
-		conn, err := ln.Accept()
-		if err != nil {
-			t.Error(err)
-		}
-
-		if err = handleConn(conn, "server"); err != nil {
-			t.Error(err)
-			errch <- err
-		}
+		head, err := headShake(c2, &headMsg{Version: Version, NetID: network.Aquarius})
+		done <- result{head, err}
 	}()
 
-	go func() {
-		conn, err := net.Dial("tcp", addr)
-		if err != nil {
-			t.Error(err)
-		}
+	their, err := headShake(c1, &headMsg{Version: Version - 1, NetID: network.MainNet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if their.Version != Version || their.NetID != network.Aquarius {
+		t.Fatalf("should receive %d/%d, got %d/%d", Version, network.Aquarius, their.Version, their.NetID)
+	}
 
-		if err = handleConn(conn, "client"); err != nil {
-			t.Error(err)
-			errch <- err
-		}
-	}()
+	r := <-done
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if r.head.Version != Version-1 || r.head.NetID != network.MainNet {
+		t.Fatalf("should receive %d/%d, got %d/%d", Version-1, network.MainNet, r.head.Version, r.head.NetID)
+	}
+}
 
-	fmt.Println("test fail", <-errch)
+func TestUint24(t *testing.T) {
+	buf := make([]byte, 3)
+	for _, v := range []uint32{0, 1, 0xff, 0x1234, 0xffffff} {
+		PutUint24(buf, v)
+		if got := Uint24(buf); got != v {
+			t.Fatalf("should be %d, got %d", v, got)
+		}
+	}
 }
@@ -723,7 +723,7 @@ func (self *BCPool) loopGenSnippetChains() int {
 	for _, v := range headMap {
 		final[v.id()] = v
 	}
-	self.chainpool.snippetChains = final
+	self.chainpool.setSnippetChains(final)
 	return i
 }
 
@@ -748,7 +748,7 @@ func (self *BCPool) loopAppendChains() int {
 			newChain, err := self.chainpool.forkChain(c, w)
 			if err == nil {
 				tmpChains = append(tmpChains, newChain)
-				delete(self.chainpool.snippetChains, w.id())
+				self.chainpool.delSnippetChain(w.id())
 			}
 			continue
 		}
@@ -994,7 +994,7 @@ func (self *BCPool) delChain(c *forkedChain) {
 	self.blockpool.delFromCompound(c.heightBlocks)
 }
 func (self *BCPool) delSnippet(c *snippetChain) {
-	delete(self.chainpool.snippetChains, c.id())
+	self.chainpool.delSnippetChain(c.id())
 	self.blockpool.delFromCompound(c.heightBlocks)
 }
 func (self *BCPool) info() map[string]interface{} {
@@ -18,6 +18,7 @@ type chainPool struct {
 	lastestChainIdx int32
 	current         *forkedChain
 	snippetChains   map[string]*snippetChain // head is fixed
+	snippetCount    int32                    // size of snippetChains, read by the metrics without locks
 	chains          map[string]*forkedChain
 	diskChain       *diskChain
 
@@ -77,7 +78,7 @@ func (self *chainPool) init() {
 	self.current.init(initBlock)
 	self.current.referChain = self.diskChain
 	self.chains = make(map[string]*forkedChain)
-	self.snippetChains = make(map[string]*snippetChain)
+	self.setSnippetChains(make(map[string]*snippetChain))
 	self.addChain(self.current)
 }
 
@@ -328,14 +329,14 @@ LOOP:
 				block = b2
 				tail := snippet.remTail()
 				if tail == nil {
-					delete(self.snippetChains, snippet.id())
+					self.delSnippetChain(snippet.id())
 					hr = nil
 					trace += "[4]"
 					err = errors.Errorf("snippet rem nil. size:%d", snippet.size())
 					break LOOP
 				}
 				if snippet.size() == 0 {
-					delete(self.snippetChains, snippet.id())
+					self.delSnippetChain(snippet.id())
 					hr = nil
 					trace += "[5]"
 					err = errors.New("snippet is empty.")
@@ -406,7 +407,7 @@ func (self *chainPool) forky(snippet *snippetChain, chains []*forkedChain) (bool
 			if sameChain(snippet, c) {
 				cutSnippet(snippet, c.headHeight)
 				if snippet.headHeight == snippet.tailHeight {
-					delete(self.snippetChains, snippet.id())
+					self.delSnippetChain(snippet.id())
 					return false, false, nil
 				} else {
 					return false, true, c
@@ -419,7 +420,7 @@ func (self *chainPool) forky(snippet *snippetChain, chains []*forkedChain) (bool
 			}
 		}
 		if snippet.headHeight == snippet.tailHeight {
-			delete(self.snippetChains, snippet.id())
+			self.delSnippetChain(snippet.id())
 			return false, false, nil
 		}
 	}
@@ -432,7 +433,7 @@ func (self *chainPool) forky(snippet *snippetChain, chains []*forkedChain) (bool
 	}
 	// todo duplication code
 	if snippet.headHeight == snippet.tailHeight {
-		delete(self.snippetChains, snippet.id())
+		self.delSnippetChain(snippet.id())
 		return false, false, nil
 	}
 	return false, false, nil
@@ -448,7 +449,7 @@ func (self *chainPool) insertSnippet(c *forkedChain, snippet *snippetChain) erro
 		snippet.deleteTail(w)
 	}
 	if snippet.tailHeight == snippet.headHeight {
-		delete(self.snippetChains, snippet.chainId)
+		self.delSnippetChain(snippet.chainId)
 	}
 	return nil
 }
@@ -608,6 +609,17 @@ func (self *chainPool) delChain(id string) {
 	defer self.chainMu.Unlock()
 	delete(self.chains, id)
 }
+func (self *chainPool) setSnippetChains(chains map[string]*snippetChain) {
+	self.snippetChains = chains
+	atomic.StoreInt32(&self.snippetCount, int32(len(chains)))
+}
+func (self *chainPool) delSnippetChain(id string) {
+	delete(self.snippetChains, id)
+	atomic.StoreInt32(&self.snippetCount, int32(len(self.snippetChains)))
+}
+func (self *chainPool) snippetSize() int {
+	return int(atomic.LoadInt32(&self.snippetCount))
+}
 func (self *chainPool) size() int {
 	self.chainMu.Lock()
 	defer self.chainMu.Unlock()
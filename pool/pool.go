@@ -185,7 +185,7 @@ func (self *pool) Info(addr *types.Address) string {
 
 		freeSize := len(bp.freeBlocks)
 		compoundSize := len(bp.compoundBlocks)
-		snippetSize := len(cp.snippetChains)
+		snippetSize := cp.snippetSize()
 		currentLen := cp.current.size()
 		chainSize := cp.size()
 		return fmt.Sprintf("freeSize:%d, compoundSize:%d, snippetSize:%d, currentLen:%d, chainSize:%d",
@@ -200,7 +200,7 @@ func (self *pool) Info(addr *types.Address) string {
 
 		freeSize := len(bp.freeBlocks)
 		compoundSize := len(bp.compoundBlocks)
-		snippetSize := len(cp.snippetChains)
+		snippetSize := cp.snippetSize()
 		currentLen := cp.current.size()
 		chainSize := cp.size()
 		return fmt.Sprintf("freeSize:%d, compoundSize:%d, snippetSize:%d, currentLen:%d, chainSize:%d",
@@ -240,6 +240,7 @@ func (self *pool) Start() {
 	self.snapshotSubId = self.sync.SubscribeSnapshotBlock(self.AddSnapshotBlock)
 
 	self.pendingSc.Start()
+	self.registerMetrics()
 	self.log.Info("pool account parallel.", "parallel", ACCOUNT_PARALLEL)
 	for i := 0; i < ACCOUNT_PARALLEL; i++ {
 		common.Go(self.loopTryInsert)
@@ -258,6 +259,48 @@ func (self *pool) Stop() {
 	self.pendingSc.Stop()
 	close(self.closed)
 	self.wg.Wait()
+	self.unregisterMetrics()
+}
+
+var poolMetrics = []string{
+	"gvite_pool_snapshot_chains",
+	"gvite_pool_snapshot_snippets",
+	"gvite_pool_account_chains",
+	"gvite_pool_account_snippets",
+}
+
+// registerMetrics exports the number of forked chains and snippet chains in the pools
+func (self *pool) registerMetrics() {
+	monitor.NewGaugeFunc(poolMetrics[0], "Forked chains in the snapshot pool", func() float64 {
+		return float64(self.pendingSc.chainpool.size())
+	})
+	monitor.NewGaugeFunc(poolMetrics[1], "Snippet chains in the snapshot pool", func() float64 {
+		return float64(self.pendingSc.chainpool.snippetSize())
+	})
+	monitor.NewGaugeFunc(poolMetrics[2], "Forked chains in all account pools", func() float64 {
+		chains, _ := self.accountPoolSize()
+		return float64(chains)
+	})
+	monitor.NewGaugeFunc(poolMetrics[3], "Snippet chains in all account pools", func() float64 {
+		_, snippets := self.accountPoolSize()
+		return float64(snippets)
+	})
+}
+
+func (self *pool) unregisterMetrics() {
+	for _, name := range poolMetrics {
+		monitor.Unregister(name)
+	}
+}
+
+func (self *pool) accountPoolSize() (chains int, snippets int) {
+	self.pendingAc.Range(func(_, v interface{}) bool {
+		cp := v.(*accountPool).chainpool
+		chains += cp.size()
+		snippets += cp.snippetSize()
+		return true
+	})
+	return
 }
 func (self *pool) Restart() {
 	self.Lock()
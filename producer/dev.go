@@ -0,0 +1,124 @@
+package producer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vite/net"
+	"github.com/vitelabs/go-vite/vm_context"
+	"github.com/vitelabs/go-vite/wallet"
+)
+
+// NewDevProducer creates the producer of dev mode. Snapshot blocks are produced whenever account blocks
+// are inserted into chain, and every interval if interval is not 0, instead of in consensus slots.
+func NewDevProducer(rw chain.Chain,
+	subscriber net.Subscriber,
+	coinbase *AddressContext,
+	cs consensus.Subscriber,
+	verifier *verifier.SnapshotVerifier,
+	wt *wallet.Manager,
+	p pool.SnapshotProducerWriter,
+	interval time.Duration) *producer {
+	miner := NewProducer(rw, subscriber, coinbase, cs, verifier, wt, p)
+	miner.dev = &devProducer{
+		chain:    rw,
+		worker:   miner.worker,
+		coinbase: coinbase,
+		interval: interval,
+	}
+	return miner
+}
+
+type devProducer struct {
+	chain    chain.Chain
+	worker   *worker
+	coinbase *AddressContext
+	interval time.Duration
+
+	listenerId uint64
+	trigger    chan struct{}
+	term       chan struct{}
+	wg         sync.WaitGroup
+}
+
+func (self *devProducer) start() {
+	self.trigger = make(chan struct{}, 1)
+	self.term = make(chan struct{})
+
+	self.listenerId = self.chain.RegisterInsertAccountBlocksSuccess(func(blocks []*vm_context.VmAccountBlock) {
+		select {
+		case self.trigger <- struct{}{}:
+		default:
+		}
+	})
+
+	self.wg.Add(1)
+	common.Go(func() {
+		defer self.wg.Done()
+		self.loop()
+	})
+}
+
+func (self *devProducer) stop() {
+	self.chain.UnRegister(self.listenerId)
+	close(self.term)
+	self.wg.Wait()
+}
+
+func (self *devProducer) loop() {
+	var tick <-chan time.Time
+	if self.interval > 0 {
+		ticker := time.NewTicker(self.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-self.term:
+			return
+		case <-self.trigger:
+			if len(self.chain.GetNeedSnapshotContent()) > 0 {
+				self.produce()
+			}
+		case <-tick:
+			self.produce()
+		}
+	}
+}
+
+func (self *devProducer) produce() {
+	head := self.chain.GetLatestSnapshotBlock()
+
+	// timestamp must be greater than head, it runs ahead of time if blocks are produced faster than 1 per second
+	timestamp := time.Unix(time.Now().Unix(), 0)
+	if !timestamp.After(*head.Timestamp) {
+		timestamp = head.Timestamp.Add(time.Second)
+	}
+
+	e := consensus.Event{
+		Gid:            types.SNAPSHOT_GID,
+		Address:        self.coinbase.Address,
+		Stime:          timestamp,
+		Etime:          timestamp.Add(time.Second),
+		Timestamp:      timestamp,
+		SnapshotHash:   head.Hash,
+		SnapshotHeight: head.Height,
+	}
+
+	if err := self.worker.tools.checkAddressLock(e.Address, self.coinbase); err != nil {
+		mLog.Error("coinbase must be unlock.", "addr", e.Address.String(), "err", err)
+		self.worker.missSlot(&e)
+		return
+	}
+
+	// produce in the loop, the next block is based on this one
+	self.worker.wg.Add(1)
+	self.worker.genAndInsert(&e)
+}
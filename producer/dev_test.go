@@ -0,0 +1,77 @@
+package producer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// devTestChain records the listeners, nothing needs to be snapshotted so the dev producer never produces
+type devTestChain struct {
+	chain.Chain
+
+	lock          sync.Mutex
+	maxListenerId uint64
+	listeners     map[uint64]chain.InsertProcessorFuncSuccess
+	checked       chan struct{}
+}
+
+func newDevTestChain() *devTestChain {
+	return &devTestChain{
+		listeners: make(map[uint64]chain.InsertProcessorFuncSuccess),
+		checked:   make(chan struct{}, 10),
+	}
+}
+
+func (c *devTestChain) RegisterInsertAccountBlocksSuccess(processor chain.InsertProcessorFuncSuccess) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.maxListenerId++
+	c.listeners[c.maxListenerId] = processor
+	return c.maxListenerId
+}
+
+func (c *devTestChain) UnRegister(listenerId uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.listeners, listenerId)
+}
+
+func (c *devTestChain) GetNeedSnapshotContent() ledger.SnapshotContent {
+	c.checked <- struct{}{}
+	return nil
+}
+
+func (c *devTestChain) trigger() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, listener := range c.listeners {
+		listener(nil)
+	}
+	return len(c.listeners)
+}
+
+func TestDevProducerStartStop(t *testing.T) {
+	c := newDevTestChain()
+	dev := &devProducer{chain: c}
+
+	for round := 0; round < 2; round++ {
+		dev.start()
+		if n := c.trigger(); n != 1 {
+			t.Fatalf("round %d: 1 listener should be registered, got %d", round, n)
+		}
+		select {
+		case <-c.checked:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round %d: inserted account blocks should trigger the dev producer", round)
+		}
+
+		dev.stop()
+		if n := c.trigger(); n != 0 {
+			t.Fatalf("round %d: the listener should be unregistered, %d left", round, n)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package producer
 
 import (
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/chain"
@@ -32,6 +33,13 @@ type Producer interface {
 	Init() error
 	Start() error
 	Stop() error
+	Schedule() *ScheduleStatus
+}
+
+// ScheduleStatus tells whether the snapshot blocks are produced in the slots of the coinbase
+type ScheduleStatus struct {
+	MissedSlots uint32    // slots missed since the last snapshot block produced
+	LastSlot    time.Time // end time of the last slot, zero if no slot has come
 }
 
 // Backend wraps all methods required for mining.
@@ -78,6 +86,8 @@ type producer struct {
 	accountFn            func(producerevent.AccountEvent)
 	syncState            net.SyncState
 	netSyncId            int
+
+	dev *devProducer
 }
 
 // todo syncDone
@@ -133,12 +143,18 @@ func (self *producer) Start() error {
 	snapshotId := self.coinbase.Address.String() + "_snapshot"
 	contractId := self.coinbase.Address.String() + "_contract"
 
-	self.cs.Subscribe(types.SNAPSHOT_GID, snapshotId, &self.coinbase.Address, func(e consensus.Event) {
-		mLog.Info("snapshot producer trigger.", "addr", self.coinbase.Address, "syncState", self.syncState, "e", e)
-		if self.syncState == net.Syncdone {
-			self.worker.produceSnapshot(e)
-		}
-	})
+	if self.dev != nil {
+		self.dev.start()
+	} else {
+		self.cs.Subscribe(types.SNAPSHOT_GID, snapshotId, &self.coinbase.Address, func(e consensus.Event) {
+			mLog.Info("snapshot producer trigger.", "addr", self.coinbase.Address, "syncState", self.syncState, "e", e)
+			if self.syncState == net.Syncdone {
+				self.worker.produceSnapshot(e)
+			} else {
+				self.worker.missSlot(&e)
+			}
+		})
+	}
 	self.cs.Subscribe(types.DELEGATE_GID, contractId, &self.coinbase.Address, func(e consensus.Event) {
 		mLog.Info("contract producer trigger.", "addr", self.coinbase.Address, "syncState", self.syncState, "e", e)
 		if self.syncState == net.Syncdone {
@@ -164,7 +180,11 @@ func (self *producer) Stop() error {
 	snapshotId := self.coinbase.Address.String() + "_snapshot"
 	contractId := self.coinbase.Address.String() + "_contract"
 
-	self.cs.UnSubscribe(types.SNAPSHOT_GID, snapshotId)
+	if self.dev != nil {
+		self.dev.stop()
+	} else {
+		self.cs.UnSubscribe(types.SNAPSHOT_GID, snapshotId)
+	}
 	self.cs.UnSubscribe(types.DELEGATE_GID, contractId)
 
 	self.subscriber.UnsubscribeSyncStatus(self.netSyncId)
@@ -202,6 +222,10 @@ func (self *producer) producerContract(e consensus.Event) {
 	}
 }
 
+func (self *producer) Schedule() *ScheduleStatus {
+	return self.worker.schedule()
+}
+
 func (self *producer) SetAccountEventFunc(accountFn func(producerevent.AccountEvent)) {
 	self.accountFn = accountFn
 }
@@ -34,8 +34,8 @@ var log = log15.New("module", "producerTest")
 var accountPrivKeyStr string
 
 func init() {
+	// parsed by go test, flag.Parse in init breaks the test flags
 	flag.StringVar(&accountPrivKeyStr, "k", "", "")
-	flag.Parse()
 	fmt.Println(accountPrivKeyStr)
 
 }
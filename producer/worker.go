@@ -2,6 +2,7 @@ package producer
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"time"
 
@@ -21,6 +22,9 @@ type worker struct {
 	coinbase *AddressContext
 	mu       sync.Mutex
 	wg       sync.WaitGroup
+
+	missedSlots uint32 // atomic, reset when a snapshot block is produced
+	lastSlot    int64  // atomic, unix time of the end of the last slot
 }
 
 func newWorker(chain *tools, coinbase *AddressContext) *worker {
@@ -57,6 +61,7 @@ func (self *worker) produceSnapshot(e consensus.Event) {
 	err := self.tools.checkAddressLock(e.Address, self.coinbase)
 	if err != nil {
 		mLog.Error("coinbase must be unlock.", "addr", e.Address.String(), "err", err)
+		self.missSlot(&e)
 		return
 	}
 	tmpE := &e
@@ -81,6 +86,7 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	b, err := self.tools.generateSnapshot(e, self.coinbase)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[generate].", "err", err)
+		self.missSlot(e)
 		return
 	}
 
@@ -88,6 +94,25 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	err = self.tools.insertSnapshot(b)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[insert].", "err", err)
+		self.missSlot(e)
 		return
 	}
+
+	atomic.StoreUint32(&self.missedSlots, 0)
+	atomic.StoreInt64(&self.lastSlot, e.Etime.Unix())
+}
+
+func (self *worker) missSlot(e *consensus.Event) {
+	atomic.AddUint32(&self.missedSlots, 1)
+	atomic.StoreInt64(&self.lastSlot, e.Etime.Unix())
+}
+
+func (self *worker) schedule() *ScheduleStatus {
+	status := &ScheduleStatus{
+		MissedSlots: atomic.LoadUint32(&self.missedSlots),
+	}
+	if lastSlot := atomic.LoadInt64(&self.lastSlot); lastSlot > 0 {
+		status.LastSlot = time.Unix(lastSlot, 0)
+	}
+	return status
 }
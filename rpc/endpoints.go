@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 
 	log "github.com/vitelabs/go-vite/log15"
 	"os"
@@ -26,8 +27,9 @@ import (
 )
 
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, exposeAll bool) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules,
+// handlers serve the paths besides the RPC, guard checks the requests if it isn't nil
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, exposeAll bool, handlers map[string]http.Handler, guard Guard) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -52,13 +54,14 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 		return nil, nil, err
 	}
 
-	go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+	handler.SetGuard(guard)
+	go NewHTTPServer(cors, vhosts, timeouts, handler, handlers).Serve(listener)
 
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint, guard checks the requests if it isn't nil
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, guard Guard) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -84,6 +87,7 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 		return nil, nil, err
 	}
 
+	handler.SetGuard(guard)
 	go NewWSServer(wsOrigins, handler).Serve(listener)
 
 	return listener, handler, err
@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Guard checks the requests before they are executed, eg. authentication, permissions and rate limits.
+// The error returned is responded to the client, with its code if it implements Error.
+type Guard interface {
+	Check(ctx context.Context, method string) error
+}
+
+// SetGuard sets the guard of all requests, it must be called before serving
+func (s *Server) SetGuard(guard Guard) {
+	s.guard = guard
+}
+
+// RemoteFromContext returns the address of the HTTP or WS client
+func RemoteFromContext(ctx context.Context) string {
+	remote, _ := ctx.Value("remote").(string)
+	return remote
+}
+
+// AuthorizationFromContext returns the Authorization header of the HTTP or WS request
+func AuthorizationFromContext(ctx context.Context) string {
+	authorization, _ := ctx.Value("authorization").(string)
+	return authorization
+}
+
+// wsAuthorization returns the Authorization header, or the bearer token in the query
+// because browsers can't set headers of websocket
+func wsAuthorization(r *http.Request) string {
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		return authorization
+	}
+	if token := r.URL.Query().Get("token"); token != "" {
+		return "Bearer " + token
+	}
+	return ""
+}
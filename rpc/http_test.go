@@ -17,6 +17,8 @@
 package rpc
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -52,3 +54,68 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestHTTPServerHandlers(t *testing.T) {
+	handlers := map[string]http.Handler{
+		"/health": http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	}
+	server := NewHTTPServer(nil, []string{"*"}, DefaultHTTPTimeouts, NewServer(), handlers)
+
+	w := httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://url.com/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/health should be served by its handler, got %d", w.Code)
+	}
+
+	// other paths are served by the RPC
+	w = httptest.NewRecorder()
+	server.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "http://url.com/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("/ should be served by the RPC, got %d", w.Code)
+	}
+}
+
+type testGuard struct {
+	method        string
+	authorization string
+}
+
+func (g *testGuard) Check(ctx context.Context, method string) error {
+	g.method = method
+	g.authorization = AuthorizationFromContext(ctx)
+	if g.authorization != "Bearer token" {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+func TestHTTPServerGuard(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	guard := &testGuard{}
+	server.SetGuard(guard)
+	httpServer := NewHTTPServer(nil, []string{"*"}, DefaultHTTPTimeouts, server, nil)
+
+	call := func(authorization string) string {
+		request := httptest.NewRequest(http.MethodPost, "http://url.com/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["x",1]}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		httpServer.Handler.ServeHTTP(w, request)
+		return w.Body.String()
+	}
+
+	if body := call("Bearer wrong"); !strings.Contains(body, `"error":{"code":-32000,"message":"unauthorized"}`) {
+		t.Fatalf("the request should be rejected, got %s", body)
+	}
+	if guard.method != "test_echo" || guard.authorization != "Bearer wrong" {
+		t.Fatalf("unexpected method %s or authorization %s", guard.method, guard.authorization)
+	}
+	if body := call("Bearer token"); !strings.Contains(body, `"result":{"String":"x","Int":1,"Args":null}`) {
+		t.Fatalf("the request should be allowed, got %s", body)
+	}
+}
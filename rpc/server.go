@@ -24,13 +24,20 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	mapset "github.com/deckarep/golang-set"
 	log "github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/monitor"
 )
 
 const MetadataApi = "rpc"
 
+var (
+	requestDuration = monitor.NewHistogramVec("gvite_rpc_request_duration_seconds", "Time taken by RPC methods", nil, "method")
+	requestErrors   = monitor.NewCounterVec("gvite_rpc_request_errors", "RPC calls returned an error", "method")
+)
+
 // CodecOption specifies which type of messages this codec supports
 type CodecOption int
 
@@ -274,6 +281,19 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	method := req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)
+	if req.callb.isSubscribe {
+		method = req.svcname + subscribeMethodSuffix
+	}
+	if s.guard != nil {
+		if err := s.guard.Check(ctx, method); err != nil {
+			if e, ok := err.(Error); ok {
+				return codec.CreateErrorResponse(&req.id, e), nil
+			}
+			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -306,12 +326,15 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	// execute RPC method and return result
+	start := time.Now()
 	reply := req.callb.method.Func.Call(arguments)
+	requestDuration.WithLabelValues(method).ObserveSince(start)
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
+			requestErrors.WithLabelValues(method).Inc()
 			e := reply[req.callb.errPos].Interface().(error)
 			ne, ok := e.(Error)
 			if ok {
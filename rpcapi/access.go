@@ -0,0 +1,238 @@
+package rpcapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/monitor"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+var rejectedRequests = monitor.NewCounterVec("gvite_rpc_rejected", "RPC requests rejected by the access guard", "reason")
+
+// Permission lists the namespaces and methods can be called, "*" allows all.
+// Methods are full names like "ledger_getBlocksByAccAddr", subscriptions are "<namespace>_subscribe".
+type Permission struct {
+	Namespaces []string `json:"Namespaces"`
+	Methods    []string `json:"Methods"`
+}
+
+func (p *Permission) allows(method string) bool {
+	namespace := method
+	if i := strings.Index(method, "_"); i >= 0 {
+		namespace = method[:i]
+	}
+	for _, n := range p.Namespaces {
+		if n == "*" || n == namespace {
+			return true
+		}
+	}
+	for _, m := range p.Methods {
+		if m == "*" || m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenPermission is the permission of a static bearer token
+type TokenPermission struct {
+	Token string `json:"Token"`
+	Permission
+}
+
+// RateLimit is a token bucket refilled by Rate tokens per second, holding at most Burst tokens
+type RateLimit struct {
+	Rate  float64 `json:"Rate"`
+	Burst int     `json:"Burst"` // ceil(Rate) if it is 0
+}
+
+// AccessConfig configures authentication, permissions and rate limits of the HTTP and WS RPC.
+// Clients send "Authorization: Bearer <token>", WS clients can also use the query "?token=<token>".
+type AccessConfig struct {
+	Tokens []*TokenPermission `json:"Tokens"`
+
+	// HS256 secret of JWT, the permission is in the claims "namespaces" and "methods", "exp" and "nbf" are checked
+	JWTSecret string `json:"JWTSecret"`
+
+	// permission of requests without a token, they are rejected if it is nil
+	Anonymous *Permission `json:"Anonymous"`
+
+	IPRateLimit      *RateLimit            `json:"IPRateLimit"`      // per client IP for all methods
+	MethodRateLimits map[string]*RateLimit `json:"MethodRateLimits"` // per client IP, keyed by method, or namespace for the methods not listed
+}
+
+type jwtClaims struct {
+	Namespaces []string `json:"namespaces"`
+	Methods    []string `json:"methods"`
+	Exp        int64    `json:"exp"`
+	Nbf        int64    `json:"nbf"`
+}
+
+// AccessGuard checks the RPC requests by AccessConfig, it implements rpc.Guard
+type AccessGuard struct {
+	tokens    []*TokenPermission
+	jwtSecret []byte
+	anonymous *Permission
+
+	ipLimiter      *limiter
+	methodLimiters map[string]*limiter
+
+	log log15.Logger
+}
+
+func NewAccessGuard(cfg *AccessConfig) (*AccessGuard, error) {
+	g := &AccessGuard{
+		tokens:         cfg.Tokens,
+		anonymous:      cfg.Anonymous,
+		methodLimiters: make(map[string]*limiter),
+		log:            log15.New("module", "rpc_access"),
+	}
+	for _, t := range cfg.Tokens {
+		if t.Token == "" {
+			return nil, errors.New("empty rpc access token")
+		}
+	}
+	if cfg.JWTSecret != "" {
+		g.jwtSecret = []byte(cfg.JWTSecret)
+	}
+
+	var err error
+	if cfg.IPRateLimit != nil {
+		if g.ipLimiter, err = newLimiter(cfg.IPRateLimit); err != nil {
+			return nil, err
+		}
+	}
+	for name, limit := range cfg.MethodRateLimits {
+		if g.methodLimiters[name], err = newLimiter(limit); err != nil {
+			return nil, fmt.Errorf("rate limit of %s: %v", name, err)
+		}
+	}
+	return g, nil
+}
+
+func (g *AccessGuard) Check(ctx context.Context, method string) error {
+	ip := clientIP(rpc.RemoteFromContext(ctx))
+
+	// before authenticating to slow down guessing tokens
+	if g.ipLimiter != nil && !g.ipLimiter.allow(ip) {
+		return g.reject("rate_limited", api.ErrRateLimited, "ip", ip, "method", method)
+	}
+
+	permission, err := g.authenticate(rpc.AuthorizationFromContext(ctx))
+	if err != nil {
+		return g.reject("unauthorized", api.ErrUnauthorized, "ip", ip, "method", method, "err", err)
+	}
+	if !permission.allows(method) {
+		return g.reject("forbidden", api.ErrForbidden, "ip", ip, "method", method)
+	}
+
+	if l := g.methodLimiter(method); l != nil && !l.allow(ip) {
+		return g.reject("rate_limited", api.ErrRateLimited, "ip", ip, "method", method)
+	}
+	return nil
+}
+
+func (g *AccessGuard) reject(reason string, err error, ctx ...interface{}) error {
+	rejectedRequests.WithLabelValues(reason).Inc()
+	g.log.Debug("rpc request rejected", append([]interface{}{"reason", reason}, ctx...)...)
+	return err
+}
+
+func (g *AccessGuard) methodLimiter(method string) *limiter {
+	if l, ok := g.methodLimiters[method]; ok {
+		return l
+	}
+	if i := strings.Index(method, "_"); i >= 0 {
+		return g.methodLimiters[method[:i]]
+	}
+	return nil
+}
+
+func (g *AccessGuard) authenticate(authorization string) (*Permission, error) {
+	if authorization == "" {
+		if g.anonymous == nil {
+			return nil, errors.New("missing token")
+		}
+		return g.anonymous, nil
+	}
+
+	const prefix = "Bearer "
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return nil, errors.New("not a bearer token")
+	}
+	token := authorization[len(prefix):]
+
+	for _, t := range g.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return &t.Permission, nil
+		}
+	}
+	if g.jwtSecret != nil && strings.Count(token, ".") == 2 {
+		return g.verifyJWT(token, time.Now())
+	}
+	return nil, errors.New("unknown token")
+}
+
+func (g *AccessGuard) verifyJWT(token string, now time.Time) (*Permission, error) {
+	parts := strings.Split(token, ".")
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("unsupported jwt alg %s", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, g.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("invalid jwt signature")
+	}
+
+	claims := &jwtClaims{}
+	if err := decodeJWTPart(parts[1], claims); err != nil {
+		return nil, err
+	}
+	if claims.Exp != 0 && now.Unix() >= claims.Exp {
+		return nil, errors.New("jwt expired")
+	}
+	if claims.Nbf != 0 && now.Unix() < claims.Nbf {
+		return nil, errors.New("jwt not valid yet")
+	}
+	return &Permission{Namespaces: claims.Namespaces, Methods: claims.Methods}, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// clientIP strips the port of the remote address
+func clientIP(remote string) string {
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
@@ -0,0 +1,122 @@
+package rpcapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+func requestContext(remote, authorization string) context.Context {
+	ctx := context.WithValue(context.Background(), "remote", remote)
+	return context.WithValue(ctx, "authorization", authorization)
+}
+
+func signJWT(secret string, claims interface{}) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAccessGuard(t *testing.T) {
+	guard, err := NewAccessGuard(&AccessConfig{
+		Tokens: []*TokenPermission{
+			{Token: "admin", Permission: Permission{Namespaces: []string{"*"}}},
+			{Token: "reader", Permission: Permission{Namespaces: []string{"ledger"}, Methods: []string{"onroad_getOnroadBlocksByAddress"}}},
+		},
+		JWTSecret: "secret",
+		Anonymous: &Permission{Methods: []string{"ledger_getSnapshotChainHeight"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		authorization string
+		method        string
+		err           error
+	}{
+		{"", "ledger_getSnapshotChainHeight", nil},
+		{"", "ledger_getBlocksByAccAddr", api.ErrForbidden},
+		{"Bearer admin", "wallet_listEntropyFilesInStandardDir", nil},
+		{"Bearer reader", "ledger_getBlocksByAccAddr", nil},
+		{"Bearer reader", "onroad_getOnroadBlocksByAddress", nil},
+		{"Bearer reader", "onroad_getOnroadBlocksByAddress2", api.ErrForbidden},
+		{"Bearer reader", "wallet_listEntropyFilesInStandardDir", api.ErrForbidden},
+		{"Bearer unknown", "ledger_getSnapshotChainHeight", api.ErrUnauthorized},
+		{"Basic admin", "ledger_getSnapshotChainHeight", api.ErrUnauthorized},
+		{"Bearer " + signJWT("secret", map[string]interface{}{"namespaces": []string{"ledger_subscribe", "net"}}), "net_syncInfo", nil},
+		{"Bearer " + signJWT("secret", map[string]interface{}{"methods": []string{"ledger_subscribe"}}), "ledger_subscribe", nil},
+		{"Bearer " + signJWT("secret", map[string]interface{}{"methods": []string{"ledger_subscribe"}}), "ledger_getBlocksByAccAddr", api.ErrForbidden},
+		{"Bearer " + signJWT("other", map[string]interface{}{"namespaces": []string{"*"}}), "net_syncInfo", api.ErrUnauthorized},
+		{"Bearer " + signJWT("secret", map[string]interface{}{"namespaces": []string{"*"}, "exp": time.Now().Unix() - 1}), "net_syncInfo", api.ErrUnauthorized},
+		{"Bearer " + signJWT("secret", map[string]interface{}{"namespaces": []string{"*"}, "exp": time.Now().Unix() + 60}), "net_syncInfo", nil},
+	}
+	for i, c := range cases {
+		if err := guard.Check(requestContext("127.0.0.1:1234", c.authorization), c.method); err != c.err {
+			t.Errorf("case %d: %s %s, expected %v, got %v", i, c.authorization, c.method, c.err, err)
+		}
+	}
+}
+
+func TestAccessGuardRateLimit(t *testing.T) {
+	guard, err := NewAccessGuard(&AccessConfig{
+		Anonymous:   &Permission{Namespaces: []string{"*"}},
+		IPRateLimit: &RateLimit{Rate: 0.001, Burst: 3},
+		MethodRateLimits: map[string]*RateLimit{
+			"ledger":                    {Rate: 0.001, Burst: 2},
+			"ledger_getBlocksByAccAddr": {Rate: 0.001, Burst: 1},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(remote, method string, expected error) {
+		if err := guard.Check(requestContext(remote, ""), method); err != expected {
+			t.Fatalf("%s %s, expected %v, got %v", remote, method, expected, err)
+		}
+	}
+	check("1.1.1.1:1", "ledger_getBlocksByAccAddr", nil)
+	check("1.1.1.1:2", "ledger_getBlocksByAccAddr", api.ErrRateLimited)
+	check("1.1.1.1:3", "ledger_getSnapshotChainHeight", nil) // limited by the namespace
+	check("2.2.2.2:1", "ledger_getBlocksByAccAddr", nil)
+	check("1.1.1.1:4", "net_syncInfo", api.ErrRateLimited) // the ip bucket is empty
+}
+
+func TestLimiter(t *testing.T) {
+	l, err := newLimiter(&RateLimit{Rate: 2, Burst: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i, expected := range []bool{true, true, false} {
+		if l.allowAt("a", now) != expected {
+			t.Fatalf("request %d should be allowed: %v", i, expected)
+		}
+	}
+	if !l.allowAt("b", now) {
+		t.Fatal("buckets should be separated by key")
+	}
+	if !l.allowAt("a", now.Add(500*time.Millisecond)) || l.allowAt("a", now.Add(500*time.Millisecond)) {
+		t.Fatal("a token should be refilled in 0.5s")
+	}
+
+	l.allowAt("a", now.Add(limiterPruneInterval))
+	if len(l.buckets) != 1 {
+		t.Fatalf("full buckets should be pruned, %d left", len(l.buckets))
+	}
+
+	if _, err := newLimiter(&RateLimit{}); err == nil {
+		t.Fatal("zero rate should be rejected")
+	}
+}
@@ -39,6 +39,7 @@ type benchmark struct {
 }
 
 func TestBenchmark(t *testing.T) {
+	w := newTestWallet(t)
 	go func() {
 		http.ListenAndServe("0.0.0.0:8080", nil)
 	}()
@@ -48,8 +49,7 @@ func TestBenchmark(t *testing.T) {
 
 	genesisAddr, _ := types.HexToAddress("vite_098dfae02679a4ca05a4c8bf5dd00a8757f0c622bfccce7d68")
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
-	b := &benchmark{passwd: "123456", w: wallet.New(nil), genesisAddr: genesisAddr, coinbase: &addr}
-	b.unlockAll()
+	b := &benchmark{passwd: "123456", w: w, genesisAddr: genesisAddr, coinbase: &addr}
 	b.init()
 
 	//waApi := NewWalletApi(vite)
@@ -126,18 +126,6 @@ func (b *benchmark) getHeight(addr types.Address) uint64 {
 	return block.Height
 }
 
-func (self *benchmark) unlockAll() []types.Address {
-	results := self.w.SeedStoreManagers.Addresses()
-
-	for _, r := range results {
-		err := self.w.SeedStoreManagers.Unlock(r, password, 0)
-		if err != nil {
-			log.Error("unlock fail.", "err", err, "address", r.String())
-		}
-	}
-	return results
-}
-
 // genesis receive
 func (b *benchmark) init() {
 	var err error
@@ -182,10 +170,15 @@ func (b *benchmark) init() {
 	b.mlog = log15.New("module", "benchmark")
 }
 func (b *benchmark) benchmark() {
-	genesisPriKey, e := b.walletTestApi.walletApi.ExportPriv(b.genesisAddr, b.passwd)
+	_, genesisKey, _, e := b.w.GlobalFindAddrWithPassphrase(b.genesisAddr, b.passwd)
+	if e != nil {
+		panic(e)
+	}
+	genesisPriv, e := genesisKey.PrivateKey()
 	if e != nil {
 		panic(e)
 	}
+	genesisPriKey := genesisPriv.Hex()
 	err := b.receive(b.genesisAddr, genesisPriKey)
 	if err != nil && err != b.normalErr {
 		panic(err)
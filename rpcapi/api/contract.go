@@ -1,11 +1,20 @@
 package api
 
 import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/abi"
 	"github.com/vitelabs/go-vite/vm/contracts"
+	cabi "github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_context"
 )
 
 type ContractApi struct {
@@ -27,3 +36,93 @@ func (c ContractApi) String() string {
 func (c *ContractApi) GetCreateContractToAddress(selfAddr types.Address, height uint64, prevHash types.Hash, snapshotHash types.Hash) types.Address {
 	return contracts.NewContractAddress(selfAddr, height, prevHash, snapshotHash)
 }
+
+func (c *ContractApi) GetCreateContractData(gid types.Gid, hexCode string) ([]byte, error) {
+	code, err := hex.DecodeString(hexCode)
+	if err != nil {
+		return nil, err
+	}
+	return contracts.GetCreateContractData(code, gid), nil
+}
+
+type CallOffChainParams struct {
+	SelfAddr     types.Address `json:"selfAddr"`
+	SnapshotHash *types.Hash   `json:"snapshotHash"`
+	Data         []byte        `json:"data"`
+	Abi          string        `json:"abi"`
+}
+
+type CallOffChainResult struct {
+	Data   []byte        `json:"data"`
+	Output []interface{} `json:"output"`
+}
+
+// CallOffChain executes a contract method against the state confirmed by a snapshot block, no block is produced.
+// Precompiled contracts are read by their off chain methods, whose outputs are decoded without params.Abi
+func (c *ContractApi) CallOffChain(params CallOffChainParams) (*CallOffChainResult, error) {
+	var snapshotBlock *ledger.SnapshotBlock
+	if params.SnapshotHash == nil {
+		snapshotBlock = c.chain.GetLatestSnapshotBlock()
+	} else {
+		var err error
+		snapshotBlock, err = c.chain.GetSnapshotBlockByHash(params.SnapshotHash)
+		if err != nil {
+			return nil, err
+		}
+		if snapshotBlock == nil {
+			return nil, errors.New("snapshot block not exist")
+		}
+	}
+
+	prevBlock, err := c.chain.GetConfirmAccountBlock(snapshotBlock.Height, &params.SelfAddr)
+	if err != nil {
+		return nil, err
+	}
+	if prevBlock == nil {
+		return nil, errors.New("contract not exist")
+	}
+
+	db, err := vm_context.NewVmContext(c.chain, &snapshotBlock.Hash, &prevBlock.Hash, &params.SelfAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if isPreCompiledContracts(params.SelfAddr) {
+		data, err := cabi.CallOffChain(db, params.SelfAddr, params.Data)
+		if err != nil {
+			return nil, err
+		}
+		result := &CallOffChainResult{Data: data}
+		abiContract, _ := cabi.GetOffChainABI(params.SelfAddr)
+		method, _ := abiContract.MethodById(params.Data)
+		if result.Output, err = abiContract.UnpackMethodOutput(method.Name, data); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	code := db.GetContractCode(&params.SelfAddr)
+	if len(code) == 0 {
+		return nil, errors.New("contract code not exist")
+	}
+
+	data, err := vm.NewVM().OffChainReader(db, code, params.Data)
+	if err != nil {
+		return nil, err
+	}
+	result := &CallOffChainResult{Data: data}
+	if len(params.Abi) > 0 {
+		abiContract, err := abi.JSONToABIContract(strings.NewReader(params.Abi))
+		if err != nil {
+			return nil, err
+		}
+		method, err := abiContract.MethodById(params.Data)
+		if err != nil {
+			return nil, err
+		}
+		if result.Output, err = abiContract.UnpackMethodOutput(method.Name, data); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// limits of the paginated and batch queries
+const (
+	maxPageSize              = 1000 // blocks in a page, or in all pages of a batch
+	maxBatchSize             = 100  // addresses in a batch
+	maxSnapshotContentBlocks = 100  // snapshot blocks in a page if they contain the snapshot content
+
+	maxLogSnapshotRange = 100000 // snapshot heights searched by a vm log query
+)
+
+const (
+	cursorAccountBlock byte = iota + 1
+	cursorOnroadBlock
+	cursorSnapshotBlock
+	cursorTransfer
+)
+
+// cursor is the position of the next page, clients get it as an opaque string.
+// Heights don't shift when the chain grows, so pages are stable unlike the index.
+type cursor struct {
+	kind      byte
+	accountId uint64     // account blocks, the cursor is rejected if it is used for another address
+	height    uint64     // account blocks and snapshot blocks, the height of the first block in the next page; transfers, the snapshot height of the last transfer in the previous page
+	hash      types.Hash // onroad blocks, the last hash in the previous page; transfers, the last send block hash in the previous page
+}
+
+func (c *cursor) String() string {
+	var data []byte
+	switch c.kind {
+	case cursorAccountBlock:
+		data = make([]byte, 17)
+		binary.BigEndian.PutUint64(data[1:9], c.accountId)
+		binary.BigEndian.PutUint64(data[9:], c.height)
+	case cursorOnroadBlock:
+		data = make([]byte, 1+types.HashSize)
+		copy(data[1:], c.hash.Bytes())
+	case cursorSnapshotBlock:
+		data = make([]byte, 9)
+		binary.BigEndian.PutUint64(data[1:], c.height)
+	case cursorTransfer:
+		data = make([]byte, 9+types.HashSize)
+		binary.BigEndian.PutUint64(data[1:9], c.height)
+		copy(data[9:], c.hash.Bytes())
+	}
+	data[0] = c.kind
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseCursor returns nil for the first page if s is empty
+func parseCursor(s string, kind byte) (*cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 || data[0] != kind {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &cursor{kind: kind}
+	switch kind {
+	case cursorAccountBlock:
+		if len(data) != 17 {
+			return nil, ErrInvalidCursor
+		}
+		c.accountId = binary.BigEndian.Uint64(data[1:9])
+		c.height = binary.BigEndian.Uint64(data[9:])
+	case cursorOnroadBlock:
+		if c.hash, err = types.BytesToHash(data[1:]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	case cursorSnapshotBlock:
+		if len(data) != 9 {
+			return nil, ErrInvalidCursor
+		}
+		c.height = binary.BigEndian.Uint64(data[1:])
+	case cursorTransfer:
+		if len(data) != 9+types.HashSize {
+			return nil, ErrInvalidCursor
+		}
+		c.height = binary.BigEndian.Uint64(data[1:9])
+		c.hash, _ = types.BytesToHash(data[9:])
+	}
+	if c.kind != cursorOnroadBlock && c.height == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// BlocksPageQuery queries a page of blocks of an address
+type BlocksPageQuery struct {
+	Addr   types.Address `json:"addr"`
+	Cursor string        `json:"cursor"` // empty for the first page
+	Count  int           `json:"count"`
+}
+
+// checkBatch checks the limits of a batch query
+func checkBatch(queries []BlocksPageQuery) error {
+	if len(queries) > maxBatchSize {
+		return ErrQueryLimit
+	}
+	total := 0
+	for _, q := range queries {
+		// checked one by one so that the total doesn't overflow
+		if q.Count <= 0 || q.Count > maxPageSize-total {
+			return ErrQueryLimit
+		}
+		total += q.Count
+	}
+	return nil
+}
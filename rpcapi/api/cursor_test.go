@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestCursor(t *testing.T) {
+	hash, _ := types.BytesToHash(types.DataHash([]byte("onroad")).Bytes())
+	cursors := []*cursor{
+		{kind: cursorAccountBlock, accountId: 7, height: 100},
+		{kind: cursorAccountBlock, accountId: 1, height: 1},
+		{kind: cursorOnroadBlock, hash: hash},
+		{kind: cursorSnapshotBlock, height: 1<<64 - 1},
+		{kind: cursorTransfer, height: 100, hash: hash},
+	}
+	for _, c := range cursors {
+		parsed, err := parseCursor(c.String(), c.kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *parsed != *c {
+			t.Fatalf("expected cursor %+v, got %+v", c, parsed)
+		}
+	}
+
+	if c, err := parseCursor("", cursorAccountBlock); c != nil || err != nil {
+		t.Fatalf("empty cursor is the first page, got %v, error %v", c, err)
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	account := (&cursor{kind: cursorAccountBlock, accountId: 7, height: 100}).String()
+	snapshot := (&cursor{kind: cursorSnapshotBlock, height: 100}).String()
+	onroad := (&cursor{kind: cursorOnroadBlock}).String()
+	transfer := (&cursor{kind: cursorTransfer, height: 100}).String()
+	encode := base64.RawURLEncoding.EncodeToString
+
+	tests := []struct {
+		name string
+		s    string
+		kind byte
+	}{
+		{"not base64", "!!", cursorAccountBlock},
+		{"std base64", "+/+/", cursorAccountBlock},
+		{"no kind", encode(nil) + "A", cursorAccountBlock},
+		{"account cursor for snapshot blocks", account, cursorSnapshotBlock},
+		{"account cursor for onroad blocks", account, cursorOnroadBlock},
+		{"snapshot cursor for account blocks", snapshot, cursorAccountBlock},
+		{"onroad cursor for account blocks", onroad, cursorAccountBlock},
+		{"transfer cursor for snapshot blocks", transfer, cursorSnapshotBlock},
+		{"snapshot cursor for transfers", snapshot, cursorTransfer},
+		{"unknown kind", encode([]byte{5, 0, 0, 0, 0, 0, 0, 0, 1}), cursorSnapshotBlock},
+		{"short account cursor", encode([]byte{cursorAccountBlock, 0, 0, 0, 0, 0, 0, 0, 1}), cursorAccountBlock},
+		{"long snapshot cursor", encode([]byte{cursorSnapshotBlock, 0, 0, 0, 0, 0, 0, 0, 0, 1}), cursorSnapshotBlock},
+		{"short onroad cursor", encode([]byte{cursorOnroadBlock, 1, 2, 3}), cursorOnroadBlock},
+		{"short transfer cursor", encode([]byte{cursorTransfer, 0, 0, 0, 0, 0, 0, 0, 1}), cursorTransfer},
+		{"zero account height", (&cursor{kind: cursorAccountBlock, accountId: 7}).String(), cursorAccountBlock},
+		{"zero snapshot height", (&cursor{kind: cursorSnapshotBlock}).String(), cursorSnapshotBlock},
+		{"zero transfer height", (&cursor{kind: cursorTransfer}).String(), cursorTransfer},
+	}
+	for _, test := range tests {
+		if c, err := parseCursor(test.s, test.kind); err != ErrInvalidCursor {
+			t.Errorf("%s: expected ErrInvalidCursor, got %+v, error %v", test.name, c, err)
+		}
+	}
+}
+
+func TestCheckBatch(t *testing.T) {
+	queries := func(n, count int) []BlocksPageQuery {
+		list := make([]BlocksPageQuery, n)
+		for i := range list {
+			list[i].Count = count
+		}
+		return list
+	}
+
+	tests := []struct {
+		name    string
+		queries []BlocksPageQuery
+		err     error
+	}{
+		{"empty", nil, nil},
+		{"max batch", queries(maxBatchSize, 1), nil},
+		{"max page", queries(1, maxPageSize), nil},
+		{"max pages of a batch", queries(maxPageSize/10, 10), nil},
+		{"too many addresses", queries(maxBatchSize+1, 1), ErrQueryLimit},
+		{"too many blocks", queries(maxBatchSize, maxPageSize/maxBatchSize+1), ErrQueryLimit},
+		{"zero count", queries(2, 0), ErrQueryLimit},
+		{"negative count", queries(1, -1), ErrQueryLimit},
+		{"overflow", append(queries(1, 1), BlocksPageQuery{Count: int(^uint(0) >> 1)}), ErrQueryLimit},
+	}
+	for _, test := range tests {
+		if err := checkBatch(test.queries); err != test.err {
+			t.Errorf("%s: expected %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestCheckSnapshotBlocksCount(t *testing.T) {
+	tests := []struct {
+		count          uint64
+		containContent bool
+		err            error
+	}{
+		{1, false, nil},
+		{maxPageSize, false, nil},
+		{maxSnapshotContentBlocks, true, nil},
+		{0, false, ErrQueryLimit},
+		{maxPageSize + 1, false, ErrQueryLimit},
+		{maxSnapshotContentBlocks + 1, true, ErrQueryLimit},
+	}
+	for _, test := range tests {
+		if err := checkSnapshotBlocksCount(test.count, test.containContent); err != test.err {
+			t.Errorf("count %d, content %v: expected %v, got %v", test.count, test.containContent, test.err, err)
+		}
+	}
+}
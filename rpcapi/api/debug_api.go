@@ -14,6 +14,9 @@ import (
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/vite/net"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm_context"
+	"github.com/vitelabs/go-vite/vm_context/vmctxt_interface"
 )
 
 type DebugApi struct {
@@ -283,12 +286,98 @@ func (api DebugApi) FetchTaskQueue() []*net.Task {
 	return api.v.Net().Tasks()
 }
 
+// TrieGcStatus returns the progress of pruning old account-state tries
+func (api DebugApi) TrieGcStatus() (*chain.TrieGcStatus, error) {
+	status := api.v.Chain().TrieGcStatus()
+	if status == nil {
+		return nil, errors.New("state pruning is disabled, set PruneHeights to enable it")
+	}
+	return status, nil
+}
+
 func (api DebugApi) MachineInfo() map[string]interface{} {
 	result := make(map[string]interface{})
 	result["now"] = time.Now().String()
 	return result
 }
 
+type TraceConfig struct {
+	// Tracer is "callTracer" for a call tree, otherwise opcode level logs are returned
+	Tracer         string
+	DisableStack   bool
+	DisableMemory  bool
+	DisableStorage bool
+}
+
+type TraceResult struct {
+	BlockHash types.Hash  `json:"blockHash"`
+	Error     string      `json:"error,omitempty"`
+	Trace     interface{} `json:"trace"`
+}
+
+// TraceBlock re-executes a confirmed account block on the state before it and returns the execution trace
+func (api DebugApi) TraceBlock(hash types.Hash, config *TraceConfig) (*TraceResult, error) {
+	c := api.v.Chain()
+	block, err := c.GetAccountBlockByHash(&hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("account block not found")
+	}
+	var sendBlock *ledger.AccountBlock
+	if block.IsReceiveBlock() {
+		sendBlock, err = c.GetAccountBlockByHash(&block.FromBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if sendBlock == nil {
+			return nil, errors.New("send block not found")
+		}
+	}
+	var prevHash *types.Hash
+	if block.Height > 1 {
+		prevHash = &block.PrevHash
+	}
+	db, err := vm_context.NewVmContext(c, &block.SnapshotHash, prevHash, &block.AccountAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if config == nil {
+		config = &TraceConfig{}
+	}
+	v := vm.NewVM()
+	var result func() interface{}
+	if config.Tracer == "callTracer" {
+		tracer := vm.NewCallTracer()
+		v.Tracer, result = tracer, func() interface{} { return tracer.Result() }
+	} else {
+		tracer := vm.NewStructLogger(&vm.StructLoggerConfig{
+			DisableStack:   config.DisableStack,
+			DisableMemory:  config.DisableMemory,
+			DisableStorage: config.DisableStorage,
+		})
+		v.Tracer, result = tracer, func() interface{} { return tracer.Result() }
+	}
+
+	_, _, err = traceRun(v, db, block, sendBlock)
+	traceResult := &TraceResult{BlockHash: hash, Trace: result()}
+	if err != nil {
+		traceResult.Error = err.Error()
+	}
+	return traceResult, nil
+}
+
+func traceRun(v *vm.VM, db vmctxt_interface.VmDatabase, block, sendBlock *ledger.AccountBlock) (blockList []*vm_context.VmAccountBlock, isRetry bool, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = errors.Errorf("vm panic: %v", e)
+		}
+	}()
+	return v.Run(db, block, sendBlock)
+}
+
 func NewDebugApi(v *vite.Vite) *DebugApi {
 	return &DebugApi{
 		v: v,
@@ -5,13 +5,10 @@ import (
 	"testing"
 
 	"github.com/vitelabs/go-vite/common/types"
-	"github.com/vitelabs/go-vite/wallet"
 )
 
 func TestDebugApi_ConsensusPlanAndActual(t *testing.T) {
-	w := wallet.New(nil)
-
-	unlockAll(w)
+	w := newTestWallet(t)
 
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
 	vite, err := startVite(w, &addr, t)
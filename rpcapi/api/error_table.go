@@ -36,6 +36,33 @@ var (
 		Code:    -34001,
 	}
 
+	// rejections of the RPC access guard
+	ErrUnauthorized = JsonRpc2Error{
+		Message: "unauthorized",
+		Code:    -36001,
+	}
+
+	ErrForbidden = JsonRpc2Error{
+		Message: "method is not allowed",
+		Code:    -36002,
+	}
+
+	ErrRateLimited = JsonRpc2Error{
+		Message: "rate limit exceeded",
+		Code:    -36003,
+	}
+
+	// paginated and batch queries
+	ErrInvalidCursor = JsonRpc2Error{
+		Message: "invalid cursor",
+		Code:    -37001,
+	}
+
+	ErrQueryLimit = JsonRpc2Error{
+		Message: "count exceeds the limit of the query",
+		Code:    -37002,
+	}
+
 	concernedErrorMap map[string]JsonRpc2Error
 )
 
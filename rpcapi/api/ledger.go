@@ -1,11 +1,14 @@
 package api
 
 import (
+	"encoding/hex"
+	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/generator"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/trie"
 	"github.com/vitelabs/go-vite/vite"
 	"strconv"
 )
@@ -118,6 +121,95 @@ func (l *LedgerApi) GetBlocksByAccAddr(addr types.Address, index int, count int)
 	}
 }
 
+// AccountBlocksPage is a page of account blocks, NextCursor is empty if it is the last page
+type AccountBlocksPage struct {
+	Blocks     []*AccountBlock `json:"blocks"`
+	NextCursor string          `json:"nextCursor,omitempty"`
+}
+
+// GetBlocksPageByAccAddr returns the blocks of addr from the latest one, cursor is the NextCursor of the previous page
+func (l *LedgerApi) GetBlocksPageByAccAddr(addr types.Address, cursor string, count int) (*AccountBlocksPage, error) {
+	l.log.Info("GetBlocksPageByAccAddr")
+	if count <= 0 || count > maxPageSize {
+		return nil, ErrQueryLimit
+	}
+	return l.getBlocksPage(addr, cursor, count)
+}
+
+// GetBlocksPagesByAccAddrs queries pages of multiple addresses, the pages are in the order of queries
+func (l *LedgerApi) GetBlocksPagesByAccAddrs(queries []BlocksPageQuery) ([]*AccountBlocksPage, error) {
+	l.log.Info("GetBlocksPagesByAccAddrs")
+	if err := checkBatch(queries); err != nil {
+		return nil, err
+	}
+
+	pages := make([]*AccountBlocksPage, len(queries))
+	for i, q := range queries {
+		page, err := l.getBlocksPage(q.Addr, q.Cursor, q.Count)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = page
+	}
+	return pages, nil
+}
+
+func (l *LedgerApi) getBlocksPage(addr types.Address, cursorStr string, count int) (*AccountBlocksPage, error) {
+	c, err := parseCursor(cursorStr, cursorAccountBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountBlocksPage{Blocks: []*AccountBlock{}}
+	account, err := l.chain.GetAccount(&addr)
+	if err != nil {
+		l.log.Error("GetAccount failed, error is "+err.Error(), "method", "getBlocksPage")
+		return nil, err
+	}
+	if account == nil {
+		if c != nil {
+			return nil, ErrInvalidCursor
+		}
+		return page, nil
+	}
+	if c != nil && c.accountId != account.AccountId {
+		return nil, ErrInvalidCursor
+	}
+
+	latestBlock, err := l.chain.GetLatestAccountBlock(&addr)
+	if err != nil {
+		l.log.Error("GetLatestAccountBlock failed, error is "+err.Error(), "method", "getBlocksPage")
+		return nil, err
+	}
+	if latestBlock == nil {
+		return page, nil
+	}
+	height := latestBlock.Height
+	// the account chain may be rolled back since the previous page
+	if c != nil && c.height < height {
+		height = c.height
+	}
+
+	list, err := l.chain.GetAccountBlocksByHeight(addr, height, uint64(count), false)
+	if err != nil {
+		l.log.Error("GetAccountBlocksByHeight failed, error is "+err.Error(), "method", "getBlocksPage")
+		return nil, err
+	}
+	blocks, err := l.ledgerBlocksToRpcBlocks(list)
+	if err != nil {
+		l.log.Error("GetConfirmTimes failed, error is "+err.Error(), "method", "getBlocksPage")
+		return nil, err
+	}
+	if len(blocks) > 0 {
+		page.Blocks = blocks
+	}
+
+	if n := len(list); n > 0 && list[n-1].Height > 1 {
+		page.NextCursor = (&cursor{kind: cursorAccountBlock, accountId: account.AccountId, height: list[n-1].Height - 1}).String()
+	}
+	return page, nil
+}
+
 func (l *LedgerApi) GetAccountByAccAddr(addr types.Address) (*RpcAccountInfo, error) {
 	l.log.Info("GetAccountByAccAddr")
 
@@ -183,6 +275,81 @@ func (l *LedgerApi) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlo
 	return block, err
 }
 
+// SnapshotBlocksPage is a page of snapshot blocks, NextCursor is empty if it is the last page
+type SnapshotBlocksPage struct {
+	Blocks     []*ledger.SnapshotBlock `json:"blocks"`
+	NextCursor string                  `json:"nextCursor,omitempty"`
+}
+
+func checkSnapshotBlocksCount(count uint64, containContent bool) error {
+	if count == 0 || count > maxPageSize || (containContent && count > maxSnapshotContentBlocks) {
+		return ErrQueryLimit
+	}
+	return nil
+}
+
+// GetSnapshotBlocksPage returns the snapshot blocks from the latest one, cursor is the NextCursor of the previous page
+func (l *LedgerApi) GetSnapshotBlocksPage(cursorStr string, count int, containContent bool) (*SnapshotBlocksPage, error) {
+	l.log.Info("GetSnapshotBlocksPage")
+	if count <= 0 {
+		return nil, ErrQueryLimit
+	}
+	if err := checkSnapshotBlocksCount(uint64(count), containContent); err != nil {
+		return nil, err
+	}
+	c, err := parseCursor(cursorStr, cursorSnapshotBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	height := l.chain.GetLatestSnapshotBlock().Height
+	// the snapshot chain may be rolled back since the previous page
+	if c != nil && c.height < height {
+		height = c.height
+	}
+
+	blocks, err := l.chain.GetSnapshotBlocksByHeight(height, uint64(count), false, containContent)
+	if err != nil {
+		l.log.Error("GetSnapshotBlocksByHeight failed, error is "+err.Error(), "method", "GetSnapshotBlocksPage")
+		return nil, err
+	}
+
+	page := &SnapshotBlocksPage{Blocks: []*ledger.SnapshotBlock{}}
+	if len(blocks) > 0 {
+		page.Blocks = blocks
+	}
+	if n := len(blocks); n > 0 && blocks[n-1].Height > 1 {
+		page.NextCursor = (&cursor{kind: cursorSnapshotBlock, height: blocks[n-1].Height - 1}).String()
+	}
+	return page, nil
+}
+
+// GetSnapshotBlocksByRange returns the snapshot blocks from startHeight to endHeight in order of height,
+// endHeight is capped by the latest height
+func (l *LedgerApi) GetSnapshotBlocksByRange(startHeight uint64, endHeight uint64, containContent bool) ([]*ledger.SnapshotBlock, error) {
+	l.log.Info("GetSnapshotBlocksByRange")
+	if latestHeight := l.chain.GetLatestSnapshotBlock().Height; endHeight > latestHeight {
+		endHeight = latestHeight
+	}
+	if startHeight == 0 {
+		startHeight = 1
+	}
+	if startHeight > endHeight {
+		return []*ledger.SnapshotBlock{}, nil
+	}
+	count := endHeight - startHeight + 1
+	if err := checkSnapshotBlocksCount(count, containContent); err != nil {
+		return nil, err
+	}
+
+	blocks, err := l.chain.GetSnapshotBlocksByHeight(startHeight, count, true, containContent)
+	if err != nil {
+		l.log.Error("GetSnapshotBlocksByHeight failed, error is "+err.Error(), "method", "GetSnapshotBlocksByRange")
+		return nil, err
+	}
+	return blocks, nil
+}
+
 func (l *LedgerApi) GetSnapshotChainHeight() string {
 	l.log.Info("GetLatestSnapshotChainHeight")
 	return strconv.FormatUint(l.chain.GetLatestSnapshotBlock().Height, 10)
@@ -240,6 +407,14 @@ func (l *LedgerApi) GetSenderInfo() (*KafkaSendInfo, error) {
 		senderInfo.RunProducers = append(senderInfo.RunProducers, createKafkaProducerInfo(producer))
 	}
 
+	for _, sink := range l.chain.KafkaSender().Sinks() {
+		senderInfo.Sinks = append(senderInfo.Sinks, createSinkInfo(sink))
+	}
+
+	for _, sink := range l.chain.KafkaSender().RunSinks() {
+		senderInfo.RunSinks = append(senderInfo.RunSinks, createSinkInfo(sink))
+	}
+
 	return senderInfo, nil
 }
 
@@ -289,3 +464,306 @@ func (l *LedgerApi) StopSender(producerId uint8) {
 	}
 	l.chain.KafkaSender().StopById(producerId)
 }
+
+func (l *LedgerApi) SetSinkHasSend(sinkId uint8, hasSend uint64) {
+	l.log.Info("SetSinkHasSend")
+
+	if l.chain.KafkaSender() == nil {
+		return
+	}
+	l.chain.KafkaSender().SetSinkHasSend(sinkId, hasSend)
+}
+
+func (l *LedgerApi) StopSink(sinkId uint8) {
+	l.log.Info("StopSink")
+
+	if l.chain.KafkaSender() == nil {
+		return
+	}
+	l.chain.KafkaSender().StopSinkById(sinkId)
+}
+
+type VmLogFilterParam struct {
+	AddrList []types.Address `json:"addrList"`
+	Topics   [][]types.Hash  `json:"topics"`
+	// The range is at most maxLogSnapshotRange heights, it ends at toSnapshotHeight if fromSnapshotHeight is 0
+	FromSnapshotHeight uint64 `json:"fromSnapshotHeight"`
+	// Latest snapshot height is used if 0
+	ToSnapshotHeight uint64 `json:"toSnapshotHeight"`
+	Cursor           string `json:"cursor"` // empty for the first page
+	Count            int    `json:"count"`
+}
+
+type RpcVmLog struct {
+	Topics             []types.Hash  `json:"topics"`
+	Data               []byte        `json:"data"`
+	LogIndex           int           `json:"logIndex"`
+	AccountAddress     types.Address `json:"accountAddress"`
+	AccountBlockHash   types.Hash    `json:"accountBlockHash"`
+	AccountBlockHeight string        `json:"accountBlockHeight"`
+	SnapshotHeight     string        `json:"snapshotHeight"`
+}
+
+func (l *LedgerApi) GetLogs(param VmLogFilterParam) ([]*RpcVmLog, error) {
+	l.log.Info("GetLogs")
+	filter := &chain.VmLogFilter{
+		AddrList:           param.AddrList,
+		Topics:             param.Topics,
+		FromSnapshotHeight: param.FromSnapshotHeight,
+		ToSnapshotHeight:   param.ToSnapshotHeight,
+		MaxCount:           maxPageSize,
+	}
+	if filter.ToSnapshotHeight == 0 {
+		filter.ToSnapshotHeight = l.chain.GetLatestSnapshotBlock().Height
+	}
+	if filter.FromSnapshotHeight == 0 {
+		if filter.ToSnapshotHeight >= maxLogSnapshotRange {
+			filter.FromSnapshotHeight = filter.ToSnapshotHeight - maxLogSnapshotRange + 1
+		}
+		start, err := l.chain.GetVmLogStart()
+		if err != nil {
+			l.log.Error("GetVmLogStart failed, error is "+err.Error(), "method", "GetLogs")
+			return nil, err
+		}
+		if filter.FromSnapshotHeight < start {
+			filter.FromSnapshotHeight = start
+		}
+		// nothing is indexed yet
+		if filter.FromSnapshotHeight > filter.ToSnapshotHeight {
+			return []*RpcVmLog{}, nil
+		}
+	}
+	if filter.ToSnapshotHeight >= filter.FromSnapshotHeight+maxLogSnapshotRange {
+		return nil, ErrQueryLimit
+	}
+
+	list, err := l.chain.GetVmLogs(filter)
+	if err != nil {
+		if err == chain.ErrTooManyVmLogs {
+			return nil, ErrQueryLimit
+		}
+		l.log.Error("GetVmLogs failed, error is "+err.Error(), "method", "GetLogs")
+		return nil, err
+	}
+
+	logs := make([]*RpcVmLog, len(list))
+	for i, item := range list {
+		logs[i] = &RpcVmLog{
+			Topics:             item.Log.Topics,
+			Data:               item.Log.Data,
+			LogIndex:           item.LogIndex,
+			AccountAddress:     item.AccountAddress,
+			AccountBlockHash:   item.AccountBlockHash,
+			AccountBlockHeight: uint64ToString(item.AccountBlockHeight),
+			SnapshotHeight:     uint64ToString(item.SnapshotHeight),
+		}
+	}
+	return logs, nil
+}
+
+type TransferFilterParam struct {
+	Addr types.Address `json:"addr"`
+	// Transfers of any token are returned if nil
+	TokenId *types.TokenTypeId `json:"tokenId"`
+	// The lowest height the transfers can be looked up from is used if 0
+	FromSnapshotHeight uint64 `json:"fromSnapshotHeight"`
+	// Latest snapshot height is used if 0
+	ToSnapshotHeight uint64 `json:"toSnapshotHeight"`
+	Cursor           string `json:"cursor"` // empty for the first page
+	Count            int    `json:"count"`
+}
+
+type RpcTransfer struct {
+	FromAddress    types.Address     `json:"fromAddress"`
+	ToAddress      types.Address     `json:"toAddress"`
+	TokenId        types.TokenTypeId `json:"tokenId"`
+	Amount         *string           `json:"amount"`
+	SendBlockHash  types.Hash        `json:"sendBlockHash"`
+	SendBlockType  byte              `json:"sendBlockType"`
+	SnapshotHeight string            `json:"snapshotHeight"` // confirms the send block
+	// nil if the send block isn't received
+	ReceiveBlockHash *types.Hash `json:"receiveBlockHash"`
+}
+
+// TransfersPage is a page of transfers, NextCursor is empty if it is the last page
+type TransfersPage struct {
+	Transfers  []*RpcTransfer `json:"transfers"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// GetTransfersByAddress returns the transfers sent from or to the address in order of snapshot height,
+// it requires the tx history index. Cursor is the NextCursor of the previous page.
+// The index of the transfers to the address is sought to the page by the snapshot height confirming them, and the
+// account chain to the first block confirmed in the page by a binary search, so the cost of a page grows with its
+// count, not with the history of the address.
+func (l *LedgerApi) GetTransfersByAddress(param TransferFilterParam) (*TransfersPage, error) {
+	l.log.Info("GetTransfersByAddress")
+	if param.Count <= 0 || param.Count > maxPageSize {
+		return nil, ErrQueryLimit
+	}
+	c, err := parseCursor(param.Cursor, cursorTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &chain.TransferFilter{
+		Addr:               param.Addr,
+		TokenId:            param.TokenId,
+		FromSnapshotHeight: param.FromSnapshotHeight,
+		ToSnapshotHeight:   param.ToSnapshotHeight,
+		// one more to know if there is a next page
+		Count: param.Count + 1,
+	}
+	if filter.FromSnapshotHeight == 0 {
+		start, err := l.chain.GetTxHistoryStart()
+		if err != nil {
+			l.log.Error("GetTxHistoryStart failed, error is "+err.Error(), "method", "GetTransfersByAddress")
+			return nil, err
+		}
+		filter.FromSnapshotHeight = start
+	}
+	if filter.ToSnapshotHeight == 0 {
+		filter.ToSnapshotHeight = l.chain.GetLatestSnapshotBlock().Height
+	}
+	// the index is enabled above the latest snapshot block
+	if param.FromSnapshotHeight == 0 && filter.FromSnapshotHeight > filter.ToSnapshotHeight {
+		return &TransfersPage{Transfers: []*RpcTransfer{}}, nil
+	}
+	if c != nil {
+		filter.After = &ledger.HashHeight{Height: c.height, Hash: c.hash}
+	}
+
+	list, err := l.chain.GetTransfers(filter)
+	if err != nil {
+		l.log.Error("GetTransfers failed, error is "+err.Error(), "method", "GetTransfersByAddress")
+		return nil, err
+	}
+
+	page := &TransfersPage{}
+	if len(list) > param.Count {
+		list = list[:param.Count]
+		last := list[param.Count-1]
+		page.NextCursor = (&cursor{kind: cursorTransfer, height: last.SnapshotHeight, hash: last.SendBlock.Hash}).String()
+	}
+
+	page.Transfers = make([]*RpcTransfer, len(list))
+	for i, item := range list {
+		page.Transfers[i] = &RpcTransfer{
+			FromAddress:      item.SendBlock.AccountAddress,
+			ToAddress:        item.SendBlock.ToAddress,
+			TokenId:          item.SendBlock.TokenId,
+			Amount:           bigIntToString(item.SendBlock.Amount),
+			SendBlockHash:    item.SendBlock.Hash,
+			SendBlockType:    item.SendBlock.BlockType,
+			SnapshotHeight:   uint64ToString(item.SnapshotHeight),
+			ReceiveBlockHash: item.ReceiveBlockHash,
+		}
+	}
+	return page, nil
+}
+
+type RpcTrieProof struct {
+	Nodes    []string `json:"nodes"`
+	RefValue string   `json:"refValue,omitempty"`
+}
+
+type RpcStorageProof struct {
+	Key   string        `json:"key"`
+	Value string        `json:"value"`
+	Proof *RpcTrieProof `json:"proof"`
+}
+
+type RpcAccountStateProof struct {
+	Address        types.Address `json:"address"`
+	SnapshotHash   types.Hash    `json:"snapshotHash"`
+	SnapshotHeight string        `json:"snapshotHeight"`
+	// StateHash of the snapshot block, the root of AccountProof
+	StateHash types.Hash `json:"stateHash"`
+	// StorageHash is the root of StorageProof, nil if the account doesn't exist at the snapshot block
+	StorageHash  *types.Hash        `json:"storageHash"`
+	AccountProof *RpcTrieProof      `json:"accountProof"`
+	StorageProof []*RpcStorageProof `json:"storageProof"`
+}
+
+func createRpcTrieProof(proof *trie.Proof) *RpcTrieProof {
+	rpcProof := &RpcTrieProof{
+		Nodes:    make([]string, len(proof.Nodes)),
+		RefValue: hex.EncodeToString(proof.RefValue),
+	}
+	for i, node := range proof.Nodes {
+		rpcProof.Nodes[i] = hex.EncodeToString(node)
+	}
+	return rpcProof
+}
+
+// GetStorageProof proves the storage of addr at keys against the StateHash of a snapshot block, keys are in hex,
+// the latest snapshot block is used if snapshotHash is nil
+func (l *LedgerApi) GetStorageProof(addr types.Address, keys []string, snapshotHash *types.Hash) (*RpcAccountStateProof, error) {
+	l.log.Info("GetStorageProof")
+	snapshotBlock := l.chain.GetLatestSnapshotBlock()
+	if snapshotHash != nil {
+		var err error
+		if snapshotBlock, err = l.chain.GetSnapshotBlockHeadByHash(snapshotHash); err != nil {
+			l.log.Error("GetSnapshotBlockHeadByHash failed, error is "+err.Error(), "method", "GetStorageProof")
+			return nil, err
+		}
+		if snapshotBlock == nil {
+			return nil, errors.New("snapshot block not found")
+		}
+	}
+
+	stateTrie := l.chain.GetStateTrie(&snapshotBlock.StateHash)
+	if stateTrie.Root == nil {
+		return nil, errors.New("state trie of the snapshot block is pruned or missing")
+	}
+	accountProof, err := stateTrie.Prove(addr.Bytes())
+	if err != nil {
+		l.log.Error("Prove account failed, error is "+err.Error(), "method", "GetStorageProof")
+		return nil, err
+	}
+
+	result := &RpcAccountStateProof{
+		Address:        addr,
+		SnapshotHash:   snapshotBlock.Hash,
+		SnapshotHeight: uint64ToString(snapshotBlock.Height),
+		StateHash:      snapshotBlock.StateHash,
+		AccountProof:   createRpcTrieProof(accountProof),
+		StorageProof:   make([]*RpcStorageProof, len(keys)),
+	}
+
+	var storageTrie *trie.Trie
+	if value := stateTrie.GetValue(addr.Bytes()); len(value) > 0 {
+		storageHash, err := types.BytesToHash(value)
+		if err != nil {
+			return nil, err
+		}
+		result.StorageHash = &storageHash
+		storageTrie = l.chain.GetStateTrie(&storageHash)
+		if storageTrie.Root == nil {
+			return nil, errors.New("storage trie of the account is pruned or missing")
+		}
+	}
+
+	for i, hexKey := range keys {
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, err
+		}
+
+		storageProof := &RpcStorageProof{
+			Key:   hexKey,
+			Proof: &RpcTrieProof{Nodes: []string{}},
+		}
+		if storageTrie != nil {
+			proof, err := storageTrie.Prove(key)
+			if err != nil {
+				l.log.Error("Prove storage failed, error is "+err.Error(), "method", "GetStorageProof")
+				return nil, err
+			}
+			storageProof.Value = hex.EncodeToString(storageTrie.GetValue(key))
+			storageProof.Proof = createRpcTrieProof(proof)
+		}
+		result.StorageProof[i] = storageProof
+	}
+	return result, nil
+}
@@ -159,9 +159,19 @@ func RawTokenInfoToRpc(tinfo *types.TokenInfo, tti types.TokenTypeId) *RpcTokenI
 type KafkaSendInfo struct {
 	Producers    []*KafkaProducerInfo `json:"producers"`
 	RunProducers []*KafkaProducerInfo `json:"runProducers"`
+	Sinks        []*SinkInfo          `json:"sinks"`
+	RunSinks     []*SinkInfo          `json:"runSinks"`
 	TotalEvent   uint64               `json:"totalEvent"`
 }
 
+type SinkInfo struct {
+	SinkId  uint8  `json:"sinkId"`
+	Type    string `json:"type"`
+	Target  string `json:"target"`
+	HasSend uint64 `json:"hasSend"`
+	Status  string `json:"status"`
+}
+
 type KafkaProducerInfo struct {
 	ProducerId uint8    `json:"producerId"`
 	BrokerList []string `json:"brokerList"`
@@ -190,6 +200,24 @@ func createKafkaProducerInfo(producer *sender.Producer) *KafkaProducerInfo {
 	return producerInfo
 }
 
+func createSinkInfo(sink *sender.SinkRunner) *SinkInfo {
+	status := "unknown"
+	switch sink.Status() {
+	case sender.STOPPED:
+		status = "stopped"
+	case sender.RUNNING:
+		status = "running"
+	}
+
+	return &SinkInfo{
+		SinkId:  sink.SinkId(),
+		Type:    sink.Type(),
+		Target:  sink.Target(),
+		HasSend: sink.HasSend(),
+		Status:  status,
+	}
+}
+
 func ledgerToRpcBlock(block *ledger.AccountBlock, chain chain.Chain) (*AccountBlock, error) {
 	confirmTimes, err := chain.GetConfirmTimes(&block.Hash)
 
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain_db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// pageTestChain keeps the account chain of one account and the onroad blocks in memory
+type pageTestChain struct {
+	chain.Chain
+
+	chainDb *chain_db.ChainDb
+	account *ledger.Account
+	blocks  []*ledger.AccountBlock // blocks[i].Height is i + 1
+	onroad  map[types.Hash]*ledger.AccountBlock
+
+	snapshotHeight uint64
+	txHistoryStart uint64
+	transferFilter *chain.TransferFilter // filter of the last GetTransfers
+}
+
+func newPageTestChain(height uint64) *pageTestChain {
+	addr, _, _ := types.CreateAddress()
+	c := &pageTestChain{
+		account: &ledger.Account{AccountAddress: addr, AccountId: 7},
+		onroad:  make(map[types.Hash]*ledger.AccountBlock),
+	}
+	for h := uint64(1); h <= height; h++ {
+		c.blocks = append(c.blocks, &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			AccountAddress: addr,
+			Height:         h,
+			Hash:           types.DataHash(append(addr.Bytes(), byte(h))),
+		})
+	}
+	return c
+}
+
+func (c *pageTestChain) ChainDb() *chain_db.ChainDb {
+	return c.chainDb
+}
+
+func (c *pageTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{Height: c.snapshotHeight}
+}
+
+func (c *pageTestChain) GetTxHistoryStart() (uint64, error) {
+	return c.txHistoryStart, nil
+}
+
+func (c *pageTestChain) GetTransfers(filter *chain.TransferFilter) ([]*chain.Transfer, error) {
+	c.transferFilter = filter
+	if filter.FromSnapshotHeight < c.txHistoryStart {
+		return nil, chain.ErrBeforeTxHistoryStart
+	}
+	return nil, nil
+}
+
+func (c *pageTestChain) GetAccount(addr *types.Address) (*ledger.Account, error) {
+	if *addr != c.account.AccountAddress {
+		return nil, nil
+	}
+	return c.account, nil
+}
+
+func (c *pageTestChain) GetLatestAccountBlock(addr *types.Address) (*ledger.AccountBlock, error) {
+	if *addr != c.account.AccountAddress || len(c.blocks) == 0 {
+		return nil, nil
+	}
+	return c.blocks[len(c.blocks)-1], nil
+}
+
+func (c *pageTestChain) GetAccountBlocksByHeight(addr types.Address, start uint64, count uint64, forward bool) ([]*ledger.AccountBlock, error) {
+	var list []*ledger.AccountBlock
+	for h := start; h >= 1 && uint64(len(list)) < count; h-- {
+		list = append(list, c.blocks[h-1])
+	}
+	return list, nil
+}
+
+func (c *pageTestChain) GetAccountBlockByHash(hash *types.Hash) (*ledger.AccountBlock, error) {
+	return c.onroad[*hash], nil
+}
+
+func (c *pageTestChain) GetConfirmTimes(hash *types.Hash) (uint64, error) {
+	return 0, nil
+}
+
+func (c *pageTestChain) GetTokenInfoById(tokenId *types.TokenTypeId) (*types.TokenInfo, error) {
+	return nil, nil
+}
+
+func TestGetBlocksPageByAccAddr(t *testing.T) {
+	c := newPageTestChain(25)
+	l := &LedgerApi{chain: c, log: log15.New("module", "rpc_api/ledger_api")}
+	addr := c.account.AccountAddress
+
+	// from the latest block to the first one
+	next := ""
+	height := uint64(25)
+	for pages := 1; ; pages++ {
+		page, err := l.GetBlocksPageByAccAddr(addr, next, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, block := range page.Blocks {
+			if block.AccountBlock.Height != height {
+				t.Fatalf("page %d: expected height %d, got %d", pages, height, block.AccountBlock.Height)
+			}
+			height--
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		next = page.NextCursor
+	}
+	if height != 0 {
+		t.Fatalf("blocks lower than %d are missing", height+1)
+	}
+
+	first, err := l.GetBlocksPageByAccAddr(addr, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pages don't shift when the account chain grows
+	c.blocks = append(c.blocks, &ledger.AccountBlock{AccountAddress: addr, Height: 26})
+	page, err := l.GetBlocksPageByAccAddr(addr, first.NextCursor, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Blocks[0].AccountBlock.Height != 15 {
+		t.Fatalf("the second page should start at 15, got %d", page.Blocks[0].AccountBlock.Height)
+	}
+
+	// the cursor is bound to the account
+	other, _, _ := types.CreateAddress()
+	if _, err := l.GetBlocksPageByAccAddr(other, first.NextCursor, 10); err != ErrInvalidCursor {
+		t.Fatalf("cursor of another address should be rejected, got %v", err)
+	}
+	c.account.AccountId++
+	if _, err := l.GetBlocksPageByAccAddr(addr, first.NextCursor, 10); err != ErrInvalidCursor {
+		t.Fatalf("cursor of another account id should be rejected, got %v", err)
+	}
+	snapshotCursor := (&cursor{kind: cursorSnapshotBlock, height: 10}).String()
+	if _, err := l.GetBlocksPageByAccAddr(addr, snapshotCursor, 10); err != ErrInvalidCursor {
+		t.Fatalf("cursor of snapshot blocks should be rejected, got %v", err)
+	}
+
+	// an address without blocks has an empty page
+	page, err = l.GetBlocksPageByAccAddr(other, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Blocks) != 0 || page.NextCursor != "" {
+		t.Fatalf("expected an empty last page, got %d blocks, cursor %q", len(page.Blocks), page.NextCursor)
+	}
+}
+
+func TestBlocksPageLimit(t *testing.T) {
+	c := newPageTestChain(5)
+	l := &LedgerApi{chain: c, log: log15.New("module", "rpc_api/ledger_api")}
+	addr := c.account.AccountAddress
+
+	for _, count := range []int{-1, 0, maxPageSize + 1} {
+		if _, err := l.GetBlocksPageByAccAddr(addr, "", count); err != ErrQueryLimit {
+			t.Errorf("count %d: expected ErrQueryLimit, got %v", count, err)
+		}
+		if _, err := l.GetSnapshotBlocksPage("", count, false); err != ErrQueryLimit {
+			t.Errorf("count %d: expected ErrQueryLimit of snapshot blocks, got %v", count, err)
+		}
+	}
+	if _, err := l.GetSnapshotBlocksPage("", maxSnapshotContentBlocks+1, true); err != ErrQueryLimit {
+		t.Errorf("expected ErrQueryLimit of snapshot blocks with content, got %v", err)
+	}
+
+	queries := make([]BlocksPageQuery, maxBatchSize+1)
+	for i := range queries {
+		queries[i] = BlocksPageQuery{Addr: addr, Count: 1}
+	}
+	if _, err := l.GetBlocksPagesByAccAddrs(queries); err != ErrQueryLimit {
+		t.Errorf("expected ErrQueryLimit of too many addresses, got %v", err)
+	}
+	if _, err := l.GetBlocksPagesByAccAddrs([]BlocksPageQuery{{Addr: addr, Count: maxPageSize}, {Addr: addr, Count: 1}}); err != ErrQueryLimit {
+		t.Errorf("expected ErrQueryLimit of too many blocks, got %v", err)
+	}
+
+	pages, err := l.GetBlocksPagesByAccAddrs(queries[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || len(pages[0].Blocks) != 1 || pages[1].NextCursor == "" {
+		t.Fatalf("unexpected pages %+v", pages)
+	}
+}
+
+func TestGetTransfersByAddressRange(t *testing.T) {
+	c := newPageTestChain(0)
+	c.snapshotHeight = 100
+	c.txHistoryStart = 30
+	l := &LedgerApi{chain: c, log: log15.New("module", "rpc_api/ledger_api")}
+	addr := c.account.AccountAddress
+
+	// no range is set
+	page, err := l.GetTransfersByAddress(TransferFilterParam{Addr: addr, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transfers) != 0 || page.NextCursor != "" {
+		t.Fatalf("expected an empty last page, got %d transfers, cursor %q", len(page.Transfers), page.NextCursor)
+	}
+	if filter := c.transferFilter; filter.FromSnapshotHeight != 30 || filter.ToSnapshotHeight != 100 {
+		t.Fatalf("expected the range [30, 100], got [%d, %d]", filter.FromSnapshotHeight, filter.ToSnapshotHeight)
+	}
+
+	// a range below the start is rejected
+	if _, err := l.GetTransfersByAddress(TransferFilterParam{Addr: addr, FromSnapshotHeight: 1, Count: 10}); err != chain.ErrBeforeTxHistoryStart {
+		t.Fatalf("expected ErrBeforeTxHistoryStart, got %v", err)
+	}
+
+	// the index is just enabled, no transfer is indexed yet
+	c.txHistoryStart = 101
+	c.transferFilter = nil
+	page, err = l.GetTransfersByAddress(TransferFilterParam{Addr: addr, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Transfers) != 0 || c.transferFilter != nil {
+		t.Fatalf("expected an empty page without looking up, got %d transfers", len(page.Transfers))
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vite/net"
+)
+
+var errNotLight = errors.New("node is not a light client")
+
+// LightApi serves the verified snapshot headers and the account blocks of the tracked addresses of a light client
+type LightApi struct {
+	light net.LightNet
+	log   log15.Logger
+}
+
+func NewLightApi(vite *vite.Vite) *LightApi {
+	return &LightApi{
+		light: vite.Light(),
+		log:   log15.New("module", "rpc_api/light_api"),
+	}
+}
+
+func (l *LightApi) GetHead() (*ledger.SnapshotBlock, error) {
+	if l.light == nil {
+		return nil, errNotLight
+	}
+	return l.light.Head(), nil
+}
+
+// GetSnapshotHeaderByHeight returns nil if the header is not in the recent headers kept in memory
+func (l *LightApi) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if l.light == nil {
+		return nil, errNotLight
+	}
+	return l.light.GetSnapshotHeaderByHeight(height), nil
+}
+
+func (l *LightApi) GetSnapshotHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	if l.light == nil {
+		return nil, errNotLight
+	}
+	return l.light.GetSnapshotHeaderByHash(hash), nil
+}
+
+// GetAccountBlocks returns the verified blocks of a tracked address, sorted by height
+func (l *LightApi) GetAccountBlocks(addr types.Address) ([]*ledger.AccountBlock, error) {
+	if l.light == nil {
+		return nil, errNotLight
+	}
+	return l.light.GetAccountBlocks(addr), nil
+}
+
+func (l *LightApi) Peers() (*net.NodeInfo, error) {
+	if l.light == nil {
+		return nil, errNotLight
+	}
+	return l.light.Info(), nil
+}
@@ -1,12 +1,17 @@
 package api
 
 import (
+	"errors"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p"
+	"github.com/vitelabs/go-vite/p2p/discovery"
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/vite/net"
 	"strconv"
 )
 
+var errP2PNotStarted = errors.New("p2p server is not started")
+
 type NetApi struct {
 	net net.Net
 	log log15.Logger
@@ -50,3 +55,118 @@ func (n *NetApi) PeersCount() uint {
 	info := n.net.Info()
 	return uint(len(info.Peers))
 }
+
+// PrivateNetApi manage banned, static and trusted peers
+type PrivateNetApi struct {
+	vite *vite.Vite
+	log  log15.Logger
+}
+
+func NewPrivateNetApi(vite *vite.Vite) *PrivateNetApi {
+	return &PrivateNetApi{
+		vite: vite,
+		log:  log15.New("module", "rpc_api/private_net_api"),
+	}
+}
+
+func (n *PrivateNetApi) Bans() ([]*p2p.BanInfo, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return nil, errP2PNotStarted
+	}
+
+	return svr.Bans(), nil
+}
+
+// Unban return false if the node is not banned
+func (n *PrivateNetApi) Unban(id string) (bool, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return false, errP2PNotStarted
+	}
+
+	nodeID, err := discovery.HexStr2NodeID(id)
+	if err != nil {
+		return false, err
+	}
+
+	n.log.Info("Unban " + id)
+	return svr.Unban(nodeID), nil
+}
+
+// AddStaticPeer add the node URL to static peers, it will be always redialed
+func (n *PrivateNetApi) AddStaticPeer(url string) error {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return errP2PNotStarted
+	}
+
+	n.log.Info("AddStaticPeer " + url)
+	return svr.AddStaticPeer(url)
+}
+
+// RemoveStaticPeer return false if the node is not static
+func (n *PrivateNetApi) RemoveStaticPeer(id string) (bool, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return false, errP2PNotStarted
+	}
+
+	nodeID, err := discovery.HexStr2NodeID(id)
+	if err != nil {
+		return false, err
+	}
+
+	n.log.Info("RemoveStaticPeer " + id)
+	return svr.RemoveStaticPeer(nodeID)
+}
+
+func (n *PrivateNetApi) StaticPeers() ([]string, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return nil, errP2PNotStarted
+	}
+
+	return svr.StaticPeers(), nil
+}
+
+// AddTrustedPeer make the node can be connected even if peers are too many
+func (n *PrivateNetApi) AddTrustedPeer(id string) error {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return errP2PNotStarted
+	}
+
+	nodeID, err := discovery.HexStr2NodeID(id)
+	if err != nil {
+		return err
+	}
+
+	n.log.Info("AddTrustedPeer " + id)
+	return svr.AddTrustedPeer(nodeID)
+}
+
+// RemoveTrustedPeer return false if the node is not trusted
+func (n *PrivateNetApi) RemoveTrustedPeer(id string) (bool, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return false, errP2PNotStarted
+	}
+
+	nodeID, err := discovery.HexStr2NodeID(id)
+	if err != nil {
+		return false, err
+	}
+
+	n.log.Info("RemoveTrustedPeer " + id)
+	return svr.RemoveTrustedPeer(nodeID)
+}
+
+func (n *PrivateNetApi) TrustedPeers() ([]string, error) {
+	svr := n.vite.P2P()
+	if svr == nil {
+		return nil, errP2PNotStarted
+	}
+
+	return svr.TrustedPeers(), nil
+}
@@ -28,6 +28,14 @@ func (o PublicOnroadApi) GetOnroadBlocksByAddress(address types.Address, index i
 	return o.api.GetOnroadBlocksByAddress(address, index, count)
 }
 
+func (o PublicOnroadApi) GetOnroadBlocksPageByAddress(address types.Address, cursor string, count int) (*AccountBlocksPage, error) {
+	return o.api.GetOnroadBlocksPageByAddress(address, cursor, count)
+}
+
+func (o PublicOnroadApi) GetOnroadBlocksPagesByAddrs(queries []BlocksPageQuery) ([]*AccountBlocksPage, error) {
+	return o.api.GetOnroadBlocksPagesByAddrs(queries)
+}
+
 func (o PublicOnroadApi) GetAccountOnroadInfo(address types.Address) (*RpcAccountInfo, error) {
 	return o.api.GetAccountOnroadInfo(address)
 
@@ -108,6 +116,72 @@ func (o PrivateOnroadApi) GetOnroadBlocksByAddress(address types.Address, index
 	return a[:sum], nil
 }
 
+// GetOnroadBlocksPageByAddress returns the onroad blocks of address in order of hash,
+// cursor is the NextCursor of the previous page
+func (o PrivateOnroadApi) GetOnroadBlocksPageByAddress(address types.Address, cursor string, count int) (*AccountBlocksPage, error) {
+	log.Info("GetOnroadBlocksPageByAddress", "addr", address, "cursor", cursor, "count", count)
+	if count <= 0 || count > maxPageSize {
+		return nil, ErrQueryLimit
+	}
+	return o.getOnroadBlocksPage(address, cursor, count)
+}
+
+// GetOnroadBlocksPagesByAddrs queries pages of multiple addresses, the pages are in the order of queries
+func (o PrivateOnroadApi) GetOnroadBlocksPagesByAddrs(queries []BlocksPageQuery) ([]*AccountBlocksPage, error) {
+	log.Info("GetOnroadBlocksPagesByAddrs", "len", len(queries))
+	if err := checkBatch(queries); err != nil {
+		return nil, err
+	}
+
+	pages := make([]*AccountBlocksPage, len(queries))
+	for i, q := range queries {
+		page, err := o.getOnroadBlocksPage(q.Addr, q.Cursor, q.Count)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = page
+	}
+	return pages, nil
+}
+
+func (o PrivateOnroadApi) getOnroadBlocksPage(address types.Address, cursorStr string, count int) (*AccountBlocksPage, error) {
+	c, err := parseCursor(cursorStr, cursorOnroadBlock)
+	if err != nil {
+		return nil, err
+	}
+	var after *types.Hash
+	if c != nil {
+		after = &c.hash
+	}
+
+	// one more to know if there is a next page
+	hashList, err := o.manager.DbAccess().GetOnroadHashsAfter(&address, after, uint64(count)+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AccountBlocksPage{Blocks: []*AccountBlock{}}
+	if len(hashList) > count {
+		hashList = hashList[:count]
+		page.NextCursor = (&cursor{kind: cursorOnroadBlock, hash: *hashList[count-1]}).String()
+	}
+
+	chain := o.manager.DbAccess().Chain
+	for _, hash := range hashList {
+		block, err := chain.GetAccountBlockByHash(hash)
+		if err != nil || block == nil {
+			// rolled back after the hash is read
+			continue
+		}
+		accountBlock, err := ledgerToRpcBlock(block, chain)
+		if err != nil {
+			return nil, err
+		}
+		page.Blocks = append(page.Blocks, accountBlock)
+	}
+	return page, nil
+}
+
 func (o PrivateOnroadApi) GetAccountOnroadInfo(address types.Address) (*RpcAccountInfo, error) {
 	log.Info("GetAccountOnroadInfo", "addr", address)
 	info, e := o.manager.GetOnroadBlocksPool().GetOnroadAccountInfo(address)
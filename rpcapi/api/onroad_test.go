@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/onroad"
+	"github.com/vitelabs/go-vite/onroad/model"
+)
+
+func TestGetOnroadBlocksPageByAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "onroad_page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := newPageTestChain(0)
+	c.chainDb = chain_db.NewChainDb(dir)
+	defer c.chainDb.Db().Close()
+
+	manager := onroad.NewManager(nil, nil, nil, nil)
+	manager.Init(c)
+	o := PrivateOnroadApi{manager: manager}
+
+	addr := c.account.AccountAddress
+	other, _, _ := types.CreateAddress()
+	store := model.NewOnroadSet(c)
+	batch := new(leveldb.Batch)
+	for i := 0; i < 25; i++ {
+		for _, to := range []types.Address{addr, other} {
+			block := &ledger.AccountBlock{
+				BlockType: ledger.BlockTypeSendCall,
+				ToAddress: to,
+				Hash:      types.DataHash(append(to.Bytes(), byte(i))),
+			}
+			c.onroad[block.Hash] = block
+			if err := store.WriteMeta(batch, &to, &block.Hash); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := c.chainDb.Commit(batch); err != nil {
+		t.Fatal(err)
+	}
+
+	// all onroad blocks of addr in order of hash without duplicates
+	var prev *types.Hash
+	next := ""
+	received := 0
+	for pages := 1; ; pages++ {
+		page, err := o.GetOnroadBlocksPageByAddress(addr, next, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Blocks) > 10 {
+			t.Fatalf("page %d has %d blocks", pages, len(page.Blocks))
+		}
+		for _, block := range page.Blocks {
+			if block.ToAddress != addr {
+				t.Fatalf("page %d: onroad block of %s", pages, block.ToAddress)
+			}
+			if prev != nil && bytes.Compare(prev.Bytes(), block.Hash.Bytes()) >= 0 {
+				t.Fatalf("page %d: %s is not after %s", pages, block.Hash, prev)
+			}
+			hash := block.Hash
+			prev = &hash
+			received++
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		next = page.NextCursor
+	}
+	if received != 25 {
+		t.Fatalf("expected 25 onroad blocks, got %d", received)
+	}
+
+	// a received block is gone from the following pages
+	first, err := o.GetOnroadBlocksPageByAddress(addr, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := o.GetOnroadBlocksPageByAddress(addr, first.NextCursor, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch = new(leveldb.Batch)
+	if err := store.DeleteMeta(batch, &addr, &first.Blocks[9].Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.chainDb.Commit(batch); err != nil {
+		t.Fatal(err)
+	}
+	page, err := o.GetOnroadBlocksPageByAddress(addr, first.NextCursor, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Blocks[0].Hash != second.Blocks[0].Hash {
+		t.Fatalf("the page should not shift after a block is received")
+	}
+
+	// the batch query pages each address on its own
+	pages, err := o.GetOnroadBlocksPagesByAddrs([]BlocksPageQuery{{Addr: addr, Count: 20}, {Addr: other, Count: 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages[0].Blocks) != 20 || pages[0].NextCursor == "" || len(pages[1].Blocks) != 25 || pages[1].NextCursor != "" {
+		t.Fatalf("unexpected pages %d %q, %d %q", len(pages[0].Blocks), pages[0].NextCursor, len(pages[1].Blocks), pages[1].NextCursor)
+	}
+
+	accountCursor := (&cursor{kind: cursorAccountBlock, accountId: 1, height: 1}).String()
+	if _, err := o.GetOnroadBlocksPageByAddress(addr, accountCursor, 10); err != ErrInvalidCursor {
+		t.Fatalf("cursor of account blocks should be rejected, got %v", err)
+	}
+	for _, count := range []int{0, maxPageSize + 1} {
+		if _, err := o.GetOnroadBlocksPageByAddress(addr, "", count); err != ErrQueryLimit {
+			t.Fatalf("count %d: expected ErrQueryLimit, got %v", count, err)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm_context"
+)
+
+const (
+	snapshotBlocksSubscription = iota
+	accountBlocksSubscription
+	onroadBlocksSubscription
+	logsSubscription
+)
+
+// pending messages of a slow client are dropped when exceeding this size
+const subscriptionBufferSize = 1024
+
+type SnapshotBlockMsg struct {
+	Hash    types.Hash `json:"hash"`
+	Height  string     `json:"height"`
+	Removed bool       `json:"removed"`
+}
+
+type AccountBlockMsg struct {
+	Hash           types.Hash    `json:"hash"`
+	Height         string        `json:"height"`
+	AccountAddress types.Address `json:"accountAddress"`
+	Removed        bool          `json:"removed"`
+}
+
+type OnroadMsg struct {
+	// Hash of the send block
+	Hash types.Hash `json:"hash"`
+	// Closed is true if the send block is received, false if it is a new onroad block
+	Closed  bool `json:"closed"`
+	Removed bool `json:"removed"`
+}
+
+type LogsMsg struct {
+	Log                *ledger.VmLog `json:"log"`
+	AccountBlockHash   types.Hash    `json:"accountBlockHash"`
+	AccountBlockHeight string        `json:"accountBlockHeight"`
+	AccountAddress     types.Address `json:"accountAddress"`
+	Removed            bool          `json:"removed"`
+}
+
+type AccountBlocksFilterParam struct {
+	AddrList []types.Address `json:"addrList"`
+}
+
+type LogsFilterParam struct {
+	AddrList []types.Address `json:"addrList"`
+	Topics   [][]types.Hash  `json:"topics"`
+}
+
+type subscription struct {
+	typ       int
+	addrSet   map[types.Address]struct{}
+	logFilter *chain.VmLogFilter
+	ch        chan interface{}
+}
+
+func (s *subscription) matchAddr(addr types.Address) bool {
+	if len(s.addrSet) == 0 {
+		return true
+	}
+	_, ok := s.addrSet[addr]
+	return ok
+}
+
+// SubscribeApi pushes chain events to websocket and ipc clients. Account blocks and logs are
+// pushed when inserted into chain before being confirmed by snapshot blocks, deleted blocks are
+// pushed again with removed set to true.
+type SubscribeApi struct {
+	chain chain.Chain
+	log   log15.Logger
+
+	lock        sync.Mutex
+	subs        map[rpc.ID]*subscription
+	listenerIds []uint64
+	// log lists of the deleting account blocks, read before removed from db
+	deletingLogs map[types.Hash]ledger.VmLogList
+}
+
+func NewSubscribeApi(vite *vite.Vite) *SubscribeApi {
+	return &SubscribeApi{
+		chain: vite.Chain(),
+		log:   log15.New("module", "rpc_api/subscribe_api"),
+		subs:  make(map[rpc.ID]*subscription),
+	}
+}
+
+func (s *SubscribeApi) String() string {
+	return "SubscribeApi"
+}
+
+func (s *SubscribeApi) NewSnapshotBlocks(ctx context.Context) (*rpc.Subscription, error) {
+	return s.subscribe(ctx, &subscription{typ: snapshotBlocksSubscription})
+}
+
+func (s *SubscribeApi) NewAccountBlocks(ctx context.Context, filter *AccountBlocksFilterParam) (*rpc.Subscription, error) {
+	sub := &subscription{typ: accountBlocksSubscription}
+	if filter != nil {
+		sub.addrSet = toAddrSet(filter.AddrList)
+	}
+	return s.subscribe(ctx, sub)
+}
+
+func (s *SubscribeApi) OnroadBlocks(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
+	return s.subscribe(ctx, &subscription{typ: onroadBlocksSubscription, addrSet: toAddrSet([]types.Address{addr})})
+}
+
+func (s *SubscribeApi) Logs(ctx context.Context, filter *LogsFilterParam) (*rpc.Subscription, error) {
+	sub := &subscription{typ: logsSubscription, logFilter: &chain.VmLogFilter{}}
+	if filter != nil {
+		sub.addrSet = toAddrSet(filter.AddrList)
+		sub.logFilter.Topics = filter.Topics
+	}
+	return s.subscribe(ctx, sub)
+}
+
+func toAddrSet(addrList []types.Address) map[types.Address]struct{} {
+	addrSet := make(map[types.Address]struct{}, len(addrList))
+	for _, addr := range addrList {
+		addrSet[addr] = struct{}{}
+	}
+	return addrSet
+}
+
+func (s *SubscribeApi) subscribe(ctx context.Context, sub *subscription) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+	sub.ch = make(chan interface{}, subscriptionBufferSize)
+
+	s.lock.Lock()
+	if len(s.subs) == 0 {
+		s.registerChainListeners()
+	}
+	s.subs[rpcSub.ID] = sub
+	s.lock.Unlock()
+
+	go func() {
+		defer s.unsubscribe(rpcSub.ID)
+		for {
+			select {
+			case msg := <-sub.ch:
+				if err := notifier.Notify(rpcSub.ID, msg); err != nil {
+					s.log.Info("notify failed, error is "+err.Error(), "method", "subscribe")
+					return
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+func (s *SubscribeApi) unsubscribe(id rpc.ID) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.subs, id)
+	if len(s.subs) == 0 {
+		s.unregisterChainListeners()
+	}
+}
+
+func (s *SubscribeApi) registerChainListeners() {
+	s.listenerIds = []uint64{
+		s.chain.RegisterInsertSnapshotBlocksSuccess(s.onInsertSnapshotBlocks),
+		s.chain.RegisterDeleteSnapshotBlocksSuccess(s.onDeleteSnapshotBlocks),
+		s.chain.RegisterInsertAccountBlocksSuccess(s.onInsertAccountBlocks),
+		s.chain.RegisterDeleteAccountBlocks(s.onDeletingAccountBlocks),
+		s.chain.RegisterDeleteAccountBlocksSuccess(s.onDeleteAccountBlocks),
+	}
+}
+
+func (s *SubscribeApi) unregisterChainListeners() {
+	for _, id := range s.listenerIds {
+		s.chain.UnRegister(id)
+	}
+	s.listenerIds = nil
+	s.deletingLogs = nil
+}
+
+// send must be called with lock held, it never blocks the chain
+func (s *SubscribeApi) send(sub *subscription, msg interface{}) {
+	select {
+	case sub.ch <- msg:
+	default:
+		s.log.Warn("subscription buffer is full, message dropped", "method", "send")
+	}
+}
+
+func (s *SubscribeApi) onInsertSnapshotBlocks(blocks []*ledger.SnapshotBlock) {
+	s.notifySnapshotBlocks(blocks, false)
+}
+
+func (s *SubscribeApi) onDeleteSnapshotBlocks(blocks []*ledger.SnapshotBlock) {
+	s.notifySnapshotBlocks(blocks, true)
+}
+
+func (s *SubscribeApi) notifySnapshotBlocks(blocks []*ledger.SnapshotBlock, removed bool) {
+	msgs := make([]*SnapshotBlockMsg, len(blocks))
+	for i, block := range blocks {
+		msgs[i] = &SnapshotBlockMsg{Hash: block.Hash, Height: uint64ToString(block.Height), Removed: removed}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sub := range s.subs {
+		if sub.typ == snapshotBlocksSubscription {
+			s.send(sub, msgs)
+		}
+	}
+}
+
+func (s *SubscribeApi) onInsertAccountBlocks(blocks []*vm_context.VmAccountBlock) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sub := range s.subs {
+		var msgs []interface{}
+		for _, vmBlock := range blocks {
+			var logList ledger.VmLogList
+			if sub.typ == logsSubscription {
+				logList = vmBlock.VmContext.UnsavedCache().LogList()
+			}
+			msgs = append(msgs, accountBlockMsgs(sub, vmBlock.AccountBlock, vmBlock.AccountBlock.AccountAddress, logList, false)...)
+		}
+		if len(msgs) > 0 {
+			s.send(sub, msgs)
+		}
+	}
+}
+
+func (s *SubscribeApi) onDeletingAccountBlocks(batch *leveldb.Batch, subLedger map[types.Address][]*ledger.AccountBlock) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deletingLogs = make(map[types.Hash]ledger.VmLogList)
+	for _, blocks := range subLedger {
+		for _, block := range blocks {
+			if block.LogHash == nil {
+				continue
+			}
+			logList, err := s.chain.GetVmLogList(block.LogHash)
+			if err != nil {
+				s.log.Error("GetVmLogList failed, error is "+err.Error(), "method", "onDeletingAccountBlocks")
+				continue
+			}
+			s.deletingLogs[block.Hash] = logList
+		}
+	}
+	return nil
+}
+
+func (s *SubscribeApi) onDeleteAccountBlocks(subLedger map[types.Address][]*ledger.AccountBlock) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, sub := range s.subs {
+		var msgs []interface{}
+		for addr, blocks := range subLedger {
+			for _, block := range blocks {
+				msgs = append(msgs, accountBlockMsgs(sub, block, addr, s.deletingLogs[block.Hash], true)...)
+			}
+		}
+		if len(msgs) > 0 {
+			s.send(sub, msgs)
+		}
+	}
+	s.deletingLogs = nil
+}
+
+func accountBlockMsgs(sub *subscription, block *ledger.AccountBlock, addr types.Address, logList ledger.VmLogList, removed bool) []interface{} {
+	var msgs []interface{}
+	switch sub.typ {
+	case accountBlocksSubscription:
+		if sub.matchAddr(addr) {
+			msgs = append(msgs, &AccountBlockMsg{Hash: block.Hash, Height: uint64ToString(block.Height), AccountAddress: addr, Removed: removed})
+		}
+	case onroadBlocksSubscription:
+		if block.IsSendBlock() && sub.matchAddr(block.ToAddress) {
+			msgs = append(msgs, &OnroadMsg{Hash: block.Hash, Closed: false, Removed: removed})
+		} else if block.IsReceiveBlock() && sub.matchAddr(addr) {
+			msgs = append(msgs, &OnroadMsg{Hash: block.FromBlockHash, Closed: true, Removed: removed})
+		}
+	case logsSubscription:
+		if !sub.matchAddr(addr) {
+			break
+		}
+		for _, vmLog := range logList {
+			if sub.logFilter.MatchTopics(vmLog) {
+				msgs = append(msgs, &LogsMsg{
+					Log:                vmLog,
+					AccountBlockHash:   block.Hash,
+					AccountBlockHeight: uint64ToString(block.Height),
+					AccountAddress:     addr,
+					Removed:            removed,
+				})
+			}
+		}
+	}
+	return msgs
+}
@@ -5,7 +5,10 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/quota"
+	"github.com/vitelabs/go-vite/vm_context"
 )
 
 var preCompiledContracts = []types.Address{
@@ -56,6 +59,73 @@ func (t Tx) SendRawTx(block AccountBlock) error {
 	return nil
 }
 
+type QuotaEstimation struct {
+	QuotaUsed   string  `json:"quotaUsed"`
+	PledgeQuota string  `json:"pledgeQuota"`
+	Difficulty  *string `json:"difficulty"`
+	Fee         string  `json:"fee"`
+}
+
+// EstimateQuota dry-runs an unsigned send block on latest state, returns the quota it uses, the quota
+// available from pledge, the PoW difficulty needed if pledge quota is not enough and the fee of the block
+func (t Tx) EstimateQuota(block AccountBlock) (*QuotaEstimation, error) {
+	log.Info("EstimateQuota")
+	lb, err := block.LedgerAccountBlock()
+	if err != nil {
+		return nil, err
+	}
+	if !lb.IsSendBlock() {
+		return nil, errors.New("only send block is supported")
+	}
+
+	chain := t.vite.Chain()
+	snapshotBlock := chain.GetLatestSnapshotBlock()
+	db, err := vm_context.NewVmContext(chain, &snapshotBlock.Hash, nil, &lb.AccountAddress)
+	if err != nil {
+		return nil, err
+	}
+	if prevBlock := db.PrevAccountBlock(); prevBlock != nil {
+		lb.Height = prevBlock.Height + 1
+		lb.PrevHash = prevBlock.Hash
+	} else {
+		lb.Height = 1
+		lb.PrevHash = types.ZERO_HASH
+	}
+	lb.SnapshotHash = snapshotBlock.Hash
+	lb.Nonce = nil
+	lb.Difficulty = nil
+
+	v := vm.NewVM()
+	v.Estimate = true
+	blockList, _, err := v.Run(db.CopyAndFreeze(), lb, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(blockList) == 0 {
+		return nil, errors.New("vm gen an empty block")
+	}
+	quotaUsed := blockList[0].AccountBlock.Quota
+
+	pledgeAmount := abi.GetPledgeBeneficialAmount(db, lb.AccountAddress)
+	pledgeQuota, _, err := quota.CalcQuota(db, lb.AccountAddress, pledgeAmount, nil)
+	if err != nil {
+		return nil, err
+	}
+	estimation := &QuotaEstimation{
+		QuotaUsed:   uint64ToString(quotaUsed),
+		PledgeQuota: uint64ToString(pledgeQuota),
+		Fee:         *bigIntToString(blockList[0].AccountBlock.Fee),
+	}
+	if pledgeQuota < quotaUsed {
+		difficulty, err := quota.CalcPoWDifficulty(db, pledgeAmount, quotaUsed)
+		if err != nil {
+			return nil, err
+		}
+		estimation.Difficulty = bigIntToString(difficulty)
+	}
+	return estimation, nil
+}
+
 func isPreCompiledContracts(address types.Address) bool {
 	for _, v := range preCompiledContracts {
 		if v == address {
@@ -2,7 +2,6 @@ package api
 
 import (
 	"flag"
-	"fmt"
 	"github.com/vitelabs/go-vite/vm"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"testing"
@@ -12,17 +11,16 @@ import (
 	"time"
 
 	"math/big"
+	"path/filepath"
 
 	"strconv"
 
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
-	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vite"
-	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/wallet"
 )
 
@@ -36,18 +34,15 @@ var defaultDifficultyStr = "65535"
 func init() {
 	flag.StringVar(&genesisAccountPrivKeyStr, "g", "", "")
 	flag.StringVar(&accountPrivKeyStr, "p", "", "")
-	flag.Parse()
-	fmt.Println(genesisAccountPrivKeyStr)
 }
 
 func TestParse(t *testing.T) {
 }
 
 func TestWallet(t *testing.T) {
-	w := wallet.New(nil)
+	w := newTestWallet(t)
 	password := "123456"
 
-	unlockAll(w)
 	genesisAddr, _ := types.HexToAddress("vite_098dfae02679a4ca05a4c8bf5dd00a8757f0c622bfccce7d68")
 
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
@@ -61,9 +56,9 @@ func TestWallet(t *testing.T) {
 	onRoadApi := NewPrivateOnroadApi(vite)
 
 	//l := NewLedgerApi(vite)
-	t.Log(waApi.Status())
+	t.Log(waApi.ListAllEntropyFiles())
 
-	vite.OnRoad().StartAutoReceiveWorker(genesisAddr, nil)
+	startAutoReceive(vite, w, genesisAddr)
 	for _, v := range vite.OnRoad().ListWorkingAutoReceiveWorker() {
 		wLog.Info(v.String())
 	}
@@ -198,16 +193,12 @@ func printBalance(vite *vite.Vite, addr types.Address) *big.Int {
 	return balance
 }
 
-func unlockAddr(w *wallet.Manager, passwd string, priKey string) types.Address {
-	w.SeedStoreManagers.ImportPriv(priKey, passwd)
-	accountPrivKey, _ := ed25519.HexToPrivateKey(priKey)
-	accountPubKey := accountPrivKey.PubByte()
-	addr := types.PubkeyToAddress(accountPubKey)
-
-	w.SeedStoreManagers.Lock(addr)
-	err := w.SeedStoreManagers.Unlock(addr, passwd, 0)
-	wLog.Info("unlock address", "address", addr.String(), "r", err)
-	return addr
+func startAutoReceive(vite *vite.Vite, w *wallet.Manager, addr types.Address) {
+	entropyStore, _, _, err := w.GlobalFindAddr(addr)
+	if err == nil {
+		err = vite.OnRoad().StartAutoReceiveWorker(entropyStore, addr, nil, nil)
+	}
+	wLog.Info("start auto receive", "address", addr.String(), "r", err)
 }
 
 func waitOnroad(api *PrivateOnroadApi, addr types.Address, t *testing.T) {
@@ -294,9 +285,7 @@ func contractOnroadNum(api *PrivateOnroadApi, addr types.Address, t *testing.T)
 }
 
 func TestGenData(t *testing.T) {
-	w := wallet.New(nil)
-
-	unlockAll(w)
+	w := newTestWallet(t)
 
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
 
@@ -310,7 +299,7 @@ func TestGenData(t *testing.T) {
 	printBalance(vite, addr)
 
 	genesisAddr, _ := types.HexToAddress("vite_098dfae02679a4ca05a4c8bf5dd00a8757f0c622bfccce7d68")
-	vite.OnRoad().StartAutoReceiveWorker(genesisAddr, nil)
+	startAutoReceive(vite, w, genesisAddr)
 
 	// if has no balance
 	if printBalance(vite, genesisAddr).Sign() == 0 {
@@ -333,7 +322,7 @@ func TestGenData(t *testing.T) {
 		panic(err)
 	}
 
-	vite.OnRoad().StartAutoReceiveWorker(addr, nil)
+	startAutoReceive(vite, w, addr)
 	waitOnroad(onRoadApi, addr, t)
 	printBalance(vite, addr)
 	waitSnapshotInc(vite, t)
@@ -341,21 +330,30 @@ func TestGenData(t *testing.T) {
 
 var password = "123456"
 
-func unlockAll(w *wallet.Manager) []types.Address {
-	results := w.SeedStoreManagers.Addresses()
+// newTestWallet unlocks the entropy stores in the default data dir, the test is skipped if there is none
+func newTestWallet(t *testing.T) *wallet.Manager {
+	w := wallet.New(&wallet.Config{DataDir: filepath.Join(common.DefaultDataDir(), "wallet")})
+	w.Start()
+	if len(unlockAll(w)) == 0 {
+		t.Skip("no entropy store in the default data dir")
+	}
+	return w
+}
+
+func unlockAll(w *wallet.Manager) []string {
+	results := w.ListAllEntropyFiles()
 
 	for _, r := range results {
-		err := w.SeedStoreManagers.Unlock(r, password, 0)
+		err := w.Unlock(r, password)
 		if err != nil {
-			log.Error("unlock fail.", "err", err, "address", r.String())
+			log.Error("unlock fail.", "err", err, "entropyStore", r)
 		}
 	}
 	return results
 }
 
 func TestQuota(t *testing.T) {
-	w := wallet.New(nil)
-	unlockAll(w)
+	w := newTestWallet(t)
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
 	vite, _ := startVite(w, &addr, t)
 
@@ -392,8 +390,7 @@ func TestContracts(t *testing.T) {
 
 func contractsInit(t *testing.T) (*vite.Vite, *wallet.Manager, *WalletApi, *PrivateOnroadApi, types.Address) {
 	wLog.Debug("contracts init")
-	w := wallet.New(nil)
-	unlockAll(w)
+	w := newTestWallet(t)
 	addr, _ := types.HexToAddress("vite_e9b7307aaf51818993bb2675fd26a600bc7ab6d0f52bc5c2c1")
 	vite, err := startVite(w, &addr, t)
 	if err != nil {
@@ -403,7 +400,7 @@ func contractsInit(t *testing.T) (*vite.Vite, *wallet.Manager, *WalletApi, *Priv
 	waApi := NewWalletApi(vite)
 	onRoadApi := NewPrivateOnroadApi(vite)
 
-	vite.OnRoad().StartAutoReceiveWorker(addr, nil)
+	startAutoReceive(vite, w, addr)
 	waitContractOnroad(onRoadApi, abi.AddressPledge, t)
 	waitOnroad(onRoadApi, addr, t)
 
@@ -448,7 +445,7 @@ func contractsPledge(vite *vite.Vite, waApi *WalletApi, onRoadApi *PrivateOnroad
 	newPledgeAmount := printPledge(vite, addr, t)
 	pledgeAmount.Add(pledgeAmount, amount)
 	if pledgeAmount.Cmp(newPledgeAmount) != 0 {
-		t.Fatal("pledge amount error, expected: %v, got %v", pledgeAmount, newPledgeAmount)
+		t.Fatalf("pledge amount error, expected: %v, got %v", pledgeAmount, newPledgeAmount)
 	}
 }
 func contractsCancelPledge(vite *vite.Vite, waApi *WalletApi, onRoadApi *PrivateOnroadApi, addr types.Address, t *testing.T) {
@@ -491,7 +488,7 @@ func contractsCancelPledge(vite *vite.Vite, waApi *WalletApi, onRoadApi *Private
 	newPledgeAmount := printPledge(vite, addr, t)
 	pledgeAmount.Sub(pledgeAmount, amount)
 	if pledgeAmount.Cmp(newPledgeAmount) != 0 {
-		t.Fatal("pledge amount error, expected: %v, got %v", pledgeAmount, newPledgeAmount)
+		t.Fatalf("pledge amount error, expected: %v, got %v", pledgeAmount, newPledgeAmount)
 	}
 }
 func contractsMintage(vite *vite.Vite, waApi *WalletApi, onRoadApi *PrivateOnroadApi, addr types.Address, t *testing.T) types.TokenTypeId {
@@ -533,7 +530,7 @@ func contractsMintage(vite *vite.Vite, waApi *WalletApi, onRoadApi *PrivateOnroa
 
 	amount, err := vite.Chain().GetAccountBalanceByTokenId(&addr, &tokenId)
 	if amount.Cmp(big.NewInt(1e18)) != 0 {
-		t.Fatal("token amount error: %v", amount)
+		t.Fatalf("token amount error: %v", amount)
 	}
 
 	balance.Sub(balance, mintagePledgeAmount)
@@ -28,6 +28,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewPrivateOnroadApi(vite),
 			Public:    false,
 		}
+	case "private_net":
+		return rpc.API{
+			Namespace: "net",
+			Version:   "1.0",
+			Service:   api.NewPrivateNetApi(vite),
+			Public:    false,
+		}
 		// public  WS HTTP IPC
 	case "pow":
 		return rpc.API{
@@ -115,6 +122,20 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewTestApi(api.NewWalletApi(vite)),
 			Public:    true,
 		}
+	case "subscribe":
+		return rpc.API{
+			Namespace: "subscribe",
+			Version:   "1.0",
+			Service:   api.NewSubscribeApi(vite),
+			Public:    true,
+		}
+	case "light":
+		return rpc.API{
+			Namespace: "light",
+			Version:   "1.0",
+			Service:   api.NewLightApi(vite),
+			Public:    true,
+		}
 	case "debug":
 		return rpc.API{
 			Namespace: "debug",
@@ -136,9 +157,17 @@ func GetApis(vite *vite.Vite, apiModule ...string) []rpc.API {
 }
 
 func GetPublicApis(vite *vite.Vite) []rpc.API {
-	return GetApis(vite, "ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx", "debug")
+	if vite.Light() != nil {
+		return GetLightApis(vite)
+	}
+	return GetApis(vite, "ledger", "public_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx", "debug", "subscribe")
+}
+
+// GetLightApis are the apis of a light client, the others need the full chain
+func GetLightApis(vite *vite.Vite) []rpc.API {
+	return GetApis(vite, "light", "pow")
 }
 
 func GetAllApis(vite *vite.Vite) []rpc.API {
-	return GetApis(vite, "ledger", "wallet", "private_onroad", "net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx", "debug")
+	return GetApis(vite, "ledger", "wallet", "private_onroad", "net", "private_net", "contract", "pledge", "register", "vote", "mintage", "consensusGroup", "testapi", "pow", "tx", "debug", "subscribe")
 }
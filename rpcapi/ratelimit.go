@@ -0,0 +1,79 @@
+package rpcapi
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const limiterPruneInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter is a token bucket per key, eg. client IP
+type limiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func newLimiter(limit *RateLimit) (*limiter, error) {
+	if limit.Rate <= 0 || limit.Burst < 0 {
+		return nil, errors.New("rate should be positive and burst should not be negative")
+	}
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = math.Ceil(limit.Rate)
+	}
+	return &limiter{
+		rate:      limit.Rate,
+		burst:     burst,
+		buckets:   make(map[string]*bucket),
+		lastPrune: time.Now(),
+	}, nil
+}
+
+func (l *limiter) allow(key string) bool {
+	return l.allowAt(key, time.Now())
+}
+
+func (l *limiter) allowAt(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) >= limiterPruneInterval {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(l.burst, b.tokens+elapsed.Seconds()*l.rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// prune removes the buckets refilled to full, they are the same as new ones
+func (l *limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,110 @@
+package trie
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// MarkSet records the trie nodes and the ref values reachable from a set of roots,
+// everything else under DBKP_TRIE_NODE and DBKP_TRIE_REF_VALUE is garbage.
+type MarkSet struct {
+	db *leveldb.DB
+
+	nodes     map[types.Hash]struct{}
+	refValues map[types.Hash]struct{}
+}
+
+func NewMarkSet(db *leveldb.DB) *MarkSet {
+	return &MarkSet{
+		db:        db,
+		nodes:     make(map[types.Hash]struct{}),
+		refValues: make(map[types.Hash]struct{}),
+	}
+}
+
+// Mark marks the nodes of the trie whose root is rootHash, subtrees which are marked already are skipped.
+// If onValue is not nil, it is called with the value of every newly marked value node.
+// Nodes missing in the database are ignored.
+func (set *MarkSet) Mark(rootHash *types.Hash, onValue func(value []byte)) error {
+	if rootHash == nil {
+		return nil
+	}
+
+	stack := []types.Hash{*rootHash}
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if _, ok := set.nodes[hash]; ok {
+			continue
+		}
+
+		node, err := set.getNode(&hash)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			continue
+		}
+		set.nodes[hash] = struct{}{}
+
+		switch node.NodeType() {
+		case TRIE_FULL_NODE:
+			for _, child := range node.children {
+				stack = append(stack, *child.hash)
+			}
+			if node.child != nil {
+				stack = append(stack, *node.child.hash)
+			}
+		case TRIE_SHORT_NODE:
+			stack = append(stack, *node.child.hash)
+		case TRIE_HASH_NODE:
+			valueHash, err := types.BytesToHash(node.value)
+			if err != nil {
+				return err
+			}
+			set.refValues[valueHash] = struct{}{}
+		case TRIE_VALUE_NODE:
+			if onValue != nil {
+				onValue(node.value)
+			}
+		}
+	}
+	return nil
+}
+
+func (set *MarkSet) getNode(hash *types.Hash) (*TrieNode, error) {
+	dbKey, _ := database.EncodeKey(database.DBKP_TRIE_NODE, hash.Bytes())
+	value, err := set.db.Get(dbKey, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	node := &TrieNode{}
+	if err := node.DbDeserialize(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (set *MarkSet) HasNode(hash *types.Hash) bool {
+	_, ok := set.nodes[*hash]
+	return ok
+}
+
+func (set *MarkSet) HasRefValue(hash *types.Hash) bool {
+	_, ok := set.refValues[*hash]
+	return ok
+}
+
+func (set *MarkSet) NodeCount() int {
+	return len(set.nodes)
+}
+
+func (set *MarkSet) RefValueCount() int {
+	return len(set.refValues)
+}
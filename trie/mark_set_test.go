@@ -0,0 +1,78 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestMarkSet(t *testing.T) {
+	trie, db, close := getTrieOfNewContext()
+	defer close()
+
+	longValue := bytes.Repeat([]byte("long value "), 10)
+	trie.SetValue([]byte("IamG"), []byte("ki10$%^%&@#!@#"))
+	trie.SetValue([]byte("IamGood"), longValue)
+
+	batch := new(leveldb.Batch)
+	callback, _ := trie.Save(batch)
+	db.Write(batch, nil)
+	callback()
+	oldRoot := trie.Hash()
+
+	newTrie := trie.Copy()
+	newTrie.SetValue([]byte("IamGood"), []byte("a1230xm90zm19ma"))
+	batch = new(leveldb.Batch)
+	callback, _ = newTrie.Save(batch)
+	db.Write(batch, nil)
+	callback()
+	newRoot := newTrie.Hash()
+
+	set := NewMarkSet(db)
+	var values [][]byte
+	if err := set.Mark(newRoot, func(value []byte) {
+		values = append(values, value)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !set.HasNode(newRoot) || set.HasNode(oldRoot) {
+		t.Fatal("root is marked wrongly")
+	}
+	if len(values) != 2 {
+		t.Fatalf("values of new trie should be visited, got %d", len(values))
+	}
+	if set.RefValueCount() != 0 {
+		t.Fatal("ref value of old trie should not be marked")
+	}
+
+	var garbage int
+	prefixKey, _ := database.EncodeKey(database.DBKP_TRIE_NODE)
+	iter := db.NewIterator(util.BytesPrefix(prefixKey), nil)
+	for iter.Next() {
+		hash, _ := types.BytesToHash(iter.Key()[1:])
+		if !set.HasNode(&hash) {
+			garbage++
+		}
+	}
+	iter.Release()
+	if garbage == 0 {
+		t.Fatal("nodes only reachable from old root should not be marked")
+	}
+
+	// marking again skips the marked nodes
+	count := set.NodeCount()
+	if err := set.Mark(oldRoot, nil); err != nil {
+		t.Fatal(err)
+	}
+	if set.NodeCount() != count+garbage {
+		t.Fatalf("expect %d nodes, got %d", count+garbage, set.NodeCount())
+	}
+	if set.RefValueCount() != 1 {
+		t.Fatal("ref value of old trie should be marked")
+	}
+}
@@ -49,3 +49,12 @@ func (pool *TrieNodePool) clear() {
 		}
 	}
 }
+
+func (pool *TrieNodePool) Delete(keys []types.Hash) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for _, key := range keys {
+		delete(pool.nodes, key)
+	}
+}
@@ -0,0 +1,164 @@
+package trie
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+// Proof is the path of a key from the root, each node is serialized as in the database.
+// RefValue is the large value referred by the hash node at the end of the path, if any.
+type Proof struct {
+	Nodes    [][]byte
+	RefValue []byte
+}
+
+// Prove returns the proof of key, it proves either the value of key or that key doesn't exist in the trie
+func (trie *Trie) Prove(key []byte) (*Proof, error) {
+	proof := &Proof{}
+
+	node := trie.Root
+	for node != nil {
+		// a child missing in the database is loaded as nil, it can't be told from a key which doesn't exist
+		if !childrenHashable(node) {
+			return nil, errors.New("trie node is missing, the trie may be pruned")
+		}
+
+		data, err := node.DbSerialize()
+		if err != nil {
+			return nil, errors.New("DbSerialize trie node failed, error is " + err.Error())
+		}
+		proof.Nodes = append(proof.Nodes, data)
+
+		if isLeafOf(node, key) {
+			if node.NodeType() == TRIE_HASH_NODE {
+				refValue, err := trie.getRefValue(node.value)
+				if err != nil {
+					return nil, errors.New("Query ref value failed, error is " + err.Error())
+				}
+				proof.RefValue = refValue
+			}
+			break
+		}
+
+		node, key = nextNodeOf(node, key)
+	}
+	return proof, nil
+}
+
+// VerifyProof checks proof against rootHash and returns the value of key, a nil value means key doesn't exist.
+// A value node and a hash node with the same 32 bytes have the same hash, so the node type in the proof is not trusted,
+// see leafValue.
+func VerifyProof(rootHash *types.Hash, key []byte, proof *Proof) ([]byte, error) {
+	if rootHash == nil {
+		return nil, nil
+	}
+	if proof == nil {
+		return nil, errors.New("proof is nil")
+	}
+
+	nodes := make(map[types.Hash]*TrieNode, len(proof.Nodes))
+	for _, data := range proof.Nodes {
+		node := &TrieNode{}
+		if err := node.DbDeserialize(data); err != nil {
+			return nil, errors.New("DbDeserialize trie node failed, error is " + err.Error())
+		}
+		nodes[*node.Hash()] = node
+	}
+
+	hash := rootHash
+	for {
+		node, ok := nodes[*hash]
+		if !ok {
+			return nil, errors.Errorf("trie node %s is missing in the proof", hash)
+		}
+
+		if isLeafOf(node, key) {
+			return leafValue(node, proof.RefValue)
+		}
+
+		var next *TrieNode
+		if next, key = nextNodeOf(node, key); next == nil {
+			if proof.RefValue != nil {
+				return nil, errors.New("proof of a missing key has a ref value")
+			}
+			return nil, nil
+		}
+		hash = next.Hash()
+	}
+}
+
+// leafValue returns the value proved by a leaf. Values longer than 32 bytes are stored in hash nodes and
+// read from DBKP_TRIE_REF_VALUE, so a value node must not be longer, and a ref value matching the leaf
+// means the leaf is a hash node whatever type the proof claims.
+func leafValue(node *TrieNode, refValue []byte) ([]byte, error) {
+	if refValue == nil {
+		if node.NodeType() == TRIE_HASH_NODE {
+			return nil, errors.New("ref value of the hash node is missing")
+		}
+		if len(node.value) > types.HashSize {
+			return nil, errors.New("value node is longer than a hash")
+		}
+		return node.value, nil
+	}
+
+	if len(refValue) <= types.HashSize || !bytes.Equal(crypto.Hash256(refValue), node.value) {
+		return nil, errors.New("ref value doesn't match the hash node")
+	}
+	if node.NodeType() != TRIE_HASH_NODE {
+		return nil, errors.New("value node refers to a ref value")
+	}
+	return refValue, nil
+}
+
+func isLeafOf(node *TrieNode, key []byte) bool {
+	return len(key) == 0 &&
+		(node.NodeType() == TRIE_VALUE_NODE || node.NodeType() == TRIE_HASH_NODE)
+}
+
+// hashable checks the hash of node is cached or can be computed from its children
+func hashable(node *TrieNode) bool {
+	if node == nil {
+		return false
+	}
+	return node.hash != nil || childrenHashable(node)
+}
+
+// childrenHashable checks the children of node are loaded with their hashes, which the node is serialized with.
+// Only the subtrees of the nodes without a cached hash are walked, the nodes loaded from the database have it.
+func childrenHashable(node *TrieNode) bool {
+	switch node.NodeType() {
+	case TRIE_FULL_NODE:
+		for _, child := range node.children {
+			if !hashable(child) {
+				return false
+			}
+		}
+		return node.child == nil || hashable(node.child)
+	case TRIE_SHORT_NODE:
+		return hashable(node.child)
+	case TRIE_VALUE_NODE, TRIE_HASH_NODE:
+		return true
+	}
+	return false
+}
+
+// nextNodeOf walks one step along key like getLeafNode, the child of a full node may be a stub with the hash only
+func nextNodeOf(node *TrieNode, key []byte) (*TrieNode, []byte) {
+	switch node.NodeType() {
+	case TRIE_FULL_NODE:
+		if len(key) == 0 {
+			return node.child, key
+		}
+		return node.children[key[0]], key[1:]
+	case TRIE_SHORT_NODE:
+		if len(key) == 0 || !bytes.HasPrefix(key, node.key) {
+			return nil, nil
+		}
+		return node.child, key[len(node.key):]
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,149 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db/database"
+)
+
+func TestProve(t *testing.T) {
+	trie, db, close := getTrieOfNewContext()
+	defer close()
+
+	longValue := bytes.Repeat([]byte("long value "), 10)
+	values := map[string][]byte{
+		"":        []byte("NilNilNilNilNil"),
+		"IamG":    []byte("ki10$%^%&@#!@#"),
+		"IamGood": longValue,
+		"IamGoo":  []byte("a1230xm90zm19ma"),
+		"tesab":   []byte("value.555"),
+	}
+	for key, value := range values {
+		trie.SetValue([]byte(key), value)
+	}
+
+	batch := new(leveldb.Batch)
+	callback, _ := trie.Save(batch)
+	db.Write(batch, nil)
+	callback()
+
+	loadedTrie := NewTrie(db, trie.Hash(), nil)
+	for key, value := range values {
+		proof, err := loadedTrie.Prove([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		provedValue, err := VerifyProof(trie.Hash(), []byte(key), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(provedValue, value) {
+			t.Fatalf("value of %s is %s, expect %s", key, provedValue, value)
+		}
+	}
+
+	for _, key := range []string{"Iam", "IamGo", "IamGoodd", "IamH", "x"} {
+		proof, err := loadedTrie.Prove([]byte(key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		provedValue, err := VerifyProof(trie.Hash(), []byte(key), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if provedValue != nil {
+			t.Fatalf("%s should not exist, got %s", key, provedValue)
+		}
+	}
+
+	// tampered proofs
+	proof, _ := loadedTrie.Prove([]byte("IamGood"))
+	proof.RefValue = []byte("fake value")
+	if _, err := VerifyProof(trie.Hash(), []byte("IamGood"), proof); err == nil {
+		t.Fatal("tampered ref value should fail")
+	}
+
+	// a value node in place of the hash node has the same hash
+	proof, _ = loadedTrie.Prove([]byte("IamGood"))
+	leaf := &TrieNode{}
+	if err := leaf.DbDeserialize(proof.Nodes[len(proof.Nodes)-1]); err != nil {
+		t.Fatal(err)
+	}
+	if leaf.NodeType() != TRIE_HASH_NODE {
+		t.Fatalf("long value should be in a hash node, got type %d", leaf.NodeType())
+	}
+	proof.Nodes[len(proof.Nodes)-1], _ = NewValueNode(leaf.value).DbSerialize()
+	if _, err := VerifyProof(trie.Hash(), []byte("IamGood"), proof); err == nil {
+		t.Fatal("value node referring to a ref value should fail")
+	}
+	proof, _ = loadedTrie.Prove([]byte("IamGood"))
+	proof.RefValue = nil
+	if _, err := VerifyProof(trie.Hash(), []byte("IamGood"), proof); err == nil {
+		t.Fatal("hash node without the ref value should fail")
+	}
+	proof, _ = loadedTrie.Prove([]byte("IamG"))
+	proof.RefValue = longValue
+	if _, err := VerifyProof(trie.Hash(), []byte("IamG"), proof); err == nil {
+		t.Fatal("value node with a ref value should fail")
+	}
+	proof, _ = loadedTrie.Prove([]byte("x"))
+	proof.RefValue = longValue
+	if _, err := VerifyProof(trie.Hash(), []byte("x"), proof); err == nil {
+		t.Fatal("proof of a missing key with a ref value should fail")
+	}
+
+	proof, _ = loadedTrie.Prove([]byte("IamG"))
+	proof.Nodes = proof.Nodes[:len(proof.Nodes)-1]
+	if _, err := VerifyProof(trie.Hash(), []byte("IamG"), proof); err == nil {
+		t.Fatal("incomplete proof should fail")
+	}
+
+	otherTrie := trie.Copy()
+	otherTrie.SetValue([]byte("IamG"), []byte("other"))
+	proof, _ = otherTrie.Prove([]byte("IamG"))
+	if _, err := VerifyProof(trie.Hash(), []byte("IamG"), proof); err == nil {
+		t.Fatal("proof of other root should fail")
+	}
+}
+
+func TestProvePruned(t *testing.T) {
+	trie, db, close := getTrieOfNewContext()
+	defer close()
+
+	trie.SetValue([]byte("IamG"), []byte("value"))
+	trie.SetValue([]byte("tesab"), []byte("value.555"))
+	trie.SetValue([]byte("tesac"), []byte("value.556"))
+	batch := new(leveldb.Batch)
+	callback, _ := trie.Save(batch)
+	db.Write(batch, nil)
+	callback()
+
+	proof, err := trie.Prove([]byte("tesab"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := &TrieNode{}
+	if err := leaf.DbDeserialize(proof.Nodes[len(proof.Nodes)-1]); err != nil {
+		t.Fatal(err)
+	}
+	dbKey, _ := database.EncodeKey(database.DBKP_TRIE_NODE, leaf.Hash().Bytes())
+	if err := db.Delete(dbKey, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	prunedTrie := NewTrie(db, trie.Hash(), nil)
+	if _, err := prunedTrie.Prove([]byte("tesab")); err == nil {
+		t.Fatal("proof through a missing node should fail")
+	}
+
+	// nodes off the path are not needed
+	proof, err = prunedTrie.Prove([]byte("IamG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, err := VerifyProof(trie.Hash(), []byte("IamG"), proof); err != nil || string(value) != "value" {
+		t.Fatalf("expected value, got %s, error %v", value, err)
+	}
+}
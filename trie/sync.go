@@ -0,0 +1,323 @@
+package trie
+
+import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+var (
+	errSyncUnrequested = errors.New("trie node is not requested")
+	errSyncNotLeaf     = errors.New("trie node referred as a leaf is not a leaf")
+)
+
+// times a ref value of a 32 bytes leaf is requested before the leaf is taken as a value node
+const syncLeafValueTries = 3
+
+// LeafCallback is called with the value of every leaf downloaded, the returned roots are downloaded too,
+// eg. the account storage tries referred by the state trie. leaves of the returned tries are not called back.
+type LeafCallback func(value []byte) []types.Hash
+
+type syncRequest struct {
+	hash    types.Hash
+	isValue bool // ref value of hash node, not trie node
+	isLeaf  bool // referred by the child of a full node, it must be a value node or a hash node
+	data    []byte
+	parents []*syncRequest
+	deps    int // children not committed
+	onLeaf  LeafCallback
+	tries   int // requests of the ref value, see Requeue
+}
+
+// Sync downloads a trie by node hash. Every node is verified by its hash, and is committed to the database
+// only after all its children have been committed, so a trie existing in the database is always complete.
+type Sync struct {
+	db *leveldb.DB
+
+	nodes  map[types.Hash]*syncRequest // requested and not committed trie nodes
+	values map[types.Hash]*syncRequest // requested and not committed ref values
+	queue  []*syncRequest              // not requested yet
+
+	batch *leveldb.Batch
+}
+
+func NewSync(db *leveldb.DB, root types.Hash, onLeaf LeafCallback) *Sync {
+	s := &Sync{
+		db:     db,
+		nodes:  make(map[types.Hash]*syncRequest),
+		values: make(map[types.Hash]*syncRequest),
+		batch:  new(leveldb.Batch),
+	}
+
+	s.schedule(root, false, nil, onLeaf)
+
+	return s
+}
+
+func (s *Sync) has(hash types.Hash, isValue bool) bool {
+	if s.db == nil {
+		return false
+	}
+
+	prefix := database.DBKP_TRIE_NODE
+	if isValue {
+		prefix = database.DBKP_TRIE_REF_VALUE
+	}
+
+	dbKey, _ := database.EncodeKey(prefix, hash.Bytes())
+	ok, _ := s.db.Has(dbKey, nil)
+	return ok
+}
+
+// schedule request hash if it doesn't exist in the database, parent will wait for it
+func (s *Sync) schedule(hash types.Hash, isValue bool, parent *syncRequest, onLeaf LeafCallback) *syncRequest {
+	requests := s.nodes
+	if isValue {
+		requests = s.values
+	}
+
+	req, ok := requests[hash]
+	if !ok {
+		if s.has(hash, isValue) {
+			return nil
+		}
+
+		req = &syncRequest{
+			hash:    hash,
+			isValue: isValue,
+			onLeaf:  onLeaf,
+		}
+		requests[hash] = req
+		s.queue = append(s.queue, req)
+	}
+
+	if parent != nil {
+		req.parents = append(req.parents, parent)
+		parent.deps++
+	}
+	return req
+}
+
+// Missing pops at most max hashes to request, hashes failed to be downloaded must be requested again by the caller
+func (s *Sync) Missing(max int) (nodes, values []types.Hash) {
+	for len(s.queue) > 0 && (max <= 0 || len(nodes)+len(values) < max) {
+		req := s.queue[0]
+		s.queue = s.queue[1:]
+
+		if req.isValue {
+			values = append(values, req.hash)
+		} else {
+			nodes = append(nodes, req.hash)
+		}
+	}
+
+	return
+}
+
+// Requeue schedule the requested hashes again if they have not been received.
+// The ref value of a 32 bytes leaf not received after syncLeafValueTries requests is taken as nonexistent.
+func (s *Sync) Requeue(nodes, values []types.Hash) {
+	for _, hash := range nodes {
+		if req, ok := s.nodes[hash]; ok && req.data == nil {
+			s.queue = append(s.queue, req)
+		}
+	}
+	for _, hash := range values {
+		req, ok := s.values[hash]
+		if !ok || req.data != nil {
+			continue
+		}
+
+		if req.tries++; req.tries >= syncLeafValueTries {
+			s.valueNotExist(req)
+			continue
+		}
+		s.queue = append(s.queue, req)
+	}
+}
+
+// ProcessNode verify and accept a serialized trie node, return the hash of it
+func (s *Sync) ProcessNode(data []byte) (hash types.Hash, err error) {
+	node := &TrieNode{}
+	if err = node.DbDeserialize(data); err != nil {
+		return
+	}
+
+	hash = *node.Hash()
+	req, ok := s.nodes[hash]
+	if !ok {
+		return hash, errSyncUnrequested
+	}
+	if req.data != nil {
+		// duplicated
+		return
+	}
+	switch node.NodeType() {
+	case TRIE_HASH_NODE, TRIE_VALUE_NODE:
+		// longer values are in hash nodes
+		if len(node.value) > types.HashSize || (node.NodeType() == TRIE_HASH_NODE && len(node.value) != types.HashSize) {
+			return hash, errors.New("value node is longer than a hash or hash node is not a hash")
+		}
+	default:
+		if req.isLeaf {
+			return hash, errSyncNotLeaf
+		}
+	}
+	req.data = data
+
+	switch node.NodeType() {
+	case TRIE_FULL_NODE:
+		for _, child := range node.children {
+			s.schedule(*child.hash, false, req, req.onLeaf)
+		}
+		if node.child != nil {
+			if child := s.schedule(*node.child.hash, false, req, req.onLeaf); child != nil {
+				child.isLeaf = true
+			}
+		}
+	case TRIE_SHORT_NODE:
+		s.schedule(*node.child.hash, false, req, req.onLeaf)
+	case TRIE_HASH_NODE, TRIE_VALUE_NODE:
+		if len(node.value) < types.HashSize {
+			s.leaf(node.value, req)
+			break
+		}
+
+		// a value node and a hash node with the same 32 bytes have the same hash, so the type sent by the peer
+		// is not trusted. The leaf is a hash node if the ref value of it is found.
+		valueHash, _ := types.BytesToHash(node.value)
+		if s.has(valueHash, true) {
+			req.data, err = NewHashNode(&valueHash).DbSerialize()
+			break
+		}
+		s.schedule(valueHash, true, req, req.onLeaf)
+	default:
+		return hash, errors.Errorf("unknown trie node type %d", node.NodeType())
+	}
+
+	if req.deps == 0 {
+		s.commit(req)
+	}
+
+	return
+}
+
+// ProcessValue verify and accept a ref value of hash node, return the hash of it
+func (s *Sync) ProcessValue(value []byte) (hash types.Hash, err error) {
+	// shorter values are in value nodes
+	if len(value) <= types.HashSize {
+		return hash, errors.New("ref value is not longer than a hash")
+	}
+	if hash, err = types.BytesToHash(crypto.Hash256(value)); err != nil {
+		return
+	}
+
+	req, ok := s.values[hash]
+	if !ok {
+		return hash, errSyncUnrequested
+	}
+	if req.data != nil {
+		return
+	}
+	req.data = value
+
+	// the leaves referring it are hash nodes
+	for _, parent := range req.parents {
+		if parent.data, err = NewHashNode(&hash).DbSerialize(); err != nil {
+			return
+		}
+	}
+
+	s.leaf(value, req)
+
+	if req.deps == 0 {
+		s.commit(req)
+	}
+
+	return
+}
+
+// valueNotExist takes the leaves referring req as value nodes
+func (s *Sync) valueNotExist(req *syncRequest) {
+	delete(s.values, req.hash)
+
+	for _, parent := range req.parents {
+		// parent.data is serialized as the value node, which is the same whatever type the peer sent
+		parent.data, _ = NewValueNode(req.hash.Bytes()).DbSerialize()
+		s.leaf(req.hash.Bytes(), parent)
+
+		if parent.deps--; parent.deps == 0 {
+			s.commit(parent)
+		}
+	}
+}
+
+func (s *Sync) leaf(value []byte, req *syncRequest) {
+	if req.onLeaf == nil {
+		return
+	}
+
+	for _, root := range req.onLeaf(value) {
+		s.schedule(root, false, req, nil)
+	}
+}
+
+func (s *Sync) commit(req *syncRequest) {
+	if req.isValue {
+		dbKey, _ := database.EncodeKey(database.DBKP_TRIE_REF_VALUE, req.hash.Bytes())
+		s.batch.Put(dbKey, req.data)
+		delete(s.values, req.hash)
+	} else {
+		dbKey, _ := database.EncodeKey(database.DBKP_TRIE_NODE, req.hash.Bytes())
+		s.batch.Put(dbKey, req.data)
+		delete(s.nodes, req.hash)
+	}
+
+	for _, parent := range req.parents {
+		parent.deps--
+		if parent.deps == 0 && parent.data != nil {
+			s.commit(parent)
+		}
+	}
+}
+
+// Commit writes the completed nodes into the database
+func (s *Sync) Commit() error {
+	if s.batch.Len() == 0 || s.db == nil {
+		return nil
+	}
+
+	if err := s.db.Write(s.batch, nil); err != nil {
+		return err
+	}
+	s.batch.Reset()
+
+	return nil
+}
+
+// Pending return the amount of nodes and values not committed
+func (s *Sync) Pending() int {
+	return len(s.nodes) + len(s.values)
+}
+
+// NodeData return the serialized trie node of hash, nil if it doesn't exist
+func NodeData(db *leveldb.DB, hash *types.Hash) ([]byte, error) {
+	dbKey, _ := database.EncodeKey(database.DBKP_TRIE_NODE, hash.Bytes())
+	data, err := db.Get(dbKey, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return data, err
+}
+
+// RefValue return the ref value of hash, nil if it doesn't exist
+func RefValue(db *leveldb.DB, hash *types.Hash) ([]byte, error) {
+	dbKey, _ := database.EncodeKey(database.DBKP_TRIE_REF_VALUE, hash.Bytes())
+	data, err := db.Get(dbKey, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return data, err
+}
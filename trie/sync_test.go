@@ -1,9 +1,19 @@
 package trie
 
 import (
+	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"sync"
 	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/chain_db/database"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
 )
 
 type tObject struct {
@@ -34,3 +44,193 @@ func TestThrowErr(t *testing.T) {
 	}
 	sw.Wait()
 }
+
+func newSyncTestDb(name string) (*leveldb.DB, func()) {
+	dbFile := filepath.Join(common.GoViteTestDataDir(), name)
+	os.RemoveAll(dbFile)
+
+	db, _ := database.NewLevelDb(dbFile)
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dbFile)
+	}
+}
+
+func saveTrie(t *testing.T, db *leveldb.DB, trie *Trie) {
+	batch := new(leveldb.Batch)
+	callback, err := trie.Save(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Write(batch, nil); err != nil {
+		t.Fatal(err)
+	}
+	callback()
+}
+
+func TestSync(t *testing.T) {
+	srcDb, closeSrc := newSyncTestDb("sync_src")
+	defer closeSrc()
+	dstDb, closeDst := newSyncTestDb("sync_dst")
+	defer closeDst()
+
+	// state trie refers storage tries by root
+	state := NewTrie(srcDb, nil, nil)
+	storages := make(map[string]map[string][]byte)
+	for i := 0; i < 20; i++ {
+		storage := NewTrie(srcDb, nil, nil)
+		values := map[string][]byte{
+			"balance":                []byte(strconv.Itoa(i)),
+			"code" + strconv.Itoa(i): bytes.Repeat([]byte{byte(i)}, 100), // hash node
+		}
+		for key, value := range values {
+			storage.SetValue([]byte(key), value)
+		}
+		saveTrie(t, srcDb, storage)
+
+		account := "account" + strconv.Itoa(i)
+		storages[account] = values
+		state.SetValue([]byte(account), storage.Hash().Bytes())
+	}
+	saveTrie(t, srcDb, state)
+
+	s := NewSync(dstDb, *state.Hash(), func(value []byte) []types.Hash {
+		root, err := types.BytesToHash(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []types.Hash{root}
+	})
+
+	// unrequested node is refused
+	data, _ := NodeData(srcDb, state.Hash())
+	if _, err := NewSync(dstDb, types.Hash{}, nil).ProcessNode(data); err != errSyncUnrequested {
+		t.Fatalf("unrequested node should be refused: %v", err)
+	}
+
+	for s.Pending() > 0 {
+		nodes, values := s.Missing(10)
+		if len(nodes) == 0 && len(values) == 0 {
+			t.Fatal("pending nodes are not requested")
+		}
+
+		// the last node is lost, it is requested again
+		received := nodes
+		if len(nodes) > 1 {
+			received = nodes[:len(nodes)-1]
+		}
+
+		for _, hash := range received {
+			data, _ := NodeData(srcDb, &hash)
+			if got, err := s.ProcessNode(data); err != nil || got != hash {
+				t.Fatalf("process node %s error: %v", hash, err)
+			}
+		}
+		for _, hash := range values {
+			// ref values of 32 bytes value nodes don't exist
+			value, _ := RefValue(srcDb, &hash)
+			if value == nil {
+				continue
+			}
+			if got, err := s.ProcessValue(value); err != nil || got != hash {
+				t.Fatalf("process value %s error: %v", hash, err)
+			}
+		}
+		s.Requeue(nodes, values)
+
+		if err := s.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		// root is committed at last, so an existing trie is complete
+		if s.Pending() > 0 {
+			if data, _ := NodeData(dstDb, state.Hash()); data != nil {
+				t.Fatal("root should not be committed before children")
+			}
+		}
+	}
+
+	synced := NewTrie(dstDb, state.Hash(), nil)
+	for account, values := range storages {
+		root, _ := types.BytesToHash(synced.GetValue([]byte(account)))
+		storage := NewTrie(dstDb, &root, nil)
+		for key, value := range values {
+			if !bytes.Equal(storage.GetValue([]byte(key)), value) {
+				t.Fatalf("value of %s/%s is not synced", account, key)
+			}
+		}
+	}
+
+	// nothing to download again
+	if NewSync(dstDb, *state.Hash(), nil).Pending() != 0 {
+		t.Fatal("synced trie should not be downloaded again")
+	}
+}
+
+func TestSyncLeafType(t *testing.T) {
+	srcDb, closeSrc := newSyncTestDb("sync_leaf_src")
+	defer closeSrc()
+	dstDb, closeDst := newSyncTestDb("sync_leaf_dst")
+	defer closeDst()
+
+	longValue := bytes.Repeat([]byte("long value "), 10)
+	hashValue := crypto.Hash256([]byte("32 bytes value"))
+	src := NewTrie(srcDb, nil, nil)
+	src.SetValue([]byte("long"), longValue)
+	src.SetValue([]byte("hash"), hashValue)
+	saveTrie(t, srcDb, src)
+
+	s := NewSync(dstDb, *src.Hash(), nil)
+	for round := 0; s.Pending() > 0; round++ {
+		if round > 10 {
+			t.Fatal("sync is stalled")
+		}
+
+		nodes, values := s.Missing(0)
+		for _, hash := range nodes {
+			data, _ := NodeData(srcDb, &hash)
+			node := &TrieNode{}
+			if err := node.DbDeserialize(data); err != nil {
+				t.Fatal(err)
+			}
+			// a malicious peer sends value nodes for hash nodes, they have the same hash
+			if node.NodeType() == TRIE_HASH_NODE {
+				data, _ = NewValueNode(node.value).DbSerialize()
+			}
+			if _, err := s.ProcessNode(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, hash := range values {
+			if value, _ := RefValue(srcDb, &hash); value != nil {
+				if _, err := s.ProcessValue(value); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		s.Requeue(nodes, values)
+		if err := s.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	synced := NewTrie(dstDb, src.Hash(), nil)
+	if value := synced.GetValue([]byte("long")); !bytes.Equal(value, longValue) {
+		t.Fatalf("value of the hash node is %x, expect %x", value, longValue)
+	}
+	if value := synced.GetValue([]byte("hash")); !bytes.Equal(value, hashValue) {
+		t.Fatalf("value of the 32 bytes value node is %x, expect %x", value, hashValue)
+	}
+
+	// a full node is not a leaf
+	s = NewSync(nil, types.Hash{}, nil)
+	leaf := s.schedule(*src.Root.Hash(), false, nil, nil)
+	leaf.isLeaf = true
+	data, _ := NodeData(srcDb, src.Root.Hash())
+	if _, err := s.ProcessNode(data); err != errSyncNotLeaf {
+		t.Fatalf("non-leaf node referred as a leaf should be refused, got %v", err)
+	}
+	if _, err := s.ProcessValue(hashValue); err == nil {
+		t.Fatal("ref value not longer than a hash should be refused")
+	}
+}
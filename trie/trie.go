@@ -52,6 +52,9 @@ func (trie *Trie) getNodeFromDb(key *types.Hash) *TrieNode {
 		trie.log.Error("Deserialize trie node  failed, error is "+dsErr.Error(), "method", "getNodeFromDb")
 		return nil
 	}
+	// nodes are saved by their hash and copied before changed, so the hash isn't computed again from the subtree
+	nodeHash := *key
+	trieNode.hash = &nodeHash
 
 	return trieNode
 }
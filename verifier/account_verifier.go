@@ -308,6 +308,22 @@ func (verifier *AccountVerifier) verifyProducerLegality(block *ledger.AccountBlo
 			}
 		}
 	}
+	if code == ledger.AccountTypeNotExist && block.IsReceiveBlock() {
+		// the first receive block of a new contract is produced by its consensus group
+		fromBlock, err := verifier.chain.GetAccountBlockByHash(&block.FromBlockHash)
+		if err != nil {
+			return FAIL, err
+		}
+		if fromBlock != nil && fromBlock.BlockType == ledger.BlockTypeSendCreate {
+			if result, err := verifier.consensus.VerifyAccountProducer(block); !result {
+				if err != nil {
+					verifier.log.Error(err.Error())
+				}
+				return FAIL, errors.New("block producer is illegal")
+			}
+			return SUCCESS, nil
+		}
+	}
 	if code == ledger.AccountTypeGeneral {
 		if types.PubkeyToAddress(block.PublicKey) != block.AccountAddress {
 			return FAIL, errors.New("publicKey doesn't match with the accountAddress")
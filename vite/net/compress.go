@@ -0,0 +1,92 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang/snappy"
+	"github.com/vitelabs/go-vite/monitor"
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+// supportedCompressions will be told to peers in handshake, peers can compress payload by one of them.
+// zstd is supported only if built with cgo, so peers built without cgo negotiate snappy
+var supportedCompressions = append([]message.Compression{message.CompressSnappy}, zstdCompressions...)
+
+// payload smaller than compressThreshold will not be compressed
+const compressThreshold = 1024
+
+// same as the max payload of p2p message
+const maxDecompressedSize = ^uint32(0) >> 8 // 15MB
+
+var errUnknownCompression = errors.New("unknown compression")
+var errDecompressedTooLarge = errors.New("decompressed payload is too large")
+
+// negotiateCompression choose the preferred compression supported by both sides, the larger one is preferred,
+// the result is the same at both sides, CompressNone if no compression in common
+func negotiateCompression(ours, theirs []message.Compression) (c message.Compression) {
+	for _, o := range ours {
+		for _, t := range theirs {
+			if o == t && o > c {
+				c = o
+			}
+		}
+	}
+
+	return
+}
+
+// compressPayload prefix payload with the compression byte,
+// payload is kept raw if it is small or can't be compressed smaller
+func compressPayload(c message.Compression, payload []byte) []byte {
+	if c == message.CompressNone || len(payload) < compressThreshold {
+		return append([]byte{byte(message.CompressNone)}, payload...)
+	}
+
+	var data []byte
+	var err error
+	switch c {
+	case message.CompressSnappy:
+		data = snappy.Encode(nil, payload)
+	case message.CompressZstd:
+		data, err = zstdEncode(payload)
+	default:
+		err = errUnknownCompression
+	}
+
+	if err != nil || len(data) >= len(payload) {
+		c, data = message.CompressNone, payload
+	}
+
+	monitor.LogEventNum("net", "compress_in", len(payload))
+	monitor.LogEventNum("net", "compress_out", len(data))
+	// percent of sent size to raw size, by the compression finally used
+	monitor.LogEventNum("net", "compress_ratio_"+c.String(), len(data)*100/len(payload))
+
+	return append([]byte{byte(c)}, data...)
+}
+
+func decompressPayload(payload []byte) ([]byte, error) {
+	if len(payload) == 0 {
+		return nil, errUnknownCompression
+	}
+
+	c, data := message.Compression(payload[0]), payload[1:]
+	switch c {
+	case message.CompressNone:
+		return data, nil
+	case message.CompressSnappy:
+		size, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if uint32(size) > maxDecompressedSize {
+			return nil, errDecompressedTooLarge
+		}
+		return snappy.Decode(nil, data)
+	case message.CompressZstd:
+		return zstdDecode(data)
+	default:
+		return nil, fmt.Errorf("%v %d", errUnknownCompression, c)
+	}
+}
@@ -0,0 +1,18 @@
+// +build !cgo
+
+package net
+
+import (
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+// zstd needs cgo, it's not told to peers
+var zstdCompressions []message.Compression
+
+func zstdEncode(payload []byte) ([]byte, error) {
+	return nil, errUnknownCompression
+}
+
+func zstdDecode(data []byte) ([]byte, error) {
+	return nil, errUnknownCompression
+}
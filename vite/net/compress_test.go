@@ -0,0 +1,75 @@
+package net
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+func TestNegotiateCompression(t *testing.T) {
+	if c := negotiateCompression(supportedCompressions, nil); c != message.CompressNone {
+		t.Fatalf("old peers should not be compressed: %s", c)
+	}
+
+	if c := negotiateCompression(supportedCompressions, []message.Compression{message.CompressSnappy, 100}); c != message.CompressSnappy {
+		t.Fatalf("should be snappy: %s", c)
+	}
+
+	if len(zstdCompressions) == 0 {
+		t.Skip("zstd needs cgo")
+	}
+	if c := negotiateCompression(supportedCompressions, []message.Compression{message.CompressZstd, message.CompressSnappy}); c != message.CompressZstd {
+		t.Fatalf("should prefer zstd: %s", c)
+	}
+}
+
+func TestCompressPayload(t *testing.T) {
+	random := make([]byte, 10000)
+	rand.Read(random)
+
+	payloads := [][]byte{
+		[]byte("small"),
+		bytes.Repeat([]byte("vite"), 10000),
+		random,
+	}
+
+	for _, c := range supportedCompressions {
+		for i, payload := range payloads {
+			buf := compressPayload(c, payload)
+
+			if i == 1 && (message.Compression(buf[0]) != c || len(buf) >= len(payload)) {
+				t.Fatalf("payload should be compressed by %s", c)
+			} else if i != 1 && message.Compression(buf[0]) != message.CompressNone {
+				t.Fatalf("payload %d should not be compressed by %s", i, c)
+			}
+
+			data, err := decompressPayload(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, payload) {
+				t.Fatalf("payload %d not equal by %s", i, c)
+			}
+		}
+	}
+
+	if _, err := decompressPayload([]byte{100, 1, 2}); err == nil {
+		t.Fatal("unknown compression should fail")
+	}
+}
+
+func TestDecompressTooLarge(t *testing.T) {
+	if len(zstdCompressions) == 0 {
+		t.Skip("zstd needs cgo")
+	}
+
+	buf := compressPayload(message.CompressZstd, make([]byte, maxDecompressedSize+1))
+	if message.Compression(buf[0]) != message.CompressZstd {
+		t.Fatal("zeros should be compressed")
+	}
+	if _, err := decompressPayload(buf); err != errDecompressedTooLarge {
+		t.Fatalf("should be too large: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+// +build cgo
+
+package net
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/DataDog/zstd"
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+var zstdCompressions = []message.Compression{message.CompressZstd}
+
+func zstdEncode(payload []byte) ([]byte, error) {
+	return zstd.Compress(nil, payload)
+}
+
+// zstdDecode stop reading if the decompressed payload exceeds maxDecompressedSize
+func zstdDecode(data []byte) ([]byte, error) {
+	r := zstd.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	payload, err := ioutil.ReadAll(io.LimitReader(r, int64(maxDecompressedSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint32(len(payload)) > maxDecompressedSize {
+		return nil, errDecompressedTooLarge
+	}
+	return payload, nil
+}
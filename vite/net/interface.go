@@ -2,17 +2,13 @@ package net
 
 import (
 	"github.com/vitelabs/go-vite/common/types"
-	"github.com/vitelabs/go-vite/compress"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/p2p"
+	"github.com/vitelabs/go-vite/trie"
 )
 
 // all query include from block
 type Chain interface {
-	// the second return value mean chunk befor/after file
-	GetSubLedgerByHeight(start, count uint64, forward bool) ([]*ledger.CompressedFileMeta, [][2]uint64)
-	GetSubLedgerByHash(origin *types.Hash, count uint64, forward bool) ([]*ledger.CompressedFileMeta, [][2]uint64, error)
-
 	// query chunk
 	GetConfirmSubLedger(start, end uint64) ([]*ledger.SnapshotBlock, map[types.Address][]*ledger.AccountBlock, error)
 
@@ -34,7 +30,14 @@ type Chain interface {
 	GetLatestSnapshotBlock() *ledger.SnapshotBlock
 	GetGenesisSnapshotBlock() *ledger.SnapshotBlock
 
-	Compressor() *compress.Compressor
+	// account state, serve light clients
+	GetStateTrie(stateHash *types.Hash) *trie.Trie
+	GetConfirmAccountBlock(snapshotHeight uint64, address *types.Address) (*ledger.AccountBlock, error)
+
+	// trie nodes, serve light clients and download storage tries of the light client
+	GetTrieNodeData(hash *types.Hash) ([]byte, error)
+	GetTrieRefValue(hash *types.Hash) ([]byte, error)
+	NewTrieSync(root types.Hash, onLeaf trie.LeafCallback) *trie.Sync
 }
 
 type Verifier interface {
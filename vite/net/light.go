@@ -0,0 +1,996 @@
+package net
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/monitor"
+	"github.com/vitelabs/go-vite/p2p"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+const lightSyncInterval = 3 * time.Second
+const lightHeaderBatch = 200
+const lightHeaderTimeout = 20 * time.Second
+
+// the latest headers kept in memory, account states are queried against them
+const lightHeaderWindow = 10000
+
+// the latest blocks of each tracked account, older blocks are dropped
+const lightAccountWindow = 1000
+
+var errLightNotLinked = errors.New("snapshot header is not linked to the current head")
+var errLightInvalidHash = errors.New("verify hash failed")
+var errLightInvalidSignature = errors.New("verify signature failed")
+var errLightInvalidSigner = errors.New("account block is not signed by the account")
+var errInvalidProducer = errors.New("producer is not in the consensus schedule")
+var errLightContentMismatch = errors.New("account block doesn't match SnapshotContent")
+
+// LightConfig configs a light client, it follows snapshot headers from Checkpoint
+// and the account chains of Addresses only
+type LightConfig struct {
+	Genesis *ledger.SnapshotBlock
+
+	// Checkpoint is the trusted snapshot block to sync headers from, it's Genesis if nil
+	Checkpoint *ledger.SnapshotBlock
+
+	// Reader is the consensus schedule, producers of snapshot headers are checked against it.
+	// If it's nil, the schedule is elected from the state of the verified headers, see lightElection
+	Reader consensus.Reader
+
+	// Chain keeps the storage tries downloaded to elect the producers, it's not needed if Reader is set
+	Chain Chain
+
+	// Addresses are the tracked user accounts, contract accounts are not supported
+	Addresses []types.Address
+}
+
+// LightNet keeps the verified snapshot headers and the verified account chains of the tracked addresses
+type LightNet interface {
+	Protocols() []*p2p.Protocol
+	Start(svr *p2p.Server) error
+	Stop()
+	Info() *NodeInfo
+
+	// the latest verified snapshot header
+	Head() *ledger.SnapshotBlock
+	GetSnapshotHeaderByHeight(height uint64) *ledger.SnapshotBlock
+	GetSnapshotHeaderByHash(hash types.Hash) *ledger.SnapshotBlock
+
+	// the verified account blocks of a tracked address, sorted by height
+	GetAccountBlocks(addr types.Address) []*ledger.AccountBlock
+
+	SubscribeSnapshotBlock(fn SnapshotBlockCallback) (subId int)
+	UnsubscribeSnapshotBlock(subId int)
+	SubscribeAccountBlock(fn AccountblockCallback) (subId int)
+	UnsubscribeAccountBlock(subId int)
+}
+
+// @section headerChain
+type headerChain struct {
+	headers []*ledger.SnapshotBlock // sorted by height, the last one is the head
+	byHash  map[types.Hash]*ledger.SnapshotBlock
+}
+
+func newHeaderChain(checkpoint *ledger.SnapshotBlock) *headerChain {
+	return &headerChain{
+		headers: []*ledger.SnapshotBlock{checkpoint},
+		byHash: map[types.Hash]*ledger.SnapshotBlock{
+			checkpoint.Hash: checkpoint,
+		},
+	}
+}
+
+func (hc *headerChain) head() *ledger.SnapshotBlock {
+	return hc.headers[len(hc.headers)-1]
+}
+
+func (hc *headerChain) getByHeight(height uint64) *ledger.SnapshotBlock {
+	first := hc.headers[0].Height
+	if height < first || height > hc.head().Height {
+		return nil
+	}
+	return hc.headers[height-first]
+}
+
+func (hc *headerChain) append(block *ledger.SnapshotBlock) {
+	hc.headers = append(hc.headers, block)
+	hc.byHash[block.Hash] = block
+
+	for len(hc.headers) > lightHeaderWindow {
+		delete(hc.byHash, hc.headers[0].Hash)
+		hc.headers[0] = nil
+		hc.headers = hc.headers[1:]
+	}
+}
+
+// prepend the headers linked to the first one by hash, sorted by height
+func (hc *headerChain) prepend(blocks []*ledger.SnapshotBlock) {
+	for _, block := range blocks {
+		hc.byHash[block.Hash] = block
+	}
+	hc.headers = append(append([]*ledger.SnapshotBlock(nil), blocks...), hc.headers...)
+}
+
+// contents return the heads of addr in SnapshotContent of the headers not higher than height, the highest first
+func (hc *headerChain) contents(addr types.Address, height uint64) (heads []ledger.HashHeight) {
+	for i := len(hc.headers) - 1; i >= 0; i-- {
+		header := hc.headers[i]
+		if header.Height > height {
+			continue
+		}
+		if head, ok := header.SnapshotContent[addr]; ok {
+			heads = append(heads, *head)
+		}
+	}
+	return
+}
+
+// @section lightAccount
+type lightTarget struct {
+	head      ledger.HashHeight
+	stateHash types.Hash
+	count     uint64              // blocks to fetch under head
+	contents  []ledger.HashHeight // heads of the account in SnapshotContent of the headers, the fetched blocks must match them
+}
+
+type lightAccount struct {
+	addr    types.Address
+	blocks  []*ledger.AccountBlock // verified, sorted by height
+	queried types.Hash             // the snapshot hash queried last time
+	target  *lightTarget           // the proven head waiting for blocks
+	pending map[types.Hash]*ledger.AccountBlock
+}
+
+func (a *lightAccount) head() *ledger.AccountBlock {
+	if len(a.blocks) == 0 {
+		return nil
+	}
+	return a.blocks[len(a.blocks)-1]
+}
+
+// setState compare the proven head with the verified blocks, return the target to fetch, or nil if nothing to fetch
+func (a *lightAccount) setState(head ledger.HashHeight, stateHash types.Hash, contents []ledger.HashHeight) *lightTarget {
+	current := a.head()
+	if current != nil && current.Hash == head.Hash {
+		a.target = nil
+		return nil
+	}
+
+	t := &lightTarget{
+		head:      head,
+		stateHash: stateHash,
+		contents:  contents,
+	}
+
+	if current != nil && head.Height > current.Height && head.Height-current.Height <= lightAccountWindow {
+		t.count = head.Height - current.Height
+	} else {
+		// too far away or forked, fetch the latest blocks only
+		a.blocks = nil
+		t.count = head.Height
+		if t.count > lightAccountWindow {
+			t.count = lightAccountWindow
+		}
+	}
+
+	a.target = t
+	a.pending = make(map[types.Hash]*ledger.AccountBlock)
+	return t
+}
+
+// link the pending blocks from the target head downward, return the new verified blocks,
+// or nil if the pending blocks are not enough yet
+func (a *lightAccount) link() ([]*ledger.AccountBlock, error) {
+	t := a.target
+	if t == nil {
+		return nil, nil
+	}
+
+	chain := make([]*ledger.AccountBlock, t.count)
+	hash := t.head.Hash
+	for i := int(t.count) - 1; i >= 0; i-- {
+		block, ok := a.pending[hash]
+		if !ok {
+			return nil, nil
+		}
+		chain[i] = block
+		hash = block.PrevHash
+	}
+
+	top := chain[len(chain)-1]
+	if top.Height != t.head.Height || top.StateHash != t.stateHash {
+		return nil, errors.Errorf("account block %s/%d doesn't match the proven state %s", top.Hash, top.Height, t.stateHash)
+	}
+
+	for _, content := range t.contents {
+		if content.Height < chain[0].Height || content.Height > top.Height {
+			continue
+		}
+		if block := chain[content.Height-chain[0].Height]; block.Hash != content.Hash {
+			return nil, errors.Errorf("account block %s/%d: %v, expect %s", block.Hash, block.Height, errLightContentMismatch, content.Hash)
+		}
+	}
+
+	for i, block := range chain {
+		if block.AccountAddress != a.addr {
+			return nil, errors.Errorf("account block %s doesn't belong to %s", block.Hash, a.addr)
+		}
+		if i > 0 && block.Height != chain[i-1].Height+1 {
+			return nil, errors.Errorf("account block %s/%d is not continuous", block.Hash, block.Height)
+		}
+		if err := verifyLightAccountBlock(block); err != nil {
+			return nil, errors.Errorf("verify account block %s failed: %v", block.Hash, err)
+		}
+	}
+
+	if current := a.head(); current != nil {
+		if chain[0].PrevHash != current.Hash || chain[0].Height != current.Height+1 {
+			return nil, errors.Errorf("account block %s/%d is not linked to %s/%d", chain[0].Hash, chain[0].Height, current.Hash, current.Height)
+		}
+	} else if chain[0].Height == 1 && chain[0].PrevHash != types.ZERO_HASH {
+		return nil, errors.Errorf("account block %s/%d is not the first", chain[0].Hash, chain[0].Height)
+	}
+
+	a.blocks = append(a.blocks, chain...)
+	if len(a.blocks) > lightAccountWindow {
+		a.blocks = append([]*ledger.AccountBlock(nil), a.blocks[len(a.blocks)-lightAccountWindow:]...)
+	}
+
+	a.target = nil
+	a.pending = nil
+	return chain, nil
+}
+
+// checkContent drop the verified blocks if the head in SnapshotContent of a new header forks them
+func (a *lightAccount) checkContent(content *ledger.HashHeight) bool {
+	if len(a.blocks) == 0 {
+		return true
+	}
+	first := a.blocks[0].Height
+	if content.Height < first || content.Height > a.head().Height {
+		return true
+	}
+	if a.blocks[content.Height-first].Hash == content.Hash {
+		return true
+	}
+
+	a.blocks = nil
+	a.queried = types.ZERO_HASH
+	return false
+}
+
+// the hash of account block doesn't cover StateHash, so every block must be signed by the account itself,
+// then the blocks under the proven head are anchored by PrevHash
+func verifyLightAccountBlock(block *ledger.AccountBlock) error {
+	if block.Timestamp == nil {
+		return errors.New("block timestamp can't be nil")
+	}
+
+	if block.Hash.IsZero() || block.ComputeHash() != block.Hash {
+		return errLightInvalidHash
+	}
+
+	if len(block.Signature) == 0 || len(block.PublicKey) == 0 || !block.VerifySignature() {
+		return errLightInvalidSignature
+	}
+
+	if types.PubkeyToAddress(block.PublicKey) != block.AccountAddress {
+		return errLightInvalidSigner
+	}
+
+	return nil
+}
+
+// @section lightNet
+type lightNet struct {
+	*LightConfig
+	checkpoint *ledger.SnapshotBlock
+	reader     consensus.Reader
+	election   *lightElection // nil if Reader is set
+	peers      *peerSet
+	*fetcher
+	filter    *filter
+	pool      *gid
+	rw        sync.RWMutex // protect headers and accounts
+	headers   *headerChain
+	accounts  map[types.Address]*lightAccount
+	headerReq time.Time // the deadline of the pending headers request
+	headerTo  uint64    // the last height of the pending headers request
+	wake      chan struct{}
+	sFeed     *snapshotBlockFeed
+	aFeed     *accountBlockFeed
+	feedLock  sync.Mutex
+	handlers  map[ViteCmd]MsgHandler
+	protocols []*p2p.Protocol
+	term      chan struct{}
+	wg        sync.WaitGroup
+	log       log15.Logger
+}
+
+// NewLight create a light client on the vite protocol, it doesn't serve queries of other peers
+func NewLight(cfg *LightConfig) LightNet {
+	checkpoint := cfg.Checkpoint
+	if checkpoint == nil {
+		checkpoint = cfg.Genesis
+	}
+
+	g := new(gid)
+	peers := newPeerSet()
+	filter := newFilter()
+
+	l := &lightNet{
+		LightConfig: cfg,
+		checkpoint:  checkpoint,
+		peers:       peers,
+		fetcher:     newFetcher(filter, peers, g),
+		filter:      filter,
+		pool:        g,
+		reader:      cfg.Reader,
+		headers:     newHeaderChain(checkpoint),
+		wake:        make(chan struct{}, 1),
+		accounts:    make(map[types.Address]*lightAccount, len(cfg.Addresses)),
+		sFeed:       newSnapshotBlockFeed(),
+		aFeed:       newAccountBlockFeed(),
+		handlers:    make(map[ViteCmd]MsgHandler),
+		log:         log15.New("module", "net/light"),
+	}
+
+	if l.reader == nil {
+		l.election = newLightElection(cfg.Chain, l.headers, cfg.Genesis)
+		l.reader = l.election.reader
+	}
+
+	for _, addr := range cfg.Addresses {
+		l.accounts[addr] = &lightAccount{addr: addr}
+	}
+
+	// headers and account chains are verified by light client itself, no need to wait for syncing
+	l.fetcher.listen(Syncdone)
+
+	l.addHandler(_statusHandler(statusHandler))
+	l.addHandler(l)
+	l.addHandler(&lightQueryHandler{})
+
+	l.protocols = append(l.protocols, &p2p.Protocol{
+		Name: Vite,
+		ID:   CmdSet,
+		Handle: func(p *p2p.Peer, rw *p2p.ProtoFrame) error {
+			peer := newPeer(p, rw, CmdSet)
+			return l.handlePeer(peer)
+		},
+	})
+
+	return l
+}
+
+func (l *lightNet) addHandler(handler MsgHandler) {
+	for _, cmd := range handler.Cmds() {
+		l.handlers[cmd] = handler
+	}
+}
+
+func (l *lightNet) Protocols() []*p2p.Protocol {
+	return l.protocols
+}
+
+func (l *lightNet) Start(svr *p2p.Server) error {
+	l.term = make(chan struct{})
+
+	if l.election != nil {
+		l.election.fetch = newStateFetcher(l.Chain, l.peers, l.pool, l.term)
+	}
+
+	l.filter.start()
+
+	l.wg.Add(1)
+	common.Go(l.loop)
+
+	return nil
+}
+
+func (l *lightNet) Stop() {
+	if l.term == nil {
+		return
+	}
+
+	select {
+	case <-l.term:
+	default:
+		close(l.term)
+		l.filter.stop()
+		l.wg.Wait()
+	}
+}
+
+func (l *lightNet) Info() *NodeInfo {
+	return &NodeInfo{
+		Peers: l.peers.Info(),
+	}
+}
+
+// will be called by p2p.Server, run as goroutine
+func (l *lightNet) handlePeer(p *peer) error {
+	// always tell the checkpoint, so full nodes will not sync from light client
+	err := p.Handshake(&message.HandShake{
+		Height:       l.checkpoint.Height,
+		Current:      l.checkpoint.Hash,
+		Genesis:      l.Genesis.Hash,
+		Compressions: supportedCompressions,
+	})
+
+	if err != nil {
+		l.log.Error(fmt.Sprintf("handshake with %s error: %v", p, err))
+		return err
+	}
+
+	l.peers.Add(p)
+	defer l.peers.Del(p)
+
+loop:
+	for {
+		select {
+		case <-l.term:
+			err = p2p.DiscQuitting
+			break loop
+
+		case err = <-p.errChan:
+			if err != nil {
+				l.log.Error(fmt.Sprintf("peer %s error: %v", p.RemoteAddr(), err))
+				break loop
+			}
+
+		default:
+			if err = l.handleMsg(p); err != nil {
+				break loop
+			}
+		}
+	}
+
+	close(p.term)
+	p.wg.Wait()
+
+	return err
+}
+
+func (l *lightNet) handleMsg(p *peer) (err error) {
+	msg, err := p.readMsg()
+	if err != nil {
+		l.log.Error(fmt.Sprintf("read message from %s error: %v", p, err))
+		return
+	}
+
+	code := ViteCmd(msg.Cmd)
+	if handler, ok := l.handlers[code]; ok {
+		begin := time.Now()
+		err = handler.Handle(msg, p)
+		monitor.LogDuration("net/light", "handle_"+code.String(), time.Now().Sub(begin).Nanoseconds())
+
+		p.msgHandled[code]++
+		return
+	}
+
+	l.log.Warn(fmt.Sprintf("missing handler for message %d from %s", msg.Cmd, p))
+	return nil
+}
+
+func (l *lightNet) loop() {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(lightSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.term:
+			return
+		case <-ticker.C:
+			l.sync()
+		case <-l.wake:
+			l.sync()
+		}
+	}
+}
+
+// request headers if the best peer is taller, or query states of the tracked accounts at the head
+func (l *lightNet) sync() {
+	p := l.peers.BestPeer()
+	if p == nil {
+		return
+	}
+
+	if l.election != nil && !l.prepare() {
+		return
+	}
+
+	l.rw.Lock()
+	head := l.headers.head()
+	if p.height > head.Height {
+		if time.Now().Before(l.headerReq) && l.headerTo > head.Height {
+			l.rw.Unlock()
+			return
+		}
+
+		count := p.height - head.Height
+		if count > lightHeaderBatch {
+			count = lightHeaderBatch
+		}
+		l.headerReq = time.Now().Add(lightHeaderTimeout)
+		l.headerTo = head.Height + count
+		l.rw.Unlock()
+
+		m := &message.GetSnapshotBlocks{
+			From:    ledger.HashHeight{Height: head.Height + 1},
+			Count:   count,
+			Forward: true,
+		}
+		if err := p.Send(GetSnapshotHeadersCode, l.pool.MsgID(), m); err != nil {
+			l.log.Error(fmt.Sprintf("send GetSnapshotHeaders %s to %s error: %v", m, p, err))
+		}
+		return
+	}
+
+	var queries []*message.GetAccountState
+	for _, a := range l.accounts {
+		if a.queried == head.Hash {
+			continue
+		}
+
+		queries = append(queries, &message.GetAccountState{
+			Address:  a.addr,
+			Snapshot: ledger.HashHeight{Hash: head.Hash, Height: head.Height},
+		})
+		a.queried = head.Hash
+	}
+	l.rw.Unlock()
+
+	for _, m := range queries {
+		if err := p.Send(GetAccountStateCode, l.pool.MsgID(), m); err != nil {
+			l.log.Error(fmt.Sprintf("send %s to %s error: %v", m, p, err))
+			return
+		}
+	}
+}
+
+// prepare load the states electing the producers of the current round and the next,
+// return false if they can't be loaded now
+func (l *lightNet) prepare() bool {
+	for {
+		l.rw.RLock()
+		header, voteTime, err := l.election.next()
+		first := l.headers.headers[0]
+		l.rw.RUnlock()
+
+		switch {
+		case err != nil:
+			l.log.Error(fmt.Sprintf("read consensus schedule error: %v", err))
+			return false
+
+		case header != nil:
+			if err = l.election.load(header); err != nil {
+				l.log.Error(fmt.Sprintf("load election state at %s/%d error: %v", header.Hash, header.Height, err))
+				return false
+			}
+
+		case !voteTime.IsZero():
+			if err = l.anchor(first, voteTime); err != nil {
+				l.log.Error(fmt.Sprintf("fetch headers under %s/%d error: %v", first.Hash, first.Height, err))
+				return false
+			}
+
+		default:
+			return true
+		}
+	}
+}
+
+// anchor fetch the headers under first until the one before t, the state electing the producers after
+// the checkpoint is at them. they are trusted as they are linked to the checkpoint by hash.
+func (l *lightNet) anchor(first *ledger.SnapshotBlock, t time.Time) error {
+	p := l.peers.BestPeer()
+	if p == nil {
+		return errFetchPeers
+	}
+
+	var headers []*ledger.SnapshotBlock
+	lowest := first
+	for !lowest.Timestamp.Before(t) {
+		if lowest.Height <= l.Genesis.Height {
+			return errors.Errorf("no snapshot block before %s", t)
+		}
+
+		msg, err := l.election.fetch.request(p, GetSnapshotHeadersCode, &message.GetSnapshotBlocks{
+			From:    ledger.HashHeight{Hash: lowest.PrevHash, Height: lowest.Height - 1},
+			Count:   chunk,
+			Forward: false,
+		}, SnapshotBlocksCode)
+		if err != nil {
+			return err
+		}
+
+		bs := new(message.SnapshotBlocks)
+		if err = bs.Deserialize(msg.Payload); err != nil {
+			p.Report(OffenceMalformed)
+			return err
+		}
+		if len(bs.Blocks) == 0 {
+			return errFetchMissing
+		}
+
+		for i := len(bs.Blocks) - 1; i >= 0 && !lowest.Timestamp.Before(t); i-- {
+			block := bs.Blocks[i]
+			if block.Hash != lowest.PrevHash || block.Height+1 != lowest.Height || block.Timestamp == nil || block.ComputeHash() != block.Hash {
+				p.Report(OffenceInvalidBlock)
+				return errLightNotLinked
+			}
+			headers = append(headers, block)
+			lowest = block
+		}
+	}
+
+	for i, j := 0, len(headers)-1; i < j; i, j = i+1, j-1 {
+		headers[i], headers[j] = headers[j], headers[i]
+	}
+
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	// the first header is dropped out of the window meanwhile
+	if l.headers.headers[0] == first {
+		l.headers.prepend(headers)
+	}
+
+	return nil
+}
+
+// implementation MsgHandler
+func (l *lightNet) ID() string {
+	return "light client"
+}
+
+func (l *lightNet) Cmds() []ViteCmd {
+	return []ViteCmd{SnapshotBlocksCode, NewSnapshotBlockCode, AccountBlocksCode, NewAccountBlockCode, AccountStateCode, TrieNodesCode, ExceptionCode}
+}
+
+func (l *lightNet) Handle(msg *p2p.Msg, sender Peer) error {
+	// responses of the election states
+	if l.election != nil && l.election.fetch != nil && l.election.fetch.handle(msg, sender) {
+		return nil
+	}
+
+	switch ViteCmd(msg.Cmd) {
+	case SnapshotBlocksCode:
+		bs := new(message.SnapshotBlocks)
+		if err := bs.Deserialize(msg.Payload); err != nil {
+			return err
+		}
+		return l.receiveHeaders(bs.Blocks, types.RemoteSync)
+
+	case NewSnapshotBlockCode:
+		block := new(ledger.SnapshotBlock)
+		if err := block.Deserialize(msg.Payload); err != nil {
+			return err
+		}
+		sender.SeeBlock(block.Hash)
+		if block.Height > sender.Height() {
+			sender.SetHead(block.Hash, block.Height)
+		}
+
+		// the new block may be ahead of headers, it will be synced later
+		if err := l.receiveHeaders([]*ledger.SnapshotBlock{block}, types.RemoteBroadcast); err != nil && err != errLightNotLinked {
+			return err
+		}
+
+	case AccountBlocksCode:
+		bs := new(message.AccountBlocks)
+		if err := bs.Deserialize(msg.Payload); err != nil {
+			return err
+		}
+		l.receiveAccountBlocks(bs.Blocks)
+
+	case AccountStateCode:
+		state := new(message.AccountState)
+		if err := state.Deserialize(msg.Payload); err != nil {
+			return err
+		}
+		return l.receiveAccountState(state)
+
+	case NewAccountBlockCode:
+		// account blocks are accepted only when they are confirmed by snapshot headers
+
+	case ExceptionCode:
+		l.log.Warn(fmt.Sprintf("receive exception of message %d from %s", msg.Id, sender.RemoteAddr()))
+	}
+
+	return nil
+}
+
+// receiveHeaders append the headers linked to the current head, return error if any header is invalid
+func (l *lightNet) receiveHeaders(blocks []*ledger.SnapshotBlock, source types.BlockSource) error {
+	var verified []*ledger.SnapshotBlock
+
+	l.rw.Lock()
+	var err error
+	for _, block := range blocks {
+		head := l.headers.head()
+		if block.Height <= head.Height {
+			continue
+		}
+		if err = l.verifyHeader(head, block); err != nil {
+			break
+		}
+
+		l.headers.append(block)
+		verified = append(verified, block)
+
+		for addr, content := range block.SnapshotContent {
+			if a, ok := l.accounts[addr]; ok && !a.checkContent(content) {
+				l.log.Warn(fmt.Sprintf("account blocks of %s are forked at %s/%d, fetch again", addr, content.Hash, content.Height))
+			}
+		}
+	}
+	if err != nil || l.headers.head().Height >= l.headerTo {
+		// request the next batch at once
+		l.headerReq = time.Time{}
+	}
+	l.rw.Unlock()
+
+	if err == errLightStatePending {
+		// the rest are verified after the state is loaded by the sync loop
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+		err = nil
+	}
+
+	if len(verified) > 0 {
+		monitor.LogEventNum("net/light", "headers", len(verified))
+		l.log.Info(fmt.Sprintf("receive %d snapshot headers, head %d", len(verified), verified[len(verified)-1].Height))
+
+		l.feedLock.Lock()
+		for _, block := range verified {
+			l.sFeed.Notify(block, source)
+		}
+		l.feedLock.Unlock()
+	}
+
+	if err != nil {
+		l.log.Error(fmt.Sprintf("verify snapshot header error: %v", err))
+	}
+	return err
+}
+
+func (l *lightNet) verifyHeader(prev, block *ledger.SnapshotBlock) error {
+	if block.Height != prev.Height+1 || block.PrevHash != prev.Hash {
+		return errLightNotLinked
+	}
+
+	if block.Timestamp == nil {
+		return errors.New("Timestamp is nil")
+	}
+
+	if block.Hash.IsZero() || block.ComputeHash() != block.Hash {
+		return errLightInvalidHash
+	}
+
+	if len(block.Signature) == 0 || len(block.PublicKey) == 0 || !block.VerifySignature() {
+		return errLightInvalidSignature
+	}
+
+	if l.election != nil {
+		if err := l.election.ready(*block.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return verifyProducer(l.reader, block)
+}
+
+// verifyProducer check the signed snapshot block is produced by the planned producer at its Timestamp
+func verifyProducer(reader consensus.Reader, block *ledger.SnapshotBlock) error {
+	events, _, err := reader.ReadByTime(types.SNAPSHOT_GID, *block.Timestamp)
+	if err != nil {
+		return errors.Errorf("read consensus schedule at %s error: %v", block.Timestamp, err)
+	}
+
+	producer := block.Producer()
+	for _, e := range events {
+		if e.Address == producer && e.Stime.Equal(*block.Timestamp) {
+			return nil
+		}
+	}
+
+	return errInvalidProducer
+}
+
+// receiveAccountState verify the state proof against the header, then fetch the missing account blocks
+func (l *lightNet) receiveAccountState(state *message.AccountState) error {
+	t, err := l.verifyAccountState(state)
+	if err != nil || t == nil {
+		return err
+	}
+
+	l.log.Info(fmt.Sprintf("fetch %d account blocks of %s from %s/%d", t.count, state.Address, t.head.Hash, t.head.Height))
+	l.FetchAccountBlocks(t.head.Hash, t.count, &state.Address)
+
+	return nil
+}
+
+// verifyAccountState return the target of the account to fetch, or nil if the account is up to date
+func (l *lightNet) verifyAccountState(state *message.AccountState) (*lightTarget, error) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	a, ok := l.accounts[state.Address]
+	if !ok {
+		return nil, nil
+	}
+
+	header, ok := l.headers.byHash[state.Snapshot.Hash]
+	if !ok {
+		// too old
+		return nil, nil
+	}
+
+	value, err := trie.VerifyProof(&header.StateHash, state.Address.Bytes(), state.Proof)
+	if err != nil {
+		a.queried = types.ZERO_HASH
+		return nil, errors.Errorf("verify state of %s at %s error: %v", state.Address, header.Hash, err)
+	}
+
+	// SnapshotContent is not covered by the header hash, so it's cross-checked with the proven state:
+	// the latest head of the account in SnapshotContent is the one whose StateHash is in the state trie
+	contents := l.headers.contents(state.Address, header.Height)
+
+	if len(value) == 0 {
+		if state.Head.Height != 0 {
+			return nil, errors.Errorf("account %s has no state at %s, but got head %d", state.Address, header.Hash, state.Head.Height)
+		}
+		if len(contents) > 0 {
+			return nil, errors.Errorf("account %s has no state at %s, but it's in SnapshotContent", state.Address, header.Hash)
+		}
+		return nil, nil
+	}
+
+	if state.Head.Height == 0 {
+		return nil, errors.Errorf("account %s has state at %s, but got no head", state.Address, header.Hash)
+	}
+
+	stateHash, err := types.BytesToHash(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(contents) > 0 && contents[0] != state.Head {
+		return nil, errors.Errorf("head %s/%d of %s at %s: %v, expect %s/%d", state.Head.Hash, state.Head.Height, state.Address, header.Hash, errLightContentMismatch, contents[0].Hash, contents[0].Height)
+	}
+
+	return a.setState(state.Head, stateHash, contents), nil
+}
+
+func (l *lightNet) receiveAccountBlocks(blocks []*ledger.AccountBlock) {
+	var verified []*ledger.AccountBlock
+
+	l.rw.Lock()
+	touched := make(map[types.Address]*lightAccount)
+	for _, block := range blocks {
+		a, ok := l.accounts[block.AccountAddress]
+		if !ok || a.target == nil {
+			continue
+		}
+		a.pending[block.Hash] = block
+		touched[a.addr] = a
+	}
+
+	for _, a := range touched {
+		target := a.target.head.Hash
+		chain, err := a.link()
+		if err != nil {
+			l.log.Error(fmt.Sprintf("link account blocks of %s error: %v", a.addr, err))
+			a.target = nil
+			a.pending = nil
+			a.queried = types.ZERO_HASH
+			l.filter.fail(target)
+			continue
+		}
+		if len(chain) > 0 {
+			l.filter.done(target)
+			verified = append(verified, chain...)
+		}
+	}
+	l.rw.Unlock()
+
+	if len(verified) > 0 {
+		monitor.LogEventNum("net/light", "accountBlocks", len(verified))
+
+		l.feedLock.Lock()
+		for _, block := range verified {
+			l.aFeed.Notify(block, types.RemoteFetch)
+		}
+		l.feedLock.Unlock()
+	}
+}
+
+func (l *lightNet) Head() *ledger.SnapshotBlock {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.headers.head()
+}
+
+func (l *lightNet) GetSnapshotHeaderByHeight(height uint64) *ledger.SnapshotBlock {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.headers.getByHeight(height)
+}
+
+func (l *lightNet) GetSnapshotHeaderByHash(hash types.Hash) *ledger.SnapshotBlock {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	return l.headers.byHash[hash]
+}
+
+func (l *lightNet) GetAccountBlocks(addr types.Address) []*ledger.AccountBlock {
+	l.rw.RLock()
+	defer l.rw.RUnlock()
+
+	if a, ok := l.accounts[addr]; ok {
+		return append([]*ledger.AccountBlock(nil), a.blocks...)
+	}
+	return nil
+}
+
+func (l *lightNet) SubscribeSnapshotBlock(fn SnapshotBlockCallback) (subId int) {
+	l.feedLock.Lock()
+	defer l.feedLock.Unlock()
+
+	return l.sFeed.Sub(fn)
+}
+
+func (l *lightNet) UnsubscribeSnapshotBlock(subId int) {
+	l.feedLock.Lock()
+	defer l.feedLock.Unlock()
+
+	l.sFeed.Unsub(subId)
+}
+
+func (l *lightNet) SubscribeAccountBlock(fn AccountblockCallback) (subId int) {
+	l.feedLock.Lock()
+	defer l.feedLock.Unlock()
+
+	return l.aFeed.Sub(fn)
+}
+
+func (l *lightNet) UnsubscribeAccountBlock(subId int) {
+	l.feedLock.Lock()
+	defer l.feedLock.Unlock()
+
+	l.aFeed.Unsub(subId)
+}
+
+// @section lightQueryHandler
+// light client has no ledger to serve, tell the peer missing
+type lightQueryHandler struct{}
+
+func (q *lightQueryHandler) ID() string {
+	return "light query handler"
+}
+
+func (q *lightQueryHandler) Cmds() []ViteCmd {
+	return []ViteCmd{GetSubLedgerCode, GetSnapshotBlocksCode, GetAccountBlocksCode, GetChunkCode, GetSnapshotHeadersCode, GetAccountStateCode, GetTrieNodesCode}
+}
+
+func (q *lightQueryHandler) Handle(msg *p2p.Msg, sender Peer) error {
+	return sender.Send(ExceptionCode, msg.Id, message.Missing)
+}
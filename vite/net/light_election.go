@@ -0,0 +1,389 @@
+package net
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vite/net/message"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_context"
+	"github.com/vitelabs/go-vite/vm_context/vmctxt_interface"
+)
+
+// the contracts whose storage elects the snapshot producers
+var lightElectionContracts = []types.Address{abi.AddressConsensusGroup, abi.AddressRegister, abi.AddressVote}
+
+// the loaded states kept, the states of the current round and the next are used only
+const lightElectionStates = 8
+
+var errLightStatePending = errors.New("the state electing the producers is not loaded yet")
+var errLightInvalidState = errors.New("invalid account state")
+
+// lightState is the storage of the contracts and the voters at a verified header,
+// the storage roots are proven against StateHash of the header and the tries are downloaded completely
+type lightState struct {
+	chain  Chain
+	header *ledger.SnapshotBlock
+	roots  map[types.Address]types.Hash // zero if the account has no state
+}
+
+func (s *lightState) storage(addr *types.Address) *trie.Trie {
+	root, ok := s.roots[*addr]
+	if !ok || root == types.ZERO_HASH {
+		return nil
+	}
+	return s.chain.GetStateTrie(&root)
+}
+
+// implementation abi.StorageDatabase, snapshotHash is ignored, the state is at the header
+func (s *lightState) GetStorageBySnapshotHash(addr *types.Address, key []byte, snapshotHash *types.Hash) []byte {
+	if t := s.storage(addr); t != nil {
+		return t.GetValue(key)
+	}
+	return nil
+}
+
+func (s *lightState) NewStorageIteratorBySnapshotHash(addr *types.Address, prefix []byte, snapshotHash *types.Hash) vmctxt_interface.StorageIterator {
+	if t := s.storage(addr); t != nil {
+		return vm_context.NewStorageIterator(t, prefix)
+	}
+	return nil
+}
+
+// lightElection takes the place of chain in the consensus of the light client. Snapshot blocks are the verified
+// headers, the consensus group, register and vote lists and the balances of voters are read from lightState.
+// Consensus is read with lightNet.rw held, states are loaded by the sync loop without it, see next and load.
+type lightElection struct {
+	chain   Chain
+	headers *headerChain
+	reader  consensus.Reader
+	fetch   *stateFetcher // requests proofs and trie nodes, set when lightNet starts
+
+	lock   sync.RWMutex
+	states map[types.Hash]*lightState
+
+	log log15.Logger
+}
+
+func newLightElection(chain Chain, headers *headerChain, genesis *ledger.SnapshotBlock) *lightElection {
+	e := &lightElection{
+		chain:   chain,
+		headers: headers,
+		states:  make(map[types.Hash]*lightState),
+		log:     log15.New("module", "net/lightElection"),
+	}
+	e.reader = consensus.NewConsensus(*genesis.Timestamp, e)
+	return e
+}
+
+func (e *lightElection) state(hash types.Hash) (*lightState, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if s, ok := e.states[hash]; ok {
+		return s, nil
+	}
+	return nil, errLightStatePending
+}
+
+func (e *lightElection) loaded(hash types.Hash) bool {
+	_, err := e.state(hash)
+	return err == nil
+}
+
+func (e *lightElection) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return e.headers.head()
+}
+
+func (e *lightElection) GetSnapshotBlockBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error) {
+	headers := e.headers.headers
+	i := sort.Search(len(headers), func(i int) bool {
+		return !headers[i].Timestamp.Before(*timestamp)
+	})
+	if i == 0 {
+		return nil, nil
+	}
+	return headers[i-1], nil
+}
+
+func (e *lightElection) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	return e.headers.getByHeight(height), nil
+}
+
+func (e *lightElection) GetSnapshotBlockByHash(hash *types.Hash) (*ledger.SnapshotBlock, error) {
+	return e.headers.byHash[*hash], nil
+}
+
+func (e *lightElection) GetContractGidByAccountBlock(block *ledger.AccountBlock) (*types.Gid, error) {
+	return nil, errors.New("light client doesn't verify account producers")
+}
+
+func (e *lightElection) GetConsensusGroupList(snapshotHash types.Hash) ([]*types.ConsensusGroupInfo, error) {
+	s, err := e.state(snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	return abi.GetActiveConsensusGroupList(s, nil), nil
+}
+
+func (e *lightElection) GetRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error) {
+	s, err := e.state(snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	return abi.GetCandidateList(s, gid, nil), nil
+}
+
+func (e *lightElection) GetVoteMap(snapshotHash types.Hash, gid types.Gid) ([]*types.VoteInfo, error) {
+	s, err := e.state(snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+	return abi.GetVoteList(s, gid, nil), nil
+}
+
+func (e *lightElection) GetBalanceList(snapshotHash types.Hash, tokenTypeId types.TokenTypeId, addressList []types.Address) (map[types.Address]*big.Int, error) {
+	s, err := e.state(snapshotHash)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceList := make(map[types.Address]*big.Int)
+	for _, addr := range addressList {
+		if _, ok := s.roots[addr]; !ok {
+			return nil, errors.Errorf("balance of %s is not loaded", addr)
+		}
+		balance := big.NewInt(0)
+		if value := s.GetStorageBySnapshotHash(&addr, vm_context.BalanceKey(&tokenTypeId), nil); value != nil {
+			balance.SetBytes(value)
+		}
+		balanceList[addr] = balance
+	}
+	return balanceList, nil
+}
+
+// voteTime return the time the state before which elects the producers at t, see teller.voteTime
+func (e *lightElection) voteTime(t time.Time) (time.Time, error) {
+	index, err := e.reader.VoteTimeToIndex(types.SNAPSHOT_GID, t)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if index < 2 {
+		index = 2
+	}
+	sTime, _, err := e.reader.VoteIndexToTime(types.SNAPSHOT_GID, index-1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return *sTime, nil
+}
+
+// ready return errLightStatePending if the state electing the producers at t is not loaded, lightNet.rw must be held
+func (e *lightElection) ready(t time.Time) error {
+	voteTime, err := e.voteTime(t)
+	if err != nil {
+		return errLightStatePending
+	}
+	header, _ := e.GetSnapshotBlockBeforeTime(&voteTime)
+	if header == nil || !e.loaded(header.Hash) {
+		return errLightStatePending
+	}
+	return nil
+}
+
+// next return the header whose state is needed to verify the headers of the current round and the next,
+// or the vote time if the headers before it are not fetched yet, nil and zero time if all are loaded.
+// lightNet.rw must be held.
+func (e *lightElection) next() (*ledger.SnapshotBlock, time.Time, error) {
+	head := e.headers.head()
+
+	// the consensus group of the snapshot producers is read at the head the first time
+	index, err := e.reader.VoteTimeToIndex(types.SNAPSHOT_GID, *head.Timestamp)
+	if err != nil {
+		if !e.loaded(head.Hash) {
+			return head, time.Time{}, nil
+		}
+		return nil, time.Time{}, err
+	}
+
+	for i := index; i <= index+1; i++ {
+		sTime, _, err := e.reader.VoteIndexToTime(types.SNAPSHOT_GID, i)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		voteTime, err := e.voteTime(*sTime)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		header, _ := e.GetSnapshotBlockBeforeTime(&voteTime)
+		if header == nil {
+			return nil, voteTime, nil
+		}
+		if !e.loaded(header.Hash) {
+			return header, time.Time{}, nil
+		}
+	}
+
+	return nil, time.Time{}, nil
+}
+
+// load prove the storage roots of the contracts and the voters at header, and download the storage tries
+func (e *lightElection) load(header *ledger.SnapshotBlock) error {
+	s := &lightState{
+		chain:  e.chain,
+		header: header,
+		roots:  make(map[types.Address]types.Hash),
+	}
+
+	if err := e.loadStorage(s, lightElectionContracts); err != nil {
+		return err
+	}
+
+	var voters []types.Address
+	for _, vote := range abi.GetVoteList(s, types.SNAPSHOT_GID, nil) {
+		if _, ok := s.roots[vote.VoterAddr]; !ok {
+			voters = append(voters, vote.VoterAddr)
+		}
+	}
+
+	if err := e.loadStorage(s, voters); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.states[header.Hash] = s
+	if len(e.states) > lightElectionStates {
+		var oldest *lightState
+		for _, st := range e.states {
+			if st != s && (oldest == nil || st.header.Height < oldest.header.Height) {
+				oldest = st
+			}
+		}
+		delete(e.states, oldest.header.Hash)
+	}
+
+	e.log.Info(fmt.Sprintf("load election state at %s/%d, %d voters", header.Hash, header.Height, len(voters)))
+	return nil
+}
+
+// loadStorage prove the storage roots of addrs at the header of s, requests are spread over peers,
+// then download the storage tries not in the database
+func (e *lightElection) loadStorage(s *lightState, addrs []types.Address) error {
+	header := s.header
+
+	stalled := 0
+	for len(addrs) > 0 {
+		peers := e.fetch.peers.Pick(header.Height)
+		if len(peers) == 0 {
+			return errFetchPeers
+		}
+
+		tasks := make(map[*peer][]types.Address, len(peers))
+		for i, addr := range addrs {
+			p := peers[i%len(peers)]
+			tasks[p] = append(tasks[p], addr)
+		}
+
+		var lock sync.Mutex
+		var failed []types.Address
+		var wg sync.WaitGroup
+
+		proven := len(s.roots)
+
+		for p, addrs := range tasks {
+			wg.Add(1)
+			go func(p *peer, addrs []types.Address) {
+				defer wg.Done()
+
+				for i, addr := range addrs {
+					root, err := e.prove(p, header, addr)
+					lock.Lock()
+					if err == nil {
+						s.roots[addr] = root
+					} else {
+						// the rest is left to other peers
+						failed = append(failed, addrs[i:]...)
+					}
+					lock.Unlock()
+
+					if err != nil {
+						e.log.Warn(fmt.Sprintf("prove state of %s at %s from %s error: %v", addr, header.Hash, p.RemoteAddr(), err))
+						return
+					}
+				}
+			}(p, addrs)
+		}
+		wg.Wait()
+
+		if e.fetch.canceled() {
+			return errFetchCanceled
+		}
+
+		if len(s.roots) > proven {
+			stalled = 0
+		} else if stalled++; stalled >= fetchMaxRetry {
+			return errFetchStalled
+		}
+
+		addrs = failed
+	}
+
+	for _, root := range s.roots {
+		if root == types.ZERO_HASH {
+			continue
+		}
+		if err := e.fetch.syncTrie(e.chain.NewTrieSync(root, nil), header.Height); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prove return the storage root of addr proven against StateHash of header, zero if the account has no state
+func (e *lightElection) prove(p *peer, header *ledger.SnapshotBlock, addr types.Address) (types.Hash, error) {
+	msg, err := e.fetch.request(p, GetAccountStateCode, &message.GetAccountState{
+		Address: addr,
+		Snapshot: ledger.HashHeight{
+			Hash:   header.Hash,
+			Height: header.Height,
+		},
+	}, AccountStateCode)
+	if err != nil {
+		return types.ZERO_HASH, err
+	}
+
+	state := new(message.AccountState)
+	if err = state.Deserialize(msg.Payload); err != nil {
+		p.Report(OffenceMalformed)
+		return types.ZERO_HASH, err
+	}
+
+	if state.Address != addr || state.Snapshot.Hash != header.Hash {
+		p.Report(OffenceMalformed)
+		return types.ZERO_HASH, errLightInvalidState
+	}
+
+	value, err := trie.VerifyProof(&header.StateHash, addr.Bytes(), state.Proof)
+	if err != nil {
+		p.Report(OffenceInvalidBlock)
+		return types.ZERO_HASH, err
+	}
+
+	if len(value) == 0 {
+		return types.ZERO_HASH, nil
+	}
+	return types.BytesToHash(value)
+}
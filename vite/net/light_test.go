@@ -0,0 +1,489 @@
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/p2p"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vite/net/message"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+type mockScheduleReader struct {
+	consensus.Reader
+	producer types.Address
+}
+
+// every second is planned for producer
+func (r *mockScheduleReader) ReadByTime(gid types.Gid, t time.Time) ([]*consensus.Event, uint64, error) {
+	return []*consensus.Event{{
+		Gid:     gid,
+		Address: r.producer,
+		Stime:   t,
+		Etime:   t.Add(time.Second),
+	}}, uint64(t.Unix()), nil
+}
+
+func mockHeader(prev *ledger.SnapshotBlock, priv ed25519.PrivateKey, pub ed25519.PublicKey) *ledger.SnapshotBlock {
+	timestamp := prev.Timestamp.Add(time.Second)
+	block := &ledger.SnapshotBlock{
+		PrevHash:  prev.Hash,
+		Height:    prev.Height + 1,
+		Timestamp: &timestamp,
+		PublicKey: pub,
+	}
+	block.StateHash[0] = byte(block.Height)
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(priv, block.Hash.Bytes())
+	return block
+}
+
+func TestLightNet_verifyHeader(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, otherPriv, _ := ed25519.GenerateKey(nil)
+
+	timestamp := time.Unix(1540000000, 0)
+	genesis := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &timestamp,
+	}
+	genesis.Hash = genesis.ComputeHash()
+
+	l := NewLight(&LightConfig{
+		Genesis: genesis,
+		Reader:  &mockScheduleReader{producer: types.PubkeyToAddress(pub)},
+	}).(*lightNet)
+
+	block := mockHeader(genesis, priv, pub)
+	if err := l.verifyHeader(genesis, block); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.verifyHeader(block, mockHeader(genesis, priv, pub)); err != errLightNotLinked {
+		t.Fatalf("should not be linked: %v", err)
+	}
+
+	if err := l.verifyHeader(genesis, mockHeader(genesis, otherPriv, otherPub)); err != errInvalidProducer {
+		t.Fatalf("producer should be invalid: %v", err)
+	}
+
+	forged := mockHeader(genesis, priv, pub)
+	forged.Signature = ed25519.Sign(otherPriv, forged.Hash.Bytes())
+	if err := l.verifyHeader(genesis, forged); err != errLightInvalidSignature {
+		t.Fatalf("signature should be invalid: %v", err)
+	}
+
+	tampered := mockHeader(genesis, priv, pub)
+	tampered.StateHash[1] = 1
+	if err := l.verifyHeader(genesis, tampered); err != errLightInvalidHash {
+		t.Fatalf("hash should be invalid: %v", err)
+	}
+
+	// headers are appended one by one until an invalid one
+	next := mockHeader(block, priv, pub)
+	bad := mockHeader(next, otherPriv, otherPub)
+	if err := l.receiveHeaders([]*ledger.SnapshotBlock{block, next, bad}, types.RemoteSync); err != errInvalidProducer {
+		t.Fatalf("should stop at the invalid header: %v", err)
+	}
+	if l.Head().Hash != next.Hash {
+		t.Fatalf("head should be %d, got %d", next.Height, l.Head().Height)
+	}
+	if l.GetSnapshotHeaderByHeight(block.Height) != block || l.GetSnapshotHeaderByHash(next.Hash) != next {
+		t.Fatal("headers should be saved")
+	}
+}
+
+func mockAccountChain(prev *ledger.AccountBlock, count int, priv ed25519.PrivateKey, pub ed25519.PublicKey) (blocks []*ledger.AccountBlock) {
+	addr := types.PubkeyToAddress(pub)
+	timestamp := time.Unix(1540000000, 0)
+
+	for i := 0; i < count; i++ {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeReceive,
+			AccountAddress: addr,
+			Height:         1,
+			Timestamp:      &timestamp,
+			PublicKey:      pub,
+		}
+		if prev != nil {
+			block.PrevHash = prev.Hash
+			block.Height = prev.Height + 1
+		}
+		block.FromBlockHash[0] = byte(block.Height)
+		block.StateHash[0] = byte(block.Height)
+		block.Hash = block.ComputeHash()
+		block.Signature = ed25519.Sign(priv, block.Hash.Bytes())
+
+		blocks = append(blocks, block)
+		prev = block
+	}
+
+	return
+}
+
+func TestLightAccount_link(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	a := &lightAccount{addr: types.PubkeyToAddress(pub)}
+
+	chain := mockAccountChain(nil, 5, priv, pub)
+	head := chain[len(chain)-1]
+	target := a.setState(ledger.HashHeight{Hash: head.Hash, Height: head.Height}, head.StateHash, nil)
+	if target == nil || target.count != 5 {
+		t.Fatalf("should fetch 5 blocks: %v", target)
+	}
+
+	// not enough
+	for _, block := range chain[1:] {
+		a.pending[block.Hash] = block
+	}
+	if blocks, err := a.link(); err != nil || blocks != nil {
+		t.Fatalf("should wait for more blocks: %v", err)
+	}
+
+	a.pending[chain[0].Hash] = chain[0]
+	if blocks, err := a.link(); err != nil || len(blocks) != 5 {
+		t.Fatalf("should link 5 blocks: %v", err)
+	}
+	if a.head() != head {
+		t.Fatal("head should be the proven one")
+	}
+
+	// the same head, nothing to fetch
+	if a.setState(ledger.HashHeight{Hash: head.Hash, Height: head.Height}, head.StateHash, nil) != nil {
+		t.Fatal("nothing to fetch")
+	}
+
+	// proven state doesn't match
+	more := mockAccountChain(head, 3, priv, pub)
+	head = more[len(more)-1]
+	var wrong types.Hash
+	a.setState(ledger.HashHeight{Hash: head.Hash, Height: head.Height}, wrong, nil)
+	for _, block := range more {
+		a.pending[block.Hash] = block
+	}
+	if _, err := a.link(); err == nil {
+		t.Fatal("state should not match")
+	}
+
+	// blocks are not signed by the account
+	otherPub, otherPriv, _ := ed25519.GenerateKey(nil)
+	forged := mockAccountChain(chain[4], 3, otherPriv, otherPub)
+	for i, block := range forged {
+		if i > 0 {
+			block.PrevHash = forged[i-1].Hash
+		}
+		block.AccountAddress = a.addr
+		block.Hash = block.ComputeHash()
+		block.Signature = ed25519.Sign(otherPriv, block.Hash.Bytes())
+	}
+	a.setState(ledger.HashHeight{Hash: forged[2].Hash, Height: forged[2].Height}, forged[2].StateHash, nil)
+	for _, block := range forged {
+		a.pending[block.Hash] = block
+	}
+	if _, err := a.link(); err == nil {
+		t.Fatal("signer should be invalid")
+	}
+
+	// extend
+	a.setState(ledger.HashHeight{Hash: head.Hash, Height: head.Height}, head.StateHash, nil)
+	for _, block := range more {
+		a.pending[block.Hash] = block
+	}
+	if blocks, err := a.link(); err != nil || len(blocks) != 3 {
+		t.Fatalf("should link 3 blocks: %v", err)
+	}
+	if len(a.blocks) != 8 || a.head() != head {
+		t.Fatalf("should have 8 blocks, got %d", len(a.blocks))
+	}
+}
+
+type capturePeer struct {
+	mock_Peer
+	code    ViteCmd
+	payload p2p.Serializable
+}
+
+func (p *capturePeer) Send(code ViteCmd, msgId uint64, payload p2p.Serializable) (err error) {
+	p.code = code
+	p.payload = payload
+	return nil
+}
+
+func queryAccountState(t *testing.T, addr types.Address) {
+	chn := getChain()
+	snapshotBlock := chn.GetLatestSnapshotBlock()
+
+	req := &message.GetAccountState{
+		Address:  addr,
+		Snapshot: ledger.HashHeight{Hash: snapshotBlock.Hash, Height: snapshotBlock.Height},
+	}
+	payload, _ := req.Serialize()
+
+	p := new(capturePeer)
+	handler := &getAccountStateHandler{chn}
+	if err := handler.Handle(&p2p.Msg{
+		CmdSet:  CmdSet,
+		Cmd:     p2p.Cmd(GetAccountStateCode),
+		Payload: payload,
+	}, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.code != AccountStateCode {
+		t.Fatalf("should response AccountState, got %s", p.code)
+	}
+
+	buf, _ := p.payload.Serialize()
+	state := new(message.AccountState)
+	if err := state.Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := trie.VerifyProof(&snapshotBlock.StateHash, addr.Bytes(), state.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	head, _ := chn.GetConfirmAccountBlock(snapshotBlock.Height, &addr)
+	if head == nil {
+		if len(value) != 0 || state.Head.Height != 0 {
+			t.Fatalf("account %s should have no state", addr)
+		}
+		return
+	}
+
+	if state.Head.Hash != head.Hash || state.Head.Height != head.Height {
+		t.Fatalf("head should be %s/%d, got %s/%d", head.Hash, head.Height, state.Head.Hash, state.Head.Height)
+	}
+	if stateHash, _ := types.BytesToHash(value); stateHash != head.StateHash {
+		t.Fatalf("proven state should be %s, got %s", head.StateHash, stateHash)
+	}
+}
+
+func TestGetAccountStateHandler_Handle(t *testing.T) {
+	// confirmed in genesis
+	queryAccountState(t, abi.AddressMintage)
+
+	// not exist
+	addr, _, _ := types.CreateAddress()
+	queryAccountState(t, addr)
+}
+
+func TestLightAccount_checkContent(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	a := &lightAccount{addr: types.PubkeyToAddress(pub)}
+
+	chain := mockAccountChain(nil, 5, priv, pub)
+	head := chain[len(chain)-1]
+
+	// the fetched blocks don't match SnapshotContent
+	otherPub, otherPriv, _ := ed25519.GenerateKey(nil)
+	forked := mockAccountChain(nil, 3, otherPriv, otherPub)
+	contents := []ledger.HashHeight{{Hash: head.Hash, Height: head.Height}, {Hash: forked[2].Hash, Height: forked[2].Height}}
+	a.setState(contents[0], head.StateHash, contents)
+	for _, block := range chain {
+		a.pending[block.Hash] = block
+	}
+	if _, err := a.link(); err == nil {
+		t.Fatal("blocks should not match SnapshotContent")
+	}
+
+	contents[1] = ledger.HashHeight{Hash: chain[2].Hash, Height: chain[2].Height}
+	a.setState(contents[0], head.StateHash, contents)
+	for _, block := range chain {
+		a.pending[block.Hash] = block
+	}
+	if blocks, err := a.link(); err != nil || len(blocks) != 5 {
+		t.Fatalf("should link 5 blocks: %v", err)
+	}
+
+	// out of the verified blocks, or the same
+	if !a.checkContent(&ledger.HashHeight{Hash: forked[0].Hash, Height: 6}) || !a.checkContent(&contents[1]) {
+		t.Fatal("content should be accepted")
+	}
+
+	// a new header forks the verified blocks
+	if a.checkContent(&ledger.HashHeight{Hash: forked[2].Hash, Height: forked[2].Height}) {
+		t.Fatal("content should fork the blocks")
+	}
+	if len(a.blocks) != 0 || a.queried != types.ZERO_HASH {
+		t.Fatal("forked blocks should be dropped")
+	}
+}
+
+func TestLightNet_verifyAccountState(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	accountPub, accountPriv, _ := ed25519.GenerateKey(nil)
+	addr := types.PubkeyToAddress(accountPub)
+	chain := mockAccountChain(nil, 3, accountPriv, accountPub)
+	head := chain[2]
+
+	timestamp := time.Unix(1540000000, 0)
+	genesis := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &timestamp,
+	}
+	genesis.Hash = genesis.ComputeHash()
+
+	l := NewLight(&LightConfig{
+		Genesis:   genesis,
+		Reader:    &mockScheduleReader{producer: types.PubkeyToAddress(pub)},
+		Addresses: []types.Address{addr},
+	}).(*lightNet)
+
+	// the state trie proves head, so the header content must be head too
+	tr := trie.NewTrie(nil, nil, nil)
+	tr.SetValue(addr.Bytes(), head.StateHash.Bytes())
+	header := mockHeader(genesis, priv, pub)
+	header.StateHash = *tr.Hash()
+	header.SnapshotContent = ledger.SnapshotContent{
+		addr: {Hash: chain[1].Hash, Height: chain[1].Height},
+	}
+	header.Hash = header.ComputeHash()
+	header.Signature = ed25519.Sign(priv, header.Hash.Bytes())
+	if err := l.receiveHeaders([]*ledger.SnapshotBlock{header}, types.RemoteSync); err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := tr.Prove(addr.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &message.AccountState{
+		Address:  addr,
+		Snapshot: ledger.HashHeight{Hash: header.Hash, Height: header.Height},
+		Head:     ledger.HashHeight{Hash: head.Hash, Height: head.Height},
+		Proof:    proof,
+	}
+	if _, err := l.verifyAccountState(state); err == nil {
+		t.Fatal("head should not match SnapshotContent")
+	}
+
+	header.SnapshotContent[addr] = &ledger.HashHeight{Hash: head.Hash, Height: head.Height}
+	target, err := l.verifyAccountState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target == nil || target.count != 3 || len(target.contents) != 1 {
+		t.Fatalf("should fetch 3 blocks checked against the content: %v", target)
+	}
+}
+
+// loadLocalState read the storage roots of the election at header from the local state trie, as load does from peers
+func loadLocalState(t *testing.T, e *lightElection, header *ledger.SnapshotBlock, stateHash types.Hash) {
+	stateTrie := e.chain.GetStateTrie(&stateHash)
+	s := &lightState{
+		chain:  e.chain,
+		header: header,
+		roots:  make(map[types.Address]types.Hash),
+	}
+
+	prove := func(addr types.Address) {
+		value := stateTrie.GetValue(addr.Bytes())
+		if len(value) == 0 {
+			s.roots[addr] = types.ZERO_HASH
+			return
+		}
+		root, err := types.BytesToHash(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.roots[addr] = root
+	}
+
+	for _, addr := range lightElectionContracts {
+		prove(addr)
+	}
+	for _, vote := range abi.GetVoteList(s, types.SNAPSHOT_GID, nil) {
+		prove(vote.VoterAddr)
+	}
+
+	e.states[header.Hash] = s
+}
+
+func TestLightElection(t *testing.T) {
+	chn := getChain()
+	genesis := chn.GetGenesisSnapshotBlock()
+
+	// the contracts are created in the second snapshot block
+	second, _ := chn.GetSnapshotBlockByHeight(genesis.Height + 1)
+
+	e := newLightElection(chn, newHeaderChain(second), genesis)
+	if err := e.ready(*second.Timestamp); err != errLightStatePending {
+		t.Fatalf("state should be pending: %v", err)
+	}
+	if header, _, err := e.next(); err != nil || header != second {
+		t.Fatalf("state at the checkpoint should be loaded first: %v", err)
+	}
+
+	loadLocalState(t, e, second, second.StateHash)
+	if err := e.ready(*second.Timestamp); err != nil {
+		t.Fatal(err)
+	}
+	if header, voteTime, err := e.next(); err != nil || header != nil || !voteTime.IsZero() {
+		t.Fatalf("all states should be loaded: %v", err)
+	}
+
+	// the same as read from the full state
+	registers, _ := e.GetRegisterList(second.Hash, types.SNAPSHOT_GID)
+	expected, _ := chn.GetRegisterList(second.Hash, types.SNAPSHOT_GID)
+	if len(registers) == 0 || len(registers) != len(expected) {
+		t.Fatalf("should read %d registers, got %d", len(expected), len(registers))
+	}
+	votes, _ := e.GetVoteMap(second.Hash, types.SNAPSHOT_GID)
+	expectedVotes, _ := chn.GetVoteMap(second.Hash, types.SNAPSHOT_GID)
+	if len(votes) != len(expectedVotes) {
+		t.Fatalf("should read %d votes, got %d", len(expectedVotes), len(votes))
+	}
+
+	producers := make(map[types.Address]bool)
+	for _, r := range registers {
+		producers[r.NodeAddr] = true
+	}
+	events, _, err := e.reader.ReadByTime(types.SNAPSHOT_GID, *second.Timestamp)
+	if err != nil || len(events) == 0 {
+		t.Fatalf("should read the schedule: %v", err)
+	}
+	for _, event := range events {
+		if !producers[event.Address] {
+			t.Fatalf("producer %s is not registered", event.Address)
+		}
+	}
+
+	// headers before the checkpoint are needed for the election after it
+	var headers []*ledger.SnapshotBlock
+	prev := second
+	for i := 0; i < 100; i++ {
+		timestamp := prev.Timestamp.Add(10 * time.Second)
+		block := &ledger.SnapshotBlock{
+			PrevHash:  prev.Hash,
+			Height:    prev.Height + 1,
+			Timestamp: &timestamp,
+		}
+		block.Hash = block.ComputeHash()
+		headers = append(headers, block)
+		prev = block
+	}
+	checkpoint := headers[len(headers)-1]
+
+	e = newLightElection(chn, newHeaderChain(checkpoint), genesis)
+	loadLocalState(t, e, checkpoint, second.StateHash)
+	header, voteTime, err := e.next()
+	if err != nil || header != nil || voteTime.IsZero() {
+		t.Fatalf("headers before the checkpoint should be fetched: %v", err)
+	}
+
+	e.headers.prepend(headers[:len(headers)-1])
+	header, _, err = e.next()
+	if err != nil || header == nil || !header.Timestamp.Before(voteTime) {
+		t.Fatalf("state before %s should be loaded: %v", voteTime, err)
+	}
+	if err = e.ready(*checkpoint.Timestamp); err != errLightStatePending {
+		t.Fatalf("state should be pending: %v", err)
+	}
+}
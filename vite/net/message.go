@@ -24,8 +24,8 @@ type ViteCmd p2p.Cmd
 const (
 	HandshakeCode ViteCmd = iota
 	StatusCode
-	ForkCode // tell peer it has forked, use for respond GetSnapshotBlocksCode
-	GetSubLedgerCode
+	ForkCode              // tell peer it has forked, use for respond GetSnapshotBlocksCode
+	GetSubLedgerCode      // deprecated, respond missing
 	GetSnapshotBlocksCode // get snapshotblocks without content
 	GetSnapshotBlocksContentCode
 	GetFullSnapshotBlocksCode   // get snapshotblocks with content
@@ -35,16 +35,21 @@ const (
 	GetAccountBlocksCode       // query single AccountChain
 	GetMultiAccountBlocksCode  // query multi AccountChain
 	GetAccountBlocksByHashCode // query accountBlocks by hashList
-	GetFilesCode
+	GetFilesCode               // deprecated, files are not served
 	GetChunkCode
 	SubLedgerCode
-	FileListCode
+	FileListCode // deprecated
 	SnapshotBlocksCode
 	SnapshotBlocksContentCode
 	FullSnapshotBlocksCode
 	AccountBlocksCode
 	NewSnapshotBlockCode
 	NewAccountBlockCode
+	GetSnapshotHeadersCode // get snapshotblocks with content but no account blocks, for light client
+	GetAccountStateCode    // get confirmed head and state proof of an account, for light client
+	AccountStateCode
+	GetTrieNodesCode // get trie nodes and ref values by hash, for light client
+	TrieNodesCode
 
 	ExceptionCode = 127
 )
@@ -73,6 +78,11 @@ var msgNames = [...]string{
 	AccountBlocksCode:                  "AccountBlocksMsg",
 	NewSnapshotBlockCode:               "NewSnapshotBlockMsg",
 	NewAccountBlockCode:                "NewAccountBlockMsg",
+	GetSnapshotHeadersCode:             "GetSnapshotHeadersMsg",
+	GetAccountStateCode:                "GetAccountStateMsg",
+	AccountStateCode:                   "AccountStateMsg",
+	GetTrieNodesCode:                   "GetTrieNodesMsg",
+	TrieNodesCode:                      "TrieNodesMsg",
 }
 
 func (t ViteCmd) String() string {
@@ -80,7 +90,7 @@ func (t ViteCmd) String() string {
 		return "ExceptionMsg"
 	}
 
-	if t > NewAccountBlockCode {
+	if t > TrieNodesCode {
 		return "UnkownMsg"
 	}
 
@@ -136,10 +146,14 @@ func newQueryHandler(chain Chain) *queryHandler {
 		queue:    list.New(),
 	}
 
-	q.addHandler(&getSubLedgerHandler{chain})
+	// files are not served, chunks are downloaded by GetChunk instead
+	q.addHandler(&missingHandler{[]ViteCmd{GetSubLedgerCode}})
 	q.addHandler(&getSnapshotBlocksHandler{chain})
 	q.addHandler(&getAccountBlocksHandler{chain})
 	q.addHandler(&getChunkHandler{chain})
+	q.addHandler(&getSnapshotHeadersHandler{chain})
+	q.addHandler(&getAccountStateHandler{chain})
+	q.addHandler(&getTrieNodesHandler{chain})
 
 	return q
 }
@@ -175,7 +189,7 @@ func (q *queryHandler) ID() string {
 }
 
 func (q *queryHandler) Cmds() []ViteCmd {
-	return []ViteCmd{GetSubLedgerCode, GetSnapshotBlocksCode, GetAccountBlocksCode, GetChunkCode}
+	return []ViteCmd{GetSubLedgerCode, GetSnapshotBlocksCode, GetAccountBlocksCode, GetChunkCode, GetSnapshotHeadersCode, GetAccountStateCode, GetTrieNodesCode}
 }
 
 type queryTask struct {
@@ -258,58 +272,22 @@ func (q *queryHandler) loop() {
 	}
 }
 
-// @section getSubLedgerHandler
-type getSubLedgerHandler struct {
-	chain Chain
+// @section missingHandler
+// missingHandler tell the peer missing, the requests are not served any more
+type missingHandler struct {
+	cmds []ViteCmd
 }
 
-func (s *getSubLedgerHandler) ID() string {
-	return "GetSubLedger Handler"
+func (s *missingHandler) ID() string {
+	return "Missing Handler"
 }
 
-func (s *getSubLedgerHandler) Cmds() []ViteCmd {
-	return []ViteCmd{GetSubLedgerCode}
+func (s *missingHandler) Cmds() []ViteCmd {
+	return s.cmds
 }
 
-func (s *getSubLedgerHandler) Handle(msg *p2p.Msg, sender Peer) (err error) {
-	defer monitor.LogTime("net", "handle_GetSubledgerMsg", time.Now())
-
-	req := new(message.GetSnapshotBlocks)
-
-	if err = req.Deserialize(msg.Payload); err != nil {
-		return
-	}
-
-	netLog.Info(fmt.Sprintf("receive %s from %s", req, sender.RemoteAddr()))
-
-	var files []*ledger.CompressedFileMeta
-	var chunks [][2]uint64
-	if req.From.Hash != types.ZERO_HASH {
-		files, chunks, err = s.chain.GetSubLedgerByHash(&req.From.Hash, req.Count, req.Forward)
-	} else {
-		files, chunks = s.chain.GetSubLedgerByHeight(req.From.Height, req.Count, req.Forward)
-	}
-
-	if err != nil || (len(files) == 0 && len(chunks) == 0) {
-		netLog.Warn(fmt.Sprintf("handle %s from %s error: %v", req, sender.RemoteAddr(), err))
-		return sender.Send(ExceptionCode, msg.Id, message.Missing)
-	}
-
-	fileList := &message.FileList{
-		Files:  files,
-		Chunks: chunks,
-		Nonce:  0,
-	}
-
-	err = sender.Send(FileListCode, msg.Id, fileList)
-
-	if err != nil {
-		netLog.Error(fmt.Sprintf("send %s to %s error: %v", fileList, sender.RemoteAddr(), err))
-	} else {
-		netLog.Info(fmt.Sprintf("send %s to %s done", fileList, sender.RemoteAddr()))
-	}
-
-	return
+func (s *missingHandler) Handle(msg *p2p.Msg, sender Peer) error {
+	return sender.Send(ExceptionCode, msg.Id, message.Missing)
 }
 
 type getSnapshotBlocksHandler struct {
@@ -538,6 +516,231 @@ func (c *getChunkHandler) Handle(msg *p2p.Msg, sender Peer) (err error) {
 	return
 }
 
+// @section getSnapshotHeadersHandler
+type getSnapshotHeadersHandler struct {
+	chain Chain
+}
+
+func (s *getSnapshotHeadersHandler) ID() string {
+	return "GetSnapshotHeaders Handler"
+}
+
+func (s *getSnapshotHeadersHandler) Cmds() []ViteCmd {
+	return []ViteCmd{GetSnapshotHeadersCode}
+}
+
+func (s *getSnapshotHeadersHandler) Handle(msg *p2p.Msg, sender Peer) (err error) {
+	defer monitor.LogTime("net", "handle_GetSnapshotHeadersMsg", time.Now())
+
+	req := new(message.GetSnapshotBlocks)
+
+	if err = req.Deserialize(msg.Payload); err != nil {
+		return
+	}
+
+	netLog.Info(fmt.Sprintf("receive GetSnapshotHeaders %s from %s", req, sender.RemoteAddr()))
+
+	var block *ledger.SnapshotBlock
+	if req.From.Hash != types.ZERO_HASH {
+		block, err = s.chain.GetSnapshotBlockByHash(&req.From.Hash)
+	} else {
+		block, err = s.chain.GetSnapshotBlockByHeight(req.From.Height)
+	}
+
+	if err != nil || block == nil || req.Count == 0 {
+		netLog.Warn(fmt.Sprintf("handle GetSnapshotHeaders %s from %s error: %v", req, sender.RemoteAddr(), err))
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+
+	// use for split
+	var from, to uint64
+	if req.Forward {
+		from = block.Height
+		to = from + req.Count - 1
+	} else {
+		to = block.Height
+		if to >= req.Count {
+			from = to - req.Count + 1
+		} else {
+			from = 0
+		}
+	}
+	chunks := splitChunk(from, to)
+
+	var blocks []*ledger.SnapshotBlock
+	for _, chunk := range chunks {
+		// light client checks the account blocks it fetches against the content
+		blocks, err = s.chain.GetSnapshotBlocksByHeight(chunk[0], chunk[1]-chunk[0]+1, true, true)
+		if err != nil || len(blocks) == 0 {
+			netLog.Warn(fmt.Sprintf("handle GetSnapshotHeaders %s from %s error: %v", req, sender.RemoteAddr(), err))
+			monitor.LogEvent("net/handle", "GetSnapshotHeaders_Fail")
+			return sender.Send(ExceptionCode, msg.Id, message.Missing)
+		}
+		monitor.LogEvent("net/handle", "GetSnapshotHeaders_Success")
+
+		if err = sender.SendSnapshotBlocks(blocks, msg.Id); err != nil {
+			netLog.Error(fmt.Sprintf("send %d SnapshotHeaders to %s error: %v", len(blocks), sender.RemoteAddr(), err))
+			return
+		} else {
+			netLog.Info(fmt.Sprintf("send %d SnapshotHeaders to %s done", len(blocks), sender.RemoteAddr()))
+		}
+
+		// the chain is shorter than requested
+		if blocks[len(blocks)-1].Height < chunk[1] {
+			break
+		}
+	}
+
+	return
+}
+
+// @section getAccountStateHandler
+type getAccountStateHandler struct {
+	chain Chain
+}
+
+func (a *getAccountStateHandler) ID() string {
+	return "GetAccountState Handler"
+}
+
+func (a *getAccountStateHandler) Cmds() []ViteCmd {
+	return []ViteCmd{GetAccountStateCode}
+}
+
+func (a *getAccountStateHandler) Handle(msg *p2p.Msg, sender Peer) (err error) {
+	defer monitor.LogTime("net", "handle_GetAccountStateMsg", time.Now())
+
+	req := new(message.GetAccountState)
+
+	if err = req.Deserialize(msg.Payload); err != nil {
+		return
+	}
+
+	netLog.Info(fmt.Sprintf("receive %s from %s", req, sender.RemoteAddr()))
+
+	var snapshotBlock *ledger.SnapshotBlock
+	if req.Snapshot.Hash != types.ZERO_HASH {
+		snapshotBlock, err = a.chain.GetSnapshotBlockByHash(&req.Snapshot.Hash)
+	} else {
+		snapshotBlock, err = a.chain.GetSnapshotBlockByHeight(req.Snapshot.Height)
+	}
+
+	if err != nil || snapshotBlock == nil {
+		netLog.Warn(fmt.Sprintf("handle %s from %s error: %v", req, sender.RemoteAddr(), err))
+		monitor.LogEvent("net/handle", "GetAccountState_Fail")
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+
+	stateTrie := a.chain.GetStateTrie(&snapshotBlock.StateHash)
+	if stateTrie == nil {
+		netLog.Warn(fmt.Sprintf("handle %s from %s error: missing state trie %s", req, sender.RemoteAddr(), snapshotBlock.StateHash))
+		monitor.LogEvent("net/handle", "GetAccountState_Fail")
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+
+	state := &message.AccountState{
+		Address: req.Address,
+		Snapshot: ledger.HashHeight{
+			Hash:   snapshotBlock.Hash,
+			Height: snapshotBlock.Height,
+		},
+	}
+
+	if state.Proof, err = stateTrie.Prove(req.Address.Bytes()); err != nil {
+		netLog.Error(fmt.Sprintf("prove %s error: %v", req, err))
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+
+	head, err := a.chain.GetConfirmAccountBlock(snapshotBlock.Height, &req.Address)
+	if err != nil {
+		netLog.Error(fmt.Sprintf("query confirmed head of %s error: %v", req, err))
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+	if head != nil {
+		state.Head = ledger.HashHeight{
+			Hash:   head.Hash,
+			Height: head.Height,
+		}
+	}
+
+	monitor.LogEvent("net/handle", "GetAccountState_Success")
+
+	if err = sender.Send(AccountStateCode, msg.Id, state); err != nil {
+		netLog.Error(fmt.Sprintf("send %s to %s error: %v", state, sender.RemoteAddr(), err))
+	} else {
+		netLog.Info(fmt.Sprintf("send %s to %s done", state, sender.RemoteAddr()))
+	}
+
+	return
+}
+
+// @section getTrieNodesHandler
+
+// the max amount of trie nodes and ref values responded by one TrieNodes message
+const maxTrieNodes = 1000
+
+type getTrieNodesHandler struct {
+	chain Chain
+}
+
+func (t *getTrieNodesHandler) ID() string {
+	return "GetTrieNodes Handler"
+}
+
+func (t *getTrieNodesHandler) Cmds() []ViteCmd {
+	return []ViteCmd{GetTrieNodesCode}
+}
+
+func (t *getTrieNodesHandler) Handle(msg *p2p.Msg, sender Peer) (err error) {
+	defer monitor.LogTime("net", "handle_GetTrieNodesMsg", time.Now())
+
+	req := new(message.GetTrieNodes)
+
+	if err = req.Deserialize(msg.Payload); err != nil {
+		return
+	}
+
+	netLog.Info(fmt.Sprintf("receive %s from %s", req, sender.RemoteAddr()))
+
+	if len(req.Nodes)+len(req.Values) > maxTrieNodes {
+		return fmt.Errorf("too many trie nodes requested: %d", len(req.Nodes)+len(req.Values))
+	}
+
+	res := new(message.TrieNodes)
+
+	// missing nodes are omitted
+	var data []byte
+	for i := range req.Nodes {
+		if data, err = t.chain.GetTrieNodeData(&req.Nodes[i]); err != nil {
+			netLog.Error(fmt.Sprintf("query trie node %s error: %v", req.Nodes[i], err))
+		} else if data != nil {
+			res.Nodes = append(res.Nodes, data)
+		}
+	}
+	for i := range req.Values {
+		if data, err = t.chain.GetTrieRefValue(&req.Values[i]); err != nil {
+			netLog.Error(fmt.Sprintf("query trie ref value %s error: %v", req.Values[i], err))
+		} else if data != nil {
+			res.Values = append(res.Values, data)
+		}
+	}
+
+	if len(res.Nodes) == 0 && len(res.Values) == 0 {
+		monitor.LogEvent("net/handle", "GetTrieNodes_Fail")
+		return sender.Send(ExceptionCode, msg.Id, message.Missing)
+	}
+
+	monitor.LogEvent("net/handle", "GetTrieNodes_Success")
+
+	if err = sender.Send(TrieNodesCode, msg.Id, res); err != nil {
+		netLog.Error(fmt.Sprintf("send %s to %s error: %v", res, sender.RemoteAddr(), err))
+	} else {
+		netLog.Info(fmt.Sprintf("send %s to %s done", res, sender.RemoteAddr()))
+	}
+
+	return
+}
+
 // helper
 type accountBlockMap = map[types.Address][]*ledger.AccountBlock
 
@@ -6,11 +6,35 @@ import (
 	"github.com/vitelabs/go-vite/vitepb"
 )
 
+// Compression is the algorithm of message payload
+type Compression byte
+
+const (
+	CompressNone Compression = iota
+	CompressSnappy
+	CompressZstd
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressNone:
+		return "none"
+	case CompressSnappy:
+		return "snappy"
+	case CompressZstd:
+		return "zstd"
+	default:
+		return "unknown compression"
+	}
+}
+
 type HandShake struct {
 	Height  uint64
-	Port    uint16
+	Port    uint16 // deprecated, port of the file server which is removed
 	Current types.Hash
 	Genesis types.Hash
+	// Compressions are supported to decompress
+	Compressions []Compression
 }
 
 func (h *HandShake) Serialize() ([]byte, error) {
@@ -21,6 +45,11 @@ func (h *HandShake) Serialize() ([]byte, error) {
 	pb.Current = h.Current[:]
 	pb.Genesis = h.Genesis[:]
 
+	pb.Compressions = make([]byte, len(h.Compressions))
+	for i, c := range h.Compressions {
+		pb.Compressions[i] = byte(c)
+	}
+
 	return proto.Marshal(pb)
 }
 
@@ -37,5 +66,10 @@ func (h *HandShake) Deserialize(data []byte) error {
 	copy(h.Current[:], pb.Current)
 	copy(h.Genesis[:], pb.Genesis)
 
+	h.Compressions = make([]Compression, len(pb.Compressions))
+	for i, c := range pb.Compressions {
+		h.Compressions[i] = Compression(c)
+	}
+
 	return nil
 }
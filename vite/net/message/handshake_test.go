@@ -0,0 +1,33 @@
+package message
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestHandShake_Serialize(t *testing.T) {
+	h := &HandShake{
+		Height:       10,
+		Port:         8484,
+		Compressions: []Compression{CompressSnappy},
+	}
+	rand.Read(h.Current[:])
+	rand.Read(h.Genesis[:])
+
+	buf, err := h.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h2 := new(HandShake)
+	if err = h2.Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if h2.Height != h.Height || h2.Port != h.Port || h2.Current != h.Current || h2.Genesis != h.Genesis {
+		t.Fatal("handshake not equal")
+	}
+	if len(h2.Compressions) != 1 || h2.Compressions[0] != CompressSnappy {
+		t.Fatalf("compressions not equal: %v", h2.Compressions)
+	}
+}
@@ -0,0 +1,194 @@
+package message
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vitepb"
+	"strconv"
+)
+
+// @section GetAccountState
+
+// GetAccountState query the confirmed head of Address at Snapshot, and the proof of its state
+type GetAccountState struct {
+	Address  types.Address
+	Snapshot ledger.HashHeight
+}
+
+func (g *GetAccountState) String() string {
+	return "GetAccountState<" + g.Address.String() + "/" + g.Snapshot.Hash.String() + "/" + strconv.FormatUint(g.Snapshot.Height, 10) + ">"
+}
+
+func (g *GetAccountState) Serialize() ([]byte, error) {
+	pb := new(vitepb.GetAccountState)
+	pb.Address = g.Address[:]
+	pb.Snapshot = g.Snapshot.Proto()
+
+	return proto.Marshal(pb)
+}
+
+func (g *GetAccountState) Deserialize(buf []byte) error {
+	pb := new(vitepb.GetAccountState)
+
+	err := proto.Unmarshal(buf, pb)
+	if err != nil {
+		return err
+	}
+
+	if pb.Snapshot == nil {
+		return errDeserialize
+	}
+
+	copy(g.Address[:], pb.Address)
+	g.Snapshot.DeProto(pb.Snapshot)
+
+	return nil
+}
+
+// @section AccountState
+
+// AccountState is the confirmed Head of Address at Snapshot, Proof is the path of Address
+// in the state trie of Snapshot, it proves the StateHash of Head. Head is zero if the account
+// has no confirmed block at Snapshot.
+type AccountState struct {
+	Address  types.Address
+	Snapshot ledger.HashHeight
+	Head     ledger.HashHeight
+	Proof    *trie.Proof
+}
+
+func (s *AccountState) String() string {
+	return "AccountState<" + s.Address.String() + "/" + s.Snapshot.Hash.String() + "/" + strconv.FormatUint(s.Head.Height, 10) + ">"
+}
+
+func (s *AccountState) Serialize() ([]byte, error) {
+	pb := new(vitepb.AccountState)
+	pb.Address = s.Address[:]
+	pb.Snapshot = s.Snapshot.Proto()
+	pb.Head = s.Head.Proto()
+
+	if s.Proof != nil {
+		pb.Proof = s.Proof.Nodes
+		pb.RefValue = s.Proof.RefValue
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (s *AccountState) Deserialize(buf []byte) error {
+	pb := new(vitepb.AccountState)
+
+	err := proto.Unmarshal(buf, pb)
+	if err != nil {
+		return err
+	}
+
+	if pb.Snapshot == nil || pb.Head == nil {
+		return errDeserialize
+	}
+
+	copy(s.Address[:], pb.Address)
+	s.Snapshot.DeProto(pb.Snapshot)
+	s.Head.DeProto(pb.Head)
+	s.Proof = &trie.Proof{
+		Nodes:    pb.Proof,
+		RefValue: pb.RefValue,
+	}
+
+	return nil
+}
+
+// @section GetTrieNodes
+
+// GetTrieNodes query serialized trie nodes and ref values of hash nodes by hash, for light client
+type GetTrieNodes struct {
+	Nodes  []types.Hash
+	Values []types.Hash
+}
+
+func (g *GetTrieNodes) String() string {
+	return "GetTrieNodes<" + strconv.Itoa(len(g.Nodes)) + "/" + strconv.Itoa(len(g.Values)) + ">"
+}
+
+func hashesToBytes(hashes []types.Hash) [][]byte {
+	list := make([][]byte, len(hashes))
+	for i := range hashes {
+		list[i] = hashes[i].Bytes()
+	}
+	return list
+}
+
+func bytesToHashes(list [][]byte) ([]types.Hash, error) {
+	hashes := make([]types.Hash, len(list))
+	for i, buf := range list {
+		hash, err := types.BytesToHash(buf)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	return hashes, nil
+}
+
+func (g *GetTrieNodes) Serialize() ([]byte, error) {
+	pb := new(vitepb.GetTrieNodes)
+	pb.Nodes = hashesToBytes(g.Nodes)
+	pb.Values = hashesToBytes(g.Values)
+
+	return proto.Marshal(pb)
+}
+
+func (g *GetTrieNodes) Deserialize(buf []byte) error {
+	pb := new(vitepb.GetTrieNodes)
+
+	err := proto.Unmarshal(buf, pb)
+	if err != nil {
+		return err
+	}
+
+	if g.Nodes, err = bytesToHashes(pb.Nodes); err != nil {
+		return err
+	}
+	if g.Values, err = bytesToHashes(pb.Values); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @section TrieNodes
+
+// TrieNodes is the response of GetTrieNodes, missing nodes and values are omitted,
+// receiver should verify them by hash.
+type TrieNodes struct {
+	Nodes  [][]byte
+	Values [][]byte
+}
+
+func (t *TrieNodes) String() string {
+	return "TrieNodes<" + strconv.Itoa(len(t.Nodes)) + "/" + strconv.Itoa(len(t.Values)) + ">"
+}
+
+func (t *TrieNodes) Serialize() ([]byte, error) {
+	pb := new(vitepb.TrieNodes)
+	pb.Nodes = t.Nodes
+	pb.Values = t.Values
+
+	return proto.Marshal(pb)
+}
+
+func (t *TrieNodes) Deserialize(buf []byte) error {
+	pb := new(vitepb.TrieNodes)
+
+	err := proto.Unmarshal(buf, pb)
+	if err != nil {
+		return err
+	}
+
+	t.Nodes = pb.Nodes
+	t.Values = pb.Values
+
+	return nil
+}
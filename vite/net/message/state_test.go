@@ -0,0 +1,119 @@
+package message
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vitepb"
+)
+
+func TestGetAccountState_Serialize(t *testing.T) {
+	var gs GetAccountState
+	crand.Read(gs.Address[:])
+	crand.Read(gs.Snapshot.Hash[:])
+	gs.Snapshot.Height = mrand.Uint64()
+
+	buf, err := gs.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g GetAccountState
+	if err = g.Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if g != gs {
+		t.Fail()
+	}
+}
+
+func TestAccountState_Serialize(t *testing.T) {
+	s := &AccountState{
+		Proof: &trie.Proof{
+			Nodes:    make([][]byte, mrand.Intn(10)+1),
+			RefValue: make([]byte, 64),
+		},
+	}
+	crand.Read(s.Address[:])
+	crand.Read(s.Snapshot.Hash[:])
+	s.Snapshot.Height = mrand.Uint64()
+	crand.Read(s.Head.Hash[:])
+	s.Head.Height = mrand.Uint64()
+	for i := range s.Proof.Nodes {
+		s.Proof.Nodes[i] = make([]byte, mrand.Intn(100)+1)
+		crand.Read(s.Proof.Nodes[i])
+	}
+	crand.Read(s.Proof.RefValue)
+
+	buf, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s2 := new(AccountState)
+	if err = s2.Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if s2.Address != s.Address || s2.Snapshot != s.Snapshot || s2.Head != s.Head {
+		t.Fatal("account state not equal")
+	}
+
+	if len(s2.Proof.Nodes) != len(s.Proof.Nodes) || !bytes.Equal(s2.Proof.RefValue, s.Proof.RefValue) {
+		t.Fatal("proof not equal")
+	}
+	for i := range s.Proof.Nodes {
+		if !bytes.Equal(s2.Proof.Nodes[i], s.Proof.Nodes[i]) {
+			t.Fatalf("proof node %d not equal", i)
+		}
+	}
+}
+
+func TestGetTrieNodes_Serialize(t *testing.T) {
+	g := &GetTrieNodes{
+		Nodes:  make([]types.Hash, mrand.Intn(10)+1),
+		Values: make([]types.Hash, mrand.Intn(10)+1),
+	}
+	for i := range g.Nodes {
+		crand.Read(g.Nodes[i][:])
+	}
+	for i := range g.Values {
+		crand.Read(g.Values[i][:])
+	}
+
+	buf, err := g.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g2 := new(GetTrieNodes)
+	if err = g2.Deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(g2.Nodes) != len(g.Nodes) || len(g2.Values) != len(g.Values) {
+		t.Fatal("wrong count")
+	}
+	for i := range g.Nodes {
+		if g2.Nodes[i] != g.Nodes[i] {
+			t.Fatal("wrong node hash")
+		}
+	}
+	for i := range g.Values {
+		if g2.Values[i] != g.Values[i] {
+			t.Fatal("wrong value hash")
+		}
+	}
+
+	// malformed hash
+	buf, _ = proto.Marshal(&vitepb.GetTrieNodes{Nodes: [][]byte{{1, 2, 3}}})
+	if err = g2.Deserialize(buf); err == nil {
+		t.Fatal("malformed hash should be refused")
+	}
+}
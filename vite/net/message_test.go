@@ -170,15 +170,15 @@ func (m *mock_Peer) Report(err error) {
 	panic("implement me")
 }
 
-func (m *mock_Peer) FileAddress() *net2.TCPAddr {
+func (m *mock_Peer) SetHead(head types.Hash, height uint64) {
 	panic("implement me")
 }
 
-func (m *mock_Peer) SetHead(head types.Hash, height uint64) {
+func (m *mock_Peer) SeeBlock(hash types.Hash) {
 	panic("implement me")
 }
 
-func (m *mock_Peer) SeeBlock(hash types.Hash) {
+func (m *mock_Peer) ReceiveNewBlock(hash types.Hash) bool {
 	panic("implement me")
 }
 
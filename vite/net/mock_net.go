@@ -26,6 +26,10 @@ func (n *mockNet) Start(svr *p2p.Server) error {
 	return nil
 }
 
+// Stop must not reach the embedded syncer, which is not started in single mode
+func (n *mockNet) Stop() {
+}
+
 func (n *mockNet) Tasks() []*Task {
 	return nil
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/vitelabs/go-vite/p2p/list"
 	"github.com/vitelabs/go-vite/vite/net/message"
 	"github.com/vitelabs/go-vite/vite/net/topo"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,18 +22,19 @@ var netLog = log15.New("module", "vite/net")
 type Config struct {
 	Single bool // for test
 
-	Port     uint16
 	Chain    Chain
 	Verifier Verifier
+	DataDir  string // sync progress is persisted in DataDir/sync, not persisted if empty
 
 	// for topo
 	Topology     []string
 	Topic        string
 	Interval     int64 // second
 	TopoDisabled bool
-}
 
-const DefaultPort uint16 = 8484
+	// nil for default penalties
+	Reputation *ReputationConfig
+}
 
 type net struct {
 	*Config
@@ -45,10 +48,10 @@ type net struct {
 	log       log15.Logger
 	protocols []*p2p.Protocol // mount to p2p.Server
 	wg        sync.WaitGroup
-	fs        *fileServer
 	handlers  map[ViteCmd]MsgHandler
 	topo      *topo.Topology
-	query     *queryHandler // handle query message (eg. getAccountBlocks, getSnapshotblocks, getChunk, getSubLedger)
+	query     *queryHandler // handle query message (eg. getAccountBlocks, getSnapshotblocks, getChunk)
+	rep       *reputation
 }
 
 // auto from
@@ -58,17 +61,17 @@ func New(cfg *Config) Net {
 		return mock()
 	}
 
-	if cfg.Port == 0 {
-		cfg.Port = DefaultPort
-	}
-
 	g := new(gid)
 	peers := newPeerSet()
 
 	broadcaster := newBroadcaster(peers)
 	filter := newFilter()
 	receiver := newReceiver(cfg.Verifier, broadcaster, filter)
-	syncer := newSyncer(cfg.Chain, peers, g, receiver)
+	var syncDir string
+	if cfg.DataDir != "" {
+		syncDir = filepath.Join(cfg.DataDir, "sync")
+	}
+	syncer := newSyncer(cfg.Chain, peers, g, receiver, syncDir)
 	fetcher := newFetcher(filter, peers, g)
 
 	syncer.feed.Sub(receiver.listen) // subscribe sync status
@@ -82,15 +85,15 @@ func New(cfg *Config) Net {
 		broadcaster: broadcaster,
 		receiver:    receiver,
 		filter:      filter,
-		fs:          newFileServer(cfg.Port, cfg.Chain),
 		handlers:    make(map[ViteCmd]MsgHandler),
 		log:         netLog,
+		rep:         newReputation(cfg.Reputation),
 	}
 
 	n.addHandler(_statusHandler(statusHandler))
 	n.query = newQueryHandler(cfg.Chain)
 	n.addHandler(n.query)
-	n.addHandler(syncer)   // FileListCode, SubLedgerCode, ExceptionCode
+	n.addHandler(syncer)   // SubLedgerCode, ExceptionCode
 	n.addHandler(receiver) // NewSnapshotBlockCode, NewAccountBlockCode, SnapshotBlocksCode, AccountBlocksCode
 
 	n.protocols = append(n.protocols, &p2p.Protocol{
@@ -99,6 +102,7 @@ func New(cfg *Config) Net {
 		Handle: func(p *p2p.Peer, rw *p2p.ProtoFrame) error {
 			// will be called by p2p.Peer.runProtocols use goroutine
 			peer := newPeer(p, rw, CmdSet)
+			peer.rep = n.rep
 			return n.handlePeer(peer)
 		},
 	})
@@ -129,8 +133,8 @@ func (n *net) addHandler(handler MsgHandler) {
 func (n *net) Start(svr *p2p.Server) (err error) {
 	n.term = make(chan struct{})
 
-	if err = n.fs.start(); err != nil {
-		return
+	if svr != nil {
+		n.rep.setBanner(svr)
 	}
 
 	if n.topo != nil {
@@ -146,6 +150,8 @@ func (n *net) Start(svr *p2p.Server) (err error) {
 
 	n.filter.start()
 
+	n.registerMetrics()
+
 	return
 }
 
@@ -159,9 +165,9 @@ func (n *net) Stop() {
 	default:
 		close(n.term)
 
-		n.syncer.Stop()
+		n.unregisterMetrics()
 
-		n.fs.stop()
+		n.syncer.Stop()
 
 		if n.topo != nil {
 			n.topo.Stop()
@@ -175,6 +181,30 @@ func (n *net) Stop() {
 	}
 }
 
+var netMetrics = []string{
+	"gvite_net_peers",
+	"gvite_net_sync_state",
+	"gvite_net_sync_target_height",
+}
+
+func (n *net) registerMetrics() {
+	monitor.NewGaugeFunc(netMetrics[0], "Peers running the vite protocol", func() float64 {
+		return float64(n.peers.Count())
+	})
+	monitor.NewGaugeFunc(netMetrics[1], "Sync state: 0 not start, 1 syncing, 2 done, 3 error, 4 canceled, 5 downloaded", func() float64 {
+		return float64(n.syncer.SyncState())
+	})
+	monitor.NewGaugeFunc(netMetrics[2], "Snapshot height the syncer is syncing to", func() float64 {
+		return float64(atomic.LoadUint64(&n.syncer.to))
+	})
+}
+
+func (n *net) unregisterMetrics() {
+	for _, name := range netMetrics {
+		monitor.Unregister(name)
+	}
+}
+
 // will be called by p2p.Server, run as goroutine
 func (n *net) handlePeer(p *peer) error {
 	current := n.Chain.GetLatestSnapshotBlock()
@@ -182,10 +212,10 @@ func (n *net) handlePeer(p *peer) error {
 
 	n.log.Debug(fmt.Sprintf("handshake with %s", p))
 	err := p.Handshake(&message.HandShake{
-		Height:  current.Height,
-		Port:    n.Port,
-		Current: current.Hash,
-		Genesis: genesis.Hash,
+		Height:       current.Height,
+		Current:      current.Hash,
+		Genesis:      genesis.Hash,
+		Compressions: supportedCompressions,
 	})
 
 	if err != nil {
@@ -272,7 +302,7 @@ func (n *net) heartbeat() {
 var errMissHandler = errors.New("missing message handler")
 
 func (n *net) handleMsg(p *peer) (err error) {
-	msg, err := p.mrw.ReadMsg()
+	msg, err := p.readMsg()
 	if err != nil {
 		n.log.Error(fmt.Sprintf("read message from %s error: %v", p, err))
 		return
@@ -299,6 +329,10 @@ func (n *net) handleMsg(p *peer) (err error) {
 
 		p.msgHandled[code]++
 
+		if err != nil {
+			p.Report(OffenceMalformed)
+		}
+
 		return err
 	}
 
@@ -19,15 +19,19 @@ import (
 
 const filterCap = 100000
 
+// new blocks broadcast by the peer recently, a block received again is duplicate
+const recentBlocksCap = 1000
+
 var errDiffGesis = errors.New("different genesis block")
 
 // @section Peer for protocol handle, not p2p Peer.
 //var errPeerTermed = errors.New("peer has been terminated")
 type Peer interface {
 	RemoteAddr() *net2.TCPAddr
-	FileAddress() *net2.TCPAddr
 	SetHead(head types.Hash, height uint64)
 	SeeBlock(hash types.Hash)
+	// ReceiveNewBlock mark the new block broadcast by the peer, return true if the peer has broadcast it recently
+	ReceiveNewBlock(hash types.Hash) (duplicate bool)
 	SendSnapshotBlocks(bs []*ledger.SnapshotBlock, msgId uint64) (err error)
 	SendAccountBlocks(bs []*ledger.AccountBlock, msgId uint64) (err error)
 	SendNewSnapshotBlock(b *ledger.SnapshotBlock) (err error)
@@ -46,13 +50,15 @@ type peer struct {
 	id          string
 	head        types.Hash // hash of the top snapshotblock in snapshotchain
 	height      uint64     // height of the snapshotchain
-	filePort    uint16     // fileServer port, for request file
 	CmdSet      p2p.CmdSet // which cmdSet it belongs
 	KnownBlocks *cuckoofilter.CuckooFilter
+	received    *recentHashes // exact, KnownBlocks has false positives
 	log         log15.Logger
 	errChan     chan error
 	term        chan struct{}
-	msgHandled  map[ViteCmd]uint64 // message statistic
+	msgHandled  map[ViteCmd]uint64  // message statistic
+	compression message.Compression // negotiated in handshake, payload is not prefixed if CompressNone
+	rep         *reputation         // nil if peers are not scored
 	wg          sync.WaitGroup
 }
 
@@ -71,6 +77,7 @@ func newPeer(p *p2p.Peer, mrw *p2p.ProtoFrame, cmdSet p2p.CmdSet) *peer {
 		id:          p.ID().String(),
 		CmdSet:      cmdSet,
 		KnownBlocks: cuckoofilter.NewCuckooFilter(filterCap),
+		received:    newRecentHashes(recentBlocksCap),
 		log:         log15.New("module", "net/peer"),
 		errChan:     make(chan error, 1),
 		term:        make(chan struct{}),
@@ -78,7 +85,17 @@ func newPeer(p *p2p.Peer, mrw *p2p.ProtoFrame, cmdSet p2p.CmdSet) *peer {
 	}
 }
 
+// Report an error will disconnect the peer, except an Offence punish the peer until it is banned
 func (p *peer) Report(err error) {
+	if o, ok := err.(Offence); ok {
+		p.log.Warn(fmt.Sprintf("peer %s offence: %s", p.RemoteAddr(), o))
+
+		if p.rep == nil || !p.rep.punish(p.Peer.ID(), o) {
+			return
+		}
+		err = p2p.DiscBanned
+	}
+
 	select {
 	case p.errChan <- err:
 	default:
@@ -86,13 +103,6 @@ func (p *peer) Report(err error) {
 	}
 }
 
-func (p *peer) FileAddress() *net2.TCPAddr {
-	return &net2.TCPAddr{
-		IP:   p.IP(),
-		Port: int(p.filePort),
-	}
-}
-
 func (p *peer) Handshake(our *message.HandShake) error {
 	errch := make(chan error, 1)
 	common.Go(func() {
@@ -113,10 +123,7 @@ func (p *peer) Handshake(our *message.HandShake) error {
 	}
 
 	p.SetHead(their.Current, their.Height)
-	p.filePort = their.Port
-	if p.filePort == 0 {
-		p.filePort = DefaultPort
-	}
+	p.compression = negotiateCompression(our.Compressions, their.Compressions)
 
 	return nil
 }
@@ -140,6 +147,21 @@ func (p *peer) ReadHandshake() (their *message.HandShake, err error) {
 	return
 }
 
+// readMsg read message after handshake, payload will be decompressed
+func (p *peer) readMsg() (msg *p2p.Msg, err error) {
+	if msg, err = p.mrw.ReadMsg(); err != nil {
+		return
+	}
+
+	if p.compression != message.CompressNone {
+		if msg.Payload, err = decompressPayload(msg.Payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
 func (p *peer) SetHead(head types.Hash, height uint64) {
 	p.head = head
 	p.height = height
@@ -151,6 +173,47 @@ func (p *peer) SeeBlock(hash types.Hash) {
 	p.KnownBlocks.InsertUnique(hash[:])
 }
 
+func (p *peer) ReceiveNewBlock(hash types.Hash) (duplicate bool) {
+	p.SeeBlock(hash)
+	return p.received.add(hash)
+}
+
+// recentHashes is a set of the latest hashes, the oldest one is dropped when it is full
+type recentHashes struct {
+	lock   sync.Mutex
+	hashes []types.Hash
+	set    map[types.Hash]struct{}
+	next   int
+}
+
+func newRecentHashes(cap int) *recentHashes {
+	return &recentHashes{
+		hashes: make([]types.Hash, 0, cap),
+		set:    make(map[types.Hash]struct{}, cap),
+	}
+}
+
+// add return true if hash exists
+func (r *recentHashes) add(hash types.Hash) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.set[hash]; ok {
+		return true
+	}
+
+	if len(r.hashes) < cap(r.hashes) {
+		r.hashes = append(r.hashes, hash)
+	} else {
+		delete(r.set, r.hashes[r.next])
+		r.hashes[r.next] = hash
+		r.next = (r.next + 1) % len(r.hashes)
+	}
+	r.set[hash] = struct{}{}
+
+	return false
+}
+
 // send
 
 func (p *peer) SendSnapshotBlocks(bs []*ledger.SnapshotBlock, msgId uint64) (err error) {
@@ -191,7 +254,13 @@ func (p *peer) Send(code ViteCmd, msgId uint64, payload p2p.Serializable) (err e
 	if msg, err = p2p.PackMsg(p.CmdSet, p2p.Cmd(code), msgId, payload); err != nil {
 		p.log.Error(fmt.Sprintf("pack message %s to %s error: %v", code, p.RemoteAddr(), err))
 		return err
-	} else if err = p.mrw.WriteMsg(msg); err != nil {
+	}
+
+	if p.compression != message.CompressNone {
+		msg.Payload = compressPayload(p.compression, msg.Payload)
+	}
+
+	if err = p.mrw.WriteMsg(msg); err != nil {
 		p.log.Error(fmt.Sprintf("send message %s to %s error: %v", code, p.RemoteAddr(), err))
 		return err
 	}
@@ -215,6 +284,7 @@ type PeerInfo struct {
 	MsgHandledDetail   map[string]uint64 `json:"msgHandledDetail"`
 	MsgSendDetail      map[string]uint64 `json:"msgSendDetail"`
 	Uptime             time.Duration     `json:"uptime"`
+	Score              int               `json:"score"` // penalty of offences, peer will be banned when it reach BanScore
 }
 
 func (p *PeerInfo) String() string {
@@ -247,6 +317,11 @@ func (p *peer) Info() *PeerInfo {
 		discard += num
 	}
 
+	var score int
+	if p.rep != nil {
+		score = p.rep.score(p.Peer.ID())
+	}
+
 	return &PeerInfo{
 		ID:                 p.id,
 		Addr:               p.RemoteAddr().String(),
@@ -261,6 +336,7 @@ func (p *peer) Info() *PeerInfo {
 		MsgHandledDetail:   handMap,
 		MsgSendDetail:      sendMap,
 		Uptime:             time.Now().Sub(p.Created),
+		Score:              score,
 	}
 }
 
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	mrand "math/rand"
 	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
 )
 
 var peerMap = newPeerSet()
@@ -34,3 +36,31 @@ func TestPeerSet_SyncPeer(t *testing.T) {
 
 	fmt.Println("mid", peerMap.SyncPeer().Height())
 }
+
+func TestRecentHashes(t *testing.T) {
+	r := newRecentHashes(3)
+
+	var hashes [5]types.Hash
+	for i := range hashes {
+		hashes[i][0] = byte(i + 1)
+	}
+
+	for _, hash := range hashes[:3] {
+		if r.add(hash) {
+			t.Fatalf("%s should be new", hash)
+		}
+	}
+	if !r.add(hashes[1]) {
+		t.Fatal("should be duplicate")
+	}
+
+	// the oldest ones are dropped
+	r.add(hashes[3])
+	r.add(hashes[4])
+	if r.add(hashes[0]) {
+		t.Fatal("the oldest hash should be dropped")
+	}
+	if !r.add(hashes[4]) || len(r.set) != 3 {
+		t.Fatalf("should keep the latest 3 hashes, got %d", len(r.set))
+	}
+}
@@ -0,0 +1,165 @@
+package net
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+const chunkFileSuffix = ".chunk"
+const targetFileName = "target"
+
+// chunkCache persists verified chunks and the sync target in dir,
+// so a restarted node can resume sync without downloading them again.
+// chunks are removed after they have been inserted into chain.
+type chunkCache struct {
+	dir string
+	log log15.Logger
+}
+
+func newChunkCache(dir string) (*chunkCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &chunkCache{
+		dir: dir,
+		log: log15.New("module", "net/chunkCache"),
+	}, nil
+}
+
+func chunkFileName(from, to uint64) string {
+	return strconv.FormatUint(from, 10) + "-" + strconv.FormatUint(to, 10) + chunkFileSuffix
+}
+
+func parseChunkFileName(name string) (from, to uint64, ok bool) {
+	if !strings.HasSuffix(name, chunkFileSuffix) {
+		return
+	}
+
+	band := strings.Split(strings.TrimSuffix(name, chunkFileSuffix), "-")
+	if len(band) != 2 {
+		return
+	}
+
+	var err error
+	if from, err = strconv.ParseUint(band[0], 10, 64); err != nil {
+		return
+	}
+	if to, err = strconv.ParseUint(band[1], 10, 64); err != nil {
+		return
+	}
+
+	return from, to, from <= to
+}
+
+// save write to a temporary file first, so a broken file will not be loaded after crash
+func (c *chunkCache) save(r *chunkRequest) error {
+	data, err := (&message.SubLedger{
+		SBlocks: r.sblocks,
+		ABlocks: r.ablocks,
+	}).Serialize()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(c.dir, chunkFileName(r.from, r.to))
+	if err = ioutil.WriteFile(file+".tmp", data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(file+".tmp", file)
+}
+
+func (c *chunkCache) remove(r *chunkRequest) {
+	os.Remove(filepath.Join(c.dir, chunkFileName(r.from, r.to)))
+}
+
+// chunks return bands of all cached chunks
+func (c *chunkCache) chunks() (bands [][2]uint64) {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		c.log.Error(fmt.Sprintf("read dir %s error: %v", c.dir, err))
+		return
+	}
+
+	for _, info := range infos {
+		if from, to, ok := parseChunkFileName(info.Name()); ok {
+			bands = append(bands, [2]uint64{from, to})
+		}
+	}
+
+	return
+}
+
+// load cached chunks contain blocks not lower than height, chunks can`t be verified will be removed
+func (c *chunkCache) load(height uint64) (rs []*chunkRequest) {
+	for _, band := range c.chunks() {
+		r := newChunkRequest(band[0], band[1])
+		file := filepath.Join(c.dir, chunkFileName(band[0], band[1]))
+
+		if band[1] < height {
+			os.Remove(file)
+			continue
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			c.log.Error(fmt.Sprintf("read chunk file %s error: %v", file, err))
+			continue
+		}
+
+		res := new(message.SubLedger)
+		if err = res.Deserialize(data); err == nil {
+			r.sblocks, r.ablocks = res.SBlocks, res.ABlocks
+			err = r.verify()
+		}
+
+		if err != nil {
+			c.log.Error(fmt.Sprintf("load chunk file %s error: %v", file, err))
+			os.Remove(file)
+			continue
+		}
+
+		r.state = reqRespond
+		rs = append(rs, r)
+	}
+
+	return
+}
+
+// clean remove chunks not higher than height
+func (c *chunkCache) clean(height uint64) {
+	for _, band := range c.chunks() {
+		if band[1] <= height {
+			os.Remove(filepath.Join(c.dir, chunkFileName(band[0], band[1])))
+		}
+	}
+}
+
+// clear remove all chunks and the target
+func (c *chunkCache) clear() {
+	c.clean(^uint64(0))
+	os.Remove(filepath.Join(c.dir, targetFileName))
+}
+
+// target return the height of the unfinished sync, 0 if there is no unfinished sync
+func (c *chunkCache) target() uint64 {
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, targetFileName))
+	if err != nil {
+		return 0
+	}
+
+	to, _ := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return to
+}
+
+func (c *chunkCache) setTarget(to uint64) error {
+	return ioutil.WriteFile(filepath.Join(c.dir, targetFileName), []byte(strconv.FormatUint(to, 10)), 0600)
+}
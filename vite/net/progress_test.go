@@ -0,0 +1,61 @@
+package net
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestChunkCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chunkcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := newChunkCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := mockSnapshotChain(1, 400, types.Hash{}, types.Hash{})
+	for _, c := range []*chunkRequest{mockChunk(blocks[:200]), mockChunk(blocks[200:])} {
+		if err = cache.save(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = cache.setTarget(1000); err != nil {
+		t.Fatal(err)
+	}
+
+	// restart
+	cache, _ = newChunkCache(dir)
+	if cache.target() != 1000 {
+		t.Fatalf("target should be 1000, but %d", cache.target())
+	}
+
+	cs := cache.load(201)
+	if len(cs) != 1 || cs[0].from != 201 || cs[0].to != 400 || len(cs[0].sblocks) != 200 {
+		t.Fatal("chunks lower than height should not be loaded")
+	}
+	if cs[0].sblocks[199].Hash != blocks[399].Hash {
+		t.Fatal("wrong block loaded")
+	}
+	if len(cache.chunks()) != 1 {
+		t.Fatal("chunks lower than height should be removed")
+	}
+
+	// resume the sync, cached chunks are not requested again
+	p := newChunkPool(newPeerSet(), new(gid), &mockBlockReceiver{}, cache)
+	p.reset(301, blocks[299].Hash, 1000)
+	if len(p.done) != 1 || p.queue[0].from != 401 {
+		t.Fatal("cached chunks should not be requested again")
+	}
+
+	cache.clear()
+	if cache.target() != 0 || len(cache.chunks()) != 0 {
+		t.Fatal("cache should be cleared")
+	}
+}
@@ -62,9 +62,16 @@ func (s *receiver) Handle(msg *p2p.Msg, sender Peer) error {
 			return err
 		}
 
-		sender.SeeBlock(block.Hash)
+		// a new block is broadcast once by each peer, the same one again is flooding
+		if sender.ReceiveNewBlock(block.Hash) {
+			s.log.Warn(fmt.Sprintf("duplicate new snapshotblock %s/%d from %s", block.Hash, block.Height, sender.RemoteAddr()))
+			sender.Report(OffenceDuplicate)
+			return nil
+		}
 
-		s.ReceiveNewSnapshotBlock(block)
+		if err = s.receiveNewSnapshotBlock(block); err != nil {
+			sender.Report(OffenceInvalidBlock)
+		}
 
 		s.log.Info(fmt.Sprintf("receive new snapshotblock %s/%d from %s", block.Hash, block.Height, sender.RemoteAddr()))
 
@@ -75,9 +82,15 @@ func (s *receiver) Handle(msg *p2p.Msg, sender Peer) error {
 			return err
 		}
 
-		sender.SeeBlock(block.Hash)
+		if sender.ReceiveNewBlock(block.Hash) {
+			s.log.Warn(fmt.Sprintf("duplicate new accountblock %s from %s", block.Hash, sender.RemoteAddr()))
+			sender.Report(OffenceDuplicate)
+			return nil
+		}
 
-		s.ReceiveNewAccountBlock(block)
+		if err = s.receiveNewAccountBlock(block); err != nil {
+			sender.Report(OffenceInvalidBlock)
+		}
 
 		s.log.Info(fmt.Sprintf("receive new accountblock %s from %s", block.Hash, sender.RemoteAddr()))
 
@@ -88,7 +101,16 @@ func (s *receiver) Handle(msg *p2p.Msg, sender Peer) error {
 			return err
 		}
 
-		s.ReceiveSnapshotBlocks(bs.Blocks)
+		var invalid bool
+		for _, block := range bs.Blocks {
+			if err = s.receiveSnapshotBlock(block); err != nil {
+				invalid = true
+			}
+		}
+		// punish once for a message
+		if invalid {
+			sender.Report(OffenceInvalidBlock)
+		}
 
 	case AccountBlocksCode:
 		bs := new(message.AccountBlocks)
@@ -97,7 +119,16 @@ func (s *receiver) Handle(msg *p2p.Msg, sender Peer) error {
 			return err
 		}
 
-		s.ReceiveAccountBlocks(bs.Blocks)
+		var invalid bool
+		for _, block := range bs.Blocks {
+			if err = s.receiveAccountBlock(block); err != nil {
+				invalid = true
+			}
+		}
+		// punish once for a message
+		if invalid {
+			sender.Report(OffenceInvalidBlock)
+		}
 	}
 
 	return nil
@@ -109,6 +140,11 @@ func (s *receiver) mark(hash types.Hash) {
 
 // implementation Receiver
 func (s *receiver) ReceiveNewSnapshotBlock(block *ledger.SnapshotBlock) {
+	s.receiveNewSnapshotBlock(block)
+}
+
+// receiveNewSnapshotBlock return the error of verifier
+func (s *receiver) receiveNewSnapshotBlock(block *ledger.SnapshotBlock) (err error) {
 	if block == nil {
 		return
 	}
@@ -123,7 +159,7 @@ func (s *receiver) ReceiveNewSnapshotBlock(block *ledger.SnapshotBlock) {
 
 	if s.verifier != nil {
 		verify_b := time.Now()
-		if err := s.verifier.VerifyNetSb(block); err != nil {
+		if err = s.verifier.VerifyNetSb(block); err != nil {
 			monitor.LogDuration("net/verifier", "SnapshotBlock", time.Now().Sub(verify_b).Nanoseconds())
 			s.log.Error(fmt.Sprintf("verify NewSnapshotBlock %s/%d fail: %v", block.Hash, block.Height, err))
 			return
@@ -153,9 +189,15 @@ func (s *receiver) ReceiveNewSnapshotBlock(block *ledger.SnapshotBlock) {
 		monitor.LogDuration("net/notify", "NewSnapshotBlock", time.Now().Sub(notify_b).Nanoseconds())
 		s.log.Info(fmt.Sprintf("notify NewSnapshotBlock %s/%d done", block.Hash, block.Height))
 	}
+	return
 }
 
 func (s *receiver) ReceiveNewAccountBlock(block *ledger.AccountBlock) {
+	s.receiveNewAccountBlock(block)
+}
+
+// receiveNewAccountBlock return the error of verifier
+func (s *receiver) receiveNewAccountBlock(block *ledger.AccountBlock) (err error) {
 	if block == nil {
 		return
 	}
@@ -170,7 +212,7 @@ func (s *receiver) ReceiveNewAccountBlock(block *ledger.AccountBlock) {
 
 	if s.verifier != nil {
 		verify_b := time.Now()
-		if err := s.verifier.VerifyNetAb(block); err != nil {
+		if err = s.verifier.VerifyNetAb(block); err != nil {
 			monitor.LogDuration("net/verifier", "AccountBlock", time.Now().Sub(verify_b).Nanoseconds())
 			s.log.Error(fmt.Sprintf("verify NewAccountBlock %s fail: %v", block.Hash, err))
 			return
@@ -198,9 +240,15 @@ func (s *receiver) ReceiveNewAccountBlock(block *ledger.AccountBlock) {
 		monitor.LogDuration("net/notify", "NewAccountBlock", time.Now().Sub(notify_b).Nanoseconds())
 		s.log.Info(fmt.Sprintf("notify NewAccountBlock %s done", block.Hash))
 	}
+	return
 }
 
 func (s *receiver) ReceiveSnapshotBlock(block *ledger.SnapshotBlock) {
+	s.receiveSnapshotBlock(block)
+}
+
+// receiveSnapshotBlock return the error of verifier
+func (s *receiver) receiveSnapshotBlock(block *ledger.SnapshotBlock) (err error) {
 	if block == nil {
 		return
 	}
@@ -215,7 +263,7 @@ func (s *receiver) ReceiveSnapshotBlock(block *ledger.SnapshotBlock) {
 
 	if s.verifier != nil {
 		verify_b := time.Now()
-		if err := s.verifier.VerifyNetSb(block); err != nil {
+		if err = s.verifier.VerifyNetSb(block); err != nil {
 			monitor.LogDuration("net/verifier", "SnapshotBlock", time.Now().Sub(verify_b).Nanoseconds())
 			s.log.Error(fmt.Sprintf("verify SnapshotBlock %s/%d fail: %v", block.Hash, block.Height, err))
 			return
@@ -229,9 +277,15 @@ func (s *receiver) ReceiveSnapshotBlock(block *ledger.SnapshotBlock) {
 	s.sFeed.Notify(block, s.batchSource)
 	monitor.LogDuration("net/notify", "SnapshotBlock", time.Now().Sub(notify_b).Nanoseconds())
 	s.log.Info(fmt.Sprintf("notify SnapshotBlock %s/%d done", block.Hash, block.Height))
+	return
 }
 
 func (s *receiver) ReceiveAccountBlock(block *ledger.AccountBlock) {
+	s.receiveAccountBlock(block)
+}
+
+// receiveAccountBlock return the error of verifier
+func (s *receiver) receiveAccountBlock(block *ledger.AccountBlock) (err error) {
 	if block == nil {
 		return
 	}
@@ -246,7 +300,7 @@ func (s *receiver) ReceiveAccountBlock(block *ledger.AccountBlock) {
 
 	if s.verifier != nil {
 		verify_b := time.Now()
-		if err := s.verifier.VerifyNetAb(block); err != nil {
+		if err = s.verifier.VerifyNetAb(block); err != nil {
 			monitor.LogDuration("net/verifier", "AccountBlock", time.Now().Sub(verify_b).Nanoseconds())
 			s.log.Error(fmt.Sprintf("verify AccountBlock %s fail: %v", block.Hash, err))
 			return
@@ -260,6 +314,7 @@ func (s *receiver) ReceiveAccountBlock(block *ledger.AccountBlock) {
 	s.aFeed.Notify(block, s.batchSource)
 	monitor.LogDuration("net/notify", "AccountBlock", time.Now().Sub(notify_b).Nanoseconds())
 	s.log.Info(fmt.Sprintf("notify AccountBlock %s done", block.Hash))
+	return
 }
 
 func (s *receiver) ReceiveSnapshotBlocks(blocks []*ledger.SnapshotBlock) {
@@ -0,0 +1,78 @@
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/p2p"
+)
+
+type reportPeer struct {
+	mock_Peer
+	received *recentHashes
+	offences []error
+}
+
+func (p *reportPeer) ReceiveNewBlock(hash types.Hash) bool {
+	return p.received.add(hash)
+}
+
+func (p *reportPeer) Report(err error) {
+	p.offences = append(p.offences, err)
+}
+
+type countBroadcaster struct {
+	Broadcaster
+	count int
+}
+
+func (b *countBroadcaster) BroadcastSnapshotBlock(block *ledger.SnapshotBlock) {
+	b.count++
+}
+
+func TestReceiver_duplicate(t *testing.T) {
+	broadcaster := new(countBroadcaster)
+	s := newReceiver(nil, broadcaster, newFilter())
+
+	timestamp := time.Unix(1540000000, 0)
+	block := &ledger.SnapshotBlock{
+		Height:    2,
+		Timestamp: &timestamp,
+	}
+	block.Hash = block.ComputeHash()
+	payload, err := block.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &p2p.Msg{
+		CmdSet:  CmdSet,
+		Cmd:     p2p.Cmd(NewSnapshotBlockCode),
+		Payload: payload,
+	}
+
+	p := &reportPeer{received: newRecentHashes(recentBlocksCap)}
+	other := &reportPeer{received: newRecentHashes(recentBlocksCap)}
+
+	if err := s.Handle(msg, p); err != nil {
+		t.Fatal(err)
+	}
+	// the same block from another peer is normal
+	if err := s.Handle(msg, other); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.offences) != 0 || len(other.offences) != 0 {
+		t.Fatalf("should not be punished: %v %v", p.offences, other.offences)
+	}
+	if broadcaster.count != 1 {
+		t.Fatalf("should be broadcast once, got %d", broadcaster.count)
+	}
+
+	if err := s.Handle(msg, p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.offences) != 1 || p.offences[0] != OffenceDuplicate {
+		t.Fatalf("should be punished for the duplicate block: %v", p.offences)
+	}
+}
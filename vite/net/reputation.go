@@ -0,0 +1,195 @@
+package net
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/monitor"
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+// Offence is reported by peer.Report, peer will be punished by the penalty of offence
+type Offence byte
+
+const (
+	OffenceInvalidBlock Offence = iota + 1 // block verified failed by Verifier
+	OffenceTimeout                         // request timeout
+	OffenceMalformed                       // message can't be handled
+	OffenceDuplicate                       // the same new block is broadcast by the peer again
+)
+
+var offenceNames = map[Offence]string{
+	OffenceInvalidBlock: "invalidBlock",
+	OffenceTimeout:      "timeout",
+	OffenceMalformed:    "malformed",
+	OffenceDuplicate:    "duplicate",
+}
+
+func (o Offence) String() string {
+	if name, ok := offenceNames[o]; ok {
+		return name
+	}
+	return "unknown offence"
+}
+
+func (o Offence) Error() string {
+	return o.String()
+}
+
+// ParseOffence parse name of Offence, eg. "timeout"
+func ParseOffence(name string) (Offence, bool) {
+	for o, str := range offenceNames {
+		if str == name {
+			return o, true
+		}
+	}
+	return 0, false
+}
+
+type ReputationConfig struct {
+	Penalties       map[Offence]int // penalty of each offence, missing ones use DefaultPenalties
+	BanScore        int             // peer will be banned when its score reach BanScore
+	BanDuration     time.Duration
+	RecoverInterval time.Duration // recover one point of score at every interval
+}
+
+var DefaultPenalties = map[Offence]int{
+	OffenceInvalidBlock: 50,
+	OffenceTimeout:      10,
+	OffenceMalformed:    50,
+	OffenceDuplicate:    10,
+}
+
+const DefaultBanScore = 100
+const DefaultBanDuration = 24 * time.Hour
+const DefaultRecoverInterval = time.Minute
+
+// sweep recovered scores when there are too many
+const maxScores = 10000
+
+// Banner will disconnect the node and refuse it during duration
+type Banner interface {
+	Ban(id discovery.NodeID, duration time.Duration) bool
+}
+
+type score struct {
+	value  int
+	update time.Time
+}
+
+func (s *score) recover(now time.Time, interval time.Duration) {
+	if n := int(now.Sub(s.update) / interval); n > 0 {
+		s.value -= n
+		if s.value < 0 {
+			s.value = 0
+		}
+		s.update = s.update.Add(time.Duration(n) * interval)
+	}
+}
+
+// reputation scores peers by NodeID, so the score is kept after reconnecting
+type reputation struct {
+	*ReputationConfig
+	banner Banner
+	lock   sync.Mutex
+	scores map[discovery.NodeID]*score
+}
+
+func newReputation(cfg *ReputationConfig) *reputation {
+	c := &ReputationConfig{
+		Penalties:       make(map[Offence]int, len(DefaultPenalties)),
+		BanScore:        DefaultBanScore,
+		BanDuration:     DefaultBanDuration,
+		RecoverInterval: DefaultRecoverInterval,
+	}
+
+	for o, penalty := range DefaultPenalties {
+		c.Penalties[o] = penalty
+	}
+
+	if cfg != nil {
+		for o, penalty := range cfg.Penalties {
+			c.Penalties[o] = penalty
+		}
+		if cfg.BanScore > 0 {
+			c.BanScore = cfg.BanScore
+		}
+		if cfg.BanDuration > 0 {
+			c.BanDuration = cfg.BanDuration
+		}
+		if cfg.RecoverInterval > 0 {
+			c.RecoverInterval = cfg.RecoverInterval
+		}
+	}
+
+	return &reputation{
+		ReputationConfig: c,
+		scores:           make(map[discovery.NodeID]*score),
+	}
+}
+
+// punish return true if the peer should be banned
+func (r *reputation) punish(id discovery.NodeID, o Offence) bool {
+	monitor.LogEvent("net/reputation", o.String())
+
+	penalty := r.Penalties[o]
+	if penalty <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	r.lock.Lock()
+	s, ok := r.scores[id]
+	if !ok {
+		if len(r.scores) >= maxScores {
+			r.sweep(now)
+		}
+		s = &score{update: now}
+		r.scores[id] = s
+	}
+
+	s.recover(now, r.RecoverInterval)
+	s.value += penalty
+
+	banned := s.value >= r.BanScore
+	if banned {
+		delete(r.scores, id)
+	}
+	banner := r.banner
+	r.lock.Unlock()
+
+	if banned && banner != nil {
+		monitor.LogEvent("net/reputation", "ban")
+		banner.Ban(id, r.BanDuration)
+	}
+
+	return banned
+}
+
+func (r *reputation) score(id discovery.NodeID) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if s, ok := r.scores[id]; ok {
+		s.recover(time.Now(), r.RecoverInterval)
+		return s.value
+	}
+
+	return 0
+}
+
+func (r *reputation) setBanner(banner Banner) {
+	r.lock.Lock()
+	r.banner = banner
+	r.lock.Unlock()
+}
+
+// must be called with lock
+func (r *reputation) sweep(now time.Time) {
+	for id, s := range r.scores {
+		if s.recover(now, r.RecoverInterval); s.value == 0 {
+			delete(r.scores, id)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package net
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/p2p/discovery"
+)
+
+type mockBanner struct {
+	bans map[discovery.NodeID]time.Duration
+}
+
+func (b *mockBanner) Ban(id discovery.NodeID, duration time.Duration) bool {
+	b.bans[id] = duration
+	return true
+}
+
+func TestReputation_punish(t *testing.T) {
+	r := newReputation(&ReputationConfig{
+		Penalties: map[Offence]int{OffenceTimeout: 30},
+	})
+	banner := &mockBanner{bans: make(map[discovery.NodeID]time.Duration)}
+	r.setBanner(banner)
+
+	if r.Penalties[OffenceInvalidBlock] != DefaultPenalties[OffenceInvalidBlock] || r.Penalties[OffenceTimeout] != 30 {
+		t.Fatalf("wrong penalties: %v", r.Penalties)
+	}
+
+	var id discovery.NodeID
+	rand.Read(id[:])
+
+	for i := 0; i < 3; i++ {
+		if r.punish(id, OffenceTimeout) {
+			t.Fatal("should not be banned")
+		}
+	}
+	if r.score(id) != 90 {
+		t.Fatalf("score should be 90, got %d", r.score(id))
+	}
+
+	if !r.punish(id, OffenceMalformed) {
+		t.Fatal("should be banned")
+	}
+	if banner.bans[id] != DefaultBanDuration {
+		t.Fatal("should be banned by banner")
+	}
+	if r.score(id) != 0 {
+		t.Fatal("score should be reset after banned")
+	}
+}
+
+func TestScore_recover(t *testing.T) {
+	now := time.Now()
+	s := &score{value: 10, update: now}
+
+	s.recover(now.Add(3*time.Minute+time.Second), time.Minute)
+	if s.value != 7 {
+		t.Fatalf("score should be 7, got %d", s.value)
+	}
+
+	s.recover(now.Add(time.Hour), time.Minute)
+	if s.value != 0 {
+		t.Fatalf("score should be 0, got %d", s.value)
+	}
+}
+
+func TestParseOffence(t *testing.T) {
+	for o, name := range offenceNames {
+		if o2, ok := ParseOffence(name); !ok || o2 != o {
+			t.Fatalf("parse %s error", name)
+		}
+	}
+
+	if _, ok := ParseOffence("unknown"); ok {
+		t.Fatal("should be unknown")
+	}
+}
@@ -1,15 +1,17 @@
 package net
 
 import (
+	"errors"
 	"fmt"
-	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/p2p"
-	"github.com/vitelabs/go-vite/p2p/list"
 	"github.com/vitelabs/go-vite/vite/net/message"
 )
 
@@ -40,30 +42,6 @@ func (s reqState) String() string {
 	return reqStatus[s]
 }
 
-type context interface {
-	Add(r Request)
-	Retry(id uint64, err error)
-	FC() *fileClient
-	Get(id uint64) Request
-	Del(id uint64)
-}
-
-type Request interface {
-	Handle(ctx context, msg *p2p.Msg, peer Peer)
-	ID() uint64
-	SetID(id uint64)
-	Run(ctx context)
-	Done(ctx context)
-	Catch(err error)
-	Expired() bool
-	State() reqState
-	Req() Request
-	Band() (from, to uint64)
-	SetBand(from, to uint64)
-	SetPeer(peer Peer)
-	Peer() Peer
-}
-
 type piece interface {
 	band() (from, to uint64)
 	setBand(from, to uint64)
@@ -75,7 +53,6 @@ type blockReceiver interface {
 	catch(piece)
 }
 
-const file2Chunk = 600
 const minSubLedger = 1000
 
 const chunk = 20
@@ -107,6 +84,20 @@ func splitChunk(from, to uint64) (chunks [][2]uint64) {
 
 var chunkTimeout = 20 * time.Second
 
+// sync range is split into chunks of syncChunkSize heights, the boundaries are aligned to syncChunkSize,
+// so the chunks cached before restart can be reused.
+const syncChunkSize = 200
+
+// max chunks requesting at the same time, and from one peer
+const maxPendingChunks = 16
+const maxPeerChunks = 2
+
+// chunks can be downloaded ahead of the next chunk to deliver, limit memory and disk usage
+const maxAheadChunks = 64
+
+var errChunkIncomplete = errors.New("chunk is incomplete")
+var errChunkDiscontinuous = errors.New("blocks of chunk are discontinuous")
+
 // @request for chunk
 type chunkRequest struct {
 	id       uint64
@@ -115,46 +106,90 @@ type chunkRequest struct {
 	state    reqState
 	deadline time.Time
 	msg      *message.GetChunk
-	count    uint64
+	failed   map[string]struct{} // peers failed to respond this chunk
+	sblocks  []*ledger.SnapshotBlock
+	ablocks  []*ledger.AccountBlock
+}
+
+func newChunkRequest(from, to uint64) *chunkRequest {
+	return &chunkRequest{
+		from:   from,
+		to:     to,
+		msg:    &message.GetChunk{Start: from, End: to},
+		failed: make(map[string]struct{}),
+	}
 }
 
 func (c *chunkRequest) setBand(from, to uint64) {
 	c.from, c.to = from, to
+	c.msg = &message.GetChunk{Start: from, End: to}
 }
 
 func (c *chunkRequest) band() (from, to uint64) {
 	return c.from, c.to
 }
 
-type chunkPool struct {
-	lock    sync.RWMutex
-	peers   *peerSet
-	gid     MsgIder
-	queue   *list.List
-	chunks  *sync.Map
-	handler blockReceiver
-	term    chan struct{}
-	wg      sync.WaitGroup
-	recing  int32
-	target  uint64
-	should  bool
+func (c *chunkRequest) String() string {
+	return fmt.Sprintf("chunk<%d-%d>", c.from, c.to)
+}
+
+// verify snapshot blocks of chunk are complete and linked by hash
+func (c *chunkRequest) verify() error {
+	if uint64(len(c.sblocks)) != c.to-c.from+1 {
+		return errChunkIncomplete
+	}
+
+	snapshotblocks(c.sblocks).Sort()
+
+	for i, block := range c.sblocks {
+		if block.Height != c.from+uint64(i) || block.ComputeHash() != block.Hash {
+			return errChunkDiscontinuous
+		}
+		if i > 0 && block.PrevHash != c.sblocks[i-1].Hash {
+			return errChunkDiscontinuous
+		}
+	}
+
+	return nil
 }
 
-func newChunkPool(peers *peerSet, gid MsgIder, handler blockReceiver) *chunkPool {
+// the last height of the aligned chunk which height belongs to
+func chunkEnd(height uint64) uint64 {
+	return (height + syncChunkSize - 1) / syncChunkSize * syncChunkSize
+}
+
+// chunkPool download chunks from multiple peers concurrently, verify and deliver them by height order,
+// failed chunks will be retried on other peers.
+type chunkPool struct {
+	lock     sync.Mutex
+	peers    *peerSet
+	gid      MsgIder
+	queue    []*chunkRequest          // waiting chunks, ordered by from
+	pending  map[uint64]*chunkRequest // requesting chunks, key is message id
+	done     map[uint64]*chunkRequest // verified chunks wait to be delivered, key is from
+	next     uint64                   // next height to deliver
+	prevHash types.Hash               // hash of the block at next - 1
+	prevPeer Peer                     // supplied the block at next - 1, nil if it is the local tip or cached
+	target   uint64
+	cache    *chunkCache // nil if progress is not persisted
+
+	deliverLock sync.Mutex // deliver chunks one by one
+	handler     blockReceiver
+
+	term chan struct{}
+	wg   sync.WaitGroup
+	log  log15.Logger
+}
+
+func newChunkPool(peers *peerSet, gid MsgIder, handler blockReceiver, cache *chunkCache) *chunkPool {
 	return &chunkPool{
 		peers:   peers,
 		gid:     gid,
-		queue:   list.New(),
-		chunks:  new(sync.Map),
+		pending: make(map[uint64]*chunkRequest),
+		done:    make(map[uint64]*chunkRequest),
+		cache:   cache,
 		handler: handler,
-	}
-}
-
-func (p *chunkPool) threshold(current uint64) {
-	if current+500 > p.target {
-		p.should = true
-	} else {
-		p.should = false
+		log:     log15.New("module", "net/chunkPool"),
 	}
 }
 
@@ -163,7 +198,7 @@ func (p *chunkPool) ID() string {
 }
 
 func (p *chunkPool) Cmds() []ViteCmd {
-	return []ViteCmd{SubLedgerCode}
+	return []ViteCmd{SubLedgerCode, ExceptionCode}
 }
 
 func (p *chunkPool) Handle(msg *p2p.Msg, sender Peer) error {
@@ -171,35 +206,101 @@ func (p *chunkPool) Handle(msg *p2p.Msg, sender Peer) error {
 		res := new(message.SubLedger)
 
 		if err := res.Deserialize(msg.Payload); err != nil {
-			netLog.Error(fmt.Sprintf("descerialize %s from %s error: %v", res, sender.RemoteAddr(), err))
-			p.retry(msg.Id)
+			p.log.Error(fmt.Sprintf("descerialize %s from %s error: %v", res, sender.RemoteAddr(), err))
+			p.fail(msg.Id, sender)
 			return err
 		}
 
-		netLog.Info(fmt.Sprintf("receive %s from %s", res, sender.RemoteAddr()))
+		p.log.Info(fmt.Sprintf("receive %s from %s", res, sender.RemoteAddr()))
 
-		// receive account blocks first
-		for _, block := range res.ABlocks {
-			p.handler.receiveAccountBlock(block)
-		}
+		p.receive(msg.Id, res, sender)
+	} else {
+		p.fail(msg.Id, sender)
+	}
 
-		for _, block := range res.SBlocks {
-			p.handler.receiveSnapshotBlock(block)
+	return nil
+}
+
+// reset the pool to sync from next to target, cached chunks will not be requested again
+func (p *chunkPool) reset(next uint64, prevHash types.Hash, target uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.queue = nil
+	p.pending = make(map[uint64]*chunkRequest)
+	p.done = make(map[uint64]*chunkRequest)
+	p.next = next
+	p.prevHash = prevHash
+	p.prevPeer = nil
+	p.target = 0
+
+	if p.cache != nil {
+		for _, c := range p.cache.load(next) {
+			p.done[c.from] = c
 		}
+	}
+
+	p.extend(target)
+}
+
+// setTarget append chunks if target grows, drop chunks out of target if target falls
+func (p *chunkPool) setTarget(target uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
 
-		c := p.chunk(msg.Id)
-		if c != nil {
-			c.count += uint64(len(res.SBlocks))
+	if target >= p.target {
+		p.extend(target)
+		return
+	}
+
+	p.target = target
 
-			if c.count >= c.to-c.from+1 {
-				p.done(msg.Id)
+	queue := p.queue[:0]
+	for _, c := range p.queue {
+		if c.from <= target {
+			if c.to > target {
+				c.setBand(c.from, target)
 			}
+			queue = append(queue, c)
 		}
-	} else {
-		p.retry(msg.Id)
 	}
+	p.queue = queue
 
-	return nil
+	for id, c := range p.pending {
+		if c.from > target {
+			delete(p.pending, id)
+		}
+	}
+}
+
+// must be called with lock
+func (p *chunkPool) extend(target uint64) {
+	from := p.target + 1
+	if p.target == 0 {
+		// chunk boundaries are aligned, the first chunk may contain blocks lower than next
+		from = chunkEnd(p.next) - syncChunkSize + 1
+	} else if last := len(p.queue) - 1; last >= 0 && p.queue[last].to == p.target && p.queue[last].to < chunkEnd(p.queue[last].from) {
+		// the last chunk is not full, fill it
+		from = p.queue[last].from
+		p.queue = p.queue[:last]
+	}
+
+	for from <= target {
+		if c, ok := p.done[from]; ok {
+			from = c.to + 1
+			continue
+		}
+
+		to := chunkEnd(from)
+		if to > target {
+			to = target
+		}
+
+		p.queue = append(p.queue, newChunkRequest(from, to))
+		from = to + 1
+	}
+
+	p.target = target
 }
 
 func (p *chunkPool) start() {
@@ -207,9 +308,6 @@ func (p *chunkPool) start() {
 
 	p.wg.Add(1)
 	common.Go(p.loop)
-
-	p.wg.Add(1)
-	common.Go(p.taskLoop)
 }
 
 func (p *chunkPool) stop() {
@@ -225,178 +323,274 @@ func (p *chunkPool) stop() {
 	}
 }
 
-func (p *chunkPool) taskLoop() {
+func (p *chunkPool) loop() {
 	defer p.wg.Done()
 
 	ticker := time.NewTicker(200 * time.Millisecond)
 	defer ticker.Stop()
 
-loop:
+	var timeout []*chunkRequest
+
 	for {
 		select {
 		case <-p.term:
-			break loop
+			return
 
-		case <-ticker.C:
-			if !p.should {
-				break
+		case now := <-ticker.C:
+			timeout = timeout[:0]
+
+			p.lock.Lock()
+			for id, c := range p.pending {
+				if now.After(c.deadline) {
+					delete(p.pending, id)
+					timeout = append(timeout, c)
+				}
 			}
+			p.lock.Unlock()
 
-			if ele := p.queue.Shift(); ele != nil {
-				c := ele.(*chunkRequest)
-				p.chunks.Store(c.id, c)
-				p.request(c)
+			for _, c := range timeout {
+				p.log.Warn(fmt.Sprintf("%s from %s timeout", c, c.peer.RemoteAddr()))
+				c.peer.Report(OffenceTimeout)
+				p.retry(c)
 			}
+
+			p.schedule()
 		}
 	}
-
-	p.chunks.Range(func(key, value interface{}) bool {
-		p.chunks.Delete(key)
-		return true
-	})
 }
 
-func (p *chunkPool) loop() {
-	defer p.wg.Done()
+// schedule request waiting chunks from the least busy peers
+func (p *chunkPool) schedule() {
+	var requests []*chunkRequest
 
-	ticker := time.NewTicker(chunkTimeout)
-	defer ticker.Stop()
+	p.lock.Lock()
 
-	var state reqState
-	var id uint64
-	var c *chunkRequest
+	busy := make(map[string]int)
+	for _, c := range p.pending {
+		busy[c.peer.ID()]++
+	}
 
-loop:
-	for {
-		select {
-		case <-p.term:
-			break loop
+	for len(p.pending) < maxPendingChunks && len(p.queue) > 0 {
+		c := p.queue[0]
+		if c.from >= p.next+maxAheadChunks*syncChunkSize {
+			break
+		}
 
-		case now := <-ticker.C:
-			p.chunks.Range(func(key, value interface{}) bool {
-				id, c = key.(uint64), value.(*chunkRequest)
-				state = c.state
-				if state == reqPending && now.After(c.deadline) {
-					p.retry(id)
-				}
-				return true
-			})
+		peers := p.peers.Pick(c.to)
+		if len(peers) == 0 {
+			// no peers can serve the chunk, maybe the target is too high
+			p.lock.Unlock()
+			p.handler.catch(c)
+			p.lock.Lock()
+			break
 		}
-	}
-}
 
-func (p *chunkPool) chunk(id uint64) *chunkRequest {
-	v, ok := p.chunks.Load(id)
+		var best *peer
+		for _, pr := range peers {
+			if _, ok := c.failed[pr.ID()]; ok {
+				continue
+			}
+			if best == nil || busy[pr.ID()] < busy[best.ID()] {
+				best = pr
+			}
+		}
 
-	if ok {
-		return v.(*chunkRequest)
-	}
+		if best == nil {
+			// all peers have failed, try them again
+			c.failed = make(map[string]struct{})
+			continue
+		}
 
-	return nil
-}
+		if busy[best.ID()] >= maxPeerChunks {
+			break
+		}
 
-func (p *chunkPool) add(from, to uint64) {
-	cs := splitChunk(from, to)
+		busy[best.ID()]++
 
-	for _, chunk := range cs {
-		c := &chunkRequest{from: chunk[0], to: chunk[1]}
+		p.queue = p.queue[1:]
 		c.id = p.gid.MsgID()
-		p.queue.Append(c)
-		c.msg = &message.GetChunk{
-			Start: c.from,
-			End:   c.to,
+		c.peer = best
+		c.state = reqPending
+		c.deadline = time.Now().Add(chunkTimeout)
+		p.pending[c.id] = c
+
+		requests = append(requests, c)
+	}
+
+	p.lock.Unlock()
+
+	for _, c := range requests {
+		if err := c.peer.Send(GetChunkCode, c.id, c.msg); err != nil {
+			p.fail(c.id, c.peer)
+		} else {
+			p.log.Info(fmt.Sprintf("request %s from %s", c, c.peer.RemoteAddr()))
 		}
 	}
 }
 
-func (p *chunkPool) exec(from, to uint64) {
-	cs := splitChunk(from, to)
+func (p *chunkPool) receive(id uint64, res *message.SubLedger, sender Peer) {
+	p.lock.Lock()
+	c, ok := p.pending[id]
+	if !ok {
+		p.lock.Unlock()
+		return
+	}
+	// message id is predictable, only the peer requested can respond
+	if c.peer == nil || c.peer.ID() != sender.ID() {
+		p.lock.Unlock()
+		p.log.Warn(fmt.Sprintf("%s is not requested from %s", c, sender.RemoteAddr()))
+		return
+	}
+
+	c.sblocks = append(c.sblocks, res.SBlocks...)
+	c.ablocks = append(c.ablocks, res.ABlocks...)
 
-	for _, chunk := range cs {
-		c := &chunkRequest{from: chunk[0], to: chunk[1]}
-		c.id = p.gid.MsgID()
-		c.msg = &message.GetChunk{
-			Start: c.from,
-			End:   c.to,
+	if uint64(len(c.sblocks)) < c.to-c.from+1 {
+		p.lock.Unlock()
+		return
+	}
+
+	delete(p.pending, id)
+	p.lock.Unlock()
+
+	if err := c.verify(); err != nil {
+		p.log.Error(fmt.Sprintf("verify %s from %s error: %v", c, sender.RemoteAddr(), err))
+		sender.Report(OffenceInvalidBlock)
+		p.retry(c)
+		return
+	}
+
+	if p.cache != nil {
+		if err := p.cache.save(c); err != nil {
+			p.log.Error(fmt.Sprintf("cache %s error: %v", c, err))
 		}
-		p.request(c)
 	}
+
+	p.lock.Lock()
+	c.state = reqRespond
+	p.done[c.from] = c
+	p.lock.Unlock()
+
+	p.deliver()
 }
 
-func (p *chunkPool) done(id uint64) {
-	if _, ok := p.chunks.Load(id); ok {
-		p.chunks.Delete(id)
+// fail retry the chunk on other peers
+func (p *chunkPool) fail(id uint64, sender Peer) {
+	p.lock.Lock()
+	c, ok := p.pending[id]
+	if ok && c.peer != nil && c.peer.ID() != sender.ID() {
+		ok = false
+	} else {
+		delete(p.pending, id)
+	}
+	p.lock.Unlock()
+
+	if ok {
+		p.log.Warn(fmt.Sprintf("%s from %s failed", c, sender.RemoteAddr()))
+		p.retry(c)
 	}
 }
 
-func (p *chunkPool) request(c *chunkRequest) {
-	if c.peer == nil {
-		peers := p.peers.Pick(c.to)
-		if len(peers) == 0 {
-			p.catch(c)
-			return
-		}
-		c.peer = peers[rand.Intn(len(peers))]
+func (p *chunkPool) retry(c *chunkRequest) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if c.from > p.target {
+		return
 	}
 
-	p.target = c.to
-	p.do(c)
+	if c.peer != nil {
+		c.failed[c.peer.ID()] = struct{}{}
+	}
+	c.peer = nil
+	c.state = reqWaiting
+	c.sblocks = nil
+	c.ablocks = nil
+
+	// keep queue ordered
+	i := sort.Search(len(p.queue), func(i int) bool {
+		return p.queue[i].from > c.from
+	})
+	p.queue = append(p.queue, nil)
+	copy(p.queue[i+1:], p.queue[i:])
+	p.queue[i] = c
 }
 
-func (p *chunkPool) retry(id uint64) {
-	v, ok := p.chunks.Load(id)
+// deliver chunks continuous with the delivered blocks to handler
+func (p *chunkPool) deliver() {
+	p.deliverLock.Lock()
+	defer p.deliverLock.Unlock()
+
+	for {
+		p.lock.Lock()
+		var c *chunkRequest
+		for _, d := range p.done {
+			if d.from <= p.next && d.to >= p.next {
+				c = d
+				break
+			}
+		}
 
-	if ok {
-		c := v.(*chunkRequest)
 		if c == nil {
+			p.lock.Unlock()
 			return
 		}
 
-		old := c.peer
-		c.peer = nil
-
-		peers := p.peers.Pick(c.to)
-		if len(peers) > 0 {
-			for _, peer := range peers {
-				if peer != old {
-					c.peer = peer
-					break
-				}
-			}
-		}
+		delete(p.done, c.from)
 
-		if c.peer == nil {
-			p.catch(c)
+		// the first block to deliver must follow the delivered blocks
+		i := p.next - c.from
+		var prev types.Hash
+		if i == 0 {
+			prev = c.sblocks[0].PrevHash
 		} else {
-			p.do(c)
+			prev = c.sblocks[i-1].Hash
 		}
-	}
-}
 
-func (p *chunkPool) catch(c *chunkRequest) {
-	c.state = reqError
-	p.handler.catch(c)
-}
+		if p.prevHash != types.ZERO_HASH && prev != p.prevHash {
+			// cached chunk maybe stale, request it again.
+			// the peer is punished only if it supplied the blocks on both sides of the gap
+			if c.peer == nil || (p.prevPeer != nil && p.prevPeer.ID() == c.peer.ID()) {
+				p.lock.Unlock()
 
-func (p *chunkPool) do(c *chunkRequest) {
-	c.deadline = time.Now().Add(chunkTimeout)
-	c.state = reqPending
-	c.peer.Send(GetChunkCode, c.id, c.msg)
-}
+				p.log.Error(fmt.Sprintf("%s is not continuous with height %d", c, p.next-1))
+				if p.cache != nil {
+					p.cache.remove(c)
+				}
+				if c.peer != nil {
+					c.peer.Report(OffenceInvalidBlock)
+				}
+				p.retry(c)
+				return
+			}
 
-// helper
-type files []*ledger.CompressedFileMeta
+			// the local tip or the previous chunk from another peer is on another fork,
+			// deliver the chunk to the block pool, it will choose the fork or roll back.
+			p.log.Warn(fmt.Sprintf("%s from %s forks at height %d", c, c.peer.RemoteAddr(), p.next-1))
+		}
 
-func (f files) Len() int {
-	return len(f)
-}
+		p.next = c.to + 1
+		p.prevHash = c.sblocks[len(c.sblocks)-1].Hash
+		p.prevPeer = c.peer
+		c.state = reqDone
+		p.lock.Unlock()
 
-func (f files) Less(i, j int) bool {
-	return f[i].StartHeight < f[j].StartHeight
+		// receive account blocks first
+		for _, block := range c.ablocks {
+			p.handler.receiveAccountBlock(block)
+		}
+
+		for _, block := range c.sblocks[i:] {
+			p.handler.receiveSnapshotBlock(block)
+		}
+	}
 }
 
-func (f files) Swap(i, j int) {
-	f[i], f[j] = f[j], f[i]
+// threshold remove cached chunks have been inserted into chain
+func (p *chunkPool) threshold(current uint64) {
+	if p.cache != nil {
+		p.cache.clean(current)
+	}
 }
 
 // helper
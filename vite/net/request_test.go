@@ -5,6 +5,10 @@ import (
 	"math/rand"
 	"testing"
 	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vite/net/message"
 )
 
 func init() {
@@ -154,3 +158,210 @@ func TestSplitChunkMini(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func mockSnapshotChain(from, to uint64, prev types.Hash, state types.Hash) (blocks []*ledger.SnapshotBlock) {
+	now := time.Unix(time.Now().Unix(), 0)
+
+	for height := from; height <= to; height++ {
+		block := &ledger.SnapshotBlock{
+			PrevHash:  prev,
+			Height:    height,
+			Timestamp: &now,
+			StateHash: state,
+		}
+		block.Hash = block.ComputeHash()
+		prev = block.Hash
+
+		blocks = append(blocks, block)
+	}
+
+	return
+}
+
+func mockChunk(blocks []*ledger.SnapshotBlock) *chunkRequest {
+	c := newChunkRequest(blocks[0].Height, blocks[len(blocks)-1].Height)
+	c.sblocks = blocks
+	return c
+}
+
+type mockBlockReceiver struct {
+	heights []uint64
+}
+
+func (m *mockBlockReceiver) receiveSnapshotBlock(block *ledger.SnapshotBlock) {
+	m.heights = append(m.heights, block.Height)
+}
+
+func (m *mockBlockReceiver) receiveAccountBlock(block *ledger.AccountBlock) {}
+
+func (m *mockBlockReceiver) catch(piece) {}
+
+func TestChunkRequest_Verify(t *testing.T) {
+	blocks := mockSnapshotChain(1, 10, types.Hash{}, types.Hash{})
+
+	// disordered blocks will be sorted
+	c := mockChunk(append([]*ledger.SnapshotBlock{blocks[9]}, blocks[:9]...))
+	c.from, c.to = 1, 10
+	if err := c.verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	c = mockChunk(blocks[:9])
+	c.to = 10
+	if err := c.verify(); err != errChunkIncomplete {
+		t.Fatalf("chunk should be incomplete: %v", err)
+	}
+
+	fork := mockSnapshotChain(6, 10, types.Hash{}, types.Hash{1})
+	c = mockChunk(append(blocks[:5:5], fork...))
+	if err := c.verify(); err != errChunkDiscontinuous {
+		t.Fatalf("chunk should be discontinuous: %v", err)
+	}
+}
+
+func TestChunkPool_Target(t *testing.T) {
+	p := newChunkPool(newPeerSet(), new(gid), &mockBlockReceiver{}, nil)
+
+	check := func(bands [][2]uint64) {
+		if len(p.queue) != len(bands) {
+			t.Fatalf("should be %d chunks, but %d", len(bands), len(p.queue))
+		}
+		for i, c := range p.queue {
+			if c.from != bands[i][0] || c.to != bands[i][1] {
+				t.Fatalf("chunk %d should be %d-%d, but %s", i, bands[i][0], bands[i][1], c)
+			}
+		}
+	}
+
+	// aligned boundaries
+	p.reset(1050, types.Hash{}, 1500)
+	check([][2]uint64{{1001, 1200}, {1201, 1400}, {1401, 1500}})
+
+	// fill the last chunk
+	p.setTarget(1700)
+	check([][2]uint64{{1001, 1200}, {1201, 1400}, {1401, 1600}, {1601, 1700}})
+
+	p.setTarget(1450)
+	check([][2]uint64{{1001, 1200}, {1201, 1400}, {1401, 1450}})
+}
+
+func TestChunkPool_Deliver(t *testing.T) {
+	handler := &mockBlockReceiver{}
+	p := newChunkPool(newPeerSet(), new(gid), handler, nil)
+
+	blocks := mockSnapshotChain(1, 600, types.Hash{}, types.Hash{})
+	head := blocks[49]
+	p.reset(head.Height+1, head.Hash, 600)
+
+	// later chunks wait for the previous one
+	p.done[401] = mockChunk(blocks[400:600])
+	p.done[201] = mockChunk(blocks[200:400])
+	p.deliver()
+	if len(handler.heights) != 0 {
+		t.Fatal("chunks should not be delivered before the previous one")
+	}
+
+	// chunk of another fork is retried
+	fork := mockSnapshotChain(1, 200, types.Hash{}, types.Hash{1})
+	p.done[1] = mockChunk(fork)
+	p.deliver()
+	if len(handler.heights) != 0 || len(p.done) != 2 || p.queue[0].from != 1 {
+		t.Fatal("chunk of another fork should be retried")
+	}
+
+	// blocks lower than next are skipped
+	p.done[1] = mockChunk(blocks[:200])
+	p.deliver()
+	if len(handler.heights) != 550 || len(p.done) != 0 {
+		t.Fatalf("should deliver 550 blocks, but %d", len(handler.heights))
+	}
+	for i, height := range handler.heights {
+		if height != head.Height+1+uint64(i) {
+			t.Fatalf("blocks should be delivered by height order: %d at %d", height, i)
+		}
+	}
+}
+
+type chunkPeer struct {
+	mock_Peer
+	id      string
+	reports []error
+}
+
+func (p *chunkPeer) ID() string {
+	return p.id
+}
+
+func (p *chunkPeer) Report(err error) {
+	p.reports = append(p.reports, err)
+}
+
+func TestChunkPool_DeliverFork(t *testing.T) {
+	handler := &mockBlockReceiver{}
+	p := newChunkPool(newPeerSet(), new(gid), handler, nil)
+	p1, p2 := &chunkPeer{id: "1"}, &chunkPeer{id: "2"}
+
+	blocks := mockSnapshotChain(1, 600, types.Hash{}, types.Hash{})
+	local := mockSnapshotChain(1, 1, types.Hash{}, types.Hash{1})[0]
+	p.reset(1, local.Hash, 600)
+
+	// the local tip is on another fork, the chunk is delivered to the pool
+	c := mockChunk(blocks[:200])
+	c.peer = p1
+	p.done[1] = c
+	p.deliver()
+	if len(handler.heights) != 200 || len(p1.reports) != 0 {
+		t.Fatalf("chunk forks from the local tip should be delivered, got %d blocks, reports %v", len(handler.heights), p1.reports)
+	}
+
+	// the previous chunk is from another peer, can't tell which one is wrong
+	fork := mockSnapshotChain(201, 400, types.Hash{}, types.Hash{1})
+	c = mockChunk(fork)
+	c.peer = p2
+	p.done[201] = c
+	p.deliver()
+	if len(handler.heights) != 400 || len(p2.reports) != 0 {
+		t.Fatalf("chunk forks from another peer should be delivered, got %d blocks, reports %v", len(handler.heights), p2.reports)
+	}
+
+	// gap between the chunks supplied by the same peer
+	c = mockChunk(blocks[400:600])
+	c.peer = p2
+	p.done[401] = c
+	p.deliver()
+	if len(handler.heights) != 400 || len(p2.reports) != 1 || p2.reports[0] != OffenceInvalidBlock {
+		t.Fatalf("peer should be punished for the gap, reports %v", p2.reports)
+	}
+	var retried bool
+	for _, q := range p.queue {
+		retried = retried || q == c
+	}
+	if _, failed := c.failed[p2.id]; !retried || !failed {
+		t.Fatal("chunk should be retried on other peers")
+	}
+}
+
+func TestChunkPool_ReceiveSender(t *testing.T) {
+	p := newChunkPool(newPeerSet(), new(gid), &mockBlockReceiver{}, nil)
+	p1, p2 := &chunkPeer{id: "1"}, &chunkPeer{id: "2"}
+
+	blocks := mockSnapshotChain(1, 200, types.Hash{}, types.Hash{})
+	p.reset(1, types.Hash{}, 200)
+	c := p.queue[0]
+	p.queue = p.queue[1:]
+	c.id = 10
+	c.peer = p1
+	p.pending[c.id] = c
+
+	res := &message.SubLedger{SBlocks: blocks}
+	p.receive(c.id, res, p2)
+	p.fail(c.id, p2)
+	if p.pending[c.id] != c || len(c.sblocks) != 0 {
+		t.Fatal("response from another peer should be ignored")
+	}
+
+	p.receive(c.id, res, p1)
+	if len(p.pending) != 0 || p.next != 201 {
+		t.Fatalf("chunk should be delivered, next %d", p.next)
+	}
+}
@@ -0,0 +1,230 @@
+package net
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/p2p"
+	"github.com/vitelabs/go-vite/trie"
+	"github.com/vitelabs/go-vite/vite/net/message"
+)
+
+// trie nodes and ref values requested from one peer at a time
+const fetchTrieBatch = maxTrieNodes / 2
+
+// rounds without any progress before fetching gives up
+const fetchMaxRetry = 5
+
+var fetchTimeout = 20 * time.Second
+
+var errFetchPeers = errors.New("not enough peers to fetch from")
+var errFetchMissing = errors.New("peer is missing the requested data")
+var errFetchTimeout = errors.New("fetch request timeout")
+var errFetchStalled = errors.New("fetch has no progress")
+var errFetchCanceled = errors.New("fetch canceled")
+
+type fetchResponse struct {
+	msg    *p2p.Msg
+	sender Peer
+}
+
+// stateFetcher requests the snapshot headers, the account state proofs and the storage tries
+// the light client needs to elect producers, responses are matched to the requests by msg id.
+type stateFetcher struct {
+	chain Chain
+	peers *peerSet
+	gid   MsgIder
+
+	lock    sync.Mutex
+	pending map[uint64]chan *fetchResponse // by msg id
+
+	term <-chan struct{}
+	log  log15.Logger
+}
+
+func newStateFetcher(chain Chain, peers *peerSet, gid MsgIder, term <-chan struct{}) *stateFetcher {
+	return &stateFetcher{
+		chain:   chain,
+		peers:   peers,
+		gid:     gid,
+		pending: make(map[uint64]chan *fetchResponse),
+		term:    term,
+		log:     log15.New("module", "net/stateFetcher"),
+	}
+}
+
+// handle return false if msg is not responded to the fetcher
+func (f *stateFetcher) handle(msg *p2p.Msg, sender Peer) bool {
+	f.lock.Lock()
+	ch, ok := f.pending[msg.Id]
+	f.lock.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	select {
+	case ch <- &fetchResponse{msg, sender}:
+	default:
+		// duplicated response
+	}
+
+	return true
+}
+
+// request send payload to p and wait for the response of code
+func (f *stateFetcher) request(p *peer, code ViteCmd, payload p2p.Serializable, resCode ViteCmd) (*p2p.Msg, error) {
+	id := f.gid.MsgID()
+	ch := make(chan *fetchResponse, 1)
+
+	f.lock.Lock()
+	f.pending[id] = ch
+	f.lock.Unlock()
+
+	defer func() {
+		f.lock.Lock()
+		delete(f.pending, id)
+		f.lock.Unlock()
+	}()
+
+	if err := p.Send(code, id, payload); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(fetchTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-ch:
+		switch ViteCmd(res.msg.Cmd) {
+		case resCode:
+			return res.msg, nil
+		case ExceptionCode:
+			return nil, errFetchMissing
+		default:
+			p.Report(OffenceMalformed)
+			return nil, fmt.Errorf("unexpected response %s of %s", ViteCmd(res.msg.Cmd), code)
+		}
+	case <-timer.C:
+		p.Report(OffenceTimeout)
+		return nil, errFetchTimeout
+	case <-f.term:
+		return nil, errFetchCanceled
+	}
+}
+
+func (f *stateFetcher) canceled() bool {
+	select {
+	case <-f.term:
+		return true
+	default:
+		return false
+	}
+}
+
+type trieBatch struct {
+	peer   *peer
+	nodes  []types.Hash
+	values []types.Hash
+	res    *message.TrieNodes
+	err    error
+}
+
+// syncTrie download the trie scheduled by s from the peers not lower than height
+func (f *stateFetcher) syncTrie(s *trie.Sync, height uint64) error {
+	stalled := 0
+	for s.Pending() > 0 {
+		if f.canceled() {
+			return errFetchCanceled
+		}
+
+		var batches []*trieBatch
+		for _, p := range f.peers.Pick(height) {
+			nodes, values := s.Missing(fetchTrieBatch)
+			if len(nodes) == 0 && len(values) == 0 {
+				break
+			}
+			batches = append(batches, &trieBatch{peer: p, nodes: nodes, values: values})
+		}
+
+		if len(batches) == 0 {
+			return errFetchPeers
+		}
+
+		var wg sync.WaitGroup
+		for _, b := range batches {
+			wg.Add(1)
+			go func(b *trieBatch) {
+				defer wg.Done()
+				b.res, b.err = f.fetchTrieNodes(b.peer, b.nodes, b.values)
+			}(b)
+		}
+		wg.Wait()
+
+		progress := false
+		for _, b := range batches {
+			if b.err == nil {
+				progress = f.processTrieNodes(s, b) || progress
+			} else {
+				f.log.Warn(fmt.Sprintf("fetch %d trie nodes from %s error: %v", len(b.nodes)+len(b.values), b.peer.RemoteAddr(), b.err))
+			}
+
+			// hashes not received will be requested again
+			s.Requeue(b.nodes, b.values)
+		}
+
+		if err := s.Commit(); err != nil {
+			return err
+		}
+
+		if progress {
+			stalled = 0
+		} else if stalled++; stalled >= fetchMaxRetry {
+			return errFetchStalled
+		}
+	}
+
+	return nil
+}
+
+func (f *stateFetcher) fetchTrieNodes(p *peer, nodes, values []types.Hash) (*message.TrieNodes, error) {
+	msg, err := f.request(p, GetTrieNodesCode, &message.GetTrieNodes{Nodes: nodes, Values: values}, TrieNodesCode)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(message.TrieNodes)
+	if err = res.Deserialize(msg.Payload); err != nil {
+		p.Report(OffenceMalformed)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// processTrieNodes return true if any node is accepted, peer responds unrequested nodes will be punished
+func (f *stateFetcher) processTrieNodes(s *trie.Sync, b *trieBatch) (progress bool) {
+	for _, data := range b.res.Nodes {
+		if _, err := s.ProcessNode(data); err != nil {
+			f.log.Warn(fmt.Sprintf("process trie node from %s error: %v", b.peer.RemoteAddr(), err))
+			b.peer.Report(OffenceMalformed)
+			return
+		}
+		progress = true
+	}
+
+	for _, value := range b.res.Values {
+		if _, err := s.ProcessValue(value); err != nil {
+			f.log.Warn(fmt.Sprintf("process trie ref value from %s error: %v", b.peer.RemoteAddr(), err))
+			b.peer.Report(OffenceMalformed)
+			return
+		}
+		progress = true
+	}
+
+	return
+}
@@ -0,0 +1,39 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/p2p"
+)
+
+func TestStateFetcher_Handle(t *testing.T) {
+	term := make(chan struct{})
+	f := newStateFetcher(nil, newPeerSet(), new(gid), term)
+
+	if f.handle(&p2p.Msg{Cmd: p2p.Cmd(TrieNodesCode), Id: 1}, nil) {
+		t.Fatal("unrequested message should not be handled by the fetcher")
+	}
+
+	ch := make(chan *fetchResponse, 1)
+	f.pending[2] = ch
+
+	if !f.handle(&p2p.Msg{Cmd: p2p.Cmd(TrieNodesCode), Id: 2}, nil) {
+		t.Fatal("requested message should be handled by the fetcher")
+	}
+	// duplicated response is dropped
+	if !f.handle(&p2p.Msg{Cmd: p2p.Cmd(TrieNodesCode), Id: 2}, nil) {
+		t.Fatal("requested message should be handled by the fetcher")
+	}
+
+	if res := <-ch; res.msg.Id != 2 {
+		t.Fatalf("wrong response %d", res.msg.Id)
+	}
+
+	if f.canceled() {
+		t.Fatal("fetcher is not canceled")
+	}
+	close(term)
+	if !f.canceled() {
+		t.Fatal("fetcher should be canceled")
+	}
+}
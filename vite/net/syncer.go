@@ -99,15 +99,15 @@ type syncer struct {
 	chain      Chain // query latest block
 	pEvent     chan *peerEvent
 	receiver   Receiver
-	fc         *fileClient
 	pool       *chunkPool
-	chunked    int32
+	cache      *chunkCache // nil if progress is not persisted
 	running    int32
 	term       chan struct{}
 	log        log15.Logger
 }
 
-func newSyncer(chain Chain, peers *peerSet, gid MsgIder, receiver Receiver) *syncer {
+// newSyncer persist sync progress in dir, progress will not be persisted if dir is empty
+func newSyncer(chain Chain, peers *peerSet, gid MsgIder, receiver Receiver, dir string) *syncer {
 	s := &syncer{
 		state:      SyncNotStart,
 		term:       make(chan struct{}),
@@ -123,11 +123,15 @@ func newSyncer(chain Chain, peers *peerSet, gid MsgIder, receiver Receiver) *syn
 	// subscribe peer add/del event
 	peers.Sub(s.pEvent)
 
-	pool := newChunkPool(peers, gid, s)
-	fc := newFileClient(chain, pool, s)
+	if dir != "" {
+		if cache, err := newChunkCache(dir); err == nil {
+			s.cache = cache
+		} else {
+			s.log.Error(fmt.Sprintf("sync progress will not be persisted: %v", err))
+		}
+	}
 
-	s.pool = pool
-	s.fc = fc
+	s.pool = newChunkPool(peers, gid, s, s.cache)
 
 	return s
 }
@@ -139,7 +143,6 @@ func (s *syncer) Stop() {
 		s.peers.UnSub(s.pEvent)
 		close(s.term)
 		s.pool.stop()
-		s.fc.stop()
 	}
 }
 
@@ -149,11 +152,7 @@ func (s *syncer) Start() {
 	}
 
 	defer atomic.StoreInt32(&s.running, 0)
-	defer atomic.StoreInt32(&s.chunked, 0)
 
-	// prepare to request file
-	s.fc.start()
-	defer s.fc.stop()
 	// stop chunk pool
 	defer s.pool.stop()
 
@@ -188,8 +187,8 @@ wait:
 
 	// compare snapshot chain height
 	current := s.chain.GetLatestSnapshotBlock()
-	// p is not all enough, no need to sync
-	if current.Height+minSubLedger > p.height {
+	// p is not all enough, no need to sync, unless there is an unfinished sync before restart
+	if current.Height >= p.height || (current.Height+minSubLedger > p.height && !s.unfinished(current.Height)) {
 		if current.Height < p.height {
 			p.Send(GetSnapshotBlocksCode, 0, &message.GetSnapshotBlocks{
 				From:    ledger.HashHeight{Hash: p.head},
@@ -199,7 +198,7 @@ wait:
 		}
 
 		s.log.Info(fmt.Sprintf("sync done: bestPeer %s at %d, our height: %d", p.RemoteAddr(), p.height, current.Height))
-		s.setState(Syncdone)
+		s.done()
 		return
 	}
 
@@ -208,7 +207,7 @@ wait:
 	s.total = s.to - s.from + 1
 	s.count = 0
 	s.setState(Syncing)
-	s.sync()
+	s.sync(current)
 
 	// check chain grow timeout
 	var timeoutChan <-chan time.Time
@@ -230,7 +229,7 @@ wait:
 						} else {
 							// no need sync
 							s.log.Info(fmt.Sprintf("no need sync to bestPeer %s at %d, our height: %d", targetPeer, targetPeer.height, current.Height))
-							s.setState(Syncdone)
+							s.done()
 							return
 						}
 					} else {
@@ -256,11 +255,10 @@ wait:
 			current := s.chain.GetLatestSnapshotBlock()
 			if current.Height >= s.to {
 				s.log.Info(fmt.Sprintf("sync done, current height: %d", current.Height))
-				s.setState(Syncdone)
+				s.done()
 				return
 			}
 
-			s.fc.threshold(current.Height)
 			s.pool.threshold(current.Height)
 			s.log.Debug(fmt.Sprintf("current height: %d", current.Height))
 
@@ -281,6 +279,9 @@ func (s *syncer) setTarget(to uint64) {
 	atomic.StoreUint64(&s.total, to-s.from+1)
 	atomic.StoreUint64(&s.to, to)
 
+	s.pool.setTarget(to)
+	s.saveTarget(to)
+
 	if s.count >= s.total {
 		select {
 		case s.downloaded <- struct{}{}:
@@ -303,31 +304,34 @@ func (s *syncer) inc() {
 	}
 }
 
-func (s *syncer) sync() {
-	peerList := s.peers.Pick(s.from + 1)
+// sync split blocks from current to target into chunks, download them from multiple peers
+func (s *syncer) sync(current *ledger.SnapshotBlock) {
+	s.saveTarget(s.to)
+	s.pool.reset(s.from, current.Hash, s.to)
+	s.pool.start()
 
-	var msg *message.GetSubLedger
+	s.log.Info(fmt.Sprintf("sync from %d to %d", s.from, s.to))
+}
 
-	from, to := s.from, s.to
-	var pTo, pHeight uint64
-	for _, peer := range peerList {
-		pHeight = peer.Height()
-		if pHeight > to {
-			pTo = to
-		} else {
-			pTo = pHeight
-		}
+// unfinished return true if there is an unfinished sync persisted
+func (s *syncer) unfinished(current uint64) bool {
+	return s.cache != nil && s.cache.target() > current
+}
 
-		msg = &message.GetSubLedger{
-			From:    ledger.HashHeight{Height: from},
-			Count:   pTo - from + 1,
-			Forward: true,
+func (s *syncer) saveTarget(to uint64) {
+	if s.cache != nil {
+		if err := s.cache.setTarget(to); err != nil {
+			s.log.Error(fmt.Sprintf("save sync target %d error: %v", to, err))
 		}
+	}
+}
 
-		peer.Send(GetSubLedgerCode, 0, msg)
-
-		s.log.Info(fmt.Sprintf("sync from %d to %d to %s at %d", from, pTo, peer.RemoteAddr(), peer.Height()))
+// done clear the persisted progress
+func (s *syncer) done() {
+	if s.cache != nil {
+		s.cache.clear()
 	}
+	s.setState(Syncdone)
 }
 
 func (s *syncer) ID() string {
@@ -335,43 +339,14 @@ func (s *syncer) ID() string {
 }
 
 func (s *syncer) Cmds() []ViteCmd {
-	return []ViteCmd{FileListCode, SubLedgerCode, ExceptionCode}
+	return []ViteCmd{SubLedgerCode, ExceptionCode}
 }
 
 func (s *syncer) Handle(msg *p2p.Msg, sender Peer) error {
-	cmd := ViteCmd(msg.Cmd)
-	if cmd == FileListCode {
-		res := new(message.FileList)
-
-		if err := res.Deserialize(msg.Payload); err != nil {
-			s.log.Error(fmt.Sprintf("descerialize %s from %s error: %v", res, sender.RemoteAddr(), err))
-			return err
-		}
-
-		s.log.Info(fmt.Sprintf("receive %s from %s", res, sender.RemoteAddr()))
-
-		if len(res.Files) > 0 {
-			s.fc.gotFiles(res.Files, sender)
-		}
-
-		if sender.Height() >= s.to && len(res.Chunks) > 0 {
-			if atomic.CompareAndSwapInt32(&s.chunked, 0, 1) {
-				for _, c := range res.Chunks {
-					if len(c) == 2 && c[1] > 0 && c[1] >= c[0] {
-						s.pool.add(c[0], c[1])
-					}
-				}
-			}
-		}
-	} else if cmd == SubLedgerCode {
-		s.pool.Handle(msg, sender)
-	} else {
-		s.log.Warn(fmt.Sprintf("syncer: got %d need %d", msg.Cmd, SubLedgerCode))
-	}
-
-	return nil
+	return s.pool.Handle(msg, sender)
 }
 
+// catch is called when no peers can serve the chunk
 func (s *syncer) catch(c piece) {
 	if s.state != Syncing || atomic.LoadInt32(&s.running) == 0 {
 		return
@@ -382,26 +357,10 @@ func (s *syncer) catch(c piece) {
 		s.setState(Syncerr)
 	} else if atomic.LoadUint64(&s.to) > bestPeer.Height() {
 		// our target is taller than bestPeer, maybe bestPeer fallback
+		from, to := c.band()
+		s.log.Warn(fmt.Sprintf("no peers can serve chunk %d-%d, set target to %d", from, to, bestPeer.Height()))
 		s.setTarget(bestPeer.Height())
 	}
-
-	from, to := c.band()
-	// piece is too taller, out of our sync target
-	if from > s.to {
-		return
-	}
-
-	newTo := to
-	if newTo > s.to {
-		newTo = s.to
-	}
-
-	if from > newTo {
-		return
-	}
-
-	s.pool.add(from, s.to)
-	s.log.Warn(fmt.Sprintf("retry sync from %d to %d", from, s.to))
 }
 
 func (s *syncer) setState(t SyncState) {
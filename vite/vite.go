@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
@@ -18,6 +19,7 @@ import (
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vite/net"
 	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/wallet"
 )
 
@@ -34,6 +36,7 @@ type Vite struct {
 	chain            chain.Chain
 	producer         producer.Producer
 	net              net.Net
+	light            net.LightNet
 	pool             pool.BlockPool
 	consensus        consensus.Consensus
 	onRoad           *onroad.Manager
@@ -45,6 +48,10 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	// chain
 	chain := chain.NewChain(cfg)
 
+	if cfg.Light {
+		return newLight(cfg, walletManager, chain)
+	}
+
 	// pool
 	pl := pool.NewPool(chain)
 	genesis := chain.GetGenesisSnapshotBlock()
@@ -52,21 +59,47 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	// consensus
 	cs := consensus.NewConsensus(*genesis.Timestamp, chain)
 
+	// dev mode accepts snapshot blocks of coinbase at any time
+	var csVerifier consensus.Verifier = cs
+	if cfg.Producer.Dev {
+		coinbase, _, err := parseCoinbase(cfg.Producer.Coinbase)
+		if err != nil {
+			log.Error(fmt.Sprintf("coinBase parse fail. %v", cfg.Producer.Coinbase), "err", err)
+			return nil, err
+		}
+		csVerifier = consensus.NewDevVerifier(cs, *coinbase)
+	}
+
 	// sb verifier
-	aVerifier := verifier.NewAccountVerifier(chain, cs)
-	sbVerifier := verifier.NewSnapshotVerifier(chain, cs)
+	aVerifier := verifier.NewAccountVerifier(chain, csVerifier)
+	sbVerifier := verifier.NewSnapshotVerifier(chain, csVerifier)
 
 	// net
 	netVerifier := verifier.NewNetVerifier(sbVerifier, aVerifier)
+
+	reputation := &net.ReputationConfig{
+		Penalties:   make(map[net.Offence]int, len(cfg.Penalties)),
+		BanScore:    cfg.BanScore,
+		BanDuration: time.Duration(cfg.BanDuration) * time.Second,
+	}
+	for name, penalty := range cfg.Penalties {
+		if offence, ok := net.ParseOffence(name); ok {
+			reputation.Penalties[offence] = penalty
+		} else {
+			log.Warn(fmt.Sprintf("unknown offence %s", name))
+		}
+	}
+
 	net := net.New(&net.Config{
 		Single:       cfg.Single,
-		Port:         uint16(cfg.FilePort),
 		Chain:        chain,
 		Verifier:     netVerifier,
+		DataDir:      cfg.DataDir,
 		Topology:     cfg.Topology,
 		Topic:        cfg.Topic,
 		Interval:     cfg.Interval,
 		TopoDisabled: cfg.TopoDisabled,
+		Reputation:   reputation,
 	})
 
 	// vite
@@ -100,7 +133,12 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 			Address:   *coinbase,
 			Index:     index,
 		}
-		vite.producer = producer.NewProducer(chain, net, addressContext, cs, sbVerifier, walletManager, pl)
+		if cfg.Producer.Dev {
+			interval := time.Duration(cfg.Producer.DevInterval) * time.Second
+			vite.producer = producer.NewDevProducer(chain, net, addressContext, cs, sbVerifier, walletManager, pl, interval)
+		} else {
+			vite.producer = producer.NewProducer(chain, net, addressContext, cs, sbVerifier, walletManager, pl)
+		}
 	}
 
 	// onroad
@@ -111,10 +149,50 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	return
 }
 
+// newLight keeps the genesis chain only, the producers of snapshot headers are elected from the state of the
+// verified headers, whose storage tries are downloaded into c, so state pruning is not supported.
+// Headers are synced from the second snapshot block, which is the first one having the state of the contracts.
+func newLight(cfg *config.Config, walletManager *wallet.Manager, c chain.Chain) (*Vite, error) {
+	if cfg.Chain != nil && cfg.Chain.PruneHeights > 0 {
+		return nil, errors.New("state pruning is not supported by light client")
+	}
+
+	addrs := make([]types.Address, 0, len(cfg.LightAddresses))
+	for _, str := range cfg.LightAddresses {
+		addr, err := types.HexToAddress(str)
+		if err != nil {
+			log.Error(fmt.Sprintf("light address parse fail. %s", str), "err", err)
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+
+	return &Vite{
+		config:        cfg,
+		walletManager: walletManager,
+		chain:         c,
+		light: net.NewLight(&net.LightConfig{
+			Genesis:    c.GetGenesisSnapshotBlock(),
+			Checkpoint: &chain.SecondSnapshotBlock,
+			Chain:      c,
+			Addresses:  addrs,
+		}),
+	}, nil
+}
+
 func (v *Vite) Init() (err error) {
 	vm.InitVmConfig(v.config.IsVmTest, v.config.IsUseVmTestParam)
+	if v.config.Chain != nil && v.config.Chain.Genesis != nil && v.config.Chain.Genesis.ContractParams != nil {
+		if err := contracts.InitContractsParams(v.config.Chain.Genesis.ContractParams); err != nil {
+			log.Error("InitContractsParams failed, error is "+err.Error(), "method", "vite.Init")
+			return err
+		}
+	}
 
 	v.chain.Init()
+	if v.light != nil {
+		return nil
+	}
 	if v.producer != nil {
 		if err := v.producer.Init(); err != nil {
 			log.Error("Init producer failed, error is "+err.Error(), "method", "vite.Init")
@@ -130,6 +208,11 @@ func (v *Vite) Init() (err error) {
 func (v *Vite) Start(p2p *p2p.Server) (err error) {
 	v.p2p = p2p
 
+	if v.light != nil {
+		v.chain.Start()
+		return v.light.Start(p2p)
+	}
+
 	v.onRoad.Start()
 
 	v.chain.Start()
@@ -160,6 +243,11 @@ func (v *Vite) Start(p2p *p2p.Server) (err error) {
 }
 
 func (v *Vite) Stop() (err error) {
+	if v.light != nil {
+		v.light.Stop()
+		v.chain.Stop()
+		return nil
+	}
 
 	v.net.Stop()
 	v.pool.Stop()
@@ -184,6 +272,11 @@ func (v *Vite) Net() net.Net {
 	return v.net
 }
 
+// Light is nil unless the node runs as a light client, Net, Pool, Producer and OnRoad are nil then
+func (v *Vite) Light() net.LightNet {
+	return v.light
+}
+
 func (v *Vite) WalletManager() *wallet.Manager {
 	return v.walletManager
 }
@@ -204,6 +297,14 @@ func (v *Vite) OnRoad() *onroad.Manager {
 	return v.onRoad
 }
 
+func (v *Vite) SnapshotVerifier() *verifier.SnapshotVerifier {
+	return v.snapshotVerifier
+}
+
+func (v *Vite) AccountVerifier() *verifier.AccountVerifier {
+	return v.accountVerifier
+}
+
 func (v *Vite) Config() *config.Config {
 	return v.config
 }
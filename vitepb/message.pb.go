@@ -26,6 +26,7 @@ type Handshake struct {
 	Port                 uint32   `protobuf:"varint,3,opt,name=Port,proto3" json:"Port,omitempty"`
 	Current              []byte   `protobuf:"bytes,4,opt,name=Current,proto3" json:"Current,omitempty"`
 	Genesis              []byte   `protobuf:"bytes,5,opt,name=Genesis,proto3" json:"Genesis,omitempty"`
+	Compressions         []byte   `protobuf:"bytes,6,opt,name=Compressions,proto3" json:"Compressions,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -91,6 +92,13 @@ func (m *Handshake) GetGenesis() []byte {
 	return nil
 }
 
+func (m *Handshake) GetCompressions() []byte {
+	if m != nil {
+		return m.Compressions
+	}
+	return nil
+}
+
 type BlockID struct {
 	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
 	Height               uint64   `protobuf:"varint,2,opt,name=Height,proto3" json:"Height,omitempty"`
@@ -609,6 +617,218 @@ func (m *AccountBlocks) GetBlocks() []*AccountBlock {
 	return nil
 }
 
+type GetAccountState struct {
+	Address              []byte   `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Snapshot             *BlockID `protobuf:"bytes,2,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAccountState) Reset()         { *m = GetAccountState{} }
+func (m *GetAccountState) String() string { return proto.CompactTextString(m) }
+func (*GetAccountState) ProtoMessage()    {}
+func (*GetAccountState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a6a8486deb9ab39, []int{11}
+}
+
+func (m *GetAccountState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAccountState.Unmarshal(m, b)
+}
+func (m *GetAccountState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAccountState.Marshal(b, m, deterministic)
+}
+func (m *GetAccountState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAccountState.Merge(m, src)
+}
+func (m *GetAccountState) XXX_Size() int {
+	return xxx_messageInfo_GetAccountState.Size(m)
+}
+func (m *GetAccountState) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAccountState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAccountState proto.InternalMessageInfo
+
+func (m *GetAccountState) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *GetAccountState) GetSnapshot() *BlockID {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+type AccountState struct {
+	Address              []byte   `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Snapshot             *BlockID `protobuf:"bytes,2,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	Head                 *BlockID `protobuf:"bytes,3,opt,name=Head,proto3" json:"Head,omitempty"`
+	Proof                [][]byte `protobuf:"bytes,4,rep,name=Proof,proto3" json:"Proof,omitempty"`
+	RefValue             []byte   `protobuf:"bytes,5,opt,name=RefValue,proto3" json:"RefValue,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AccountState) Reset()         { *m = AccountState{} }
+func (m *AccountState) String() string { return proto.CompactTextString(m) }
+func (*AccountState) ProtoMessage()    {}
+func (*AccountState) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a6a8486deb9ab39, []int{12}
+}
+
+func (m *AccountState) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountState.Unmarshal(m, b)
+}
+func (m *AccountState) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountState.Marshal(b, m, deterministic)
+}
+func (m *AccountState) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountState.Merge(m, src)
+}
+func (m *AccountState) XXX_Size() int {
+	return xxx_messageInfo_AccountState.Size(m)
+}
+func (m *AccountState) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountState.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountState proto.InternalMessageInfo
+
+func (m *AccountState) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *AccountState) GetSnapshot() *BlockID {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+func (m *AccountState) GetHead() *BlockID {
+	if m != nil {
+		return m.Head
+	}
+	return nil
+}
+
+func (m *AccountState) GetProof() [][]byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *AccountState) GetRefValue() []byte {
+	if m != nil {
+		return m.RefValue
+	}
+	return nil
+}
+
+type GetTrieNodes struct {
+	Nodes                [][]byte `protobuf:"bytes,1,rep,name=Nodes,proto3" json:"Nodes,omitempty"`
+	Values               [][]byte `protobuf:"bytes,2,rep,name=Values,proto3" json:"Values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTrieNodes) Reset()         { *m = GetTrieNodes{} }
+func (m *GetTrieNodes) String() string { return proto.CompactTextString(m) }
+func (*GetTrieNodes) ProtoMessage()    {}
+func (*GetTrieNodes) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a6a8486deb9ab39, []int{13}
+}
+
+func (m *GetTrieNodes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTrieNodes.Unmarshal(m, b)
+}
+func (m *GetTrieNodes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTrieNodes.Marshal(b, m, deterministic)
+}
+func (m *GetTrieNodes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTrieNodes.Merge(m, src)
+}
+func (m *GetTrieNodes) XXX_Size() int {
+	return xxx_messageInfo_GetTrieNodes.Size(m)
+}
+func (m *GetTrieNodes) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTrieNodes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTrieNodes proto.InternalMessageInfo
+
+func (m *GetTrieNodes) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *GetTrieNodes) GetValues() [][]byte {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type TrieNodes struct {
+	Nodes                [][]byte `protobuf:"bytes,1,rep,name=Nodes,proto3" json:"Nodes,omitempty"`
+	Values               [][]byte `protobuf:"bytes,2,rep,name=Values,proto3" json:"Values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TrieNodes) Reset()         { *m = TrieNodes{} }
+func (m *TrieNodes) String() string { return proto.CompactTextString(m) }
+func (*TrieNodes) ProtoMessage()    {}
+func (*TrieNodes) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2a6a8486deb9ab39, []int{14}
+}
+
+func (m *TrieNodes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TrieNodes.Unmarshal(m, b)
+}
+func (m *TrieNodes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TrieNodes.Marshal(b, m, deterministic)
+}
+func (m *TrieNodes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TrieNodes.Merge(m, src)
+}
+func (m *TrieNodes) XXX_Size() int {
+	return xxx_messageInfo_TrieNodes.Size(m)
+}
+func (m *TrieNodes) XXX_DiscardUnknown() {
+	xxx_messageInfo_TrieNodes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TrieNodes proto.InternalMessageInfo
+
+func (m *TrieNodes) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *TrieNodes) GetValues() [][]byte {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Handshake)(nil), "vitepb.Handshake")
 	proto.RegisterType((*BlockID)(nil), "vitepb.BlockID")
@@ -621,45 +841,56 @@ func init() {
 	proto.RegisterType((*SnapshotBlocks)(nil), "vitepb.SnapshotBlocks")
 	proto.RegisterType((*GetAccountBlocks)(nil), "vitepb.GetAccountBlocks")
 	proto.RegisterType((*AccountBlocks)(nil), "vitepb.AccountBlocks")
+	proto.RegisterType((*GetAccountState)(nil), "vitepb.GetAccountState")
+	proto.RegisterType((*AccountState)(nil), "vitepb.AccountState")
+	proto.RegisterType((*GetTrieNodes)(nil), "vitepb.GetTrieNodes")
+	proto.RegisterType((*TrieNodes)(nil), "vitepb.TrieNodes")
 }
 
 func init() { proto.RegisterFile("vitepb/message.proto", fileDescriptor_2a6a8486deb9ab39) }
 
 var fileDescriptor_2a6a8486deb9ab39 = []byte{
-	// 548 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0x4d, 0x6f, 0xd3, 0x4c,
-	0x10, 0xc7, 0xe5, 0xd8, 0x79, 0x9b, 0xa4, 0xcf, 0x53, 0x56, 0x01, 0x59, 0x81, 0x83, 0x65, 0x2e,
-	0x39, 0x40, 0x8a, 0x82, 0xe0, 0x86, 0x50, 0x08, 0x4d, 0x82, 0x54, 0x2a, 0xb4, 0xfe, 0x00, 0x68,
-	0x1d, 0x8f, 0x62, 0x93, 0xda, 0x8e, 0x76, 0xd7, 0x20, 0x71, 0x43, 0xdc, 0xf8, 0x34, 0x7c, 0x44,
-	0xb4, 0x2f, 0x4e, 0xe2, 0x42, 0xa5, 0xde, 0xf6, 0x3f, 0x33, 0xff, 0x1d, 0xff, 0x76, 0x26, 0x81,
-	0xd1, 0xd7, 0x4c, 0xe2, 0x3e, 0xbe, 0xc8, 0x51, 0x08, 0xb6, 0xc5, 0xe9, 0x9e, 0x97, 0xb2, 0x24,
-	0x1d, 0x13, 0x1d, 0x8f, 0x6d, 0x96, 0x6d, 0x36, 0x65, 0x55, 0xc8, 0xcf, 0xf1, 0x4d, 0xb9, 0xd9,
-	0x99, 0x9a, 0xf1, 0x63, 0x9b, 0x13, 0x05, 0xdb, 0x8b, 0xb4, 0x6c, 0x24, 0xc3, 0x9f, 0x0e, 0xf4,
-	0xd7, 0xac, 0x48, 0x44, 0xca, 0x76, 0x48, 0x1e, 0x41, 0x67, 0x91, 0x27, 0x11, 0x4a, 0xdf, 0x09,
-	0x9c, 0x89, 0x47, 0xad, 0x52, 0xf1, 0x35, 0x66, 0xdb, 0x54, 0xfa, 0x2d, 0x13, 0x37, 0x8a, 0x10,
-	0xf0, 0x3e, 0x95, 0x5c, 0xfa, 0x6e, 0xe0, 0x4c, 0xce, 0xa8, 0x3e, 0x13, 0x1f, 0xba, 0x8b, 0x8a,
-	0x73, 0x2c, 0xa4, 0xef, 0x05, 0xce, 0x64, 0x48, 0x6b, 0xa9, 0x32, 0x2b, 0x2c, 0x50, 0x64, 0xc2,
-	0x6f, 0x9b, 0x8c, 0x95, 0xe1, 0x2b, 0xe8, 0xbe, 0x53, 0x1f, 0xf5, 0xe1, 0xbd, 0xba, 0x72, 0xcd,
-	0x44, 0xaa, 0x3f, 0x60, 0x48, 0xf5, 0xf9, 0xae, 0xf6, 0xe1, 0x6f, 0x07, 0xc8, 0xa2, 0xcc, 0xf7,
-	0x1c, 0x85, 0xc0, 0x64, 0x99, 0xdd, 0xe0, 0x47, 0x94, 0x8c, 0x04, 0x30, 0x88, 0x24, 0xe3, 0xd2,
-	0x7a, 0x0c, 0xca, 0x69, 0x88, 0x3c, 0x81, 0xfe, 0x65, 0x91, 0x34, 0xee, 0x3c, 0x06, 0xc8, 0x18,
-	0x7a, 0xea, 0xae, 0x82, 0xe5, 0xa8, 0xc9, 0xfa, 0xf4, 0xa0, 0xeb, 0x5c, 0x94, 0x7d, 0x47, 0x8d,
-	0xe7, 0xd2, 0x83, 0x26, 0x21, 0x0c, 0x35, 0xc5, 0x75, 0x95, 0xc7, 0xc8, 0x0d, 0xa4, 0x47, 0x1b,
-	0xb1, 0xf0, 0x8b, 0xf1, 0x5f, 0x65, 0x42, 0x92, 0x17, 0xd0, 0x56, 0x67, 0xe1, 0x3b, 0x81, 0x3b,
-	0x19, 0xcc, 0xc6, 0x53, 0x33, 0xa8, 0xe9, 0xdf, 0x48, 0xd4, 0x14, 0xea, 0xf9, 0xa4, 0x55, 0xb1,
-	0x13, 0x7e, 0x2b, 0x70, 0xf5, 0x7c, 0xb4, 0x22, 0x23, 0x68, 0x5f, 0x97, 0xc5, 0xc6, 0x7c, 0xae,
-	0x47, 0x8d, 0x08, 0x5f, 0x43, 0x6f, 0x85, 0xd2, 0x38, 0x55, 0x05, 0xcb, 0x6d, 0xaf, 0x3e, 0x35,
-	0xe2, 0xe8, 0x6b, 0x9d, 0xfa, 0x66, 0xda, 0xa7, 0xaf, 0x56, 0x15, 0xfa, 0xe1, 0xec, 0x2b, 0x1a,
-	0x41, 0xce, 0xc1, 0xbd, 0x2c, 0x12, 0xeb, 0x52, 0xc7, 0xf0, 0x97, 0x03, 0xfd, 0xa8, 0x8a, 0xaf,
-	0x30, 0xd9, 0x22, 0x27, 0x17, 0xd0, 0x8d, 0x34, 0x76, 0xcd, 0xf6, 0xb0, 0x66, 0x8b, 0xec, 0x12,
-	0xea, 0x2c, 0xad, 0xab, 0xc8, 0x14, 0xba, 0x73, 0x6b, 0x68, 0x69, 0xc3, 0xa8, 0x36, 0xcc, 0xcd,
-	0x46, 0xdb, 0x7a, 0x5b, 0xa4, 0x06, 0x38, 0x8f, 0xed, 0xbb, 0x5a, 0xe8, 0x63, 0x20, 0x4c, 0xe1,
-	0xc1, 0x0a, 0x65, 0xa3, 0x95, 0x20, 0x4f, 0xc1, 0x5b, 0xf2, 0x32, 0xd7, 0x20, 0x83, 0xd9, 0xff,
-	0xf5, 0xfd, 0x76, 0xef, 0xa8, 0x4e, 0x2a, 0xdc, 0x85, 0x6a, 0x57, 0x3f, 0x88, 0x16, 0x6a, 0x71,
-	0x97, 0x25, 0xff, 0xc6, 0x78, 0xa2, 0x7b, 0xf5, 0x68, 0x2d, 0xc3, 0xb7, 0xf0, 0xdf, 0xad, 0x36,
-	0xcf, 0xa1, 0x73, 0x1f, 0x72, 0x5b, 0x14, 0xfe, 0x70, 0xe0, 0x7c, 0x85, 0xf2, 0x94, 0x52, 0xa8,
-	0x7e, 0xf3, 0x24, 0x51, 0x2b, 0x60, 0x7f, 0x06, 0xb5, 0x3c, 0x40, 0xb4, 0xee, 0x05, 0xe1, 0xde,
-	0x01, 0xe1, 0x35, 0x21, 0xde, 0xc0, 0x59, 0xb3, 0xff, 0xb3, 0x5b, 0x0c, 0xff, 0x1e, 0x86, 0xad,
-	0x89, 0x3b, 0xfa, 0x9f, 0xe4, 0xe5, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x82, 0x97, 0xa9, 0x9d,
-	0xa2, 0x04, 0x00, 0x00,
+	// 657 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x54, 0xdf, 0x6b, 0x13, 0x41,
+	0x10, 0xe6, 0x72, 0xd7, 0xfc, 0x98, 0x5e, 0x6d, 0x5d, 0xaa, 0x1c, 0xd1, 0x87, 0xe3, 0x7c, 0x09,
+	0xa8, 0xa9, 0x54, 0x14, 0x04, 0x45, 0x62, 0x6c, 0x13, 0xa1, 0x96, 0xb2, 0x27, 0xe2, 0x9b, 0x6c,
+	0x72, 0xd3, 0xe6, 0x6c, 0xef, 0x36, 0xdc, 0x6e, 0x14, 0x7c, 0xf3, 0xd5, 0x7f, 0xc4, 0x17, 0x1f,
+	0xfc, 0x13, 0x65, 0x7f, 0x25, 0xbd, 0xda, 0x4a, 0x11, 0x7c, 0xdb, 0x6f, 0x66, 0xbe, 0x99, 0xfd,
+	0x66, 0x66, 0x17, 0xb6, 0x3f, 0xe7, 0x12, 0xe7, 0x93, 0x9d, 0x02, 0x85, 0x60, 0x27, 0xd8, 0x9f,
+	0x57, 0x5c, 0x72, 0xd2, 0x34, 0xd6, 0x6e, 0xd7, 0x7a, 0xd9, 0x74, 0xca, 0x17, 0xa5, 0xfc, 0x38,
+	0x39, 0xe3, 0xd3, 0x53, 0x13, 0xd3, 0xbd, 0x63, 0x7d, 0xa2, 0x64, 0x73, 0x31, 0xe3, 0x35, 0x67,
+	0xf2, 0xc3, 0x83, 0xce, 0x98, 0x95, 0x99, 0x98, 0xb1, 0x53, 0x24, 0xb7, 0xa1, 0x39, 0x2c, 0xb2,
+	0x14, 0x65, 0xe4, 0xc5, 0x5e, 0x2f, 0xa0, 0x16, 0x29, 0xfb, 0x18, 0xf3, 0x93, 0x99, 0x8c, 0x1a,
+	0xc6, 0x6e, 0x10, 0x21, 0x10, 0x1c, 0xf1, 0x4a, 0x46, 0x7e, 0xec, 0xf5, 0x36, 0xa8, 0x3e, 0x93,
+	0x08, 0x5a, 0xc3, 0x45, 0x55, 0x61, 0x29, 0xa3, 0x20, 0xf6, 0x7a, 0x21, 0x75, 0x50, 0x79, 0x46,
+	0x58, 0xa2, 0xc8, 0x45, 0xb4, 0x66, 0x3c, 0x16, 0x92, 0x04, 0xc2, 0x21, 0x2f, 0xe6, 0x15, 0x0a,
+	0x91, 0xf3, 0x52, 0x44, 0x4d, 0xed, 0xae, 0xd9, 0x92, 0x27, 0xd0, 0x7a, 0xa5, 0x2e, 0xfe, 0xe6,
+	0xb5, 0x2a, 0x3b, 0x66, 0x62, 0xa6, 0x2f, 0x19, 0x52, 0x7d, 0xbe, 0xea, 0x8a, 0xc9, 0x2f, 0x0f,
+	0x88, 0xcb, 0x83, 0xd9, 0x7e, 0x7e, 0x86, 0x6f, 0x51, 0x32, 0x12, 0xc3, 0x7a, 0x2a, 0x59, 0x25,
+	0x2d, 0xc7, 0xc8, 0x3d, 0x6f, 0x22, 0x77, 0xa1, 0xb3, 0x57, 0x66, 0xb5, 0x9c, 0x2b, 0x03, 0xe9,
+	0x42, 0x5b, 0xe5, 0x2a, 0x59, 0x81, 0x5a, 0x7d, 0x87, 0x2e, 0xb1, 0xf3, 0xa5, 0xf9, 0x57, 0xd4,
+	0x2d, 0xf0, 0xe9, 0x12, 0x2b, 0xa5, 0x5a, 0xc5, 0xe1, 0xa2, 0x98, 0x60, 0x65, 0x1a, 0x11, 0xd0,
+	0x9a, 0x2d, 0xf9, 0x64, 0xf8, 0x07, 0xb9, 0x90, 0xe4, 0x11, 0xac, 0xa9, 0xb3, 0x88, 0xbc, 0xd8,
+	0xef, 0xad, 0xef, 0x76, 0xfb, 0x66, 0x98, 0xfd, 0x3f, 0x25, 0x51, 0x13, 0xa8, 0x67, 0x38, 0x5b,
+	0x94, 0xa7, 0x22, 0x6a, 0xc4, 0xbe, 0x9e, 0xa1, 0x46, 0x64, 0x1b, 0xd6, 0x0e, 0x79, 0x39, 0x35,
+	0xd7, 0x0d, 0xa8, 0x01, 0xc9, 0x53, 0x68, 0x8f, 0x50, 0x1a, 0xa6, 0x8a, 0x60, 0x85, 0xad, 0xd5,
+	0xa1, 0x06, 0xac, 0x78, 0x8d, 0xf3, 0xbc, 0x5d, 0xcd, 0xd3, 0xa9, 0x55, 0x84, 0x6e, 0x9c, 0xed,
+	0xa2, 0x01, 0x64, 0x0b, 0xfc, 0xbd, 0x32, 0xb3, 0x2c, 0x75, 0x4c, 0xbe, 0x7b, 0xd0, 0x49, 0x17,
+	0x93, 0x03, 0xcc, 0x4e, 0xb0, 0x22, 0x3b, 0xd0, 0x4a, 0xb5, 0x6c, 0xa7, 0xed, 0x96, 0xd3, 0x96,
+	0xda, 0x45, 0xd5, 0x5e, 0xea, 0xa2, 0x48, 0x1f, 0x5a, 0x03, 0x4b, 0x68, 0x68, 0xc2, 0xb6, 0x23,
+	0x0c, 0xcc, 0xd6, 0xdb, 0x78, 0x1b, 0xa4, 0x06, 0x38, 0x98, 0xd8, 0xbe, 0x5a, 0xd1, 0x2b, 0x43,
+	0x32, 0x83, 0x9b, 0x23, 0x94, 0xb5, 0x52, 0x82, 0xdc, 0x83, 0x60, 0xbf, 0xe2, 0x85, 0x16, 0xb2,
+	0xbe, 0xbb, 0xe9, 0xf2, 0xdb, 0xbd, 0xa3, 0xda, 0xa9, 0xe4, 0x0e, 0x55, 0x39, 0xd7, 0x10, 0x0d,
+	0xd4, 0x72, 0xef, 0xf3, 0xea, 0x0b, 0xab, 0x32, 0x5d, 0xab, 0x4d, 0x1d, 0x4c, 0x5e, 0xc2, 0x8d,
+	0x0b, 0x65, 0x1e, 0x42, 0xf3, 0x3a, 0xca, 0x6d, 0x50, 0xf2, 0xcd, 0x83, 0xad, 0x11, 0xca, 0xf3,
+	0x2a, 0x85, 0xaa, 0x37, 0xc8, 0x32, 0xb5, 0x02, 0xf6, 0x19, 0x38, 0xb8, 0x14, 0xd1, 0xb8, 0x96,
+	0x08, 0xff, 0x0a, 0x11, 0x41, 0x5d, 0xc4, 0x0b, 0xd8, 0xa8, 0xd7, 0x7f, 0x70, 0x41, 0xc3, 0xe5,
+	0xc3, 0x70, 0x12, 0x3e, 0xc0, 0xe6, 0x4a, 0x41, 0x2a, 0x99, 0xc4, 0xbf, 0x08, 0xb8, 0x0f, 0x6d,
+	0xd7, 0x88, 0xab, 0x44, 0x2c, 0x03, 0x92, 0x9f, 0x1e, 0x84, 0xff, 0x21, 0xaf, 0xea, 0xe2, 0x18,
+	0x99, 0x19, 0xe6, 0x65, 0x5d, 0x54, 0x4e, 0xd5, 0xc5, 0xa3, 0x8a, 0xf3, 0xe3, 0x28, 0x88, 0xfd,
+	0x5e, 0x48, 0x0d, 0x50, 0xef, 0x9f, 0xe2, 0xf1, 0x7b, 0x76, 0xb6, 0x40, 0xfb, 0xd1, 0x2d, 0x71,
+	0xf2, 0x1c, 0xc2, 0x11, 0xca, 0x77, 0x55, 0x8e, 0x87, 0x3c, 0x73, 0xaf, 0x2b, 0xb3, 0x6f, 0x2e,
+	0xa4, 0x06, 0xa8, 0x37, 0xac, 0xc3, 0xcd, 0xa6, 0x87, 0xd4, 0xa2, 0xe4, 0x19, 0x74, 0xfe, 0x91,
+	0x3a, 0x69, 0xea, 0xff, 0xfe, 0xf1, 0xef, 0x01, 0x00, 0x07, 0xeb, 0x26, 0xfa, 0x48, 0x06, 0x00,
+	0x00,
 }
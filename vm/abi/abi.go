@@ -82,6 +82,21 @@ func (abi ABIContract) UnpackMethod(v interface{}, name string, output []byte) (
 	return fmt.Errorf("abi: could not locate named method")
 }
 
+// UnpackMethodOutput unpacks the return data of a method call into a value list
+func (abi ABIContract) UnpackMethodOutput(name string, output []byte) ([]interface{}, error) {
+	method, exist := abi.Methods[name]
+	if !exist {
+		return nil, fmt.Errorf("method '%s' not found", name)
+	}
+	if len(method.Outputs) == 0 {
+		return nil, nil
+	}
+	if len(output) == 0 {
+		return nil, errEmptyOutput
+	}
+	return method.Outputs.UnpackValues(output)
+}
+
 // UnpackEvent output in v according to the abi specification
 func (abi ABIContract) UnpackEvent(v interface{}, name string, output []byte) (err error) {
 	if len(output) == 0 {
@@ -130,9 +145,10 @@ func (abi *ABIContract) UnmarshalJSON(data []byte) error {
 			// empty defaults to function according to the abi spec
 		case "function", "":
 			abi.Methods[field.Name] = Method{
-				Name:   field.Name,
-				Const:  field.Constant,
-				Inputs: field.Inputs,
+				Name:    field.Name,
+				Const:   field.Constant,
+				Inputs:  field.Inputs,
+				Outputs: field.Outputs,
 			}
 		case "event":
 			abi.Events[field.Name] = Event{
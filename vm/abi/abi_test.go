@@ -48,16 +48,16 @@ func TestReader(t *testing.T) {
 		Constructor: Method{
 			"", false, []Argument{
 				{"owner", typeAddress, false},
-			},
+			}, nil,
 		},
 		Methods: map[string]Method{
 			"balance": {
-				"balance", true, nil,
+				"balance", true, nil, nil,
 			},
 			"send": {
 				"send", false, []Argument{
 					{"amount", typeUint256, false},
-				},
+				}, nil,
 			},
 		},
 		Events: map[string]Event{
@@ -213,7 +213,7 @@ func TestTestSlice(t *testing.T) {
 
 func TestMethodSignature(t *testing.T) {
 	String, _ := NewType("string")
-	m := Method{"foo", false, []Argument{{"bar", String, false}, {"baz", String, false}}}
+	m := Method{"foo", false, []Argument{{"bar", String, false}, {"baz", String, false}}, nil}
 	exp := "foo(string,string)"
 	if m.Sig() != exp {
 		t.Error("signature mismatch", exp, "!=", m.Sig())
@@ -225,7 +225,7 @@ func TestMethodSignature(t *testing.T) {
 	}
 
 	uintt, _ := NewType("uint256")
-	m = Method{"foo", false, []Argument{{"bar", uintt, false}}}
+	m = Method{"foo", false, []Argument{{"bar", uintt, false}}, nil}
 	exp = "foo(uint256)"
 	if m.Sig() != exp {
 		t.Error("signature mismatch", exp, "!=", m.Sig())
@@ -809,3 +809,28 @@ func TestABI_MethodById(t *testing.T) {
 	}
 
 }
+
+func TestUnpackMethodOutput(t *testing.T) {
+	const abiJSON = `[
+		{"type":"function","name":"getBalance","constant":true,"inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"amount","type":"uint256"},{"name":"name","type":"string"}]},
+		{"type":"function","name":"setBalance","constant":false,"inputs":[{"name":"amount","type":"uint256"}]}
+	]`
+	abi, err := JSONToABIContract(strings.NewReader(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := abi.Methods["getBalance"].Outputs.Pack(big.NewInt(10), "vite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := abi.UnpackMethodOutput("getBalance", output)
+	if err != nil || len(values) != 2 || values[0].(*big.Int).Cmp(big.NewInt(10)) != 0 || values[1].(string) != "vite" {
+		t.Fatalf("unpack method output failed, values %v, err %v", values, err)
+	}
+	if values, err := abi.UnpackMethodOutput("setBalance", nil); err != nil || values != nil {
+		t.Fatalf("unpack empty method output failed, values %v, err %v", values, err)
+	}
+	if _, err := abi.UnpackMethodOutput("getBalance", nil); err == nil {
+		t.Fatalf("unpack empty output of method with outputs should fail")
+	}
+}
@@ -15,9 +15,10 @@ import (
 // be flagged `true`.
 // Input specifies the required input parameters for this gives method.
 type Method struct {
-	Name   string
-	Const  bool
-	Inputs Arguments
+	Name    string
+	Const   bool
+	Inputs  Arguments
+	Outputs Arguments
 }
 
 // Sig returns the methods string signature according to the ABI spec.
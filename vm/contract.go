@@ -59,5 +59,16 @@ func (c *contract) run(vm *VM) (ret []byte, err error) {
 		c.intPool = nil
 	}()
 
+	if vm.Tracer != nil {
+		if c.codeAddr == c.address {
+			vm.Tracer.CaptureEnter(callTypeCall, c.caller, c.address, c.sendBlock.Data, c.quotaLeft)
+		} else {
+			vm.Tracer.CaptureEnter(callTypeDelegateCall, c.address, c.codeAddr, c.sendBlock.Data, c.quotaLeft)
+		}
+		defer func(quotaLeft uint64) {
+			vm.Tracer.CaptureExit(ret, quotaLeft-c.quotaLeft, err)
+		}(c.quotaLeft)
+	}
+
 	return vm.i.Run(vm, c)
 }
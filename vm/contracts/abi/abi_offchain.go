@@ -0,0 +1,121 @@
+package abi
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+// off chain methods read the state of the precompiled contracts, they are not callable by account blocks
+const (
+	jsonRegisterOffChain = `
+	[
+		{"type":"function","name":"getRegistration","inputs":[{"name":"gid","type":"gid"},{"name":"name","type":"string"}],"outputs":[{"name":"name","type":"string"},{"name":"nodeAddr","type":"address"},{"name":"pledgeAddr","type":"address"},{"name":"amount","type":"uint256"},{"name":"withdrawHeight","type":"uint64"},{"name":"cancelHeight","type":"uint64"}]}
+	]`
+	jsonVoteOffChain = `
+	[
+		{"type":"function","name":"getVote","inputs":[{"name":"gid","type":"gid"},{"name":"voter","type":"address"}],"outputs":[{"name":"nodeName","type":"string"}]}
+	]`
+	jsonPledgeOffChain = `
+	[
+		{"type":"function","name":"getPledgeBeneficialAmount","inputs":[{"name":"beneficial","type":"address"}],"outputs":[{"name":"amount","type":"uint256"}]}
+	]`
+	jsonMintageOffChain = `
+	[
+		{"type":"function","name":"getTokenInfo","inputs":[{"name":"tokenId","type":"tokenId"}],"outputs":[{"name":"tokenName","type":"string"},{"name":"tokenSymbol","type":"string"},{"name":"totalSupply","type":"uint256"},{"name":"decimals","type":"uint8"},{"name":"owner","type":"address"},{"name":"pledgeAmount","type":"uint256"},{"name":"withdrawHeight","type":"uint64"}]}
+	]`
+
+	MethodNameGetRegistration           = "getRegistration"
+	MethodNameGetVote                   = "getVote"
+	MethodNameGetPledgeBeneficialAmount = "getPledgeBeneficialAmount"
+	MethodNameGetTokenInfo              = "getTokenInfo"
+)
+
+var (
+	ABIRegisterOffChain, _ = abi.JSONToABIContract(strings.NewReader(jsonRegisterOffChain))
+	ABIVoteOffChain, _     = abi.JSONToABIContract(strings.NewReader(jsonVoteOffChain))
+	ABIPledgeOffChain, _   = abi.JSONToABIContract(strings.NewReader(jsonPledgeOffChain))
+	ABIMintageOffChain, _  = abi.JSONToABIContract(strings.NewReader(jsonMintageOffChain))
+
+	offChainAbiMap = map[types.Address]abi.ABIContract{
+		AddressRegister: ABIRegisterOffChain,
+		AddressVote:     ABIVoteOffChain,
+		AddressPledge:   ABIPledgeOffChain,
+		AddressMintage:  ABIMintageOffChain,
+	}
+
+	ErrOffChainMethodNotExist = errors.New("off chain method doesn't exist")
+)
+
+type ParamGetRegistration struct {
+	Gid  types.Gid
+	Name string
+}
+type ParamGetVote struct {
+	Gid   types.Gid
+	Voter types.Address
+}
+
+// GetOffChainABI returns the off chain methods of a precompiled contract
+func GetOffChainABI(contractAddr types.Address) (abi.ABIContract, bool) {
+	abiContract, ok := offChainAbiMap[contractAddr]
+	return abiContract, ok
+}
+
+// CallOffChain runs an off chain method of a precompiled contract against db, it returns the packed outputs
+func CallOffChain(db StorageDatabase, contractAddr types.Address, data []byte) ([]byte, error) {
+	abiContract, ok := offChainAbiMap[contractAddr]
+	if !ok || len(data) < 4 {
+		return nil, ErrOffChainMethodNotExist
+	}
+	method, err := abiContract.MethodById(data[0:4])
+	if err != nil {
+		return nil, ErrOffChainMethodNotExist
+	}
+
+	var outputs []interface{}
+	switch method.Name {
+	case MethodNameGetRegistration:
+		param := new(ParamGetRegistration)
+		if err := abiContract.UnpackMethod(param, method.Name, data); err != nil {
+			return nil, errInvalidParam
+		}
+		registration := GetRegistration(db, param.Gid, param.Name)
+		if registration == nil {
+			return nil, errors.New("registration doesn't exist")
+		}
+		outputs = []interface{}{registration.Name, registration.NodeAddr, registration.PledgeAddr, registration.Amount, registration.WithdrawHeight, registration.CancelHeight}
+	case MethodNameGetVote:
+		param := new(ParamGetVote)
+		if err := abiContract.UnpackMethod(param, method.Name, data); err != nil {
+			return nil, errInvalidParam
+		}
+		// the node name is empty if the voter doesn't vote
+		nodeName := ""
+		if vote := GetVote(db, param.Gid, param.Voter); vote != nil {
+			nodeName = vote.NodeName
+		}
+		outputs = []interface{}{nodeName}
+	case MethodNameGetPledgeBeneficialAmount:
+		beneficial := new(types.Address)
+		if err := abiContract.UnpackMethod(beneficial, method.Name, data); err != nil {
+			return nil, errInvalidParam
+		}
+		outputs = []interface{}{GetPledgeBeneficialAmount(db, *beneficial)}
+	case MethodNameGetTokenInfo:
+		tokenId := new(types.TokenTypeId)
+		if err := abiContract.UnpackMethod(tokenId, method.Name, data); err != nil {
+			return nil, errInvalidParam
+		}
+		tokenInfo := GetTokenById(db, *tokenId)
+		if tokenInfo == nil {
+			return nil, errors.New("token doesn't exist")
+		}
+		outputs = []interface{}{tokenInfo.TokenName, tokenInfo.TokenSymbol, tokenInfo.TotalSupply, tokenInfo.Decimals, tokenInfo.Owner, tokenInfo.PledgeAmount, tokenInfo.WithdrawHeight}
+	default:
+		return nil, ErrOffChainMethodNotExist
+	}
+	return method.Outputs.Pack(outputs...)
+}
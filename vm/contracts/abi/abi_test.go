@@ -5,6 +5,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/vm_context/vmctxt_interface"
 	"math/big"
 	"strings"
 	"testing"
@@ -59,3 +60,70 @@ func TestPackConsensusGroupConditionParam(t *testing.T) {
 		t.Fatalf("pack consensus group condition param failed")
 	}
 }
+
+// offChainTestDb keeps the storage of the precompiled contracts
+type offChainTestDb map[types.Address]map[string][]byte
+
+func (db offChainTestDb) GetStorageBySnapshotHash(addr *types.Address, key []byte, snapshotHash *types.Hash) []byte {
+	return db[*addr][string(key)]
+}
+func (db offChainTestDb) NewStorageIteratorBySnapshotHash(addr *types.Address, prefix []byte, snapshotHash *types.Hash) vmctxt_interface.StorageIterator {
+	return nil
+}
+
+func TestCallOffChain(t *testing.T) {
+	addr, _, _ := types.CreateAddress()
+	tokenName, tokenSymbol := "Vite Token", "VITE"
+	supply := big.NewInt(1e18)
+	mintage, _ := ABIMintage.PackVariable(VariableNameMintage, tokenName, tokenSymbol, supply, uint8(18), addr, big.NewInt(0), uint64(0))
+	pledge, _ := ABIPledge.PackVariable(VariableNamePledgeBeneficial, big.NewInt(100))
+	vote, _ := ABIVote.PackVariable(VariableNameVoteStatus, "node1")
+	db := offChainTestDb{
+		AddressMintage: {string(GetMintageKey(ledger.ViteTokenId)): mintage},
+		AddressPledge:  {string(GetPledgeBeneficialKey(addr)): pledge},
+		AddressVote:    {string(GetVoteKey(addr, types.DELEGATE_GID)): vote},
+	}
+
+	call := func(contractAddr types.Address, abiContract abi.ABIContract, name string, params ...interface{}) ([]interface{}, error) {
+		data, err := abiContract.PackMethod(name, params...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output, err := CallOffChain(db, contractAddr, data)
+		if err != nil {
+			return nil, err
+		}
+		return abiContract.UnpackMethodOutput(name, output)
+	}
+
+	output, err := call(AddressMintage, ABIMintageOffChain, MethodNameGetTokenInfo, ledger.ViteTokenId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output[0] != tokenName || output[1] != tokenSymbol || output[2].(*big.Int).Cmp(supply) != 0 || output[4] != addr {
+		t.Fatalf("unexpected token info %v", output)
+	}
+	if _, err := call(AddressMintage, ABIMintageOffChain, MethodNameGetTokenInfo, types.TokenTypeId{}); err == nil {
+		t.Fatal("reading a token not existing should fail")
+	}
+
+	if output, err := call(AddressPledge, ABIPledgeOffChain, MethodNameGetPledgeBeneficialAmount, addr); err != nil || output[0].(*big.Int).Cmp(big.NewInt(100)) != 0 {
+		t.Fatalf("unexpected pledge amount %v, error %v", output, err)
+	}
+	if output, err := call(AddressVote, ABIVoteOffChain, MethodNameGetVote, types.DELEGATE_GID, addr); err != nil || output[0] != "node1" {
+		t.Fatalf("unexpected vote %v, error %v", output, err)
+	}
+	if _, err := call(AddressRegister, ABIRegisterOffChain, MethodNameGetRegistration, types.DELEGATE_GID, "node1"); err == nil {
+		t.Fatal("reading a registration not existing should fail")
+	}
+
+	// methods of other contracts and on chain methods are not readable
+	data, _ := ABIPledgeOffChain.PackMethod(MethodNameGetPledgeBeneficialAmount, addr)
+	if _, err := CallOffChain(db, AddressMintage, data); err != ErrOffChainMethodNotExist {
+		t.Fatalf("method of another contract should not exist, got %v", err)
+	}
+	data, _ = ABIPledge.PackMethod(MethodNamePledge, addr)
+	if _, err := CallOffChain(db, AddressPledge, data); err != ErrOffChainMethodNotExist {
+		t.Fatalf("on chain method should not be called off chain, got %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package contracts
 
 import (
+	"errors"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm_context/vmctxt_interface"
 	"math/big"
@@ -11,7 +13,7 @@ type NodeConfig struct {
 	params ContractsParams
 }
 
-var nodeConfig NodeConfig
+var nodeConfig = NodeConfig{params: ContractsParamsMainNet}
 
 func InitContractsConfig(isTestParam bool) {
 	if isTestParam {
@@ -21,6 +23,50 @@ func InitContractsConfig(isTestParam bool) {
 	}
 }
 
+// InitContractsParams replaces the params chosen by InitContractsConfig with the ones in the genesis spec
+func InitContractsParams(cfg *config.GenesisContractParams) error {
+	params, err := NewContractsParams(cfg)
+	if err != nil {
+		return err
+	}
+	nodeConfig.params = params
+	return nil
+}
+
+// NewContractsParams parses the params in the genesis spec, amounts are decimal strings
+func NewContractsParams(cfg *config.GenesisContractParams) (ContractsParams, error) {
+	params := ContractsParams{
+		MinPledgeHeight:                  cfg.MinPledgeHeight,
+		CreateConsensusGroupPledgeHeight: cfg.CreateConsensusGroupPledgeHeight,
+		MintagePledgeHeight:              cfg.MintagePledgeHeight,
+		RewardEndTimeLimit:               cfg.RewardEndTimeLimit,
+		RewardTimeUnit:                   cfg.RewardTimeUnit,
+	}
+	if params.RewardTimeUnit == 0 {
+		return params, errors.New("RewardTimeUnit is 0")
+	}
+
+	amounts := []struct {
+		name  string
+		value string
+		param **big.Int
+	}{
+		{"PledgeAmountMin", cfg.PledgeAmountMin, &params.PledgeAmountMin},
+		{"MintageFee", cfg.MintageFee, &params.MintageFee},
+		{"MintagePledgeAmount", cfg.MintagePledgeAmount, &params.MintagePledgeAmount},
+		{"CreateConsensusGroupPledgeAmount", cfg.CreateConsensusGroupPledgeAmount, &params.CreateConsensusGroupPledgeAmount},
+		{"RewardPerBlock", cfg.RewardPerBlock, &params.RewardPerBlock},
+	}
+	for _, amount := range amounts {
+		value, ok := new(big.Int).SetString(amount.value, 10)
+		if !ok || value.Sign() < 0 {
+			return params, errors.New(amount.name + " " + amount.value + " is not a valid amount")
+		}
+		*amount.param = value
+	}
+	return params, nil
+}
+
 type SendBlock struct {
 	Block     *ledger.AccountBlock
 	ToAddress types.Address
@@ -27,7 +27,7 @@ func (p *MethodCreateConsensusGroup) DoSend(db vmctxt_interface.VmDatabase, bloc
 	if err != nil {
 		return quotaLeft, err
 	}
-	if block.Amount.Cmp(createConsensusGroupPledgeAmount) != 0 ||
+	if block.Amount.Cmp(nodeConfig.params.CreateConsensusGroupPledgeAmount) != 0 ||
 		!util.IsViteToken(block.TokenId) ||
 		!IsUserAccount(db, block.AccountAddress) {
 		return quotaLeft, errors.New("invalid block data")
@@ -211,7 +211,7 @@ func (p *MethodReCreateConsensusGroup) DoSend(db vmctxt_interface.VmDatabase, bl
 	if err != nil {
 		return quotaLeft, err
 	}
-	if block.Amount.Cmp(createConsensusGroupPledgeAmount) != 0 ||
+	if block.Amount.Cmp(nodeConfig.params.CreateConsensusGroupPledgeAmount) != 0 ||
 		!util.IsViteToken(block.TokenId) ||
 		!IsUserAccount(db, block.AccountAddress) {
 		return quotaLeft, errors.New("invalid block data")
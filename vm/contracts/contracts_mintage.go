@@ -15,14 +15,14 @@ import (
 type MethodMintage struct{}
 
 func (p *MethodMintage) GetFee(db vmctxt_interface.VmDatabase, block *ledger.AccountBlock) (*big.Int, error) {
-	if block.Amount.Cmp(mintagePledgeAmount) == 0 && util.IsViteToken(block.TokenId) {
+	if block.Amount.Cmp(nodeConfig.params.MintagePledgeAmount) == 0 && util.IsViteToken(block.TokenId) {
 		// Pledge ViteToken to mintage
 		return big.NewInt(0), nil
 	} else if block.Amount.Sign() > 0 {
 		return big.NewInt(0), errors.New("invalid amount")
 	}
 	// Destroy ViteToken to mintage
-	return new(big.Int).Set(mintageFee), nil
+	return new(big.Int).Set(nodeConfig.params.MintageFee), nil
 }
 
 func (p *MethodMintage) GetRefundData() []byte {
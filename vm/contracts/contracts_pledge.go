@@ -27,7 +27,7 @@ func (p *MethodPledge) DoSend(db vmctxt_interface.VmDatabase, block *ledger.Acco
 	if err != nil {
 		return quotaLeft, err
 	}
-	if block.Amount.Cmp(pledgeAmountMin) < 0 ||
+	if block.Amount.Cmp(nodeConfig.params.PledgeAmountMin) < 0 ||
 		!util.IsViteToken(block.TokenId) ||
 		!IsUserAccount(db, block.AccountAddress) {
 		return quotaLeft, errors.New("invalid block data")
@@ -379,7 +379,7 @@ func CalcReward(db vmctxt_interface.VmDatabase, old *types.Registration, gid typ
 	}
 	reward, _ := new(big.Int).SetString(rewardF.Text('f', 0), 10)
 	if reward.Sign() > 0 {
-		reward.Mul(reward, rewardPerBlock)
+		reward.Mul(reward, nodeConfig.params.RewardPerBlock)
 		reward.Quo(reward, helper.Big2)
 	}
 	return old.RewardIndex, endIndex, reward, periodTime, nil
@@ -54,6 +54,12 @@ type ContractsParams struct {
 	MintagePledgeHeight              uint64 // Pledge height for mintage if choose to pledge instead of destroy vite token
 	RewardEndTimeLimit               uint64 // Cannot get snapshot block reward of current few blocks, for latest snapshot block could be reverted
 	RewardTimeUnit                   uint64
+
+	PledgeAmountMin                  *big.Int
+	MintageFee                       *big.Int
+	MintagePledgeAmount              *big.Int
+	CreateConsensusGroupPledgeAmount *big.Int
+	RewardPerBlock                   *big.Int
 }
 
 var (
@@ -63,6 +69,11 @@ var (
 		MintagePledgeHeight:              1,
 		RewardEndTimeLimit:               75,
 		RewardTimeUnit:                   75 * 2,
+		PledgeAmountMin:                  pledgeAmountMin,
+		MintageFee:                       mintageFee,
+		MintagePledgeAmount:              mintagePledgeAmount,
+		CreateConsensusGroupPledgeAmount: createConsensusGroupPledgeAmount,
+		RewardPerBlock:                   rewardPerBlock,
 	}
 	ContractsParamsMainNet = ContractsParams{
 		MinPledgeHeight:                  3600 * 24 * 3,
@@ -70,5 +81,10 @@ var (
 		MintagePledgeHeight:              3600 * 24 * 30 * 3,
 		RewardEndTimeLimit:               3600 * 24,
 		RewardTimeUnit:                   1152 * 75,
+		PledgeAmountMin:                  pledgeAmountMin,
+		MintageFee:                       mintageFee,
+		MintagePledgeAmount:              mintagePledgeAmount,
+		CreateConsensusGroupPledgeAmount: createConsensusGroupPledgeAmount,
+		RewardPerBlock:                   rewardPerBlock,
 	}
 )
@@ -61,11 +61,11 @@ func (db *testDatabase) AddBalance(tokenTypeId *types.TokenTypeId, amount *big.I
 	}
 
 }
-func (db *testDatabase) GetSnapshotBlockByHeight(height uint64) *ledger.SnapshotBlock {
+func (db *testDatabase) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
 	if height < uint64(len(db.snapshotBlockList)) {
-		return db.snapshotBlockList[height-1]
+		return db.snapshotBlockList[height-1], nil
 	}
-	return nil
+	return nil, nil
 }
 
 // forward=true return [startHeight, startHeight+count), forward=false return (startHeight-count, startHeight]
@@ -197,7 +197,7 @@ func (db *testDatabase) GetGid() *types.Gid {
 	return nil
 }
 func (db *testDatabase) Address() *types.Address {
-	return nil
+	return &db.addr
 }
 func (db *testDatabase) CurrentSnapshotBlock() *ledger.SnapshotBlock {
 	return db.snapshotBlockList[len(db.snapshotBlockList)-1]
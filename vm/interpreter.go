@@ -40,6 +40,10 @@ func (i *Interpreter) Run(vm *VM, c *contract) (ret []byte, err error) {
 			return nil, err
 		}
 
+		if vm.readOnly && operation.writes {
+			return nil, util.ErrWriteProtection
+		}
+
 		var memorySize uint64
 		if operation.memorySize != nil {
 			memSize, overflow := helper.BigUint64(operation.memorySize(st))
@@ -55,6 +59,9 @@ func (i *Interpreter) Run(vm *VM, c *contract) (ret []byte, err error) {
 		if err != nil {
 			return nil, err
 		}
+		if vm.Tracer != nil {
+			vm.Tracer.CaptureState(currentPc, opCodeToString[op], c.quotaLeft, cost, mem.store, st.data)
+		}
 		c.quotaLeft, err = util.UseQuota(c.quotaLeft, cost)
 		if err != nil {
 			return nil, err
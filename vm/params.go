@@ -39,6 +39,9 @@ const (
 
 	getBlockByHeightLimit uint64 = 256
 
+	offChainReaderGas  uint64 = 1000000 // Quota limit of an off chain contract call
+	estimateQuotaLimit uint64 = 1000000 // Quota limit of a block run for quota estimation
+
 	//CallValueTransferGas  uint64 = 9000  // Paid for CALL when the amount transfer is non-zero.
 	//CallNewAccountGas     uint64 = 25000 // Paid for CALL when the destination address didn't exist prior.
 	//CallStipend           uint64 = 2300  // Free gas given at beginning of call.
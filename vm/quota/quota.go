@@ -88,20 +88,11 @@ func CalcQuotaV2(db quotaDb, addr types.Address, pledgeAmount *big.Int, difficul
 			quotaUsed = quotaUsed + prevBlock.Quota
 			prevBlock = db.GetAccountBlockByHash(&prevBlock.PrevHash)
 		} else {
-			x := new(big.Float).SetPrec(precForFloat).SetUint64(0)
-			tmpFLoat := new(big.Float).SetPrec(precForFloat)
+			x := calcPledgeParam(db, prevBlock, pledgeAmount)
 			var quotaWithoutPoW uint64
 			if pledgeAmount.Sign() == 0 {
 				quotaWithoutPoW = 0
 			} else {
-				if prevBlock == nil {
-					tmpFLoat.SetUint64(helper.Min(maxQuotaHeightGap, db.CurrentSnapshotBlock().Height))
-				} else {
-					tmpFLoat.SetUint64(helper.Min(maxQuotaHeightGap, db.CurrentSnapshotBlock().Height-db.GetSnapshotBlockByHash(&prevBlock.SnapshotHash).Height))
-				}
-				x.Mul(tmpFLoat, nodeConfig.paramA)
-				tmpFLoat.SetInt(pledgeAmount)
-				x.Mul(tmpFLoat, x)
 				quotaWithoutPoW = calcQuotaInSection(x)
 			}
 			if quotaWithoutPoW < quotaUsed {
@@ -109,6 +100,7 @@ func CalcQuotaV2(db quotaDb, addr types.Address, pledgeAmount *big.Int, difficul
 			}
 			quotaTotal := quotaWithoutPoW
 			if isPoW {
+				tmpFLoat := new(big.Float).SetPrec(precForFloat)
 				tmpFLoat.SetInt(difficulty)
 				tmpFLoat.Mul(tmpFLoat, nodeConfig.paramB)
 				x.Add(x, tmpFLoat)
@@ -119,6 +111,67 @@ func CalcQuotaV2(db quotaDb, addr types.Address, pledgeAmount *big.Int, difficul
 	}
 }
 
+// CalcPoWDifficulty returns the minimum PoW difficulty with which an account gets quotaRequired quota
+// referring to current snapshot block, quota gained by pledge is counted in
+func CalcPoWDifficulty(db quotaDb, pledgeAmount *big.Int, quotaRequired uint64) (*big.Int, error) {
+	currentSnapshotHash := db.CurrentSnapshotBlock().Hash
+	prevBlock := db.PrevAccountBlock()
+	quotaUsed := uint64(0)
+	for prevBlock != nil && currentSnapshotHash == prevBlock.SnapshotHash {
+		if prevBlock.BlockType == ledger.BlockTypeReceiveError {
+			return nil, errors.New("no quota after a receive error block referring to the same snapshot block")
+		}
+		if IsPoW(prevBlock.Nonce) {
+			return nil, errors.New("calc PoW twice referring to one snapshot block")
+		}
+		quotaUsed = quotaUsed + prevBlock.Quota
+		prevBlock = db.GetAccountBlockByHash(&prevBlock.PrevHash)
+	}
+	index := (quotaUsed + quotaRequired + quotaForSection - 1) / quotaForSection
+	if index >= uint64(len(nodeConfig.sectionList)) {
+		return nil, errors.New("quota required is out of range")
+	}
+	x := calcPledgeParam(db, prevBlock, pledgeAmount)
+	if nodeConfig.sectionList[index].Cmp(x) <= 0 {
+		return big.NewInt(0), nil
+	}
+	tmpFloat := new(big.Float).SetPrec(precForFloat).Sub(nodeConfig.sectionList[index], x)
+	tmpFloat.Quo(tmpFloat, nodeConfig.paramB)
+	difficulty, _ := tmpFloat.Int(nil)
+	// float calculation loses precision, increase difficulty until it reaches the required section
+	step := new(big.Int).Rsh(difficulty, 16)
+	if step.Sign() == 0 {
+		step.SetUint64(1)
+	}
+	for {
+		tmpFloat.SetInt(difficulty)
+		tmpFloat.Mul(tmpFloat, nodeConfig.paramB)
+		tmpFloat.Add(tmpFloat, x)
+		if uint64(getIndexInSection(tmpFloat)) >= index {
+			return difficulty, nil
+		}
+		difficulty.Add(difficulty, step)
+	}
+}
+
+// x = fPledge * snapshotHeightGap * pledgeAmount
+func calcPledgeParam(db quotaDb, prevBlock *ledger.AccountBlock, pledgeAmount *big.Int) *big.Float {
+	x := new(big.Float).SetPrec(precForFloat).SetUint64(0)
+	if pledgeAmount == nil || pledgeAmount.Sign() == 0 {
+		return x
+	}
+	tmpFLoat := new(big.Float).SetPrec(precForFloat)
+	if prevBlock == nil {
+		tmpFLoat.SetUint64(helper.Min(maxQuotaHeightGap, db.CurrentSnapshotBlock().Height))
+	} else {
+		tmpFLoat.SetUint64(helper.Min(maxQuotaHeightGap, db.CurrentSnapshotBlock().Height-db.GetSnapshotBlockByHash(&prevBlock.SnapshotHash).Height))
+	}
+	x.Mul(tmpFLoat, nodeConfig.paramA)
+	tmpFLoat.SetInt(pledgeAmount)
+	x.Mul(tmpFLoat, x)
+	return x
+}
+
 func calcQuotaInSection(x *big.Float) uint64 {
 	// TODO calc Qm according to net congestion in past 3600 snapshot blocks
 	return uint64(getIndexInSection(x)) * quotaForSection
@@ -0,0 +1,199 @@
+package vm
+
+import (
+	"encoding/hex"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/vm_context/vmctxt_interface"
+	"math/big"
+)
+
+const (
+	callTypeCall         = "call"
+	callTypeDelegateCall = "delegatecall"
+)
+
+// Tracer is notified of every step of contract execution. Memory and stack
+// passed to CaptureState belong to the interpreter and must be copied if retained.
+type Tracer interface {
+	// CaptureEnter is called when contract code starts running, either a receive call or a delegate call
+	CaptureEnter(typ string, from, to types.Address, input []byte, quota uint64)
+	// CaptureState is called before each opcode is executed, quota is the quota left before cost is charged
+	CaptureState(pc uint64, op string, quota, cost uint64, memory []byte, stack []*big.Int)
+	// CaptureStorage is called on every storage write of the running account
+	CaptureStorage(addr types.Address, key, value []byte)
+	// CaptureExit is called when contract code stops running
+	CaptureExit(output []byte, quotaUsed uint64, err error)
+}
+
+// tracedDatabase reports storage writes, including writes of precompiled contracts, to tracer
+type tracedDatabase struct {
+	vmctxt_interface.VmDatabase
+	tracer Tracer
+}
+
+func (db *tracedDatabase) SetStorage(key []byte, value []byte) {
+	db.VmDatabase.SetStorage(key, value)
+	if addr := db.Address(); addr != nil {
+		db.tracer.CaptureStorage(*addr, key, value)
+	}
+}
+
+type StructLoggerConfig struct {
+	DisableStack   bool
+	DisableMemory  bool
+	DisableStorage bool
+}
+
+type StructLog struct {
+	Pc        uint64            `json:"pc"`
+	Op        string            `json:"op"`
+	Quota     uint64            `json:"quota"`
+	QuotaCost uint64            `json:"quotaCost"`
+	Depth     int               `json:"depth"`
+	Stack     []string          `json:"stack,omitempty"`
+	Memory    []string          `json:"memory,omitempty"`
+	Storage   map[string]string `json:"storage,omitempty"`
+}
+
+type StorageWrite struct {
+	Address types.Address `json:"address"`
+	Key     string        `json:"key"`
+	Value   string        `json:"value"`
+}
+
+type StructLoggerResult struct {
+	QuotaUsed     uint64         `json:"quotaUsed"`
+	Failed        bool           `json:"failed"`
+	Error         string         `json:"error,omitempty"`
+	ReturnValue   string         `json:"returnValue"`
+	StructLogs    []*StructLog   `json:"structLogs"`
+	StorageWrites []StorageWrite `json:"storageWrites"`
+}
+
+// StructLogger records every executed opcode together with stack, memory and storage writes
+type StructLogger struct {
+	cfg    StructLoggerConfig
+	depth  int
+	result StructLoggerResult
+}
+
+func NewStructLogger(cfg *StructLoggerConfig) *StructLogger {
+	l := &StructLogger{
+		result: StructLoggerResult{StructLogs: []*StructLog{}, StorageWrites: []StorageWrite{}},
+	}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+func (l *StructLogger) CaptureEnter(typ string, from, to types.Address, input []byte, quota uint64) {
+	l.depth++
+}
+
+func (l *StructLogger) CaptureState(pc uint64, op string, quota, cost uint64, memory []byte, stack []*big.Int) {
+	log := &StructLog{Pc: pc, Op: op, Quota: quota, QuotaCost: cost, Depth: l.depth}
+	if !l.cfg.DisableStack {
+		log.Stack = make([]string, len(stack))
+		for i, v := range stack {
+			log.Stack[i] = hex.EncodeToString(helper.LeftPadBytes(v.Bytes(), helper.WordSize))
+		}
+	}
+	if !l.cfg.DisableMemory {
+		log.Memory = make([]string, 0, len(memory)/helper.WordSize)
+		for i := 0; i+helper.WordSize <= len(memory); i += helper.WordSize {
+			log.Memory = append(log.Memory, hex.EncodeToString(memory[i:i+helper.WordSize]))
+		}
+	}
+	l.result.StructLogs = append(l.result.StructLogs, log)
+}
+
+func (l *StructLogger) CaptureStorage(addr types.Address, key, value []byte) {
+	if l.cfg.DisableStorage {
+		return
+	}
+	keyStr, valueStr := hex.EncodeToString(key), hex.EncodeToString(value)
+	l.result.StorageWrites = append(l.result.StorageWrites, StorageWrite{addr, keyStr, valueStr})
+	if l.depth > 0 && len(l.result.StructLogs) > 0 {
+		log := l.result.StructLogs[len(l.result.StructLogs)-1]
+		if log.Storage == nil {
+			log.Storage = make(map[string]string)
+		}
+		log.Storage[keyStr] = valueStr
+	}
+}
+
+func (l *StructLogger) CaptureExit(output []byte, quotaUsed uint64, err error) {
+	l.depth--
+	if l.depth > 0 {
+		return
+	}
+	l.result.QuotaUsed = quotaUsed
+	l.result.ReturnValue = hex.EncodeToString(output)
+	if err != nil {
+		l.result.Failed = true
+		l.result.Error = err.Error()
+	}
+}
+
+func (l *StructLogger) Result() *StructLoggerResult {
+	return &l.result
+}
+
+type CallFrame struct {
+	Type      string        `json:"type"`
+	From      types.Address `json:"from"`
+	To        types.Address `json:"to"`
+	Input     string        `json:"input"`
+	Output    string        `json:"output"`
+	Quota     uint64        `json:"quota"`
+	QuotaUsed uint64        `json:"quotaUsed"`
+	Error     string        `json:"error,omitempty"`
+	Calls     []*CallFrame  `json:"calls,omitempty"`
+}
+
+// CallTracer records contract calls as a tree, without opcode details
+type CallTracer struct {
+	root  *CallFrame
+	stack []*CallFrame
+}
+
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (t *CallTracer) CaptureEnter(typ string, from, to types.Address, input []byte, quota uint64) {
+	frame := &CallFrame{Type: typ, From: from, To: to, Input: hex.EncodeToString(input), Quota: quota}
+	if len(t.stack) == 0 {
+		t.root = frame
+	} else {
+		parent := t.stack[len(t.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	t.stack = append(t.stack, frame)
+}
+
+func (t *CallTracer) CaptureState(pc uint64, op string, quota, cost uint64, memory []byte, stack []*big.Int) {
+}
+
+func (t *CallTracer) CaptureStorage(addr types.Address, key, value []byte) {
+}
+
+func (t *CallTracer) CaptureExit(output []byte, quotaUsed uint64, err error) {
+	if len(t.stack) == 0 {
+		return
+	}
+	frame := t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+	frame.Output = hex.EncodeToString(output)
+	frame.QuotaUsed = quotaUsed
+	if err != nil {
+		frame.Error = err.Error()
+	}
+}
+
+// Result returns the outermost call, nil if no contract code was run
+func (t *CallTracer) Result() *CallFrame {
+	return t.root
+}
@@ -11,4 +11,5 @@ var (
 	ErrExecutionReverted           = errors.New("execution reverted")
 	ErrGasUintOverflow             = errors.New("gas uint64 overflow")
 	ErrReturnDataOutOfBounds       = errors.New("evm: return data out of bounds")
+	ErrWriteProtection             = errors.New("write protection")
 )
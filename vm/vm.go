@@ -22,6 +22,10 @@ import (
 
 type VMConfig struct {
 	Debug bool
+	// Estimate runs blocks with a fixed quota limit instead of the quota of the account, used for quota estimation
+	Estimate bool
+	// Tracer is notified of contract execution steps if not nil, used for debugging
+	Tracer Tracer
 }
 
 type NodeConfig struct {
@@ -55,9 +59,12 @@ type VmContext struct {
 	blockList []*vm_context.VmAccountBlock
 }
 
+var runDuration = monitor.NewHistogram("gvite_vm_run_duration_seconds", "Time taken by VM.Run", nil)
+
 type VM struct {
 	VMConfig
-	abort int32
+	abort    int32
+	readOnly bool
 	VmContext
 	i *Interpreter
 }
@@ -68,24 +75,25 @@ func NewVM() *VM {
 
 func (vm *VM) Run(database vmctxt_interface.VmDatabase, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock) (blockList []*vm_context.VmAccountBlock, isRetry bool, err error) {
 	defer monitor.LogTime("vm", "run", time.Now())
+	defer runDuration.ObserveSince(time.Now())
+	if vm.Tracer != nil {
+		database = &tracedDatabase{database, vm.Tracer}
+	}
 	blockContext := &vm_context.VmAccountBlock{block.Copy(), database}
 	switch block.BlockType {
 	case ledger.BlockTypeReceive, ledger.BlockTypeReceiveError:
 		blockContext.AccountBlock.Data = nil
 		// block data, amount, tokenId, fee is already changed to send block data by generator
-		/* TODO not support create contract this version
 		if sendBlock.BlockType == ledger.BlockTypeSendCreate {
 			return vm.receiveCreate(blockContext, sendBlock, quota.CalcCreateQuota(sendBlock.Fee))
-		} else*/
-		if sendBlock.BlockType == ledger.BlockTypeSendCall || sendBlock.BlockType == ledger.BlockTypeSendReward {
+		} else if sendBlock.BlockType == ledger.BlockTypeSendCall || sendBlock.BlockType == ledger.BlockTypeSendReward {
 			return vm.receiveCall(blockContext, sendBlock)
 		}
-	/* TODO not support create contract this version
 	case ledger.BlockTypeSendCreate:
-		quotaTotal, quotaAddition, err := nodeConfig.calcQuota(
+		quotaTotal, quotaAddition, err := vm.calcQuota(
 			database,
 			block.AccountAddress,
-			contracts.GetPledgeBeneficialAmount(database, block.AccountAddress),
+			abi.GetPledgeBeneficialAmount(database, block.AccountAddress),
 			block.Difficulty)
 		if err != nil {
 			return nil, NoRetry, err
@@ -95,9 +103,9 @@ func (vm *VM) Run(database vmctxt_interface.VmDatabase, block *ledger.AccountBlo
 			return nil, NoRetry, err
 		} else {
 			return []*vm_context.VmAccountBlock{blockContext}, NoRetry, nil
-		}*/
+		}
 	case ledger.BlockTypeSendCall:
-		quotaTotal, quotaAddition, err := nodeConfig.calcQuota(
+		quotaTotal, quotaAddition, err := vm.calcQuota(
 			database,
 			block.AccountAddress,
 			abi.GetPledgeBeneficialAmount(database, block.AccountAddress),
@@ -115,6 +123,46 @@ func (vm *VM) Run(database vmctxt_interface.VmDatabase, block *ledger.AccountBlo
 	return nil, NoRetry, errors.New("transaction type not supported")
 }
 
+// OffChainReader runs contract code against current state of db without producing blocks,
+// any state modifying operation is rejected
+func (vm *VM) OffChainReader(db vmctxt_interface.VmDatabase, code []byte, data []byte) (result []byte, err error) {
+	defer monitor.LogTime("vm", "OffChainReader", time.Now())
+	if db.Address() == nil {
+		return nil, errors.New("contract address can't be nil")
+	}
+	vm.readOnly = true
+	defer func() {
+		vm.readOnly = false
+	}()
+	addr := *db.Address()
+	sendBlock := &ledger.AccountBlock{
+		AccountAddress: addr,
+		ToAddress:      addr,
+		BlockType:      ledger.BlockTypeSendCall,
+		Amount:         helper.Big0,
+		Fee:            helper.Big0,
+		TokenId:        ledger.ViteTokenId,
+		Data:           data,
+	}
+	block := &vm_context.VmAccountBlock{
+		AccountBlock: &ledger.AccountBlock{
+			AccountAddress: addr,
+			BlockType:      ledger.BlockTypeReceive,
+		},
+		VmContext: db,
+	}
+	c := newContract(addr, addr, block, sendBlock, offChainReaderGas, 0)
+	c.setCallCode(addr, code)
+	return c.run(vm)
+}
+
+func (vm *VM) calcQuota(db vmctxt_interface.VmDatabase, addr types.Address, pledgeAmount *big.Int, difficulty *big.Int) (quotaTotal uint64, quotaAddition uint64, err error) {
+	if vm.Estimate {
+		return estimateQuotaLimit, 0, nil
+	}
+	return nodeConfig.calcQuota(db, addr, pledgeAmount, difficulty)
+}
+
 func (vm *VM) Cancel() {
 	atomic.StoreInt32(&vm.abort, 1)
 }
@@ -125,6 +173,9 @@ func (vm *VM) sendCreate(block *vm_context.VmAccountBlock, quotaTotal, quotaAddi
 	// check can make transaction
 	quotaLeft := quotaTotal
 	quotaRefund := uint64(0)
+	if len(block.AccountBlock.Data) < types.GidSize {
+		return nil, util.ErrInvalidMethodParam
+	}
 	cost, err := util.IntrinsicGasCost(block.AccountBlock.Data, false)
 	if err != nil {
 		return nil, err
@@ -144,7 +195,7 @@ func (vm *VM) sendCreate(block *vm_context.VmAccountBlock, quotaTotal, quotaAddi
 		return nil, errors.New("consensus group not exist")
 	}
 
-	if !CanTransfer(block.VmContext, block.AccountBlock.AccountAddress, block.AccountBlock.TokenId, block.AccountBlock.Amount, block.AccountBlock.Fee) {
+	if !CanTransfer(block.VmContext, block.AccountBlock.AccountAddress, block.AccountBlock.TokenId, block.AccountBlock.Amount, contractFee) {
 		return nil, util.ErrInsufficientBalance
 	}
 
@@ -191,11 +242,13 @@ func (vm *VM) receiveCreate(block *vm_context.VmAccountBlock, sendBlock *ledger.
 	// create contract account and add balance
 	block.VmContext.AddBalance(&sendBlock.TokenId, sendBlock.Amount)
 
-	sendBlock.Data = sendBlock.Data[types.GidSize:]
+	// send block data is gid followed by init code, strip gid without changing the referred send block
+	initBlock := *sendBlock
+	initBlock.Data = sendBlock.Data[types.GidSize:]
 
 	// init contract state and set contract code
-	c := newContract(sendBlock.AccountAddress, block.AccountBlock.AccountAddress, block, sendBlock, quotaLeft, 0)
-	c.setCallCode(block.AccountBlock.AccountAddress, sendBlock.Data)
+	c := newContract(sendBlock.AccountAddress, block.AccountBlock.AccountAddress, block, &initBlock, quotaLeft, 0)
+	c.setCallCode(block.AccountBlock.AccountAddress, initBlock.Data)
 	code, err := c.run(vm)
 	if err == nil && len(code) <= MaxCodeSize {
 		codeCost := uint64(len(code)) * contractCodeGas
@@ -360,7 +413,7 @@ func (vm *VM) receiveCall(block *vm_context.VmAccountBlock, sendBlock *ledger.Ac
 		return vm.blockList, NoRetry, err
 	} else {
 		// check can make transaction
-		quotaTotal, quotaAddition, err := nodeConfig.calcQuota(
+		quotaTotal, quotaAddition, err := vm.calcQuota(
 			block.VmContext,
 			block.AccountBlock.AccountAddress,
 			abi.GetPledgeBeneficialAmount(block.VmContext, block.AccountBlock.AccountAddress),
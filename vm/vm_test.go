@@ -6,6 +6,7 @@ import (
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/quota"
 	"github.com/vitelabs/go-vite/vm/util"
@@ -216,6 +217,53 @@ func TestVmRun(t *testing.T) {
 	db.accountBlockMap[addr2][hash23] = receiveCallBlockList2[0].AccountBlock
 }
 
+func TestVmRunCreateError(t *testing.T) {
+	// prepare db
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	db, addr1, _, hash12, snapshot, _ := prepareDb(viteTotalSupply)
+	blockTime := time.Now()
+	code, _ := hex.DecodeString("608060405260858060116000396000f300")
+
+	// send create error, data shorter than gid
+	block13 := &ledger.AccountBlock{
+		Height:         3,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCreate,
+		PrevHash:       hash12,
+		Amount:         big.NewInt(1e18),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+		SnapshotHash:   snapshot.Hash,
+		Data:           []byte{1, 2, 3},
+		Timestamp:      &blockTime,
+	}
+	vm := NewVM()
+	db.addr = addr1
+	blockList, isRetry, err := vm.Run(db, block13, nil)
+	if len(blockList) != 0 || isRetry || err != util.ErrInvalidMethodParam {
+		t.Fatalf("send create with invalid data error")
+	}
+
+	// send create error, consensus group not exist
+	block13.Data = contracts.GetCreateContractData(code, types.Gid{1, 2, 3})
+	vm = NewVM()
+	blockList, isRetry, err = vm.Run(db, block13, nil)
+	if len(blockList) != 0 || isRetry || err == nil {
+		t.Fatalf("send create with invalid gid error")
+	}
+
+	// send create error, insufficient balance for amount and contract fee
+	block13.Data = contracts.GetCreateContractData(code, types.DELEGATE_GID)
+	block13.Amount = new(big.Int).Sub(viteTotalSupply, big.NewInt(1))
+	vm = NewVM()
+	blockList, isRetry, err = vm.Run(db, block13, nil)
+	if len(blockList) != 0 || isRetry || err != util.ErrInsufficientBalance ||
+		db.balanceMap[addr1][ledger.ViteTokenId].Cmp(viteTotalSupply) != 0 ||
+		len(db.contractGidMap) != 0 {
+		t.Fatalf("send create with insufficient balance error")
+	}
+}
+
 func TestDelegateCall(t *testing.T) {
 	// prepare db, add account1, add account2 with code, add account3 with code
 	db := NewNoDatabase()
@@ -261,6 +309,161 @@ func TestDelegateCall(t *testing.T) {
 
 var DefaultDifficulty = new(big.Int).SetUint64(67108863)
 
+func TestTracer(t *testing.T) {
+	db := NewNoDatabase()
+	// code1 return 1+2
+	addr1, _, _ := types.CreateAddress()
+	code1 := []byte{byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD), byte(PUSH1), 32, byte(DUP1), byte(SWAP2), byte(SWAP1), byte(MSTORE), byte(PUSH1), 32, byte(SWAP1), byte(RETURN)}
+	db.codeMap = make(map[types.Address][]byte)
+	db.codeMap[addr1] = code1
+	// code2 delegate call code1
+	addr2, _, _ := types.CreateAddress()
+	code2 := helper.JoinBytes([]byte{byte(PUSH1), 32, byte(PUSH1), 0, byte(PUSH1), 0, byte(PUSH1), 0, byte(PUSH20)}, addr1.Bytes(), []byte{byte(DELEGATECALL), byte(PUSH1), 32, byte(PUSH1), 0, byte(RETURN)})
+	db.codeMap[addr2] = code2
+	db.addr = addr2
+	blockTime := time.Now()
+	sendCallBlock := &ledger.AccountBlock{
+		AccountAddress: addr1,
+		ToAddress:      addr2,
+		BlockType:      ledger.BlockTypeSendCall,
+		Amount:         big.NewInt(10),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+	receiveCallBlock := &ledger.AccountBlock{
+		AccountAddress: addr2,
+		BlockType:      ledger.BlockTypeReceive,
+		Timestamp:      &blockTime,
+	}
+
+	// call tracer
+	vm := NewVM()
+	callTracer := NewCallTracer()
+	vm.Tracer = callTracer
+	c := newContract(addr1, addr2, &vm_context.VmAccountBlock{receiveCallBlock, db}, sendCallBlock, 1000000, 0)
+	c.setCallCode(addr2, code2)
+	if _, err := c.run(vm); err != nil {
+		t.Fatalf("call tracer run error, err %v", err)
+	}
+	root := callTracer.Result()
+	if root == nil || root.Type != callTypeCall || root.From != addr1 || root.To != addr2 || root.QuotaUsed == 0 ||
+		len(root.Calls) != 1 || root.Calls[0].Type != callTypeDelegateCall || root.Calls[0].To != addr1 ||
+		root.Calls[0].Output != hex.EncodeToString(helper.LeftPadBytes([]byte{3}, 32)) {
+		t.Fatalf("call tracer result error, %v", root)
+	}
+
+	// struct logger, code3 sets storage value at location 0
+	code3 := []byte{byte(PUSH1), 1, byte(PUSH1), 0, byte(SSTORE), byte(STOP)}
+	vm = NewVM()
+	structLogger := NewStructLogger(nil)
+	vm.Tracer = structLogger
+	c = newContract(addr1, addr2, &vm_context.VmAccountBlock{receiveCallBlock, &tracedDatabase{db, structLogger}}, sendCallBlock, 1000000, 0)
+	c.setCallCode(addr2, code3)
+	if _, err := c.run(vm); err != nil {
+		t.Fatalf("struct logger run error, err %v", err)
+	}
+	result := structLogger.Result()
+	if len(result.StructLogs) != 4 || result.Failed || result.QuotaUsed == 0 {
+		t.Fatalf("struct logger result error, %v", result)
+	}
+	if log := result.StructLogs[2]; log.Op != "SSTORE" || log.Pc != 4 || len(log.Stack) != 2 || len(log.Storage) != 1 {
+		t.Fatalf("struct logger sstore log error, %v", log)
+	}
+	if len(result.StorageWrites) != 1 || result.StorageWrites[0].Address != addr2 || result.StorageWrites[0].Value != "01" {
+		t.Fatalf("struct logger storage writes error, %v", result.StorageWrites)
+	}
+}
+
+func TestOffChainReader(t *testing.T) {
+	db := NewNoDatabase()
+	addr1, _, _ := types.CreateAddress()
+	db.addr = addr1
+	db.storageMap[addr1] = make(map[string][]byte)
+	loc, _ := types.BigToHash(big.NewInt(0))
+	db.storageMap[addr1][string(loc.Bytes())] = []byte{5}
+
+	vm := NewVM()
+	// code returns storage value at location 0
+	code1 := []byte{byte(PUSH1), 0, byte(SLOAD), byte(PUSH1), 0, byte(MSTORE), byte(PUSH1), 32, byte(PUSH1), 0, byte(RETURN)}
+	ret, err := vm.OffChainReader(db, code1, nil)
+	if err != nil || !bytes.Equal(ret, helper.LeftPadBytes([]byte{5}, 32)) {
+		t.Fatalf("off chain read error, ret %v, err %v", ret, err)
+	}
+
+	// code sets storage value at location 0
+	code2 := []byte{byte(PUSH1), 1, byte(PUSH1), 0, byte(SSTORE), byte(STOP)}
+	ret, err = vm.OffChainReader(db, code2, nil)
+	if err != util.ErrWriteProtection || !bytes.Equal(db.storageMap[addr1][string(loc.Bytes())], []byte{5}) {
+		t.Fatalf("off chain write error, ret %v, err %v", ret, err)
+	}
+	if vm.readOnly {
+		t.Fatalf("off chain reader doesn't reset read only flag")
+	}
+}
+
+func TestCalcPoWDifficulty(t *testing.T) {
+	quota.InitQuotaConfig(false)
+	// prepare db
+	addr1, _, _ := types.CreateAddress()
+	db := NewNoDatabase()
+	timestamp := time.Unix(1536214502, 0)
+	snapshot1 := &ledger.SnapshotBlock{Height: 1, Timestamp: &timestamp, Hash: types.DataHash([]byte{10, 1})}
+	db.snapshotBlockList = append(db.snapshotBlockList, snapshot1)
+	db.addr = addr1
+	maxPledgeAmount := new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18))
+
+	// pledge amount reaches quota required
+	difficulty, err := quota.CalcPoWDifficulty(db, maxPledgeAmount, 21000)
+	if err != nil || difficulty.Sign() != 0 {
+		t.Fatalf("calc PoW difficulty error, pledge amount reaches quota required")
+	}
+	// no pledge, PoW for one and two transactions
+	for _, quotaRequired := range []uint64{21000, 42000} {
+		difficulty, err = quota.CalcPoWDifficulty(db, helper.Big0, quotaRequired)
+		if err != nil {
+			t.Fatalf("calc PoW difficulty error, quota required %v, err %v", quotaRequired, err)
+		}
+		quotaTotal, _, err := quota.CalcQuotaV2(db, addr1, helper.Big0, difficulty)
+		if err != nil || quotaTotal < quotaRequired {
+			t.Fatalf("calc PoW difficulty error, quota required %v, got %v", quotaRequired, quotaTotal)
+		}
+	}
+	// quota required out of range
+	if _, err = quota.CalcPoWDifficulty(db, helper.Big0, 1e8); err == nil {
+		t.Fatalf("calc PoW difficulty error, quota required out of range")
+	}
+}
+
+func TestVmRunEstimate(t *testing.T) {
+	// prepare db
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	db, addr1, _, hash12, snapshot, _ := prepareDb(viteTotalSupply)
+	blockTime := time.Now()
+	addr2, _, _ := types.CreateAddress()
+
+	block13 := &ledger.AccountBlock{
+		Height:         3,
+		AccountAddress: addr1,
+		ToAddress:      addr2,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         big.NewInt(1e18),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+		SnapshotHash:   snapshot.Hash,
+		Timestamp:      &blockTime,
+	}
+	vm := NewVM()
+	vm.Estimate = true
+	db.addr = addr1
+	blockList, isRetry, err := vm.Run(db, block13, nil)
+	if len(blockList) != 1 || isRetry || err != nil ||
+		blockList[0].AccountBlock.Quota != util.TxGas ||
+		blockList[0].AccountBlock.Fee.Sign() != 0 {
+		t.Fatalf("estimate send call transaction error")
+	}
+}
+
 func TestCalcQuotaV2(t *testing.T) {
 	quota.InitQuotaConfig(false)
 	// prepare db
@@ -0,0 +1,42 @@
+package wallet
+
+import (
+	"os"
+
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+)
+
+// The well known key of dev mode, never use it on a real network
+const (
+	DevMnemonic   = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	DevPassphrase = "dev"
+)
+
+// UnlockDevEntropyStore stores the dev entropy store into the wallet dir if not exists and unlocks it,
+// the primary address of the returned store is the producer and the funded account of dev mode.
+func (m *Manager) UnlockDevEntropyStore() (*entropystore.Manager, error) {
+	primaryAddr, err := entropystore.MnemonicToPrimaryAddr(DevMnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	var em *entropystore.Manager
+	filename := entropystore.FullKeyFileName(m.config.DataDir, *primaryAddr)
+	if _, statErr := os.Stat(filename); os.IsNotExist(statErr) {
+		if em, err = m.RecoverEntropyStoreFromMnemonic(DevMnemonic, DevPassphrase); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = m.AddEntropyStore(filename); err != nil {
+			return nil, err
+		}
+		if em, err = m.GetEntropyStoreManager(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := em.Unlock(DevPassphrase); err != nil {
+		return nil, err
+	}
+	return em, nil
+}